@@ -0,0 +1,29 @@
+package idl
+
+import "fmt"
+
+// AliasPackage allows references to the package pkg to be written using
+// alias instead, such as vendor.Money for org.vendor.v1.Money, insulating
+// local schemas from renames of imported packages. Aliases take precedence
+// over packages sharing their name, and cannot be redefined.
+func (f *FileSet) AliasPackage(alias, pkg string) error {
+	if alias == "" || pkg == "" {
+		return fmt.Errorf("invalid alias %q for package %q", alias, pkg)
+	}
+	if prev, ok := f.aliases[alias]; ok && prev != pkg {
+		return fmt.Errorf("alias %s already refers to package %s", alias, prev)
+	}
+	if f.aliases == nil {
+		f.aliases = map[string]string{}
+	}
+	f.aliases[alias] = pkg
+	return nil
+}
+
+// dealias replaces the package of name by the one it aliases, if any.
+func (f *FileSet) dealias(name FQN) FQN {
+	if pkg, ok := f.aliases[name.Package()]; ok {
+		return NewFQN(pkg, name.Name())
+	}
+	return name
+}
@@ -0,0 +1,49 @@
+package idl
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetAliasPackage(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app/yarp.mod":    {Data: []byte("package org.example.app\nalias vendor org.vendor.v1\n")},
+		"app/order.yarp":  {Data: []byte("package org.example.app;\n\nimport \"./vendor\";\n\nmessage Order {\n    total vendor.Money = 0;\n}\n\nservice Orders {\n    convert(vendor.Money) -> Order;\n}\n")},
+		"app/vendor.yarp": {Data: []byte("package org.vendor.v1;\n\nmessage Money {\n    cents int64 = 0;\n}\n\nservice Rates {\n    convert(Money) -> Money;\n}\n")},
+	}
+
+	fs := NewFileSetFS(fsys)
+	require.NoError(t, fs.Load("app/order.yarp"))
+	diags := fs.Resolve()
+	assert.Equal(t, []string{CodeUnknownType, CodeUnknownType}, diagnosticCodes(diags))
+
+	fs = NewFileSetFS(fsys)
+	require.NoError(t, fs.UseModule("app"))
+	require.NoError(t, fs.Load("app/order.yarp"))
+	assert.Empty(t, fs.Validate())
+
+	money, ok := fs.FindMessage("vendor.Money")
+	require.True(t, ok)
+	order, ok := fs.FindMessage("Order")
+	require.True(t, ok)
+	assert.Equal(t, Resolved{Name: "org.vendor.v1.Money", Message: money}, order.Fields[0].(Field).Type)
+	assert.Equal(t, money, slices.Collect(fs.AllServices())[0].Methods[0].Argument.Target)
+
+	rates, ok := fs.FindService("vendor.Rates")
+	require.True(t, ok)
+	assert.Equal(t, "Rates", rates.Name)
+	sym, ok := fs.FindSymbol("vendor.Money")
+	require.True(t, ok)
+	assert.Equal(t, money, sym.Message())
+	sym, ok = fs.FindSymbol("vendor.Rates")
+	require.True(t, ok)
+	assert.Equal(t, rates, sym.Service())
+
+	assert.NoError(t, fs.AliasPackage("vendor", "org.vendor.v1"))
+	assert.Error(t, fs.AliasPackage("vendor", "org.vendor.v2"))
+	assert.Error(t, fs.AliasPackage("", "org.vendor.v2"))
+}
@@ -0,0 +1,135 @@
+package idl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ExportAvro returns an Avro record schema describing m, which must have been
+// resolved by a FileSet. Messages referenced by m are defined inline upon
+// their first use, named after their FQN, and referred to by name afterwards.
+//
+// Fields annotated with @optional, along with cases of a oneof, are
+// represented as unions of null and their type, defaulting to null; at most
+// one case of a oneof is expected to be set. Arrays of uint8 are represented
+// as bytes, and map keys are always strings. Avro has no unsigned types:
+// uint32 is represented as long, and uint64 values above the range of long
+// are stored as their two's complement.
+func ExportAvro(m *Message) ([]byte, error) {
+	e := &avroExporter{defined: map[*Message]bool{m: true}, names: map[*Message]string{m: m.Name}}
+	schema, err := e.record(m.Name, m)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+type avroExporter struct {
+	defined map[*Message]bool
+	names   map[*Message]string
+}
+
+func (e *avroExporter) record(name string, m *Message) (map[string]any, error) {
+	fields := []any{}
+	for _, item := range m.Fields {
+		switch v := item.(type) {
+		case Field:
+			_, optional := v.Annotations.FindByName(OptionalAnnotation)
+			f, err := e.field(v, optional)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", m.Name, v.Name, err)
+			}
+			fields = append(fields, f)
+		case OneOfField:
+			for _, c := range allFields(v.Items) {
+				f, err := e.field(c, true)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %w", m.Name, c.Name, err)
+				}
+				fields = append(fields, f)
+			}
+		}
+	}
+	schema := map[string]any{"type": "record", "name": name, "fields": fields}
+	annotateAvro(schema, m.Comments, m.Annotations)
+	return schema, nil
+}
+
+func (e *avroExporter) field(f Field, nullable bool) (map[string]any, error) {
+	t, err := e.typeSchema(f.Type)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := f.Annotations.FindByName(RepeatedAnnotation); ok {
+		if _, isArray := f.Type.(Array); !isArray {
+			t = map[string]any{"type": "array", "items": t}
+		}
+	}
+	s := map[string]any{"name": f.Name, "type": t}
+	if nullable {
+		s["type"] = []any{"null", t}
+		s["default"] = nil
+	}
+	annotateAvro(s, f.Comments, f.Annotations)
+	return s, nil
+}
+
+func (e *avroExporter) typeSchema(t Type) (any, error) {
+	switch v := t.(type) {
+	case Primitive:
+		return avroPrimitive(v.Kind)
+	case Array:
+		if p, ok := v.Of.(Primitive); ok && p.Kind == Uint8 {
+			return "bytes", nil
+		}
+		items, err := e.typeSchema(v.Of)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case Map:
+		values, err := e.typeSchema(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "map", "values": values}, nil
+	case Resolved:
+		if e.defined[v.Message] {
+			return e.names[v.Message], nil
+		}
+		name := v.Name.String()
+		e.defined[v.Message], e.names[v.Message] = true, name
+		return e.record(name, v.Message)
+	case Unresolved:
+		return nil, fmt.Errorf("unresolved type %s", v.Name)
+	}
+	return nil, fmt.Errorf("unsupported type %T", t)
+}
+
+func avroPrimitive(p PrimitiveType) (string, error) {
+	switch p {
+	case Uint8, Uint16, Int8, Int16, Int32:
+		return "int", nil
+	case Uint32, Uint64, Int64:
+		return "long", nil
+	case Float32:
+		return "float", nil
+	case Float64:
+		return "double", nil
+	case Bool:
+		return "boolean", nil
+	case String:
+		return "string", nil
+	}
+	return "", fmt.Errorf("unsupported primitive %s", p)
+}
+
+func annotateAvro(s map[string]any, comments []string, a AnnotationCollection) {
+	if len(comments) > 0 {
+		s["doc"] = strings.Join(comments, " ")
+	}
+	if _, ok := a.FindByName(DeprecatedAnnotation); ok {
+		s["deprecated"] = true
+	}
+}
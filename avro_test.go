@@ -0,0 +1,37 @@
+package idl
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAvro(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/typescript/main.yarp"))
+	require.Empty(t, fs.Resolve())
+	order, ok := fs.FindMessage("Order")
+	require.True(t, ok)
+	data, err := ExportAvro(order)
+	require.NoError(t, err)
+	expected, err := os.ReadFile("./test/avro/order.avsc")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(data))
+
+	fs = NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/recursive.yarp"))
+	fs.Resolve()
+	node, ok := fs.FindMessage("Node")
+	require.True(t, ok)
+	data, err = ExportAvro(node)
+	require.NoError(t, err)
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, map[string]any{"name": "children", "type": map[string]any{"type": "array", "items": "Node"}}, schema["fields"].([]any)[1])
+
+	_, err = ExportAvro(&Message{Name: "Broken", Fields: []FieldItem{Field{Name: "missing", Type: Unresolved{Name: "Missing"}}}})
+	assert.EqualError(t, err, "Broken.missing: unresolved type Missing")
+}
@@ -0,0 +1,71 @@
+package idl
+
+import (
+	"crypto/sha256"
+	"sync"
+)
+
+type cacheKey struct {
+	path     string
+	checksum [sha256.Size]byte
+}
+
+// Cache stores parsed source files, keyed by their path and checksum, so that
+// FileSets loading unchanged files do not scan and parse them again. A Cache
+// may be shared by multiple FileSets, and is safe for concurrent use. Files
+// are copied when stored and retrieved, so FileSets never share declarations.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[cacheKey]*File
+}
+
+// NewCache returns a new, empty Cache.
+func NewCache() *Cache {
+	return &Cache{entries: map[cacheKey]*File{}}
+}
+
+func (c *Cache) get(path string, checksum [sha256.Size]byte) (*File, bool) {
+	c.mu.Lock()
+	f, ok := c.entries[cacheKey{path, checksum}]
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	return f.Clone(), true
+}
+
+func (c *Cache) put(f *File) {
+	clone := f.Clone()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[cacheKey]*File{}
+	}
+	// Drop stale versions of the same file.
+	for k := range c.entries {
+		if k.path == f.SourcePath {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[cacheKey{f.SourcePath, f.Checksum}] = clone
+}
+
+// Len returns the amount of files currently stored in the Cache.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Purge removes all files stored in the Cache.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[cacheKey]*File{}
+}
+
+// SetCache configures the FileSet to use the provided Cache when loading
+// files. Passing nil disables caching.
+func (f *FileSet) SetCache(c *Cache) {
+	f.cache = c
+}
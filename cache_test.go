@@ -0,0 +1,50 @@
+package idl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	cache := NewCache()
+	first := NewFileSet()
+	first.SetCache(cache)
+	require.NoError(t, first.Load("./test/resolve/main.yarp"))
+	assert.Equal(t, 2, cache.Len())
+	first.Resolve()
+
+	second := NewFileSet()
+	second.SetCache(cache)
+	require.NoError(t, second.Load("./test/resolve/main.yarp"))
+	assert.Equal(t, 2, cache.Len())
+
+	a, _ := first.FindMessage("User")
+	b, _ := second.FindMessage("User")
+	assert.NotSame(t, a, b)
+	assert.Equal(t, a.Name, b.Name)
+	assert.IsType(t, Unresolved{}, b.Fields[1].(Field).Type)
+	assert.IsType(t, Resolved{}, a.Fields[1].(Field).Type)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.yarp")
+	require.NoError(t, os.WriteFile(path, []byte("package a;\n\nmessage A {\n    id int64 = 0;\n}\n"), 0o644))
+	third := NewFileSet()
+	third.SetCache(cache)
+	require.NoError(t, third.Load(path))
+	assert.Equal(t, 3, cache.Len())
+
+	require.NoError(t, os.WriteFile(path, []byte("package a;\n\nmessage B {\n    id int64 = 0;\n}\n"), 0o644))
+	fourth := NewFileSet()
+	fourth.SetCache(cache)
+	require.NoError(t, fourth.Load(path))
+	_, ok := fourth.FindMessage("B")
+	assert.True(t, ok)
+	assert.Equal(t, 3, cache.Len())
+
+	cache.Purge()
+	assert.Zero(t, cache.Len())
+}
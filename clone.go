@@ -0,0 +1,109 @@
+package idl
+
+// Clone returns a deep copy of the File. Declarations of the returned File may
+// be modified (for instance, by FileSet.Resolve) without affecting the
+// original File.
+func (f *File) Clone() *File {
+	c := *f
+	c.Tree = nil
+	c.DeclaredMessages = nil
+	c.DeclaredServices = nil
+	c.ImportedFiles = nil
+	c.declaredNames = nil
+	for _, d := range f.Tree {
+		c.push(cloneDeclaration(d))
+	}
+	if f.tokenRanges != nil {
+		c.tokenRanges = make(map[Offset]TokenRange, len(f.tokenRanges))
+		for k, v := range f.tokenRanges {
+			c.tokenRanges[k] = v
+		}
+	}
+	return &c
+}
+
+func cloneDeclaration(d Declaration) Declaration {
+	switch v := d.(type) {
+	case *Package:
+		p := *v
+		return &p
+	case *Import:
+		i := *v
+		return &i
+	case *Message:
+		m := *v
+		m.Comments = cloneStrings(v.Comments)
+		m.Annotations = cloneAnnotations(v.Annotations)
+		m.Fields = cloneFieldItems(v.Fields)
+		return &m
+	case *Service:
+		s := *v
+		s.Comments = cloneStrings(v.Comments)
+		s.Annotations = cloneAnnotations(v.Annotations)
+		s.Methods = make([]Method, len(v.Methods))
+		for i, m := range v.Methods {
+			m.Comments = cloneStrings(m.Comments)
+			m.Annotations = cloneAnnotations(m.Annotations)
+			m.Argument.Target = nil
+			m.Return.Target = nil
+			s.Methods[i] = m
+		}
+		return &s
+	}
+	return d
+}
+
+func cloneFieldItems(items []FieldItem) []FieldItem {
+	if items == nil {
+		return nil
+	}
+	r := make([]FieldItem, len(items))
+	for i, item := range items {
+		switch v := item.(type) {
+		case Field:
+			v.Comments = cloneStrings(v.Comments)
+			v.Annotations = cloneAnnotations(v.Annotations)
+			v.Type = unresolveType(v.Type)
+			r[i] = v
+		case OneOfField:
+			v.Comments = cloneStrings(v.Comments)
+			v.Annotations = cloneAnnotations(v.Annotations)
+			v.Items = cloneFieldItems(v.Items)
+			r[i] = v
+		}
+	}
+	return r
+}
+
+// unresolveType returns t with every Resolved reference replaced by an
+// Unresolved one, detaching the type from declarations of other files.
+func unresolveType(t Type) Type {
+	switch v := t.(type) {
+	case Resolved:
+		return Unresolved{Name: v.Name.String()}
+	case Array:
+		return Array{Of: unresolveType(v.Of)}
+	case Map:
+		return Map{Key: v.Key, Value: unresolveType(v.Value)}
+	}
+	return t
+}
+
+func cloneAnnotations(a AnnotationCollection) AnnotationCollection {
+	if a == nil {
+		return nil
+	}
+	r := make(AnnotationCollection, len(a))
+	for i, v := range a {
+		v.Value = cloneStrings(v.Value)
+		r[i] = v
+	}
+	return r
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
@@ -0,0 +1,20 @@
+// Command yarpgen compiles YARP schemas and runs code generators as described
+// by a configuration file. See package driver for details.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/libyarp/idl/driver"
+)
+
+func main() {
+	if err := driver.Main(os.Args[1:], os.Stderr); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "yarpgen:", err)
+		}
+		os.Exit(1)
+	}
+}
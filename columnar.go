@@ -0,0 +1,345 @@
+package idl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ColumnSeparator contains the separator joining names of nested fields into
+// column names by FlattenMessage.
+const ColumnSeparator = "_"
+
+// Column represents a single column of a flattened message.
+type Column struct {
+	// Name contains the name of the column, composed of the names of the
+	// fields leading to it joined by ColumnSeparator.
+	Name string
+
+	// Path contains the names of the fields leading to the column, starting
+	// from the flattened message.
+	Path []string
+
+	// Type contains the type of the column. Nested messages are only present
+	// within arrays and maps, as Resolved types.
+	Type Type
+
+	// Nullable indicates whether the column may hold no value, which is the
+	// case for optional fields, cases of oneofs, and fields nested within
+	// them.
+	Nullable bool
+
+	// Comments contains the comments of the field the column represents.
+	Comments []string
+}
+
+// FlattenMessage converts m, which must have been resolved by a FileSet, into
+// a list of columns suitable for tabular storage. Fields holding messages are
+// replaced by the columns of the referenced message, named after the path
+// leading to them: field street of a field shipping becomes shipping_street.
+// Repeated fields, arrays, and maps are kept as single columns, as
+// flattening them would multiply rows. Messages that reference themselves
+// outside of arrays and maps cannot be flattened.
+func FlattenMessage(m *Message) ([]Column, error) {
+	c := &columnFlattener{visiting: map[*Message]bool{}, names: map[string]string{}}
+	if err := c.message(m, nil, false); err != nil {
+		return nil, err
+	}
+	return c.columns, nil
+}
+
+type columnFlattener struct {
+	columns  []Column
+	visiting map[*Message]bool
+	// names maps column names to the path of the field that produced them.
+	names map[string]string
+}
+
+func (c *columnFlattener) message(m *Message, path []string, nullable bool) error {
+	if c.visiting[m] {
+		return fmt.Errorf("%s: recursive message %s cannot be flattened", strings.Join(path, "."), m.Name)
+	}
+	c.visiting[m] = true
+	defer delete(c.visiting, m)
+	for _, item := range m.Fields {
+		switch v := item.(type) {
+		case Field:
+			_, optional := v.Annotations.FindByName(OptionalAnnotation)
+			if err := c.field(v, path, nullable || optional); err != nil {
+				return err
+			}
+		case OneOfField:
+			for _, f := range allFields(v.Items) {
+				if err := c.field(f, path, true); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *columnFlattener) field(f Field, parent []string, nullable bool) error {
+	path := append(parent[:len(parent):len(parent)], f.Name)
+	t := repeatedType(f)
+	switch v := t.(type) {
+	case Unresolved:
+		return fmt.Errorf("%s: unresolved type %s", strings.Join(path, "."), v.Name)
+	case Resolved:
+		return c.message(v.Message, path, nullable)
+	}
+	name := strings.Join(path, ColumnSeparator)
+	if other, ok := c.names[name]; ok {
+		return fmt.Errorf("%s: column %s is also produced by %s", strings.Join(path, "."), name, other)
+	}
+	c.names[name] = strings.Join(path, ".")
+	c.columns = append(c.columns, Column{Name: name, Path: path, Type: t, Nullable: nullable, Comments: f.Comments})
+	return nil
+}
+
+// ExportParquetSchema returns the Parquet schema, in the message type notation
+// used by parquet-mr and related tools, of the columns of m as produced by
+// FlattenMessage. Strings use the STRING logical type, arrays of uint8 are
+// represented as binary, and arrays and maps use the LIST and MAP logical
+// types, with messages within them represented as nested groups.
+func ExportParquetSchema(m *Message) (string, error) {
+	columns, err := FlattenMessage(m)
+	if err != nil {
+		return "", err
+	}
+	w := &parquetWriter{visiting: map[*Message]bool{}}
+	w.buf.WriteString("message " + m.Name + " {\n")
+	for _, col := range columns {
+		if err = w.field("  ", parquetRepetition(col.Nullable), col.Name, col.Type); err != nil {
+			return "", fmt.Errorf("%s: %w", strings.Join(col.Path, "."), err)
+		}
+	}
+	w.buf.WriteString("}\n")
+	return w.buf.String(), nil
+}
+
+type parquetWriter struct {
+	buf      strings.Builder
+	visiting map[*Message]bool
+}
+
+func parquetRepetition(nullable bool) string {
+	if nullable {
+		return "optional"
+	}
+	return "required"
+}
+
+func (w *parquetWriter) field(indent, repetition, name string, t Type) error {
+	switch v := t.(type) {
+	case Primitive:
+		physical, logical, err := parquetPrimitive(v.Kind)
+		if err != nil {
+			return err
+		}
+		if logical != "" {
+			logical = " (" + logical + ")"
+		}
+		fmt.Fprintf(&w.buf, "%s%s %s %s%s;\n", indent, repetition, physical, name, logical)
+	case Array:
+		if p, ok := v.Of.(Primitive); ok && p.Kind == Uint8 {
+			fmt.Fprintf(&w.buf, "%s%s binary %s;\n", indent, repetition, name)
+			return nil
+		}
+		fmt.Fprintf(&w.buf, "%s%s group %s (LIST) {\n%s  repeated group list {\n", indent, repetition, name, indent)
+		if err := w.field(indent+"    ", "required", "element", v.Of); err != nil {
+			return err
+		}
+		fmt.Fprintf(&w.buf, "%s  }\n%s}\n", indent, indent)
+	case Map:
+		fmt.Fprintf(&w.buf, "%s%s group %s (MAP) {\n%s  repeated group key_value {\n", indent, repetition, name, indent)
+		if err := w.field(indent+"    ", "required", "key", Primitive{Kind: v.Key}); err != nil {
+			return err
+		}
+		if err := w.field(indent+"    ", "required", "value", v.Value); err != nil {
+			return err
+		}
+		fmt.Fprintf(&w.buf, "%s  }\n%s}\n", indent, indent)
+	case Resolved:
+		if w.visiting[v.Message] {
+			return fmt.Errorf("recursive message %s cannot be represented", v.Message.Name)
+		}
+		w.visiting[v.Message] = true
+		defer delete(w.visiting, v.Message)
+		fmt.Fprintf(&w.buf, "%s%s group %s {\n", indent, repetition, name)
+		for _, item := range v.Message.Fields {
+			switch f := item.(type) {
+			case Field:
+				_, optional := f.Annotations.FindByName(OptionalAnnotation)
+				if err := w.field(indent+"  ", parquetRepetition(optional), f.Name, repeatedType(f)); err != nil {
+					return err
+				}
+			case OneOfField:
+				for _, c := range allFields(f.Items) {
+					if err := w.field(indent+"  ", "optional", c.Name, repeatedType(c)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		fmt.Fprintf(&w.buf, "%s}\n", indent)
+	case Unresolved:
+		return fmt.Errorf("unresolved type %s", v.Name)
+	default:
+		return fmt.Errorf("unsupported type %T", t)
+	}
+	return nil
+}
+
+// repeatedType returns the type of f, wrapped in an Array in case f is
+// annotated with @repeated.
+func repeatedType(f Field) Type {
+	if _, ok := f.Annotations.FindByName(RepeatedAnnotation); ok {
+		if _, isArray := f.Type.(Array); !isArray {
+			return Array{Of: f.Type}
+		}
+	}
+	return f.Type
+}
+
+// parquetPrimitive returns the physical and logical types representing p. The
+// logical type is empty when not required.
+func parquetPrimitive(p PrimitiveType) (string, string, error) {
+	switch p {
+	case Int8, Int16, Uint8, Uint16, Uint32:
+		info, _ := p.Info()
+		return "int32", fmt.Sprintf("INTEGER(%d,%t)", info.BitWidth, info.Signed), nil
+	case Int32:
+		return "int32", "", nil
+	case Int64:
+		return "int64", "", nil
+	case Uint64:
+		return "int64", "INTEGER(64,false)", nil
+	case Float32:
+		return "float", "", nil
+	case Float64:
+		return "double", "", nil
+	case Bool:
+		return "boolean", "", nil
+	case String:
+		return "binary", "STRING", nil
+	}
+	return "", "", fmt.Errorf("unsupported primitive %s", p)
+}
+
+// ExportArrowSchema returns the Arrow schema of the columns of m, as produced
+// by FlattenMessage, in the JSON representation used by Arrow integration
+// tests. Comments are stored under the "description" metadata key of each
+// field.
+func ExportArrowSchema(m *Message) ([]byte, error) {
+	columns, err := FlattenMessage(m)
+	if err != nil {
+		return nil, err
+	}
+	e := &arrowExporter{visiting: map[*Message]bool{}}
+	fields := make([]any, 0, len(columns))
+	for _, col := range columns {
+		f, err := e.field(col.Name, col.Type, col.Nullable)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strings.Join(col.Path, "."), err)
+		}
+		if len(col.Comments) > 0 {
+			f["metadata"] = []any{map[string]any{"key": "description", "value": strings.Join(col.Comments, " ")}}
+		}
+		fields = append(fields, f)
+	}
+	return json.MarshalIndent(map[string]any{"fields": fields}, "", "  ")
+}
+
+type arrowExporter struct {
+	visiting map[*Message]bool
+}
+
+func (e *arrowExporter) field(name string, t Type, nullable bool) (map[string]any, error) {
+	f := map[string]any{"name": name, "nullable": nullable, "children": []any{}}
+	switch v := t.(type) {
+	case Primitive:
+		typ, err := arrowPrimitive(v.Kind)
+		if err != nil {
+			return nil, err
+		}
+		f["type"] = typ
+	case Array:
+		if p, ok := v.Of.(Primitive); ok && p.Kind == Uint8 {
+			f["type"] = map[string]any{"name": "binary"}
+			break
+		}
+		item, err := e.field("item", v.Of, false)
+		if err != nil {
+			return nil, err
+		}
+		f["type"] = map[string]any{"name": "list"}
+		f["children"] = []any{item}
+	case Map:
+		key, err := e.field("key", Primitive{Kind: v.Key}, false)
+		if err != nil {
+			return nil, err
+		}
+		value, err := e.field("value", v.Value, false)
+		if err != nil {
+			return nil, err
+		}
+		f["type"] = map[string]any{"name": "map", "keysSorted": false}
+		f["children"] = []any{map[string]any{
+			"name":     "entries",
+			"nullable": false,
+			"type":     map[string]any{"name": "struct"},
+			"children": []any{key, value},
+		}}
+	case Resolved:
+		if e.visiting[v.Message] {
+			return nil, fmt.Errorf("recursive message %s cannot be represented", v.Message.Name)
+		}
+		e.visiting[v.Message] = true
+		defer delete(e.visiting, v.Message)
+		children := []any{}
+		for _, item := range v.Message.Fields {
+			switch i := item.(type) {
+			case Field:
+				_, optional := i.Annotations.FindByName(OptionalAnnotation)
+				c, err := e.field(i.Name, repeatedType(i), optional)
+				if err != nil {
+					return nil, err
+				}
+				children = append(children, c)
+			case OneOfField:
+				for _, o := range allFields(i.Items) {
+					c, err := e.field(o.Name, repeatedType(o), true)
+					if err != nil {
+						return nil, err
+					}
+					children = append(children, c)
+				}
+			}
+		}
+		f["type"] = map[string]any{"name": "struct"}
+		f["children"] = children
+	case Unresolved:
+		return nil, fmt.Errorf("unresolved type %s", v.Name)
+	default:
+		return nil, fmt.Errorf("unsupported type %T", t)
+	}
+	return f, nil
+}
+
+func arrowPrimitive(p PrimitiveType) (map[string]any, error) {
+	info, ok := p.Info()
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("unsupported primitive %s", p)
+	case p == Bool:
+		return map[string]any{"name": "bool"}, nil
+	case p == String:
+		return map[string]any{"name": "utf8"}, nil
+	case p == Float32:
+		return map[string]any{"name": "floatingpoint", "precision": "SINGLE"}, nil
+	case p == Float64:
+		return map[string]any{"name": "floatingpoint", "precision": "DOUBLE"}, nil
+	}
+	return map[string]any{"name": "int", "bitWidth": info.BitWidth, "isSigned": info.Signed}, nil
+}
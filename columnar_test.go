@@ -0,0 +1,60 @@
+package idl
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlattenMessage(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/typescript/main.yarp"))
+	require.Empty(t, fs.Resolve())
+	order, ok := fs.FindMessage("Order")
+	require.True(t, ok)
+
+	columns, err := FlattenMessage(order)
+	require.NoError(t, err)
+	var names []string
+	for _, c := range columns {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"id", "items", "shipping_street", "shipping_number", "quantities", "card", "voucher_code", "notes"}, names)
+	assert.Equal(t, []string{"shipping", "number"}, columns[3].Path)
+	assert.True(t, columns[3].Nullable)
+	assert.True(t, columns[6].Nullable)
+	assert.False(t, columns[2].Nullable)
+
+	schema, err := ExportParquetSchema(order)
+	require.NoError(t, err)
+	expected, err := os.ReadFile("./test/columnar/order.parquet")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), schema)
+
+	data, err := ExportArrowSchema(order)
+	require.NoError(t, err)
+	var arrow struct {
+		Fields []struct {
+			Name     string
+			Nullable bool
+			Type     map[string]any
+			Children []map[string]any
+		}
+	}
+	require.NoError(t, json.Unmarshal(data, &arrow))
+	require.Len(t, arrow.Fields, 8)
+	assert.Equal(t, map[string]any{"name": "int", "bitWidth": 64.0, "isSigned": true}, arrow.Fields[0].Type)
+	assert.Equal(t, "list", arrow.Fields[1].Type["name"])
+	assert.Equal(t, "struct", arrow.Fields[1].Children[0]["type"].(map[string]any)["name"])
+	assert.Equal(t, "map", arrow.Fields[4].Type["name"])
+
+	fs = NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/recursive.yarp"))
+	fs.Resolve()
+	node, _ := fs.FindMessage("Node")
+	_, err = FlattenMessage(node)
+	assert.EqualError(t, err, "parent: recursive message Node cannot be flattened")
+}
@@ -0,0 +1,284 @@
+package idl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeKind identifies the kind of a Change found by CompareFileSets.
+type ChangeKind string
+
+const (
+	ChangeMessageAdded     ChangeKind = "message-added"
+	ChangeMessageRemoved   ChangeKind = "message-removed"
+	ChangeFieldAdded       ChangeKind = "field-added"
+	ChangeFieldRemoved     ChangeKind = "field-removed"
+	ChangeFieldRenamed     ChangeKind = "field-renamed"
+	ChangeIndexChanged     ChangeKind = "index-changed"
+	ChangeIndexReused      ChangeKind = "index-reused"
+	ChangeTypeChanged      ChangeKind = "type-changed"
+	ChangeServiceAdded     ChangeKind = "service-added"
+	ChangeServiceRemoved   ChangeKind = "service-removed"
+	ChangeMethodAdded      ChangeKind = "method-added"
+	ChangeMethodRemoved    ChangeKind = "method-removed"
+	ChangeMethodSignature  ChangeKind = "method-signature-changed"
+	ChangeStreamingChanged ChangeKind = "streaming-changed"
+	ChangeDeprecated       ChangeKind = "deprecated"
+)
+
+// Change represents a single difference between two versions of a schema.
+// Changes breaking wire compatibility have SeverityError, changes breaking
+// source compatibility only have SeverityWarning, and compatible changes have
+// SeverityInfo.
+type Change struct {
+	Kind     ChangeKind
+	Severity Severity
+
+	// Symbol contains the FQN of the affected message or service, followed
+	// by the name of the affected field or method, if any.
+	Symbol string
+
+	// Message contains a human-readable description of the change.
+	Message string
+
+	// Old and New represent the location of the affected declaration in
+	// each version, and are empty in case the declaration does not exist in
+	// that version.
+	Old, New Location
+}
+
+// Changes represents a list of Change values.
+type Changes []Change
+
+// HasBreaking returns whether the list contains at least one change breaking
+// wire compatibility.
+func (c Changes) HasBreaking() bool {
+	return len(c.Breaking()) > 0
+}
+
+// Breaking returns a list containing only changes breaking wire
+// compatibility.
+func (c Changes) Breaking() Changes {
+	var r Changes
+	for _, v := range c {
+		if v.Severity == SeverityError {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+// CompareFileSets resolves and compares two versions of a schema, prev and
+// next, returning all changes found, ordered by the FQN of the affected
+// declarations. Fields are matched by their index, and methods by their name.
+func CompareFileSets(prev, next *FileSet) Changes {
+	prev.Resolve()
+	next.Resolve()
+	c := &schemaComparison{old: prev, new: next}
+
+	for _, fqn := range unionKeys(prev.messages, next.messages) {
+		om, inOld := prev.messages[fqn]
+		nm, inNew := next.messages[fqn]
+		switch {
+		case !inNew:
+			c.add(ChangeMessageRemoved, SeverityError, fqn.String(), "message %s was removed", locate(prev, om)(om), Location{})
+		case !inOld:
+			c.add(ChangeMessageAdded, SeverityInfo, fqn.String(), "message %s was added", Location{}, locate(next, nm)(nm))
+		default:
+			c.compareMessages(fqn, om, nm)
+		}
+	}
+	for _, fqn := range unionKeys(prev.services, next.services) {
+		os, inOld := prev.services[fqn]
+		ns, inNew := next.services[fqn]
+		switch {
+		case !inNew:
+			c.add(ChangeServiceRemoved, SeverityError, fqn.String(), "service %s was removed", locate(prev, os)(os), Location{})
+		case !inOld:
+			c.add(ChangeServiceAdded, SeverityInfo, fqn.String(), "service %s was added", Location{}, locate(next, ns)(ns))
+		default:
+			c.compareServices(fqn, os, ns)
+		}
+	}
+	return c.changes
+}
+
+type schemaComparison struct {
+	old, new *FileSet
+	changes  Changes
+}
+
+// add records a change. format is provided with symbol, followed by args.
+func (c *schemaComparison) add(kind ChangeKind, severity Severity, symbol, format string, o, n Location, args ...any) {
+	c.changes = append(c.changes, Change{
+		Kind:     kind,
+		Severity: severity,
+		Symbol:   symbol,
+		Message:  fmt.Sprintf(format, append([]any{symbol}, args...)...),
+		Old:      o,
+		New:      n,
+	})
+}
+
+// locate returns a function computing locations of nodes nested in the
+// provided declaration of f.
+func locate(f *FileSet, d Declaration) func(Node) Location {
+	return func(n Node) Location {
+		return locationOf(f.declaredIn[d], n.Span())
+	}
+}
+
+func (c *schemaComparison) compareMessages(fqn FQN, om, nm *Message) {
+	ol, nl := locate(c.old, om), locate(c.new, nm)
+	if isDeprecated(nm.Annotations) && !isDeprecated(om.Annotations) {
+		c.add(ChangeDeprecated, SeverityInfo, fqn.String(), "message %s was deprecated", ol(om), nl(nm))
+	}
+	oldFields, newFields := allFields(om.Fields), allFields(nm.Fields)
+	newByIndex := map[int]Field{}
+	newByName := map[string]Field{}
+	for _, f := range newFields {
+		newByIndex[f.Index] = f
+		newByName[f.Name] = f
+	}
+	oldIndexes := map[int]bool{}
+	oldNames := map[string]bool{}
+	reserved, _ := reservedOf(nm)
+	sort.SliceStable(oldFields, func(i, j int) bool { return oldFields[i].Index < oldFields[j].Index })
+
+	for _, of := range oldFields {
+		oldIndexes[of.Index] = true
+		oldNames[of.Name] = true
+		symbol := fqn.String() + "." + of.Name
+		nf, ok := newByIndex[of.Index]
+		if !ok {
+			if moved, ok := newByName[of.Name]; ok {
+				c.add(ChangeIndexChanged, SeverityError, symbol, "field %s changed index from %d to %d", ol(of), nl(moved), of.Index, moved.Index)
+			} else if reserved[of.Index] {
+				c.add(ChangeFieldRemoved, SeverityWarning, symbol, "field %s was removed, and its index %d reserved", ol(of), Location{}, of.Index)
+			} else {
+				c.add(ChangeFieldRemoved, SeverityError, symbol, "field %s was removed without reserving its index %d", ol(of), Location{}, of.Index)
+			}
+			continue
+		}
+		ot, nt := describeFieldType(of), describeFieldType(nf)
+		if nf.Name != of.Name {
+			moved, isMoved := newByName[of.Name]
+			if isMoved {
+				c.add(ChangeIndexChanged, SeverityError, symbol, "field %s changed index from %d to %d", ol(of), nl(moved), of.Index, moved.Index)
+			}
+			if ot != nt {
+				c.add(ChangeIndexReused, SeverityError, symbol, "index %[2]d of field %[1]s was reused by field %[3]s of type %[4]s", ol(of), nl(nf), of.Index, nf.Name, nt)
+			} else if !isMoved && renamedFrom(nf, of.Name) {
+				c.add(ChangeFieldRenamed, SeverityInfo, symbol, "field %s was renamed to %s, retaining its former name", ol(of), nl(nf), nf.Name)
+			} else if !isMoved {
+				c.add(ChangeFieldRenamed, SeverityWarning, symbol, "field %s was renamed to %s", ol(of), nl(nf), nf.Name)
+			}
+			continue
+		}
+		if ot != nt {
+			c.add(ChangeTypeChanged, SeverityError, symbol, "field %s changed type from %s to %s", ol(of), nl(nf), ot, nt)
+		}
+		if isDeprecated(nf.Annotations) && !isDeprecated(of.Annotations) {
+			c.add(ChangeDeprecated, SeverityInfo, symbol, "field %s was deprecated", ol(of), nl(nf))
+		}
+	}
+	sort.SliceStable(newFields, func(i, j int) bool { return newFields[i].Index < newFields[j].Index })
+	for _, nf := range newFields {
+		if !oldIndexes[nf.Index] && !oldNames[nf.Name] {
+			c.add(ChangeFieldAdded, SeverityInfo, fqn.String()+"."+nf.Name, "field %s was added with index %d", Location{}, nl(nf), nf.Index)
+		}
+	}
+}
+
+func (c *schemaComparison) compareServices(fqn FQN, os, ns *Service) {
+	ol, nl := locate(c.old, os), locate(c.new, ns)
+	if isDeprecated(ns.Annotations) && !isDeprecated(os.Annotations) {
+		c.add(ChangeDeprecated, SeverityInfo, fqn.String(), "service %s was deprecated", ol(os), nl(ns))
+	}
+	newMethods := map[string]Method{}
+	for _, m := range ns.Methods {
+		newMethods[m.Name] = m
+	}
+	oldMethods := map[string]bool{}
+	for _, om := range os.Methods {
+		oldMethods[om.Name] = true
+		symbol := fqn.String() + "." + om.Name
+		nm, ok := newMethods[om.Name]
+		if !ok {
+			c.add(ChangeMethodRemoved, SeverityError, symbol, "method %s was removed", ol(om), Location{})
+			continue
+		}
+		oldSig := c.old.refName(om.Argument) + " -> " + c.old.refName(om.Return)
+		newSig := c.new.refName(nm.Argument) + " -> " + c.new.refName(nm.Return)
+		if oldSig != newSig {
+			c.add(ChangeMethodSignature, SeverityError, symbol, "method %s changed signature from (%s) to (%s)", ol(om), nl(nm), oldSig, newSig)
+		}
+		if om.Return.Streaming != nm.Return.Streaming {
+			what := "no longer streams"
+			if nm.Return.Streaming {
+				what = "now streams"
+			}
+			c.add(ChangeStreamingChanged, SeverityError, symbol, "method %s %s its return value", ol(om), nl(nm), what)
+		}
+		if isDeprecated(nm.Annotations) && !isDeprecated(om.Annotations) {
+			c.add(ChangeDeprecated, SeverityInfo, symbol, "method %s was deprecated", ol(om), nl(nm))
+		}
+	}
+	for _, nm := range ns.Methods {
+		if !oldMethods[nm.Name] {
+			c.add(ChangeMethodAdded, SeverityInfo, fqn.String()+"."+nm.Name, "method %s was added", Location{}, nl(nm))
+		}
+	}
+}
+
+// describeFieldType returns a representation of the type of a field,
+// including annotations affecting how it is encoded.
+func describeFieldType(f Field) string {
+	var labels []string
+	for _, a := range []string{OptionalAnnotation, RepeatedAnnotation} {
+		if _, ok := f.Annotations.FindByName(a); ok {
+			labels = append(labels, "@"+a)
+		}
+	}
+	return strings.Join(append(labels, canonicalType(f.Type)), " ")
+}
+
+// refName returns the FQN of the message referenced by t, or its name as
+// written in case it could not be resolved.
+func (f *FileSet) refName(t TypeRef) string {
+	if t.Target != nil {
+		return NewFQN(f.packageOf(t.Target), t.Target.Name).String()
+	}
+	return t.String()
+}
+
+// renamedFrom returns whether f declares name as a former name.
+func renamedFrom(f Field, name string) bool {
+	for _, n := range f.Annotations.RenamedFrom() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isDeprecated(a AnnotationCollection) bool {
+	_, ok := a.FindByName(DeprecatedAnnotation)
+	return ok
+}
+
+func unionKeys[V any](a, b map[FQN]V) []FQN {
+	seen := map[FQN]bool{}
+	var r []FQN
+	for _, m := range []map[FQN]V{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				r = append(r, k)
+			}
+		}
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i] < r[j] })
+	return r
+}
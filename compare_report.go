@@ -0,0 +1,129 @@
+package idl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Categories of changes, as returned by Change.Category.
+const (
+	CategoryAdded      = "added"
+	CategoryRemoved    = "removed"
+	CategoryDeprecated = "deprecated"
+	CategoryChanged    = "changed"
+)
+
+// Category returns whether the change represents an addition, a removal, a
+// deprecation, or a modification of an existing declaration.
+func (c Change) Category() string {
+	switch {
+	case c.Kind == ChangeDeprecated:
+		return CategoryDeprecated
+	case strings.HasSuffix(string(c.Kind), "-added"):
+		return CategoryAdded
+	case strings.HasSuffix(string(c.Kind), "-removed"):
+		return CategoryRemoved
+	default:
+		return CategoryChanged
+	}
+}
+
+// WriteMarkdown writes a changelog describing the changes in Markdown.
+// Breaking changes are listed first, followed by deprecations, additions,
+// removals, and other changes.
+func (c Changes) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Schema changes\n")
+	if len(c) == 0 {
+		b.WriteString("\nNo changes.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	sections := []struct {
+		title string
+		match func(Change) bool
+	}{
+		{"Breaking changes", func(ch Change) bool { return ch.Severity == SeverityError }},
+		{"Deprecations", func(ch Change) bool { return ch.Category() == CategoryDeprecated }},
+		{"Additions", func(ch Change) bool { return ch.Category() == CategoryAdded }},
+		{"Removals", func(ch Change) bool { return ch.Category() == CategoryRemoved }},
+		{"Other changes", func(Change) bool { return true }},
+	}
+	listed := make([]bool, len(c))
+	for _, s := range sections {
+		var items []string
+		for i, ch := range c {
+			if !listed[i] && s.match(ch) {
+				listed[i] = true
+				items = append(items, fmt.Sprintf("- `%s`: %s\n", ch.Symbol, ch.Message))
+			}
+		}
+		if len(items) > 0 {
+			fmt.Fprintf(&b, "\n## %s\n\n%s", s.title, strings.Join(items, ""))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type changeReport struct {
+	Breaking bool           `json:"breaking"`
+	Summary  map[string]int `json:"summary"`
+	Changes  []changeEntry  `json:"changes"`
+}
+
+type changeEntry struct {
+	Kind     ChangeKind      `json:"kind"`
+	Category string          `json:"category"`
+	Severity string          `json:"severity"`
+	Symbol   string          `json:"symbol"`
+	Message  string          `json:"message"`
+	Old      *reportLocation `json:"old,omitempty"`
+	New      *reportLocation `json:"new,omitempty"`
+}
+
+type reportLocation struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+func newReportLocation(l Location) *reportLocation {
+	if l == (Location{}) {
+		return nil
+	}
+	return &reportLocation{File: l.File, Line: l.Offset.StartsAt.Line, Column: l.Offset.StartsAt.Column}
+}
+
+// WriteJSON writes a machine-readable report of the changes as JSON. The
+// report indicates whether any change is breaking, how many changes belong to
+// each category, and lists every change.
+func (c Changes) WriteJSON(w io.Writer) error {
+	r := changeReport{
+		Breaking: c.HasBreaking(),
+		Summary: map[string]int{
+			CategoryAdded:      0,
+			CategoryRemoved:    0,
+			CategoryDeprecated: 0,
+			CategoryChanged:    0,
+		},
+		Changes: []changeEntry{},
+	}
+	for _, ch := range c {
+		r.Summary[ch.Category()]++
+		r.Changes = append(r.Changes, changeEntry{
+			Kind:     ch.Kind,
+			Category: ch.Category(),
+			Severity: ch.Severity.String(),
+			Symbol:   ch.Symbol,
+			Message:  ch.Message,
+			Old:      newReportLocation(ch.Old),
+			New:      newReportLocation(ch.New),
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,93 @@
+package idl
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadVersions(t *testing.T) (*FileSet, *FileSet) {
+	prev := NewFileSet()
+	require.NoError(t, prev.Load("./test/compare/v1.yarp"))
+	next := NewFileSet()
+	require.NoError(t, next.Load("./test/compare/v2.yarp"))
+	return prev, next
+}
+
+func TestCompareFileSets(t *testing.T) {
+	changes := CompareFileSets(loadVersions(t))
+	var found []string
+	for _, c := range changes {
+		found = append(found, c.Severity.String()+" "+string(c.Kind)+": "+c.Message)
+	}
+	assert.Equal(t, []string{
+		"error message-removed: message org.example.shop.Coupon was removed",
+		"info message-added: message org.example.shop.Discount was added",
+		"warning field-renamed: field org.example.shop.Item.name was renamed to title",
+		"error type-changed: field org.example.shop.Item.price changed type from int32 to int64",
+		"info deprecated: field org.example.shop.Item.sku was deprecated",
+		"warning field-removed: field org.example.shop.Item.legacy_code was removed, and its index 4 reserved",
+		"error field-removed: field org.example.shop.Item.weight was removed without reserving its index 5",
+		"error index-changed: field org.example.shop.Item.tags changed index from 6 to 7",
+		"error index-reused: index 6 of field org.example.shop.Item.tags was reused by field weight_grams of type int64",
+		"info field-added: field org.example.shop.Item.color was added with index 8",
+		"error streaming-changed: method org.example.shop.Shop.list_items now streams its return value",
+		"error streaming-changed: method org.example.shop.Shop.watch_cart no longer streams its return value",
+		"error method-removed: method org.example.shop.Shop.apply_coupon was removed",
+		"info method-added: method org.example.shop.Shop.add_discount was added",
+	}, found)
+	assert.True(t, changes.HasBreaking())
+
+	assert.Equal(t, 5, changes[2].Old.Offset.StartsAt.Line)
+	assert.Equal(t, 6, changes[2].New.Offset.StartsAt.Line)
+	assert.Contains(t, changes[2].Old.File, "v1.yarp")
+
+	prev, _ := loadVersions(t)
+	assert.Empty(t, CompareFileSets(prev, prev))
+}
+
+func TestChangesWriteMarkdown(t *testing.T) {
+	changes := CompareFileSets(loadVersions(t))
+	var b strings.Builder
+	require.NoError(t, changes.WriteMarkdown(&b))
+	out := b.String()
+	assert.True(t, strings.HasPrefix(out, "# Schema changes\n\n## Breaking changes\n\n- `org.example.shop.Coupon`: message org.example.shop.Coupon was removed\n"))
+	assert.Contains(t, out, "\n## Deprecations\n\n- `org.example.shop.Item.sku`: field org.example.shop.Item.sku was deprecated\n")
+	assert.Contains(t, out, "\n## Additions\n\n- `org.example.shop.Discount`: ")
+	assert.Contains(t, out, "\n## Removals\n\n- `org.example.shop.Item.legacy_code`: ")
+	assert.Contains(t, out, "\n## Other changes\n\n- `org.example.shop.Item.name`: field org.example.shop.Item.name was renamed to title\n")
+
+	b.Reset()
+	require.NoError(t, Changes(nil).WriteMarkdown(&b))
+	assert.Equal(t, "# Schema changes\n\nNo changes.\n", b.String())
+}
+
+func TestChangesWriteJSON(t *testing.T) {
+	changes := CompareFileSets(loadVersions(t))
+	var b strings.Builder
+	require.NoError(t, changes.WriteJSON(&b))
+	var report struct {
+		Breaking bool           `json:"breaking"`
+		Summary  map[string]int `json:"summary"`
+		Changes  []struct {
+			Kind     string `json:"kind"`
+			Category string `json:"category"`
+			Old      *struct {
+				Line int `json:"line"`
+			} `json:"old"`
+			New *struct {
+				Line int `json:"line"`
+			} `json:"new"`
+		} `json:"changes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(b.String()), &report))
+	assert.True(t, report.Breaking)
+	assert.Equal(t, map[string]int{"added": 3, "removed": 4, "deprecated": 1, "changed": 6}, report.Summary)
+	require.Len(t, report.Changes, len(changes))
+	assert.Equal(t, "message-removed", report.Changes[0].Kind)
+	assert.NotNil(t, report.Changes[0].Old)
+	assert.Nil(t, report.Changes[0].New)
+}
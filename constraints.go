@@ -0,0 +1,247 @@
+package idl
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// MinAnnotation contains the name of @min annotations, which define the
+	// lowest value accepted by a numeric field, inclusive.
+	MinAnnotation = "min"
+
+	// MaxAnnotation contains the name of @max annotations, which define the
+	// highest value accepted by a numeric field, inclusive.
+	MaxAnnotation = "max"
+
+	// LenAnnotation contains the name of @len annotations, which define the
+	// length accepted by strings, arrays, and maps. A single value requires
+	// an exact length, while two values define an inclusive range. Strings
+	// are measured in characters, and other types in elements.
+	LenAnnotation = "len"
+
+	// PatternAnnotation contains the name of @pattern annotations, which
+	// define a regular expression, in the syntax accepted by package regexp,
+	// that string fields must match in their entirety.
+	PatternAnnotation = "pattern"
+
+	// RequiredAnnotation contains the name of @required annotations. Optional
+	// fields and oneof cases annotated with @required must be set, while
+	// other fields must hold a value other than their zero value.
+	RequiredAnnotation = "required"
+
+	// CodeInvalidConstraint identifies diagnostics emitted for constraint
+	// annotations that are malformed or applied to incompatible types.
+	CodeInvalidConstraint = "invalid-constraint"
+)
+
+// Constraints represents the validation rules declared by a field through
+// constraint annotations. Values and patterns of arrays other than
+// array<uint8> apply to each of their elements, while lengths apply to the
+// array itself.
+type Constraints struct {
+	// Min and Max contain the bounds of numeric values, or nil.
+	Min, Max *big.Rat
+
+	// Len contains the accepted length of the field, or nil.
+	Len *LengthRange
+
+	// Pattern contains the expression string values must match, or nil.
+	Pattern *regexp.Regexp
+
+	// Required indicates whether the field is annotated with @required.
+	Required bool
+}
+
+// LengthRange represents an inclusive range of lengths.
+type LengthRange struct{ Min, Max int }
+
+// IsZero returns whether c declares no rules.
+func (c Constraints) IsZero() bool {
+	return c.Min == nil && c.Max == nil && c.Len == nil && c.Pattern == nil && !c.Required
+}
+
+// FieldConstraints returns the constraints declared by f, or an error in case
+// they are malformed or incompatible with the type of f. Since annotation
+// values are kept as written, negative and fractional bounds must be quoted,
+// as in @min("-1.5").
+func FieldConstraints(f Field) (Constraints, error) {
+	c, problems := fieldConstraints(f)
+	if len(problems) > 0 {
+		return Constraints{}, fmt.Errorf("field %s: %s", f.Name, problems[0].message)
+	}
+	return c, nil
+}
+
+// constraintProblem represents a malformed annotation found while parsing
+// constraints or method options.
+type constraintProblem struct {
+	offset  Offset
+	message string
+}
+
+func fieldConstraints(f Field) (Constraints, []constraintProblem) {
+	var c Constraints
+	var problems []constraintProblem
+	fail := func(a *AnnotationValue, format string, args ...any) {
+		problems = append(problems, constraintProblem{a.Offset, "@" + a.Name + ": " + fmt.Sprintf(format, args...)})
+	}
+
+	t := f.Type
+	if _, ok := f.Annotations.FindByName(RepeatedAnnotation); ok {
+		if _, isArray := t.(Array); !isArray {
+			t = Array{Of: t}
+		}
+	}
+	elem := t
+	if a, ok := t.(Array); ok && !isByteArray(a) {
+		elem = a.Of
+	}
+	p, _ := elem.(Primitive)
+	numeric := p.Kind != Invalid && p.Kind != Bool && p.Kind != String
+
+	bound := func(name string) *big.Rat {
+		a, ok := f.Annotations.FindByName(name)
+		if !ok {
+			return nil
+		}
+		if !numeric {
+			fail(a, "cannot be applied to %s", typeString(t))
+			return nil
+		}
+		if len(a.Value) != 1 {
+			fail(a, "expected a single value")
+			return nil
+		}
+		r, err := parseBound(p.Kind, a.Value[0])
+		if err != nil {
+			fail(a, "%s", err)
+		}
+		return r
+	}
+	c.Min, c.Max = bound(MinAnnotation), bound(MaxAnnotation)
+	if c.Min != nil && c.Max != nil && c.Min.Cmp(c.Max) > 0 {
+		a, _ := f.Annotations.FindByName(MinAnnotation)
+		fail(a, "minimum %s is greater than maximum %s", c.Min.RatString(), c.Max.RatString())
+	}
+
+	if a, ok := f.Annotations.FindByName(LenAnnotation); ok {
+		switch v := t.(type) {
+		case Array, Map:
+		case Primitive:
+			if v.Kind != String {
+				fail(a, "cannot be applied to %s", typeString(t))
+			}
+		default:
+			fail(a, "cannot be applied to %s", typeString(t))
+		}
+		var bounds []int
+		for _, v := range a.Value {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				fail(a, "invalid length %q", v)
+				continue
+			}
+			bounds = append(bounds, n)
+		}
+		switch {
+		case len(a.Value) == 0 || len(a.Value) > 2:
+			fail(a, "expected a length, or a minimum and maximum length")
+		case len(bounds) == 1 && len(a.Value) == 1:
+			c.Len = &LengthRange{bounds[0], bounds[0]}
+		case len(bounds) == 2:
+			if bounds[0] > bounds[1] {
+				fail(a, "minimum length %d is greater than maximum length %d", bounds[0], bounds[1])
+			} else {
+				c.Len = &LengthRange{bounds[0], bounds[1]}
+			}
+		}
+	}
+
+	if a, ok := f.Annotations.FindByName(PatternAnnotation); ok {
+		switch {
+		case p.Kind != String:
+			fail(a, "cannot be applied to %s", typeString(t))
+		case len(a.Value) != 1:
+			fail(a, "expected a single value")
+		default:
+			re, err := regexp.Compile(`^(?:` + a.Value[0] + `)$`)
+			if err != nil {
+				fail(a, "invalid expression %q", a.Value[0])
+			}
+			c.Pattern = re
+		}
+	}
+
+	if a, ok := f.Annotations.FindByName(RequiredAnnotation); ok {
+		if len(a.Value) > 0 {
+			fail(a, "takes no values")
+		}
+		c.Required = true
+	}
+	return c, problems
+}
+
+// parseBound parses v as a value of type p.
+func parseBound(p PrimitiveType, v string) (*big.Rat, error) {
+	var err error
+	switch {
+	case p.IsFloat():
+		var f float64
+		if f, err = strconv.ParseFloat(v, p.BitWidth()); err == nil {
+			return new(big.Rat).SetFloat64(f), nil
+		}
+	case p.IsSigned():
+		_, err = strconv.ParseInt(v, 10, p.BitWidth())
+	default:
+		_, err = strconv.ParseUint(v, 10, p.BitWidth())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value %q", p.Keyword(), v)
+	}
+	r, _ := new(big.Rat).SetString(v)
+	return r, nil
+}
+
+func isByteArray(a Array) bool {
+	p, ok := a.Of.(Primitive)
+	return ok && p.Kind == Uint8
+}
+
+// typeString returns the representation of t in the YARP syntax.
+func typeString(t Type) string {
+	switch v := t.(type) {
+	case Primitive:
+		return v.Kind.Keyword()
+	case Array:
+		return "array<" + typeString(v.Of) + ">"
+	case Map:
+		return "map<" + v.Key.Keyword() + ", " + typeString(v.Value) + ">"
+	case Resolved:
+		return v.Name.String()
+	case Unresolved:
+		return v.Name
+	}
+	return fmt.Sprintf("%T", t)
+}
+
+func checkConstraints(f *FileSet) Diagnostics {
+	var diags Diagnostics
+	for _, m := range f.allMessages {
+		file := f.declaredIn[m]
+		for _, field := range allFields(m.Fields) {
+			_, problems := fieldConstraints(field)
+			for _, p := range problems {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     CodeInvalidConstraint,
+					Message:  fmt.Sprintf("field %s of %s: %s", field.Name, m.Name, p.message),
+					Location: locationOf(file, p.offset),
+				})
+			}
+		}
+	}
+	return diags
+}
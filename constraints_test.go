@@ -0,0 +1,62 @@
+package idl
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldConstraints(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/constraints/user.yarp"))
+	assert.Empty(t, fs.Validate())
+	user, ok := fs.FindMessage("User")
+	require.True(t, ok)
+
+	constraints := func(name string) Constraints {
+		for _, f := range allFields(user.Fields) {
+			if f.Name == name {
+				c, err := FieldConstraints(f)
+				require.NoError(t, err)
+				return c
+			}
+		}
+		t.Fatalf("no field %s", name)
+		return Constraints{}
+	}
+
+	handle := constraints("handle")
+	assert.Equal(t, &LengthRange{3, 32}, handle.Len)
+	assert.True(t, handle.Pattern.MatchString("jane_doe"))
+	assert.False(t, handle.Pattern.MatchString("Jane"), "patterns must match entire values")
+
+	coordinates := constraints("coordinates")
+	assert.Equal(t, 0, coordinates.Min.Cmp(big.NewRat(-90, 1)))
+	assert.Equal(t, 0, coordinates.Max.Cmp(big.NewRat(181, 2)))
+
+	assert.True(t, constraints("email").Required)
+	assert.True(t, constraints("profile").IsZero())
+}
+
+func TestConstraintsCheck(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/constraints/invalid.yarp"))
+	var messages []string
+	for _, d := range fs.Validate() {
+		assert.Equal(t, CodeInvalidConstraint, d.Code)
+		messages = append(messages, d.Message)
+	}
+	assert.Equal(t, []string{
+		"field name of Invalid: @min: cannot be applied to string",
+		`field handle of Invalid: @pattern: invalid expression "[a-z"`,
+		`field small of Invalid: @min: invalid uint8 value "300"`,
+		"field inverted of Invalid: @min: minimum 10 is greater than maximum 5",
+		"field count of Invalid: @len: cannot be applied to int32",
+		`field count of Invalid: @len: invalid length "a"`,
+		"field items of Invalid: @len: minimum length 5 is greater than maximum length 2",
+		"field flag of Invalid: @required: takes no values",
+		"field size of Invalid: @pattern: cannot be applied to uint32",
+	}, messages)
+}
@@ -0,0 +1,126 @@
+package idl
+
+// Definition resolves the name written at the byte offset pos of the file
+// loaded into fs under path to the location of its definition, along with a
+// boolean indicating whether a definition could be found. Names of types used
+// by fields, and by method arguments and return types, resolve to the
+// declaration of the referenced message, even when declared by an imported
+// file, or referenced through a former name declared with @renamed_from.
+// Import paths resolve to the package directive of the imported file. pos may
+// also point to the end of a name, as cursors of editors usually do.
+func Definition(fs *FileSet, path string, pos int) (Location, bool) {
+	file, ok := fs.FileByPath(path)
+	if !ok {
+		return Location{}, false
+	}
+	for _, d := range file.Tree {
+		if !spans(d.Span(), pos) {
+			continue
+		}
+		switch v := d.(type) {
+		case *Import:
+			return fs.importDefinition(file, v)
+		case *Message:
+			return fs.fieldDefinition(file, v.Fields, pos)
+		case *Service:
+			for _, m := range v.Methods {
+				for _, ref := range []TypeRef{m.Argument, m.Return} {
+					if !ref.IsVoid() && spans(ref.Offset, pos) {
+						return fs.messageDefinition(file, ref.FQN())
+					}
+				}
+			}
+		}
+	}
+	return Location{}, false
+}
+
+// spans returns whether pos lies within o, including its end.
+func spans(o Offset, pos int) bool {
+	return o.Start <= pos && pos <= o.End
+}
+
+func (f *FileSet) importDefinition(file *File, imp *Import) (Location, bool) {
+	path, _, err := f.resolveImport(file.SourcePath, imp.Path)
+	if err != nil {
+		return Location{}, false
+	}
+	imported, ok := f.files[path]
+	if !ok {
+		return Location{}, false
+	}
+	l := Location{File: imported.SourcePath}
+	for _, d := range imported.Tree {
+		if p, ok := d.(*Package); ok {
+			l.Offset = p.Offset
+		}
+	}
+	return l, true
+}
+
+func (f *FileSet) fieldDefinition(file *File, items []FieldItem, pos int) (Location, bool) {
+	for _, item := range items {
+		if !spans(item.Span(), pos) {
+			continue
+		}
+		switch v := item.(type) {
+		case Field:
+			for _, n := range typeNamesOf(file, v) {
+				if spans(n.Offset, pos) {
+					return f.messageDefinition(file, FQN(n.Name))
+				}
+			}
+			return Location{}, false
+		case OneOfField:
+			return f.fieldDefinition(file, v.Items, pos)
+		}
+	}
+	return Location{}, false
+}
+
+// typeName represents a message name written within the type of a field.
+type typeName struct {
+	Name   string
+	Offset Offset
+}
+
+// typeNamesOf returns the message names written within the type of field, in
+// order, or nil in case the source of file is not available.
+func typeNamesOf(file *File, field Field) []typeName {
+	if file.Source == nil || field.Offset.End > len(file.Source) {
+		return nil
+	}
+	tokens, err := scanRange(file.Source, field.Offset.Start, field.Offset.End)
+	if err != nil {
+		return nil
+	}
+	var names []typeName
+	// The first token contains the name of the field.
+	for i := 1; i < len(tokens) && !tokens[i].is(Equal); i++ {
+		t := tokens[i]
+		if !t.is(Identifier) || t.Value == "array" || t.Value == "map" {
+			continue
+		}
+		if _, ok := stringToPrimitive[t.Value]; ok {
+			continue
+		}
+		name := t.Value
+		j := i + 1
+		for ; j < len(tokens) && (tokens[j].is(Identifier) || tokens[j].is(Dot)); j++ {
+			name += tokens[j].Value
+		}
+		names = append(names, typeName{Name: name, Offset: offsetBetween(t, tokens[j-1])})
+		i = j - 1
+	}
+	return names
+}
+
+func (f *FileSet) messageDefinition(file *File, name FQN) (Location, bool) {
+	msg, ok := f.lookupMessage(file, name)
+	if !ok {
+		if msg, ok = f.lookupRenamed(file, name); !ok {
+			return Location{}, false
+		}
+	}
+	return locationOf(f.declaredIn[msg], msg.Offset), true
+}
@@ -0,0 +1,69 @@
+package idl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefinition(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/definition/shop.yarp"))
+	shop, ok := fs.FileByPath("./test/definition/shop.yarp")
+	require.True(t, ok)
+	common, ok := fs.FileByPath("./test/definition/common.yarp")
+	require.True(t, ok)
+	money, ok := fs.FindSymbol("org.example.common.Money")
+	require.True(t, ok)
+	item, ok := fs.FindSymbol("Item")
+	require.True(t, ok)
+	order, ok := fs.FindSymbol("Order")
+	require.True(t, ok)
+
+	src := string(shop.Source)
+	at := func(s string, n int) int { return strings.Index(src, s) + n }
+	for _, c := range []struct {
+		pos      int
+		expected Symbol
+	}{
+		{at("common.Money = 1", 0), money},
+		{at("Money = 1", 0), money},
+		{at("Money = 1", 5), money},
+		{at("org.example.common.Money = 1", 0), money},
+		{at("Item> = 0", 2), item},
+		{at("Cash", 1), money},
+		{at("Order = 1", 0), order},
+		{at("Money>", 3), money},
+		{at("(Order)", 1), order},
+		{at("Item;", 4), item},
+	} {
+		l, ok := Definition(fs, "./test/definition/shop.yarp", c.pos)
+		require.True(t, ok, "position %d", c.pos)
+		assert.Equal(t, Location{File: c.expected.File.SourcePath, Offset: c.expected.Offset}, l, "position %d", c.pos)
+	}
+
+	l, ok := Definition(fs, "./test/definition/shop.yarp", at("common.yarp", 0))
+	require.True(t, ok)
+	assert.Equal(t, common.SourcePath, l.File)
+	assert.Equal(t, common.Tree[0].Span(), l.Offset)
+
+	for _, pos := range []int{
+		at("package", 2),
+		at("sku", 1),
+		at("string = 0", 1),
+		at("array<", 1),
+		at("map<", 1),
+		at("string, ", 1),
+		at("Unknown", 1),
+		at("= 1;", 2),
+		at("clear", 1),
+		at("stream", 1),
+	} {
+		_, ok := Definition(fs, "./test/definition/shop.yarp", pos)
+		assert.False(t, ok, "position %d", pos)
+	}
+	_, ok = Definition(fs, "./test/definition/other.yarp", 0)
+	assert.False(t, ok)
+}
@@ -0,0 +1,117 @@
+package idl
+
+// Deprecation represents a declaration annotated with @deprecated.
+type Deprecation struct {
+	// Kind contains the kind of the deprecated declaration: "message",
+	// "service", "field", or "method".
+	Kind string
+
+	// Symbol contains the FQN of the deprecated message or service, followed
+	// by the name of the deprecated field or method, if any.
+	Symbol string
+
+	// Reason contains the first value provided to the annotation, such as
+	// "use Customer instead" in @deprecated("use Customer instead"), or an
+	// empty string.
+	Reason string
+
+	// Location represents the position of the deprecated declaration.
+	Location Location
+
+	// ReferencedBy lists fields and methods still referencing a deprecated
+	// message, in declaration order.
+	ReferencedBy []Reference
+}
+
+// Reference represents a field or method referencing a message.
+type Reference struct {
+	// Symbol contains the FQN of the message or service holding the
+	// reference, followed by the name of the field or method.
+	Symbol   string
+	Location Location
+}
+
+// Deprecations resolves the FileSet and returns all deprecated messages,
+// services, fields, and methods, in declaration order (see Symbols).
+func (f *FileSet) Deprecations() []Deprecation {
+	f.Resolve()
+	var r []Deprecation
+	for _, sym := range f.Symbols() {
+		loc := locate(f, sym.Declaration)
+		switch d := sym.Declaration.(type) {
+		case *Message:
+			if a, ok := d.Annotations.FindByName(DeprecatedAnnotation); ok {
+				r = append(r, Deprecation{
+					Kind:         "message",
+					Symbol:       sym.Name.String(),
+					Reason:       deprecationReason(a),
+					Location:     loc(d),
+					ReferencedBy: f.referencesTo(d),
+				})
+			}
+			for _, field := range allFields(d.Fields) {
+				if a, ok := field.Annotations.FindByName(DeprecatedAnnotation); ok {
+					r = append(r, Deprecation{
+						Kind:     "field",
+						Symbol:   sym.Name.String() + "." + field.Name,
+						Reason:   deprecationReason(a),
+						Location: loc(field),
+					})
+				}
+			}
+		case *Service:
+			if a, ok := d.Annotations.FindByName(DeprecatedAnnotation); ok {
+				r = append(r, Deprecation{
+					Kind:     "service",
+					Symbol:   sym.Name.String(),
+					Reason:   deprecationReason(a),
+					Location: loc(d),
+				})
+			}
+			for _, m := range d.Methods {
+				if a, ok := m.Annotations.FindByName(DeprecatedAnnotation); ok {
+					r = append(r, Deprecation{
+						Kind:     "method",
+						Symbol:   sym.Name.String() + "." + m.Name,
+						Reason:   deprecationReason(a),
+						Location: loc(m),
+					})
+				}
+			}
+		}
+	}
+	return r
+}
+
+func deprecationReason(a *AnnotationValue) string {
+	if len(a.Value) == 0 {
+		return ""
+	}
+	return a.Value[0]
+}
+
+// referencesTo returns all fields and methods referencing target.
+func (f *FileSet) referencesTo(target *Message) []Reference {
+	var r []Reference
+	for _, sym := range f.Symbols() {
+		loc := locate(f, sym.Declaration)
+		switch d := sym.Declaration.(type) {
+		case *Message:
+			for _, field := range allFields(d.Fields) {
+				for _, m := range referencedMessages(field.Type) {
+					if m == target {
+						r = append(r, Reference{Symbol: sym.Name.String() + "." + field.Name, Location: loc(field)})
+						break
+					}
+				}
+			}
+		case *Service:
+			for _, m := range d.Methods {
+				if m.Argument.Target == target || m.Return.Target == target {
+					r = append(r, Reference{Symbol: sym.Name.String() + "." + m.Name, Location: loc(m)})
+				}
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,37 @@
+package idl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetDeprecations(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/deprecation/main.yarp"))
+	deps := fs.Deprecations()
+	require.Len(t, deps, 3)
+
+	assert.Equal(t, "message", deps[0].Kind)
+	assert.Equal(t, "org.example.accounts.User", deps[0].Symbol)
+	assert.Equal(t, "use Customer instead", deps[0].Reason)
+	assert.Equal(t, 4, deps[0].Location.Offset.StartsAt.Line)
+	var refs []string
+	for _, r := range deps[0].ReferencedBy {
+		refs = append(refs, r.Symbol)
+	}
+	assert.Equal(t, []string{
+		"org.example.accounts.Customer.legacy",
+		"org.example.accounts.Customer.history",
+		"org.example.accounts.Accounts.get_user",
+	}, refs)
+
+	assert.Equal(t, "field", deps[1].Kind)
+	assert.Equal(t, "org.example.accounts.User.name", deps[1].Symbol)
+	assert.Empty(t, deps[1].Reason)
+
+	assert.Equal(t, "method", deps[2].Kind)
+	assert.Equal(t, "org.example.accounts.Accounts.get_user", deps[2].Symbol)
+	assert.Equal(t, "use get_customer", deps[2].Reason)
+}
@@ -0,0 +1,319 @@
+package idl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Descriptors are encoded as follows. Integers are encoded as varints, and
+// strings and lists are prefixed by their length. A descriptor begins with
+// descriptorMagic and DescriptorVersion, followed by the primary package name
+// and the list of files, in the order returned by FileSet.Files. Each file
+// contains its path, checksum, package, import chain, resolved import paths,
+// and declarations. Paths of local files are slash-separated, and relative to
+// the deepest directory containing all of them, so descriptors do not depend
+// on where sources are located; URLs of remote files are stored as is. Each
+// declaration, field item and type begins with a tag identifying its kind.
+// References to messages are stored as their FQN.
+const descriptorMagic = "YIDL"
+
+// DescriptorVersion contains the version of the encoding produced by
+// MarshalDescriptor.
+const DescriptorVersion = 1
+
+const (
+	descPackage byte = iota + 1
+	descImport
+	descMessage
+	descService
+)
+
+const (
+	descField byte = iota + 1
+	descOneOf
+)
+
+const (
+	descPrimitive byte = iota + 1
+	descArray
+	descMap
+	descNamed
+)
+
+// MarshalDescriptor resolves the FileSet and returns a compact binary
+// encoding of all files loaded into it, which can be turned back into a
+// FileSet through LoadDescriptor without access to source files. In case
+// references cannot be resolved, the Diagnostics reported by Resolve are
+// returned as an error.
+func (f *FileSet) MarshalDescriptor() ([]byte, error) {
+	if diags := f.Resolve(); diags.HasErrors() {
+		return nil, diags
+	}
+	return f.marshalDescriptor(f.packageName, f.fileOrder), nil
+}
+
+// MarshalDescriptorOf works like MarshalDescriptor, but only encodes the
+// provided files, which must have been loaded into the FileSet, along with
+// the files they import, directly or not, so the descriptor can be loaded on
+// its own. The package of the first file becomes the primary package of the
+// descriptor.
+func (f *FileSet) MarshalDescriptorOf(files ...*File) ([]byte, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files to marshal")
+	}
+	if diags := f.Resolve(); diags.HasErrors() {
+		return nil, diags
+	}
+	included := map[*File]bool{}
+	var include func(file *File) error
+	include = func(file *File) error {
+		if included[file] {
+			return nil
+		}
+		if f.files[file.SourcePath] != file {
+			return fmt.Errorf("%s is not loaded into the FileSet", file.SourcePath)
+		}
+		included[file] = true
+		for _, p := range f.imports[file] {
+			if imported, ok := f.files[p]; ok {
+				if err := include(imported); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	for _, file := range files {
+		if err := include(file); err != nil {
+			return nil, err
+		}
+	}
+	var order []*File
+	for _, file := range f.fileOrder {
+		if included[file] {
+			order = append(order, file)
+		}
+	}
+	return f.marshalDescriptor(files[0].Package, order), nil
+}
+
+func (f *FileSet) marshalDescriptor(pkg string, files []*File) []byte {
+	w := &descriptorWriter{root: f.descriptorRoot()}
+	w.buf.WriteString(descriptorMagic)
+	w.uint(DescriptorVersion)
+	w.string(pkg)
+	w.uint(uint64(len(files)))
+	for _, file := range files {
+		w.file(file, f.importChains[file], f.imports[file])
+	}
+	return w.buf.Bytes()
+}
+
+// descriptorRoot returns the deepest directory containing every local file
+// known by the FileSet, as a slash-separated path. Remote and standard library
+// files keep their own paths.
+func (f *FileSet) descriptorRoot() string {
+	root := ""
+	add := func(p string) {
+		if isRemote(p) || isStd(p) {
+			return
+		}
+		p = filepath.ToSlash(p)
+		if root == "" {
+			root = path.Dir(p)
+			return
+		}
+		for relativeTo(root, p) == p && path.Dir(root) != root {
+			root = path.Dir(root)
+		}
+	}
+	for _, file := range f.fileOrder {
+		add(file.SourcePath)
+		for _, p := range f.importChains[file] {
+			add(p)
+		}
+		for _, p := range f.imports[file] {
+			add(p)
+		}
+	}
+	return root
+}
+
+// relativeTo returns p, a slash-separated path, relative to root, or p itself
+// in case it does not lie within root.
+func relativeTo(root, p string) string {
+	switch root {
+	case "", ".":
+		return p
+	case "/":
+		return strings.TrimPrefix(p, "/")
+	}
+	if r, ok := strings.CutPrefix(p, root+"/"); ok {
+		return r
+	}
+	return p
+}
+
+type descriptorWriter struct {
+	buf  bytes.Buffer
+	root string
+}
+
+// path returns the representation of p stored by the descriptor.
+func (w *descriptorWriter) path(p string) string {
+	if isRemote(p) {
+		return p
+	}
+	return relativeTo(w.root, filepath.ToSlash(p))
+}
+
+func (w *descriptorWriter) paths(p []string) {
+	w.uint(uint64(len(p)))
+	for _, v := range p {
+		w.string(w.path(v))
+	}
+}
+
+func (w *descriptorWriter) uint(v uint64) {
+	w.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func (w *descriptorWriter) int(v int) {
+	w.buf.Write(binary.AppendVarint(nil, int64(v)))
+}
+
+func (w *descriptorWriter) bool(v bool) {
+	if v {
+		w.buf.WriteByte(1)
+	} else {
+		w.buf.WriteByte(0)
+	}
+}
+
+func (w *descriptorWriter) string(s string) {
+	w.uint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *descriptorWriter) strings(s []string) {
+	w.uint(uint64(len(s)))
+	for _, v := range s {
+		w.string(v)
+	}
+}
+
+func (w *descriptorWriter) offset(o Offset) {
+	w.int(o.StartsAt.Line)
+	w.int(o.StartsAt.Column)
+	w.int(o.EndsAt.Line)
+	w.int(o.EndsAt.Column)
+	w.int(o.Start)
+	w.int(o.End)
+}
+
+func (w *descriptorWriter) annotations(a AnnotationCollection) {
+	w.uint(uint64(len(a)))
+	for _, v := range a {
+		w.offset(v.Offset)
+		w.string(v.Name)
+		w.strings(v.Value)
+	}
+}
+
+func (w *descriptorWriter) file(f *File, chain, imports []string) {
+	w.string(w.path(f.SourcePath))
+	w.buf.Write(f.Checksum[:])
+	w.string(f.Package)
+	w.paths(chain)
+	w.paths(imports)
+	w.uint(uint64(len(f.Tree)))
+	for _, d := range f.Tree {
+		switch v := d.(type) {
+		case *Package:
+			w.buf.WriteByte(descPackage)
+			w.offset(v.Offset)
+			w.string(v.Name)
+		case *Import:
+			w.buf.WriteByte(descImport)
+			w.offset(v.Offset)
+			w.string(v.Path)
+		case *Message:
+			w.buf.WriteByte(descMessage)
+			w.offset(v.Offset)
+			w.string(v.Name)
+			w.strings(v.Comments)
+			w.annotations(v.Annotations)
+			w.items(v.Fields)
+		case *Service:
+			w.buf.WriteByte(descService)
+			w.offset(v.Offset)
+			w.string(v.Name)
+			w.strings(v.Comments)
+			w.annotations(v.Annotations)
+			w.uint(uint64(len(v.Methods)))
+			for _, m := range v.Methods {
+				w.offset(m.Offset)
+				w.string(m.Name)
+				w.strings(m.Comments)
+				w.annotations(m.Annotations)
+				w.typeRef(m.Argument)
+				w.typeRef(m.Return)
+			}
+		}
+	}
+}
+
+func (w *descriptorWriter) items(items []FieldItem) {
+	w.uint(uint64(len(items)))
+	for _, item := range items {
+		switch v := item.(type) {
+		case Field:
+			w.buf.WriteByte(descField)
+			w.offset(v.Offset)
+			w.string(v.Name)
+			w.strings(v.Comments)
+			w.annotations(v.Annotations)
+			w.int(v.Index)
+			w.typ(v.Type)
+		case OneOfField:
+			w.buf.WriteByte(descOneOf)
+			w.offset(v.Offset)
+			w.strings(v.Comments)
+			w.annotations(v.Annotations)
+			w.int(v.Index)
+			w.items(v.Items)
+		}
+	}
+}
+
+func (w *descriptorWriter) typeRef(t TypeRef) {
+	w.offset(t.Offset)
+	w.string(t.Package)
+	w.string(t.Name)
+	w.bool(t.Streaming)
+}
+
+func (w *descriptorWriter) typ(t Type) {
+	switch v := t.(type) {
+	case Primitive:
+		w.buf.WriteByte(descPrimitive)
+		w.uint(uint64(v.Kind))
+	case Array:
+		w.buf.WriteByte(descArray)
+		w.typ(v.Of)
+	case Map:
+		w.buf.WriteByte(descMap)
+		w.uint(uint64(v.Key))
+		w.typ(v.Value)
+	case Resolved:
+		w.buf.WriteByte(descNamed)
+		w.string(v.Name.String())
+	case Unresolved:
+		w.buf.WriteByte(descNamed)
+		w.string(v.Name)
+	}
+}
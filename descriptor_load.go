@@ -0,0 +1,249 @@
+package idl
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// LoadDescriptor reconstructs a resolved FileSet from a descriptor produced by
+// MarshalDescriptor. Files of the returned FileSet retain their checksums and
+// declarations, but have no Source. Their paths are the ones recorded by the
+// descriptor, relative to the directory containing the original files.
+func LoadDescriptor(data []byte) (*FileSet, error) {
+	r := &descriptorReader{data: data}
+	if string(r.next(len(descriptorMagic))) != descriptorMagic {
+		return nil, InvalidDescriptorError{Reason: "missing magic header"}
+	}
+	if v := r.uint(); r.err == nil && v != DescriptorVersion {
+		return nil, InvalidDescriptorError{Reason: fmt.Sprintf("unsupported version %d", v)}
+	}
+	f := NewFileSet()
+	f.packageName = r.string()
+	count := r.count()
+	for i := 0; i < count && r.err == nil; i++ {
+		file, chain, imports := r.file()
+		if r.err != nil {
+			break
+		}
+		f.loadedFiles[file.SourcePath] = true
+		if err := f.register(file, chain); err != nil {
+			return nil, err
+		}
+		if f.imports == nil {
+			f.imports = map[*File][]string{}
+		}
+		f.imports[file] = imports
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.pos != len(r.data) {
+		return nil, InvalidDescriptorError{Reason: "trailing data"}
+	}
+	if diags := f.Resolve(); diags.HasErrors() {
+		return nil, diags
+	}
+	return f, nil
+}
+
+type descriptorReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *descriptorReader) fail(reason string) {
+	if r.err == nil {
+		r.err = InvalidDescriptorError{Reason: reason}
+	}
+}
+
+func (r *descriptorReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.data)-r.pos < n {
+		r.fail("unexpected end of data")
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *descriptorReader) byte() byte {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *descriptorReader) uint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("malformed integer")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *descriptorReader) int() int {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail("malformed integer")
+		return 0
+	}
+	r.pos += n
+	return int(v)
+}
+
+// count reads a length prefix, ensuring it does not exceed the amount of data
+// left, as every element takes at least one byte.
+func (r *descriptorReader) count() int {
+	n := r.uint()
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("invalid length")
+		return 0
+	}
+	return int(n)
+}
+
+func (r *descriptorReader) bool() bool {
+	return r.byte() == 1
+}
+
+func (r *descriptorReader) string() string {
+	return string(r.next(r.count()))
+}
+
+func (r *descriptorReader) strings() []string {
+	n := r.count()
+	s := make([]string, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		s = append(s, r.string())
+	}
+	return s
+}
+
+func (r *descriptorReader) offset() Offset {
+	return Offset{
+		StartsAt: Position{Line: r.int(), Column: r.int()},
+		EndsAt:   Position{Line: r.int(), Column: r.int()},
+		Start:    r.int(),
+		End:      r.int(),
+	}
+}
+
+func (r *descriptorReader) annotations() AnnotationCollection {
+	n := r.count()
+	a := make(AnnotationCollection, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		v := AnnotationValue{Offset: r.offset(), Name: r.string(), Value: r.strings()}
+		if len(v.Value) == 0 {
+			// Annotations without arguments have no value.
+			v.Value = nil
+		}
+		a = append(a, v)
+	}
+	return a
+}
+
+func (r *descriptorReader) file() (*File, []string, []string) {
+	f := &File{SourcePath: r.string()}
+	copy(f.Checksum[:], r.next(len(f.Checksum)))
+	pkg := r.string()
+	chain := r.strings()
+	imports := r.strings()
+	n := r.count()
+	for i := 0; i < n && r.err == nil; i++ {
+		switch tag := r.byte(); tag {
+		case descPackage:
+			f.push(&Package{Offset: r.offset(), Name: r.string()})
+		case descImport:
+			f.push(&Import{Offset: r.offset(), Path: r.string()})
+		case descMessage:
+			m := &Message{Offset: r.offset(), Name: r.string(), Comments: r.strings(), Annotations: r.annotations()}
+			m.Fields = r.items()
+			f.push(m)
+		case descService:
+			s := &Service{Offset: r.offset(), Name: r.string(), Comments: r.strings(), Annotations: r.annotations()}
+			methods := r.count()
+			for j := 0; j < methods && r.err == nil; j++ {
+				m := Method{Offset: r.offset(), Name: r.string(), Comments: r.strings(), Annotations: r.annotations()}
+				m.Argument = r.typeRef()
+				m.Return = r.typeRef()
+				m.ArgumentType = m.Argument.String()
+				m.ReturnType = m.Return.String()
+				m.ReturnStreaming = m.Return.Streaming
+				s.Methods = append(s.Methods, m)
+			}
+			f.push(s)
+		default:
+			r.fail(fmt.Sprintf("unknown declaration tag %d", tag))
+		}
+	}
+	if f.Package != pkg {
+		r.fail(fmt.Sprintf("%s: package mismatch", f.SourcePath))
+	}
+	return f, chain, imports
+}
+
+func (r *descriptorReader) items() []FieldItem {
+	n := r.count()
+	if n == 0 {
+		return nil
+	}
+	items := make([]FieldItem, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		switch tag := r.byte(); tag {
+		case descField:
+			items = append(items, Field{
+				Offset:      r.offset(),
+				Name:        r.string(),
+				Comments:    r.strings(),
+				Annotations: r.annotations(),
+				Index:       r.int(),
+				Type:        r.typ(),
+			})
+		case descOneOf:
+			items = append(items, OneOfField{
+				Offset:      r.offset(),
+				Comments:    r.strings(),
+				Annotations: r.annotations(),
+				Index:       r.int(),
+				Items:       r.items(),
+			})
+		default:
+			r.fail(fmt.Sprintf("unknown field tag %d", tag))
+		}
+	}
+	return items
+}
+
+func (r *descriptorReader) typeRef() TypeRef {
+	return TypeRef{Offset: r.offset(), Package: r.string(), Name: r.string(), Streaming: r.bool()}
+}
+
+func (r *descriptorReader) typ() Type {
+	switch tag := r.byte(); tag {
+	case descPrimitive:
+		return Primitive{Kind: PrimitiveType(r.uint())}
+	case descArray:
+		return Array{Of: r.typ()}
+	case descMap:
+		return Map{Key: PrimitiveType(r.uint()), Value: r.typ()}
+	case descNamed:
+		return Unresolved{Name: r.string()}
+	default:
+		r.fail(fmt.Sprintf("unknown type tag %d", tag))
+		return nil
+	}
+}
@@ -0,0 +1,151 @@
+package idl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalDescriptor(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/diamond/main.yarp"))
+	data, err := fs.MarshalDescriptor()
+	require.NoError(t, err)
+	assert.Equal(t, "YIDL", string(data[:4]))
+	assert.Equal(t, byte(DescriptorVersion), data[4])
+
+	again, err := fs.MarshalDescriptor()
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	fs = NewFileSet()
+	require.NoError(t, fs.Load("./test/resolve/main.yarp"))
+	_, err = fs.MarshalDescriptor()
+	var diags Diagnostics
+	require.ErrorAs(t, err, &diags)
+	assert.True(t, diags.HasErrors())
+}
+
+func TestLoadDescriptor(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/unreferenced/main.yarp"))
+	require.NoError(t, fs.Load("./test/validate/recursive.yarp"))
+	data, err := fs.MarshalDescriptor()
+	require.NoError(t, err)
+
+	loaded, err := LoadDescriptor(data)
+	require.NoError(t, err)
+	assert.Equal(t, fs.Package(), loaded.Package())
+	require.Len(t, loaded.Files(), len(fs.Files()))
+	root, err := filepath.Abs("test")
+	require.NoError(t, err)
+	for i, f := range fs.Files() {
+		l := loaded.Files()[i]
+		rel, err := filepath.Rel(root, f.SourcePath)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.ToSlash(rel), l.SourcePath)
+		assert.Equal(t, f.Checksum, l.Checksum)
+		// Declarations are compared regardless of the paths they record.
+		expected, actual := f.Clone().Tree, l.Clone().Tree
+		for j := range expected {
+			detach(expected[j])
+			detach(actual[j])
+		}
+		assert.Equal(t, expected, actual)
+	}
+	assert.Equal(t, symbolNames(fs.Symbols()), symbolNames(loaded.Symbols()))
+
+	node, ok := loaded.FindMessage("org.example.recursive.Node")
+	require.True(t, ok)
+	assert.Same(t, node, node.Fields[1].(Field).Type.(Resolved).Message)
+	svc, ok := loaded.FindService("Items")
+	require.True(t, ok)
+	req, _ := loaded.FindMessage("Request")
+	assert.Same(t, req, svc.Methods[0].Argument.Target)
+
+	again, err := loaded.MarshalDescriptor()
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	for _, bad := range [][]byte{nil, []byte("YIDL"), []byte("XIDL\x01"), []byte("YIDL\x02"), data[:len(data)-3], append(data, 0)} {
+		_, err = LoadDescriptor(bad)
+		var invalid InvalidDescriptorError
+		assert.ErrorAs(t, err, &invalid)
+	}
+}
+
+func TestMarshalDescriptorRelocatable(t *testing.T) {
+	marshal := func() []byte {
+		dir := t.TempDir()
+		require.NoError(t, os.CopyFS(dir, os.DirFS("test/diamond")))
+		fs := NewFileSet()
+		require.NoError(t, fs.Load(filepath.Join(dir, "main.yarp")))
+		data, err := fs.MarshalDescriptor()
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), dir)
+		return data
+	}
+	assert.Equal(t, marshal(), marshal())
+}
+
+func TestMarshalDescriptorStd(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "schemas")
+	require.NoError(t, os.CopyFS(dir, os.DirFS("test/stdimport")))
+	fs := NewFileSet()
+	require.NoError(t, fs.Load(filepath.Join(dir, "main.yarp")))
+	data, err := fs.MarshalDescriptor()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), dir)
+
+	loaded, err := LoadDescriptor(data)
+	require.NoError(t, err)
+	var paths []string
+	for _, f := range loaded.Files() {
+		paths = append(paths, f.SourcePath)
+	}
+	assert.ElementsMatch(t, []string{"main.yarp", "yarp/std/time.yarp"}, paths)
+	_, ok := loaded.FindMessage("yarp.std.Timestamp")
+	assert.True(t, ok)
+
+	again, err := loaded.MarshalDescriptor()
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+}
+
+func TestMarshalDescriptorOf(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/diamond/main.yarp"))
+	left, ok := fs.FileByPath("./test/diamond/left.yarp")
+	require.True(t, ok)
+
+	data, err := fs.MarshalDescriptorOf(left)
+	require.NoError(t, err)
+	loaded, err := LoadDescriptor(data)
+	require.NoError(t, err)
+	var paths []string
+	for _, f := range loaded.Files() {
+		paths = append(paths, f.SourcePath)
+	}
+	assert.Equal(t, []string{"base.yarp", "left.yarp"}, paths)
+	_, ok = loaded.FindMessage("Left")
+	assert.True(t, ok)
+	_, ok = loaded.FindMessage("Right")
+	assert.False(t, ok)
+
+	all, err := fs.MarshalDescriptorOf(fs.Files()...)
+	require.NoError(t, err)
+	expected, err := fs.MarshalDescriptor()
+	require.NoError(t, err)
+	assert.Equal(t, expected, all)
+
+	other, err := ParseSource([]byte("package org.example.other;\n"), ParseOptions{})
+	require.NoError(t, err)
+	other.SourcePath = "other.yarp"
+	_, err = fs.MarshalDescriptorOf(other)
+	assert.EqualError(t, err, "other.yarp is not loaded into the FileSet")
+	_, err = fs.MarshalDescriptorOf()
+	assert.EqualError(t, err, "no files to marshal")
+}
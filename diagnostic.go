@@ -0,0 +1,115 @@
+package idl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity represents how severe a Diagnostic is.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// ParseSeverity returns the Severity whose String representation is s, such
+// as "warning".
+func ParseSeverity(s string) (Severity, error) {
+	for _, v := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+		if v.String() == s {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid severity %q", s)
+}
+
+// Location represents a region within a given source file.
+type Location struct {
+	File   string
+	Offset Offset
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Offset.StartsAt.Line, l.Offset.StartsAt.Column)
+}
+
+// Diagnostic represents a problem found in a set of source files, along with
+// the location in which it was found.
+type Diagnostic struct {
+	// Severity indicates how severe the problem is.
+	Severity Severity
+
+	// Code contains a short, stable identifier of the kind of problem, such
+	// as "unknown-type".
+	Code string
+
+	// Message contains a human-readable description of the problem.
+	Message string
+
+	// Location represents the point in which the problem was found.
+	Location Location
+
+	// Related contains other locations involved in the problem, such as the
+	// previous declaration of a duplicated name.
+	Related []Location
+}
+
+func (d Diagnostic) Error() string {
+	msg := fmt.Sprintf("%s: %s: %s", d.Location, d.Severity, d.Message)
+	if len(d.Related) == 0 {
+		return msg
+	}
+	related := make([]string, len(d.Related))
+	for i, r := range d.Related {
+		related[i] = r.String()
+	}
+	return fmt.Sprintf("%s (see %s)", msg, strings.Join(related, ", "))
+}
+
+// Diagnostics represents a list of Diagnostic values.
+type Diagnostics []Diagnostic
+
+// HasErrors returns whether the list contains at least one Diagnostic with
+// SeverityError.
+func (d Diagnostics) HasErrors() bool {
+	for _, v := range d {
+		if v.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Errors returns a list containing only diagnostics with SeverityError.
+func (d Diagnostics) Errors() Diagnostics {
+	var r Diagnostics
+	for _, v := range d {
+		if v.Severity == SeverityError {
+			r = append(r, v)
+		}
+	}
+	return r
+}
+
+func (d Diagnostics) Error() string {
+	msgs := make([]string, len(d))
+	for i, v := range d {
+		msgs[i] = v.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
@@ -0,0 +1,376 @@
+// Package driver compiles YARP schemas and runs code generators as described
+// by a configuration file, allowing projects to wire generation into
+// go:generate with a single line:
+//
+//	//go:generate go run github.com/libyarp/idl/cmd/yarpgen
+//
+// Configuration is read from a JSON file, named yarpgen.json by default:
+//
+//	{
+//	  "module": ".",
+//	  "sources": ["schemas"],
+//	  "output": "generated",
+//	  "generators": [
+//	    {"name": "typescript", "parameters": {"int64": "string"}},
+//	    {"name": "htmldoc", "output": "docs", "parameters": {"title": "API"}}
+//	  ]
+//	}
+//
+// Generated files may be placed according to the packages or source files
+// they originate from through a layout, shared by all generators or
+// configured for each of them:
+//
+//	"layout": {"split": "package", "path": "{package_dir}/{path}", "suffix": ".gen.csv"}
+//
+// Source directories of layouts are relative to the module directory, if
+// any, or to the directory containing the configuration file. See gen.Layout
+// for the placeholders available to paths. Splitting output requires
+// generators to implement gen.Partitioner; among the generators of this
+// module, go-descriptor, inventory, and typescript do. Other ones may opt out
+// of a shared layout by configuring an empty one.
+//
+// Generators implemented by plugins, in any language, are configured through
+// the command executing them; see package plugin:
+//
+//	{"name": "docs", "command": ["./tools/yarp-docs", "--verbose"]}
+//
+// Relative paths are resolved against the directory containing the
+// configuration file. All generators provided by this module are registered
+// by the driver.
+//
+// Generated output is reproducible: timestamps are only embedded when the
+// SOURCE_DATE_EPOCH environment variable holds a number of seconds since the
+// Unix epoch, and reflect that time rather than the current one.
+package driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	_ "github.com/libyarp/idl/gen/diagram"
+	_ "github.com/libyarp/idl/gen/godesc"
+	_ "github.com/libyarp/idl/gen/htmldoc"
+	_ "github.com/libyarp/idl/gen/inventory"
+	_ "github.com/libyarp/idl/gen/openapi"
+	_ "github.com/libyarp/idl/gen/tsfake"
+	_ "github.com/libyarp/idl/gen/tsvalidate"
+	_ "github.com/libyarp/idl/gen/typescript"
+	"github.com/libyarp/idl/plugin"
+)
+
+// ConfigFile contains the default name of driver configuration files.
+const ConfigFile = "yarpgen.json"
+
+// Config represents the contents of a driver configuration file.
+type Config struct {
+	// Dir contains the directory against which relative paths are resolved.
+	// LoadConfig sets it to the directory containing the configuration file.
+	Dir string `json:"-"`
+
+	// Module contains the directory holding a yarp.mod file to be used by
+	// the FileSet, if any. See idl.FileSet.UseModule.
+	Module string `json:"module,omitempty"`
+
+	// Includes contains additional include paths.
+	Includes []string `json:"includes,omitempty"`
+
+	// Sources contains the source files, directories, or glob patterns to be
+	// loaded. Directories are loaded recursively.
+	Sources []string `json:"sources"`
+
+	// Output contains the directory in which generated files are written.
+	Output string `json:"output"`
+
+	// Generators contains the generators to be executed, in order.
+	Generators []GeneratorConfig `json:"generators"`
+
+	// Layout contains the layout shared by generators not configuring their
+	// own.
+	Layout *LayoutConfig `json:"layout,omitempty"`
+
+	// UpdateLock allows remote imports lacking a pinned checksum to be
+	// loaded, and records the checksums of all remote imports in the
+	// yarp.lock file of the module. See idl.FileSet.UpdateLock.
+	UpdateLock bool `json:"-"`
+}
+
+// LayoutConfig represents the layout of generated files. See gen.Layout.
+type LayoutConfig struct {
+	// Split contains either "package" or "source", to produce output for each
+	// package or source file, or is empty.
+	Split gen.Split `json:"split,omitempty"`
+
+	// Path contains the template of output paths.
+	Path string `json:"path,omitempty"`
+
+	// Suffix replaces the extension of produced files.
+	Suffix string `json:"suffix,omitempty"`
+}
+
+// GeneratorConfig represents the configuration of a single generator.
+type GeneratorConfig struct {
+	// Name contains the name under which the generator is registered, or
+	// the name identifying a plugin.
+	Name string `json:"name"`
+
+	// Command contains the path of a plugin executable, followed by its
+	// arguments, in case the generator is implemented by a plugin. Paths
+	// containing separators are relative to the configuration file, while
+	// other ones are looked up in PATH. See package plugin.
+	Command []string `json:"command,omitempty"`
+
+	// Output contains a directory, relative to Config.Output, in which files
+	// produced by the generator are placed.
+	Output string `json:"output,omitempty"`
+
+	// Parameters contains generator-specific options. See gen.Options.
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// TemplateDir contains a directory holding templates overriding the ones
+	// embedded by the generator. See gen.Options.
+	TemplateDir string `json:"template_dir,omitempty"`
+
+	// Layout overrides Config.Layout for the generator.
+	Layout *LayoutConfig `json:"layout,omitempty"`
+}
+
+// ParseConfig parses the contents of a configuration file. Unknown keys are
+// rejected.
+func ParseConfig(data []byte) (*Config, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	c := &Config{}
+	if err := dec.Decode(c); err != nil {
+		return nil, err
+	}
+	if len(c.Sources) == 0 {
+		return nil, errors.New("no sources configured")
+	}
+	if len(c.Generators) == 0 {
+		return nil, errors.New("no generators configured")
+	}
+	if err := c.Layout.validate(); err != nil {
+		return nil, err
+	}
+	for i, g := range c.Generators {
+		if g.Name == "" {
+			return nil, fmt.Errorf("generator %d has no name", i)
+		}
+		if err := g.Layout.validate(); err != nil {
+			return nil, fmt.Errorf("generator %s: %w", g.Name, err)
+		}
+		if len(g.Command) > 0 {
+			continue
+		}
+		if _, ok := gen.Lookup(g.Name); !ok {
+			return nil, gen.UnknownGeneratorError{Name: g.Name}
+		}
+	}
+	return c, nil
+}
+
+// LoadConfig reads and parses the configuration file under the provided path.
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	c, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	c.Dir = filepath.Dir(file)
+	return c, nil
+}
+
+func (c *Config) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(c.Dir, filepath.FromSlash(p))
+}
+
+// Build creates a FileSet holding all configured sources.
+func (c *Config) Build() (*idl.FileSet, error) {
+	fs := idl.NewFileSet()
+	if c.UpdateLock {
+		fs.UpdateLock()
+	}
+	if c.Module != "" {
+		if err := fs.UseModule(c.path(c.Module)); err != nil {
+			return nil, err
+		}
+	}
+	for _, inc := range c.Includes {
+		if err := fs.AddIncludePath(c.path(inc)); err != nil {
+			return nil, err
+		}
+	}
+	for _, src := range c.Sources {
+		p := c.path(src)
+		var err error
+		if strings.ContainsAny(src, "*?[") {
+			err = fs.LoadGlob(p)
+		} else if info, statErr := os.Stat(p); statErr == nil && info.IsDir() {
+			err = fs.LoadDir(p)
+		} else {
+			err = fs.Load(p)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c.UpdateLock && c.Module != "" {
+		if err := fs.WriteLock(c.path(c.Module)); err != nil {
+			return nil, err
+		}
+	}
+	return fs, nil
+}
+
+// Generate builds the FileSet described by c, and executes the generators
+// whose names are provided, or all configured generators, in case none are.
+// Returned paths are relative to Config.Output.
+func (c *Config) Generate(names ...string) ([]gen.OutputFile, error) {
+	selected := c.Generators
+	if len(names) > 0 {
+		selected = nil
+		for _, n := range names {
+			found := false
+			for _, g := range c.Generators {
+				if g.Name == n {
+					selected, found = append(selected, g), true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("generator %s is not configured", n)
+			}
+		}
+	}
+
+	timestamp, err := sourceDateEpoch()
+	if err != nil {
+		return nil, err
+	}
+	fs, err := c.Build()
+	if err != nil {
+		return nil, err
+	}
+	root, err := filepath.Abs(c.path(c.Module))
+	if err != nil {
+		return nil, err
+	}
+	var out []gen.OutputFile
+	producedBy := map[string]string{}
+	for _, g := range selected {
+		opts := gen.Options{Parameters: g.Parameters, Timestamp: timestamp}
+		if l := g.Layout; l != nil || c.Layout != nil {
+			if l == nil {
+				l = c.Layout
+			}
+			opts.Layout = gen.Layout{Split: l.Split, Path: l.Path, Suffix: l.Suffix, Root: root}
+		}
+		if g.TemplateDir != "" {
+			opts.TemplateDir = c.path(g.TemplateDir)
+		}
+		var files []gen.OutputFile
+		if len(g.Command) > 0 {
+			files, err = gen.RunGenerators(fs, opts, c.plugin(g))
+		} else {
+			files, err = gen.Run(fs, opts, g.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			f.Path = path.Join(filepath.ToSlash(g.Output), f.Path)
+			if prev, ok := producedBy[f.Path]; ok {
+				return nil, gen.OutputConflictError{Path: f.Path, First: prev, Second: g.Name}
+			}
+			producedBy[f.Path] = g.Name
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+// plugin returns the generator executing the plugin configured by g.
+func (c *Config) plugin(g GeneratorConfig) plugin.Generator {
+	command := append([]string{}, g.Command...)
+	if strings.ContainsAny(command[0], `/\`) {
+		command[0] = c.path(command[0])
+	}
+	return plugin.Generator{Plugin: g.Name, Command: command, Dir: c.Dir}
+}
+
+func (l *LayoutConfig) validate() error {
+	if l == nil {
+		return nil
+	}
+	switch l.Split {
+	case gen.SplitNone, gen.SplitPackage, gen.SplitSource:
+		return nil
+	}
+	return fmt.Errorf("invalid layout split %q", l.Split)
+}
+
+// sourceDateEpoch returns the time held by SOURCE_DATE_EPOCH, or the zero
+// time, in case it is not set.
+func sourceDateEpoch() (time.Time, error) {
+	v := os.Getenv("SOURCE_DATE_EPOCH")
+	if v == "" {
+		return time.Time{}, nil
+	}
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q", v)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// Run executes the generators whose names are provided, or all configured
+// generators, and writes the files they produce under Config.Output.
+func (c *Config) Run(names ...string) error {
+	files, err := c.Generate(names...)
+	if err != nil {
+		return err
+	}
+	return gen.WriteFiles(c.path(c.Output), files)
+}
+
+// Main implements the yarpgen command, reading its arguments from args and
+// reporting usage errors to stderr. Remaining arguments restrict the
+// generators to be executed.
+func Main(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("yarpgen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	config := flags.String("config", ConfigFile, "path of the configuration file")
+	output := flags.String("output", "", "directory in which generated files are written, overriding the configuration")
+	updateLock := flags.Bool("update-lock", false, "trust remote imports lacking a pinned checksum, and record them in yarp.lock")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	c, err := LoadConfig(*config)
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		if c.Output, err = filepath.Abs(*output); err != nil {
+			return err
+		}
+	}
+	c.UpdateLock = *updateLock
+	return c.Run(flags.Args()...)
+}
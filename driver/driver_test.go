@@ -0,0 +1,135 @@
+package driver
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libyarp/idl/gen"
+	"github.com/libyarp/idl/gen/inventory"
+	"github.com/libyarp/idl/plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain executes the test binary as a plugin exposing the inventory
+// generator in case YARP_TEST_PLUGIN is set.
+func TestMain(m *testing.M) {
+	if os.Getenv("YARP_TEST_PLUGIN") != "" {
+		plugin.Main(inventory.Generator{})
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestParseConfig(t *testing.T) {
+	_, err := ParseConfig([]byte(`{"sources": ["a.yarp"], "generators": [{"name": "typescript"}], "extra": 1}`))
+	assert.ErrorContains(t, err, `unknown field "extra"`)
+
+	_, err = ParseConfig([]byte(`{"generators": [{"name": "typescript"}]}`))
+	assert.EqualError(t, err, "no sources configured")
+
+	_, err = ParseConfig([]byte(`{"sources": ["a.yarp"], "generators": [{"name": "cobol"}]}`))
+	assert.ErrorIs(t, err, gen.UnknownGeneratorError{Name: "cobol"})
+
+	_, err = ParseConfig([]byte(`{"sources": ["a.yarp"], "generators": [{"name": "inventory", "layout": {"split": "message"}}]}`))
+	assert.EqualError(t, err, `generator inventory: invalid layout split "message"`)
+}
+
+func TestGenerate(t *testing.T) {
+	c, err := LoadConfig("../test/driver/yarpgen.json")
+	require.NoError(t, err)
+
+	files, err := c.Generate()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "ts/schema.d.ts", files[0].Path)
+	assert.Contains(t, string(files[0].Content), "id: number;")
+	assert.Equal(t, "inventory.json", files[1].Path)
+
+	files, err = c.Generate("inventory")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "inventory.json", files[0].Path)
+
+	_, err = c.Generate("htmldoc")
+	assert.EqualError(t, err, "generator htmldoc is not configured")
+
+	c.Generators = append(c.Generators, GeneratorConfig{Name: "inventory", Parameters: map[string]string{"format": "json"}})
+	_, err = c.Generate()
+	assert.ErrorIs(t, err, gen.OutputConflictError{Path: "inventory.json", First: "inventory", Second: "inventory"})
+}
+
+func TestGenerateLayout(t *testing.T) {
+	c, err := LoadConfig("../test/driver/yarpgen.json")
+	require.NoError(t, err)
+	c.Layout = &LayoutConfig{Split: gen.SplitSource, Suffix: ".inventory.json"}
+	c.Generators[0].Layout = &LayoutConfig{}
+
+	files, err := c.Generate()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "ts/schema.d.ts", files[0].Path)
+	assert.Equal(t, "schemas/shop.inventory.json", files[1].Path)
+
+	c.Generators[0].Layout = &LayoutConfig{Split: gen.SplitSource}
+	files, err = c.Generate("typescript")
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "ts/schemas/shop.d.ts", files[0].Path)
+
+	c.Generators = append(c.Generators, GeneratorConfig{Name: "openapi"})
+	_, err = c.Generate("openapi")
+	assert.EqualError(t, err, "openapi: output cannot be split by source")
+}
+
+func TestGeneratePlugin(t *testing.T) {
+	c, err := ParseConfig([]byte(`{"sources": ["schemas"], "generators": [{"name": "fields", "command": ["plugin"]}]}`))
+	require.NoError(t, err)
+	c.Dir = "../test/driver"
+	c.Generators[0].Command = []string{os.Args[0]}
+	c.Generators[0].Parameters = map[string]string{"format": "json"}
+	t.Setenv("YARP_TEST_PLUGIN", "1")
+
+	files, err := c.Generate()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "inventory.json", files[0].Path)
+	assert.Contains(t, string(files[0].Content), `"message": "Order"`)
+
+	c.Generators[0].Parameters = map[string]string{"format": "xml"}
+	_, err = c.Generate()
+	assert.EqualError(t, err, `fields: invalid format parameter "xml": expected csv or json`)
+}
+
+func TestGenerateSourceDateEpoch(t *testing.T) {
+	c, err := LoadConfig("../test/driver/yarpgen.json")
+	require.NoError(t, err)
+	c.Generators = []GeneratorConfig{{Name: "html"}}
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	files, err := c.Generate()
+	require.NoError(t, err)
+	assert.Contains(t, string(files[0].Content), "<footer>Generated on 2023-11-14</footer>")
+
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	files, err = c.Generate()
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[0].Content), "<footer>")
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err = c.Generate()
+	assert.EqualError(t, err, `invalid SOURCE_DATE_EPOCH "yesterday"`)
+}
+
+func TestMainFunc(t *testing.T) {
+	out := t.TempDir()
+	var stderr bytes.Buffer
+	require.NoError(t, Main([]string{"-config", "../test/driver/yarpgen.json", "-output", out, "typescript"}, &stderr))
+	_, err := os.Stat(filepath.Join(out, "ts", "schema.d.ts"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(out, "inventory.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.Empty(t, stderr.String())
+}
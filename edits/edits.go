@@ -0,0 +1,98 @@
+// Package edits applies textual edits, such as the ones produced by lint
+// quick-fixes and refactorings, to source buffers. Sets of edits produced
+// independently may be merged, in which case repeated edits are applied once,
+// and edits changing overlapping regions differently are reported as
+// conflicts instead of producing corrupted sources.
+package edits
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Edit represents the replacement of the bytes between Start (inclusive) and
+// End (exclusive) of a buffer with Text. Edits where Start equals End insert
+// Text, and edits with an empty Text delete bytes.
+type Edit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// ConflictError indicates that two edits change overlapping regions of a
+// buffer differently.
+type ConflictError struct {
+	First, Second Edit
+}
+
+func (c ConflictError) Error() string {
+	return fmt.Sprintf("edit of bytes %d-%d conflicts with edit of bytes %d-%d", c.Second.Start, c.Second.End, c.First.Start, c.First.End)
+}
+
+// Merge combines sets of edits into a single one, sorted by position, in
+// which identical edits appear once. Insertions at the same position are
+// retained in the order they are provided. A ConflictError is returned in
+// case edits overlap, or an insertion falls within a region changed by
+// another edit. Insertions at the boundaries of a region are allowed.
+func Merge(sets ...[]Edit) ([]Edit, error) {
+	var all []Edit
+	for _, set := range sets {
+		for _, e := range set {
+			if e.Start < 0 || e.End < e.Start {
+				return nil, fmt.Errorf("invalid edit of bytes %d-%d", e.Start, e.End)
+			}
+			all = append(all, e)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Start != all[j].Start {
+			return all[i].Start < all[j].Start
+		}
+		// Insertions precede replacements starting at the same position.
+		return all[i].End-all[i].Start == 0 && all[j].End-all[j].Start > 0
+	})
+
+	var r []Edit
+	for _, e := range all {
+		duplicate := false
+		for i := len(r) - 1; i >= 0 && r[i].Start == e.Start; i-- {
+			if r[i] == e {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		if len(r) > 0 {
+			prev := r[len(r)-1]
+			if e.Start < prev.End {
+				return nil, ConflictError{First: prev, Second: e}
+			}
+		}
+		r = append(r, e)
+	}
+	return r, nil
+}
+
+// Apply returns a copy of src with edits applied. Edits are merged as by
+// Merge, and refer to offsets of src, regardless of the order in which they
+// are provided. An error is returned in case edits conflict, or exceed the
+// bounds of src.
+func Apply(src []byte, edits ...[]Edit) ([]byte, error) {
+	merged, err := Merge(edits...)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(src))
+	last := 0
+	for _, e := range merged {
+		if e.End > len(src) {
+			return nil, fmt.Errorf("edit of bytes %d-%d exceeds the %d bytes of the buffer", e.Start, e.End, len(src))
+		}
+		out = append(out, src[last:e.Start]...)
+		out = append(out, e.Text...)
+		last = e.End
+	}
+	return append(out, src[last:]...), nil
+}
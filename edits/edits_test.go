@@ -0,0 +1,46 @@
+package edits
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	src := []byte("message Foo {\n    bar int32 = 0;\n}\n")
+	out, err := Apply(src,
+		[]Edit{{Start: 8, End: 11, Text: "Baz"}, {Start: 30, End: 31, Text: "1"}},
+		[]Edit{{Start: 8, End: 11, Text: "Baz"}, {Start: 18, End: 18, Text: "@optional "}},
+		[]Edit{{Start: 18, End: 18, Text: "@deprecated "}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "message Baz {\n    @optional @deprecated bar int32 = 1;\n}\n", string(out))
+
+	out, err = Apply(src, []Edit{{Start: 18, End: 21, Text: "qux"}, {Start: 18, End: 18, Text: "# doc\n    "}, {Start: 21, End: 21, Text: "_id"}})
+	require.NoError(t, err)
+	assert.Equal(t, "message Foo {\n    # doc\n    qux_id int32 = 0;\n}\n", string(out))
+
+	out, err = Apply(src)
+	require.NoError(t, err)
+	assert.Equal(t, string(src), string(out))
+}
+
+func TestConflicts(t *testing.T) {
+	src := []byte("message Foo {}\n")
+	_, err := Apply(src, []Edit{{Start: 8, End: 11, Text: "Bar"}}, []Edit{{Start: 8, End: 11, Text: "Baz"}})
+	assert.Equal(t, ConflictError{First: Edit{8, 11, "Bar"}, Second: Edit{8, 11, "Baz"}}, err)
+	assert.EqualError(t, err, "edit of bytes 8-11 conflicts with edit of bytes 8-11")
+
+	_, err = Apply(src, []Edit{{Start: 0, End: 11, Text: ""}, {Start: 9, End: 9, Text: "x"}})
+	assert.ErrorAs(t, err, &ConflictError{})
+
+	_, err = Apply(src, []Edit{{Start: 0, End: 9}, {Start: 8, End: 12}})
+	assert.ErrorAs(t, err, &ConflictError{})
+
+	_, err = Apply(src, []Edit{{Start: 10, End: 20}})
+	assert.EqualError(t, err, "edit of bytes 10-20 exceeds the 15 bytes of the buffer")
+
+	_, err = Merge([]Edit{{Start: 5, End: 4}})
+	assert.EqualError(t, err, "invalid edit of bytes 5-4")
+}
@@ -2,6 +2,7 @@ package idl
 
 import (
 	"fmt"
+	"strings"
 )
 
 // ParseError indicates that one or more productions from the scanner does not
@@ -47,10 +48,90 @@ type SourceIsDirectoryError struct{ Path string }
 
 func (s SourceIsDirectoryError) Error() string { return fmt.Sprintf("%s: is a directory", s.Path) }
 
+// PathCaseMismatchError indicates that a path differs only in case from the
+// file it refers to, and CaseStrict is in use.
+type PathCaseMismatchError struct{ Path, Actual string }
+
+func (p PathCaseMismatchError) Error() string {
+	return fmt.Sprintf("%s: case does not match %s", p.Path, p.Actual)
+}
+
+// ImportDepthExceededError indicates that an import chain is longer than
+// allowed by FileSetOptions.MaxImportDepth.
+type ImportDepthExceededError struct {
+	Chain []string
+	Max   int
+}
+
+func (i ImportDepthExceededError) Error() string {
+	return fmt.Sprintf("%s: import depth exceeds limit of %d (%s)",
+		i.Chain[len(i.Chain)-1], i.Max, strings.Join(i.Chain, " imports "))
+}
+
+// TooManyFilesError indicates that loading a file would exceed the amount of
+// files allowed by FileSetOptions.MaxFiles.
+type TooManyFilesError struct {
+	Path string
+	Max  int
+}
+
+func (t TooManyFilesError) Error() string {
+	return fmt.Sprintf("%s: loading file exceeds limit of %d files", t.Path, t.Max)
+}
+
 // MixedPackagesError indicates that source files provides different packages.
-// Only a single package can be compiled at a time.
+//
+// Deprecated: FileSet supports multiple packages, and no longer returns this
+// error.
 type MixedPackagesError struct{ Path, Package1, Package2 string }
 
 func (m MixedPackagesError) Error() string {
 	return fmt.Sprintf("mixed packages in source (reading %s): found both %s and %s", m.Path, m.Package1, m.Package2)
 }
+
+// CircularImportError indicates that a source file imports itself, either
+// directly or through other files. Chain contains the paths of all files
+// involved, starting and ending with the same file.
+type CircularImportError struct{ Chain []string }
+
+func (c CircularImportError) Error() string {
+	return fmt.Sprintf("circular import: %s", strings.Join(c.Chain, " imports "))
+}
+
+// RemoteImportNotPinnedError indicates that a remote import has no checksum
+// pinned through FileSet.Pin, and therefore cannot be trusted.
+type RemoteImportNotPinnedError struct{ URL string }
+
+func (r RemoteImportNotPinnedError) Error() string {
+	return fmt.Sprintf("%s: remote import has no pinned checksum", r.URL)
+}
+
+// ChecksumMismatchError indicates that the contents of a remote source file do
+// not match the checksum pinned for it. Expected and Actual contain
+// hex-encoded SHA-256 checksums.
+type ChecksumMismatchError struct{ URL, Expected, Actual string }
+
+func (c ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("%s: checksum mismatch (expected %s, got %s)", c.URL, c.Expected, c.Actual)
+}
+
+// MergeConflictError indicates that two FileSets being merged cannot be
+// combined. Name contains either the FQN of a symbol declared by both sets,
+// or the path of a file loaded by both with different contents. Existing and
+// Incoming contain the paths of the conflicting files.
+type MergeConflictError struct{ Name, Existing, Incoming string }
+
+func (m MergeConflictError) Error() string {
+	if m.Existing == m.Incoming {
+		return fmt.Sprintf("merge conflict: %s was loaded with different contents", m.Name)
+	}
+	return fmt.Sprintf("merge conflict: %s is declared by both %s and %s", m.Name, m.Existing, m.Incoming)
+}
+
+// InvalidDescriptorError indicates that data provided to LoadDescriptor is not
+// a valid descriptor.
+type InvalidDescriptorError struct{ Reason string }
+
+func (i InvalidDescriptorError) Error() string {
+	return fmt.Sprintf("invalid descriptor: %s", i.Reason)
+}
@@ -0,0 +1,280 @@
+package idl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// ExampleAnnotation contains a constant representing the name of @example
+// annotations, which provide sample values of fields to GenerateExample.
+const ExampleAnnotation = "example"
+
+// ExampleOptions configures GenerateExample.
+type ExampleOptions struct {
+	// OmitOptional omits fields annotated with @optional lacking an @example
+	// annotation.
+	OmitOptional bool
+
+	// Elements contains the amount of elements generated for arrays, repeated
+	// fields, and maps lacking an @example annotation. Defaults to 1.
+	Elements int
+
+	// MaxDepth contains the maximum nesting of messages within the example.
+	// Once reached, optional fields referencing messages are omitted, and
+	// arrays and maps of messages are left empty. Defaults to 3.
+	MaxDepth int
+}
+
+// GenerateExample returns a sample instance of m, which must have been
+// resolved by a FileSet, in the JSON representation described by
+// ExportJSONSchema. Fields are emitted in declaration order, and the first
+// case of each oneof is set, preferring cases annotated with @example.
+//
+// Values of fields annotated with @example are taken from the annotation:
+// primitive fields take a single value, such as @example("42"), arrays of uint8
+// take a single base64-encoded value, other arrays and repeated fields of
+// primitives take one value per element, and other fields take a single JSON
+// document. Other fields hold their zero value, except for
+// strings, which hold the name of their field.
+func GenerateExample(m *Message, opts ExampleOptions) ([]byte, error) {
+	if opts.Elements <= 0 {
+		opts.Elements = 1
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 3
+	}
+	g := exampleGenerator{opts: opts}
+	v, err := g.message(m, 1)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// exampleObject represents a JSON object retaining the order of its members.
+type exampleObject []exampleMember
+
+type exampleMember struct {
+	name  string
+	value any
+}
+
+func (o exampleObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, _ := json.Marshal(m.name)
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+type exampleGenerator struct {
+	opts ExampleOptions
+}
+
+func (g exampleGenerator) message(m *Message, depth int) (exampleObject, error) {
+	obj := exampleObject{}
+	for _, item := range m.Fields {
+		switch v := item.(type) {
+		case Field:
+			_, optional := v.Annotations.FindByName(OptionalAnnotation)
+			_, example := v.Annotations.FindByName(ExampleAnnotation)
+			if optional && !example && g.opts.OmitOptional {
+				continue
+			}
+			value, ok, err := g.field(v, depth, optional)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", m.Name, v.Name, err)
+			}
+			if ok {
+				obj = append(obj, exampleMember{JSONFieldName(v), value})
+			}
+		case OneOfField:
+			cases := allFields(v.Items)
+			for _, c := range cases {
+				if _, ok := c.Annotations.FindByName(ExampleAnnotation); ok {
+					cases = []Field{c}
+					break
+				}
+			}
+			for _, c := range cases {
+				value, ok, err := g.field(c, depth, true)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %w", m.Name, c.Name, err)
+				}
+				if ok {
+					obj = append(obj, exampleMember{JSONFieldName(c), value})
+					break
+				}
+			}
+		}
+	}
+	return obj, nil
+}
+
+// field returns the example value of f. The returned boolean is false in
+// case f is optional and was omitted as MaxDepth was reached.
+func (g exampleGenerator) field(f Field, depth int, optional bool) (any, bool, error) {
+	t := f.Type
+	if _, ok := f.Annotations.FindByName(RepeatedAnnotation); ok {
+		if _, isArray := t.(Array); !isArray {
+			t = Array{Of: t}
+		}
+	}
+	if a, ok := f.Annotations.FindByName(ExampleAnnotation); ok {
+		v, err := exampleFromAnnotation(t, a.Value)
+		return v, true, err
+	}
+	if _, nested := t.(Resolved); nested && optional && depth >= g.opts.MaxDepth {
+		return nil, false, nil
+	}
+	v, err := g.value(f.Name, t, depth)
+	return v, true, err
+}
+
+func (g exampleGenerator) value(name string, t Type, depth int) (any, error) {
+	switch v := t.(type) {
+	case Primitive:
+		return examplePrimitive(name, v.Kind), nil
+	case Array:
+		if p, ok := v.Of.(Primitive); ok && p.Kind == Uint8 {
+			return base64.StdEncoding.EncodeToString(make([]byte, g.opts.Elements)), nil
+		}
+		items := []any{}
+		if _, nested := v.Of.(Resolved); nested && depth >= g.opts.MaxDepth {
+			return items, nil
+		}
+		for i := 0; i < g.opts.Elements; i++ {
+			item, err := g.value(name, v.Of, depth)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case Map:
+		obj := exampleObject{}
+		if _, nested := v.Value.(Resolved); nested && depth >= g.opts.MaxDepth {
+			return obj, nil
+		}
+		for i := 0; i < g.opts.Elements; i++ {
+			value, err := g.value(name, v.Value, depth)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, exampleMember{exampleMapKey(v.Key, i), value})
+		}
+		return obj, nil
+	case Resolved:
+		if depth >= g.opts.MaxDepth {
+			return nil, fmt.Errorf("cannot generate %s beyond a depth of %d", v.Name, g.opts.MaxDepth)
+		}
+		return g.message(v.Message, depth+1)
+	case Unresolved:
+		return nil, fmt.Errorf("unresolved type %s", v.Name)
+	}
+	return nil, fmt.Errorf("unsupported type %T", t)
+}
+
+func examplePrimitive(name string, p PrimitiveType) any {
+	switch {
+	case p == String:
+		return name
+	case p == Bool:
+		return false
+	case p.JSONType() == "string":
+		return "0"
+	}
+	return 0
+}
+
+func exampleMapKey(p PrimitiveType, i int) string {
+	switch p {
+	case String:
+		return "key" + strconv.Itoa(i)
+	case Bool:
+		return strconv.FormatBool(i%2 == 1)
+	}
+	return strconv.Itoa(i)
+}
+
+// exampleFromAnnotation converts values of an @example annotation into a
+// value of type t.
+func exampleFromAnnotation(t Type, values []string) (any, error) {
+	if a, ok := t.(Array); ok {
+		if p, ok := a.Of.(Primitive); ok && p.Kind == Uint8 {
+			if len(values) != 1 {
+				return nil, fmt.Errorf("@%s expects a single value", ExampleAnnotation)
+			}
+			if _, err := base64.StdEncoding.DecodeString(values[0]); err != nil {
+				return nil, fmt.Errorf("@%s value %q is not valid base64", ExampleAnnotation, values[0])
+			}
+			return values[0], nil
+		}
+		if p, ok := a.Of.(Primitive); ok {
+			items := make([]any, 0, len(values))
+			for _, s := range values {
+				v, err := parseExamplePrimitive(p.Kind, s)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, v)
+			}
+			return items, nil
+		}
+	}
+	if len(values) != 1 {
+		return nil, fmt.Errorf("@%s expects a single value", ExampleAnnotation)
+	}
+	if p, ok := t.(Primitive); ok {
+		return parseExamplePrimitive(p.Kind, values[0])
+	}
+	if !json.Valid([]byte(values[0])) {
+		return nil, fmt.Errorf("@%s value %q is not valid JSON", ExampleAnnotation, values[0])
+	}
+	return json.RawMessage(values[0]), nil
+}
+
+func parseExamplePrimitive(p PrimitiveType, s string) (any, error) {
+	var err error
+	switch {
+	case p == String:
+		return s, nil
+	case p == Bool:
+		var v bool
+		if v, err = strconv.ParseBool(s); err == nil {
+			return v, nil
+		}
+	case p.IsFloat():
+		// ParseFloat accepts forms such as NaN, which JSON cannot represent.
+		if _, err = strconv.ParseFloat(s, p.BitWidth()); err == nil && !json.Valid([]byte(s)) {
+			err = strconv.ErrSyntax
+		}
+	case p.IsSigned():
+		if _, err = strconv.ParseInt(s, 10, p.BitWidth()); err == nil && p.JSONType() == "string" {
+			return s, nil
+		}
+	default:
+		if _, err = strconv.ParseUint(s, 10, p.BitWidth()); err == nil && p.JSONType() == "string" {
+			return s, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("@%s value %q is not a valid %s", ExampleAnnotation, s, p.Keyword())
+	}
+	return json.Number(s), nil
+}
@@ -0,0 +1,72 @@
+package idl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateExample(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/example/order.yarp"))
+	require.Empty(t, fs.Resolve())
+	order, ok := fs.FindMessage("Order")
+	require.True(t, ok)
+
+	data, err := GenerateExample(order, ExampleOptions{MaxDepth: 2})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "1024",
+		"customer": "Jane Doe",
+		"items": [{"sku": "sku", "weight": 2.5, "quantity": 0}],
+		"tags": ["urgent", "gift"],
+		"totals": {"key0": 0},
+		"parent": {
+			"id": "1024",
+			"customer": "Jane Doe",
+			"items": [],
+			"tags": ["urgent", "gift"],
+			"totals": {"key0": 0},
+			"cash": true,
+			"address": {"street": "Main St"},
+			"note": "note"
+		},
+		"cash": true,
+		"address": {"street": "Main St"},
+		"note": "note"
+	}`, string(data))
+	assert.Regexp(t, `^\{\n  "id": "1024",\n  "customer"`, string(data))
+
+	data, err = GenerateExample(order, ExampleOptions{OmitOptional: true, Elements: 2})
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"parent"`)
+	assert.NotContains(t, string(data), `"note"`)
+	assert.Contains(t, string(data), `"key1": 0`)
+	assert.Contains(t, string(data), `"address"`)
+
+	item, _ := fs.FindMessage("Item")
+	item.Fields[1] = Field{Name: "weight", Type: Primitive{Kind: Float32}, Annotations: AnnotationCollection{{Name: ExampleAnnotation, Value: []string{"heavy"}}}}
+	_, err = GenerateExample(item, ExampleOptions{})
+	assert.EqualError(t, err, `Item.weight: @example value "heavy" is not a valid float32`)
+
+	bytesType := Array{Of: Primitive{Kind: Uint8}}
+	item.Fields = []FieldItem{
+		Field{Name: "sku", Type: Primitive{Kind: String}, Annotations: AnnotationCollection{{Name: "json.name", Value: []string{"SKU"}}}},
+		Field{Name: "digest", Type: bytesType},
+		Field{Name: "thumbnail", Type: bytesType, Annotations: AnnotationCollection{{Name: ExampleAnnotation, Value: []string{"AQI="}}}},
+	}
+	data, err = GenerateExample(item, ExampleOptions{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"SKU": "sku", "digest": "AA==", "thumbnail": "AQI="}`, string(data))
+	item.Fields[2] = Field{Name: "thumbnail", Type: bytesType, Annotations: AnnotationCollection{{Name: ExampleAnnotation, Value: []string{"1", "2"}}}}
+	_, err = GenerateExample(item, ExampleOptions{})
+	assert.EqualError(t, err, "Item.thumbnail: @example expects a single value")
+
+	fs = NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/recursive.yarp"))
+	fs.Resolve()
+	impossible, _ := fs.FindMessage("Impossible")
+	_, err = GenerateExample(impossible, ExampleOptions{})
+	assert.ErrorContains(t, err, "cannot generate org.example.recursive.Impossible beyond a depth of 3")
+}
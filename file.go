@@ -1,12 +1,17 @@
 package idl
 
-import "path/filepath"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"time"
+)
 
 // File represents a single YARP source file.
 type File struct {
-	// Tree contains a list of Package, Import, Message, and Service objects
-	// representing structures defined in a source file.
-	Tree []any
+	// Tree contains a list of *Package, *Import, *Message, and *Service
+	// objects representing structures defined in a source file.
+	Tree []Declaration
 
 	// Package represents the package name defined by the source file.
 	Package string
@@ -21,33 +26,82 @@ type File struct {
 
 	// ImportedFiles contains a list of paths provided to `import` directives.
 	ImportedFiles []string
+
+	// Tokens contains the list of tokens the File was parsed from. It is only
+	// populated when the parser is configured to retain tokens.
+	// See ParseOptions.
+	Tokens []Token
+
+	// Source contains the raw contents of the file, when available. It is
+	// populated by ParseSource and when loading files through a FileSet.
+	Source []byte
+
+	// SourcePath contains the absolute path of the file, when loaded through
+	// a FileSet.
+	SourcePath string
+
+	// Checksum contains the SHA-256 digest of Source, when loaded through a
+	// FileSet.
+	Checksum [sha256.Size]byte
+
+	// ModTime contains the modification time of the file, when loaded through
+	// a FileSet.
+	ModTime time.Time
+
 	declaredNames map[string]any
+	tokenRanges   map[Offset]TokenRange
+}
+
+// TokenRange represents a range of tokens within File.Tokens composing a
+// given node. Start is inclusive, End is exclusive.
+type TokenRange struct {
+	Start int
+	End   int
 }
 
-func (f *File) push(val any) {
+func (f *File) push(val Declaration) {
 	f.Tree = append(f.Tree, val)
 	switch v := val.(type) {
-	case Package:
+	case *Package:
 		f.Package = v.Name
-	case Import:
-		f.ImportedFiles = append(f.ImportedFiles, filepath.Clean(v.Path))
-	case Message:
+	case *Import:
+		path := v.Path
+		if !isRemote(path) {
+			path = filepath.Clean(path)
+		}
+		f.ImportedFiles = append(f.ImportedFiles, path)
+	case *Message:
 		f.DeclaredMessages = append(f.DeclaredMessages, v.Name)
 		if f.declaredNames == nil {
 			f.declaredNames = map[string]any{}
 		}
-		f.declaredNames[v.Name] = &v
-	case Service:
+		f.declaredNames[v.Name] = v
+	case *Service:
 		f.DeclaredServices = append(f.DeclaredServices, v.Name)
 		if f.declaredNames == nil {
 			f.declaredNames = map[string]any{}
 		}
-		f.declaredNames[v.Name] = &v
+		f.declaredNames[v.Name] = v
+	}
+}
+
+// importPaths returns paths provided to `import` directives, as written in the
+// source file. Unlike ImportedFiles, paths are not cleaned, allowing imports
+// relative to the file (e.g. ./types) to be told apart.
+func (f *File) importPaths() []string {
+	var r []string
+	for _, d := range f.Tree {
+		if v, ok := d.(*Import); ok {
+			r = append(r, v.Path)
+		}
 	}
+	return r
 }
 
 func (f *File) isImported(path string) bool {
-	path = filepath.Clean(path)
+	if !isRemote(path) {
+		path = filepath.Clean(path)
+	}
 	for _, p := range f.ImportedFiles {
 		if p == path {
 			return true
@@ -71,7 +125,7 @@ func (f *File) definitionByName(name string) any {
 	return f.declaredNames[name]
 }
 
-func (f File) last() any {
+func (f File) last() Declaration {
 	return f.Tree[len(f.Tree)-1]
 }
 
@@ -96,3 +150,51 @@ func (f File) ServiceByName(name string) (*Service, bool) {
 	s, ok := v.(*Service)
 	return s, ok
 }
+
+func (f *File) markTokens(o Offset, r TokenRange) {
+	if f.tokenRanges == nil {
+		f.tokenRanges = map[Offset]TokenRange{}
+	}
+	f.tokenRanges[o] = r
+}
+
+// TokenRangeOf takes a node and returns the range of tokens composing it, along
+// with a boolean indicating whether the range is known. Ranges are only
+// available when the File was parsed with ParseOptions.RetainTokens.
+func (f File) TokenRangeOf(node Node) (TokenRange, bool) {
+	if node == nil || f.tokenRanges == nil {
+		return TokenRange{}, false
+	}
+	r, ok := f.tokenRanges[node.Span()]
+	return r, ok
+}
+
+// TokensOf takes a node and returns the slice of Tokens composing it, along
+// with a boolean indicating whether tokens for the provided node are known.
+// See also: TokenRangeOf
+func (f File) TokensOf(node Node) ([]Token, bool) {
+	r, ok := f.TokenRangeOf(node)
+	if !ok {
+		return nil, false
+	}
+	return f.Tokens[r.Start:r.End], true
+}
+
+// TextOf takes a node and returns the exact slice of the source text it was
+// parsed from, along with a boolean indicating whether the text is available.
+// Text is only available when the File retains its Source.
+func (f File) TextOf(node Node) (string, bool) {
+	if node == nil {
+		return "", false
+	}
+	o := node.Span()
+	if f.Source == nil || o.End <= o.Start || o.End > len(f.Source) {
+		return "", false
+	}
+	return string(f.Source[o.Start:o.End]), true
+}
+
+// ChecksumHex returns the File's Checksum as a hex-encoded string.
+func (f File) ChecksumHex() string {
+	return hex.EncodeToString(f.Checksum[:])
+}
@@ -1,78 +1,201 @@
 package idl
 
 import (
+	"crypto/sha256"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
+	"io/fs"
+	"time"
 )
 
 // FileSet represents structures provided by a set of source files.
+// A FileSet may hold structures from multiple packages. The package of the
+// first loaded file is considered the primary package, and is used to resolve
+// names provided without a package to FileSet methods.
 type FileSet struct {
-	loadedFiles   map[string]bool
-	knownServices map[string]bool
-	packageName   string
-	messages      map[string]*Message
-	Messages      []*Message
-	Services      []*Service
+	loadedFiles  map[string]bool
+	packageName  string
+	messages     map[FQN]*Message
+	services     map[FQN]*Service
+	allMessages  []*Message
+	allServices  []*Service
+	declaredIn   map[Declaration]*File
+	importChains map[*File][]string
+	files        map[string]*File
+	imports      map[*File][]string
+	fileOrder    []*File
+	packages     map[string]*PackageView
+	packageOrder []string
+	includePaths []string
+	vendorRoots  map[string]bool
+	fetcher      Fetcher
+	module       *Module
+	unpinned     bool
+	pins         map[string][32]byte
+	source       sourceFS
+	casePolicy   CasePolicy
+	options      FileSetOptions
+	loadDiags    Diagnostics
+	checks       []Check
+	aliases      map[string]string
+	searchRoots  []string
+	progress     struct{ n, total int }
+	prefetched   map[string]prefetchResult
+	cache        *Cache
+	renamedRefs  []Diagnostic
+
+	// Messages contains all messages provided by the primary package.
+	//
+	// Deprecated: Use AllMessages, or PackageByName instead.
+	Messages []*Message
+
+	// Services contains all services provided by the primary package.
+	//
+	// Deprecated: Use AllServices, or PackageByName instead.
+	Services []*Service
 }
 
 // NewFileSet creates a new FileSet structure
 func NewFileSet() *FileSet {
 	return &FileSet{
-		loadedFiles:   map[string]bool{},
-		knownServices: map[string]bool{},
-		packageName:   "",
-		messages:      map[string]*Message{},
-		Messages:      nil,
-		Services:      nil,
+		loadedFiles:  map[string]bool{},
+		packageName:  "",
+		messages:     map[FQN]*Message{},
+		services:     map[FQN]*Service{},
+		declaredIn:   map[Declaration]*File{},
+		importChains: map[*File][]string{},
+		packages:     map[string]*PackageView{},
+		source:       osSource{},
+		Messages:     nil,
+		Services:     nil,
 	}
 }
 
 func (f *FileSet) registerMessage(file *File, msg *Message) error {
-	fqn := fmt.Sprintf("%s.%s", file.Package, msg.Name)
+	fqn := NewFQN(file.Package, msg.Name)
 	if f.messages == nil {
-		f.messages = map[string]*Message{}
+		f.messages = map[FQN]*Message{}
 	}
 	if _, ok := f.messages[fqn]; ok {
 		// TODO: Normalize errors
 		return fmt.Errorf("duplicated definition of %s", fqn)
 	}
 	f.messages[fqn] = msg
+	msg.SourceFile = file.SourcePath
+	f.allMessages = append(f.allMessages, msg)
+	f.declare(file, msg)
+	view := f.packageView(file.Package)
+	view.messages = append(view.messages, msg)
+	if file.Package == f.packageName {
+		f.Messages = append(f.Messages, msg)
+	}
+	return nil
+}
+
+func (f *FileSet) registerService(file *File, svc *Service) error {
+	fqn := NewFQN(file.Package, svc.Name)
+	if f.services == nil {
+		f.services = map[FQN]*Service{}
+	}
+	if _, ok := f.services[fqn]; ok {
+		return fmt.Errorf("multiple declarations of service %s (duplicate found in %s)", fqn, file.SourcePath)
+	}
+	f.services[fqn] = svc
+	svc.SourceFile = file.SourcePath
+	f.allServices = append(f.allServices, svc)
+	f.declare(file, svc)
+	view := f.packageView(file.Package)
+	view.services = append(view.services, svc)
+	if file.Package == f.packageName {
+		f.Services = append(f.Services, svc)
+	}
 	return nil
 }
 
+// register adds all messages and services declared by file to the FileSet.
+// chain contains the paths of files leading to file, from the entry file up to
+// file itself.
+func (f *FileSet) register(file *File, chain []string) error {
+	if f.importChains == nil {
+		f.importChains = map[*File][]string{}
+	}
+	f.importChains[file] = append([]string{}, chain...)
+	if f.files == nil {
+		f.files = map[string]*File{}
+	}
+	f.files[file.SourcePath] = file
+	f.fileOrder = append(f.fileOrder, file)
+	for _, n := range file.DeclaredMessages {
+		m, ok := file.MessageByName(n)
+		if !ok {
+			return fmt.Errorf("BUG: %s declares %s, but message could not be found", file.SourcePath, n)
+		}
+		if err := f.registerMessage(file, m); err != nil {
+			if err = f.fail(err, locationOf(file, m.Offset)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, n := range file.DeclaredServices {
+		s, ok := file.ServiceByName(n)
+		if !ok {
+			return fmt.Errorf("BUG: %s declares %s, but service could not be found", file.SourcePath, n)
+		}
+		if err := f.registerService(file, s); err != nil {
+			if err = f.fail(err, locationOf(file, s.Offset)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (f *FileSet) declare(file *File, d Declaration) {
+	if f.declaredIn == nil {
+		f.declaredIn = map[Declaration]*File{}
+	}
+	f.declaredIn[d] = file
+}
+
+func (f FileSet) sourceFS() sourceFS {
+	if f.source == nil {
+		return osSource{}
+	}
+	return f.source
+}
+
 func (f FileSet) isLoaded(path string) bool {
 	_, ok := f.loadedFiles[path]
 	return ok
 }
 
-func (f FileSet) findAndLoad(path string) (string, *File, error) {
-	s, err := filepath.Abs(path)
+// locate takes a path provided by the user or by an import directive and
+// returns the canonical absolute path of the source file it refers to,
+// appending the .yarp extension when required. Symbolic links are resolved,
+// and the case of each component matches the one used on disk, so that each
+// file is identified by a single path.
+func (f FileSet) locate(path string) (string, fs.FileInfo, error) {
+	src := f.sourceFS()
+	s, err := src.abs(path)
 	if err != nil {
 		return "", nil, err
 	}
-	if f.isLoaded(s) {
-		return "", nil, nil
-	}
-	stat, err := os.Stat(s)
+	stat, err := src.stat(s)
 	exist := true
-	if os.IsNotExist(err) {
+	if errors.Is(err, fs.ErrNotExist) {
 		exist = false
 	} else if err != nil {
 		return "", nil, err
 	}
 
 	if !exist || stat.IsDir() {
-		next := path + ".yarp"
-		st, err := os.Stat(next)
+		next := s + ".yarp"
+		st, err := src.stat(next)
 		if err == nil && !st.IsDir() {
 			stat = st
-			path = next
+			s = next
 			exist = true
 		}
-	} else {
-		path = s
 	}
 
 	if !exist {
@@ -81,142 +204,325 @@ func (f FileSet) findAndLoad(path string) (string, *File, error) {
 	if stat.IsDir() {
 		return "", nil, SourceIsDirectoryError{Path: path}
 	}
-
-	file, err := os.Open(path)
+	c, caseChanged, err := src.canonical(s)
 	if err != nil {
 		return "", nil, err
 	}
-	defer func(file *os.File) {
-		_ = file.Close()
-	}(file)
-	tokens, err := Scan(file)
+	if caseChanged && f.casePolicy == CaseStrict {
+		return "", nil, PathCaseMismatchError{Path: path, Actual: c}
+	}
+	return c, stat, nil
+}
+
+// loadFile reads and parses the source file under the provided absolute path.
+// Remote files, identified by their URL, are fetched and verified against
+// their pinned checksum, and have no stat information. Standard library files
+// are read from the copy embedded into the package.
+func (f FileSet) loadFile(path string, stat fs.FileInfo) (*File, error) {
+	var src []byte
+	var err error
+	if isRemote(path) {
+		src, err = f.fetchRemote(path)
+	} else if isStd(path) {
+		src, err = readStd(path)
+	} else {
+		src, err = f.sourceFS().readFile(path)
+	}
 	if err != nil {
-		return "", nil, err
+		return nil, err
+	}
+	var modTime time.Time
+	if stat != nil {
+		modTime = stat.ModTime()
 	}
-	result, err := Parse(tokens)
+	checksum := sha256.Sum256(src)
+	if f.cache != nil {
+		if cached, ok := f.cache.get(path, checksum); ok {
+			cached.Source = src
+			cached.ModTime = modTime
+			return cached, nil
+		}
+	}
+	result, err := ParseSource(src, ParseOptions{})
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
+	result.SourcePath = path
+	result.Checksum = checksum
+	result.ModTime = modTime
+	if f.cache != nil {
+		f.cache.put(result)
+	}
+	return result, nil
+}
 
-	return path, result, nil
+// findAndLoad locates and loads the file under the provided path. In case the
+// file has already been loaded, an empty path and a nil File are returned. In
+// case the file exists but cannot be loaded, its path is returned along with
+// the error.
+func (f FileSet) findAndLoad(path string) (string, *File, error) {
+	s, stat, err := f.locate(path)
+	if err != nil {
+		return "", nil, err
+	}
+	if f.isLoaded(s) {
+		return "", nil, nil
+	}
+	result, err := f.loadFile(s, stat)
+	if err != nil {
+		return s, nil, err
+	}
+	return s, result, nil
 }
 
 // Load attempts to load a given file under the provided path and add its
 // contents to the current FileSet. In case the file cannot be loaded, an error
-// is returned.
+// is returned. When FileSetOptions.ContinueOnError is set, loading proceeds
+// past problems, and all of them are returned as Diagnostics.
 func (f *FileSet) Load(path string) error {
+	f.loadDiags = nil
+	defer func() { f.loadDiags = nil }()
+	if err := f.load(path); err != nil {
+		return err
+	}
+	if len(f.loadDiags) > 0 {
+		return f.loadDiags
+	}
+	return nil
+}
+
+func (f *FileSet) load(path string) error {
 	finalPath, file, err := f.findAndLoad(path)
+	if err != nil && finalPath != "" && f.options.ContinueOnError {
+		return f.fail(err, Location{File: finalPath})
+	}
 	if err != nil {
-		return fmt.Errorf("%s: %w", path, err)
+		return f.fail(fmt.Errorf("%s: %w", path, err), Location{File: path})
+	}
+	if file == nil {
+		// File was already loaded.
+		return nil
+	}
+	if err = f.checkLimits(nil, finalPath); err != nil {
+		return f.fail(err, Location{File: finalPath})
 	}
 	f.loadedFiles[finalPath] = true
 	if f.packageName == "" {
 		f.packageName = file.Package
-	} else if f.packageName != file.Package {
-		return MixedPackagesError{
-			Path:     path,
-			Package1: f.packageName,
-			Package2: file.Package,
-		}
 	}
 
-	if err = f.processImports(finalPath, file); err != nil {
+	f.prefetched = f.prefetch(finalPath, file)
+	defer func() { f.prefetched = nil }()
+	f.startProgress(finalPath)
+	if err = f.processImports(finalPath, file, []string{finalPath}); err != nil {
 		return err
 	}
-
-	for _, n := range file.DeclaredMessages {
-		m, ok := file.MessageByName(n)
-		if !ok {
-			return fmt.Errorf("BUG: %s declares %s, but message could not be found", finalPath, n)
-		}
-		f.Messages = append(f.Messages, m)
-		if err = f.registerMessage(file, m); err != nil {
-			return err
-		}
+	if err = f.register(file, []string{finalPath}); err != nil {
+		return err
 	}
+	f.linkMethods()
+	return nil
+}
 
-	for _, n := range file.DeclaredServices {
-		s, ok := file.ServiceByName(n)
-		if !ok {
-			return fmt.Errorf("BUG: %s declares %s, but service could not be found", finalPath, n)
-		}
-		if f.knownServices == nil {
-			f.knownServices = map[string]bool{}
-		}
-		if _, ok := f.knownServices[n]; ok {
-			return fmt.Errorf("multiple declarations of service %s (duplicate found in %s)", n, finalPath)
+// linkMethods sets the Target of every method argument and return type
+// referencing a message known by the FileSet.
+func (f *FileSet) linkMethods() {
+	for _, s := range f.allServices {
+		for i := range s.Methods {
+			m := &s.Methods[i]
+			for _, ref := range []*TypeRef{&m.Argument, &m.Return} {
+				if ref.IsVoid() {
+					continue
+				}
+				if msg, ok := f.lookupMessage(f.declaredIn[s], ref.FQN()); ok {
+					ref.Target = msg
+				}
+			}
 		}
-		f.knownServices[n] = true
-		f.Services = append(f.Services, s)
 	}
-	return nil
 }
 
-func (f *FileSet) processImports(path string, file *File) error {
-	for _, i := range file.ImportedFiles {
-		pwd := filepath.Dir(path)
-		target, err := filepath.Abs(filepath.Join(pwd, i))
+// processImports loads all files imported by file, which is located under
+// path. chain contains the paths of files currently being loaded, from the
+// entry file up to the importing one, and is used to detect circular imports.
+func (f *FileSet) processImports(path string, file *File, chain []string) error {
+	for _, i := range file.importPaths() {
+		finalPath, stat, err := f.resolveImport(path, i)
 		if err != nil {
-			return err
-		}
-		finalPath, imported, err := f.findAndLoad(target)
-		if err != nil {
-			if nf, ok := err.(SourceFileNotFoundError); ok {
-				return ImportFileNotFoundError{
-					Source: path,
-					Path:   nf.Path,
-				}
-			} else {
+			if err = f.fail(err, Location{File: path}); err != nil {
 				return err
 			}
+			continue
 		}
-		f.loadedFiles[finalPath] = true
-		if err := f.processImports(finalPath, imported); err != nil {
-			return err
+		if f.imports == nil {
+			f.imports = map[*File][]string{}
 		}
-		for _, m := range imported.DeclaredMessages {
-			msg, ok := imported.MessageByName(m)
-			if !ok {
-				return fmt.Errorf("BUG: %s declares %s, but message could not be found", finalPath, m)
+		f.imports[file] = append(f.imports[file], finalPath)
+		cyclic := false
+		for idx, p := range chain {
+			if p == finalPath {
+				cycle := append(append([]string{}, chain[idx:]...), finalPath)
+				if err = f.fail(CircularImportError{Chain: cycle}, Location{File: path}); err != nil {
+					return err
+				}
+				cyclic = true
+				break
 			}
-			if err = f.registerMessage(imported, msg); err != nil {
+		}
+		if cyclic || f.isLoaded(finalPath) {
+			continue
+		}
+		if err = f.checkLimits(chain, finalPath); err != nil {
+			if err = f.fail(err, Location{File: path}); err != nil {
 				return err
 			}
-			if imported.Package == f.packageName {
-				f.Messages = append(f.Messages, msg)
+			continue
+		}
+		var imported *File
+		if r, ok := f.prefetched[finalPath]; ok {
+			imported, err = r.file, r.err
+		} else {
+			imported, err = f.loadFile(finalPath, stat)
+		}
+		if err != nil {
+			if err = f.fail(err, Location{File: finalPath}); err != nil {
+				return err
 			}
+			continue
 		}
-		if imported.Package == f.packageName {
-			for _, n := range imported.DeclaredServices {
-				s, ok := imported.ServiceByName(n)
-				if !ok {
-					return fmt.Errorf("BUG: %s declares %s, but service could not be found", finalPath, n)
-				}
-				if f.knownServices == nil {
-					f.knownServices = map[string]bool{}
+		f.loadedFiles[finalPath] = true
+		f.reportProgress(finalPath)
+		importChain := append(append([]string{}, chain...), finalPath)
+		if err := f.processImports(finalPath, imported, importChain); err != nil {
+			return err
+		}
+		if err = f.register(imported, importChain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddIncludePath adds a directory to the list of roots searched when resolving
+// imports. Imports are first resolved relative to the importing file, and then
+// against each include path, in the order they were added.
+func (f *FileSet) AddIncludePath(dir string) error {
+	abs, err := f.sourceFS().abs(dir)
+	if err != nil {
+		return err
+	}
+	stat, err := f.sourceFS().stat(abs)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("%s: not a directory", dir)
+	}
+	if abs, _, err = f.sourceFS().canonical(abs); err != nil {
+		return err
+	}
+	f.includePaths = append(f.includePaths, abs)
+	return nil
+}
+
+// resolveImport locates the file referenced by an import directive present in
+// the file under the provided path. Imports that are not relative to the
+// importing file are looked up in the closest vendor directory, and then
+// against requirements of the module in use, if any. When they cannot be
+// found relative to the importing file or include paths either, they are
+// finally looked up in the search path (see SearchPathEnv).
+// Imports of URLs made by local files are looked up in the closest vendor
+// directory before being fetched.
+func (f *FileSet) resolveImport(from, path string) (string, fs.FileInfo, error) {
+	if isStd(path) {
+		return resolveStd(path)
+	}
+	if isRemote(path) {
+		if !isRemote(from) {
+			if vendor, ok := f.vendorRoot(from); ok {
+				if p, ok := f.remoteVendorPath(path); ok {
+					if finalPath, stat, ok, err := f.locateIn([]string{vendor}, p); ok || err != nil {
+						return finalPath, stat, err
+					}
 				}
-				if _, ok := f.knownServices[n]; ok {
-					return fmt.Errorf("multiple declarations of service %s (duplicate found in %s)", n, finalPath)
+			}
+		}
+		u, err := resolveRemoteImport(from, path)
+		return u, nil, err
+	}
+	if !isRelativeImport(path) {
+		if !isRemote(from) {
+			if vendor, ok := f.vendorRoot(from); ok {
+				if finalPath, stat, ok, err := f.locateIn([]string{vendor}, path); ok || err != nil {
+					return finalPath, stat, err
 				}
-				f.knownServices[n] = true
-				f.Services = append(f.Services, s)
 			}
 		}
+		if u, ok, err := f.module.resolve(path); ok || err != nil {
+			return u, nil, err
+		}
 	}
-	return nil
+	if isRemote(from) {
+		u, err := resolveRemoteImport(from, path)
+		return u, nil, err
+	}
+	roots := append([]string{f.sourceFS().dir(from)}, f.includePaths...)
+	if !isRelativeImport(path) {
+		roots = append(roots, f.searchPath()...)
+	}
+	if finalPath, stat, ok, err := f.locateIn(roots, path); ok || err != nil {
+		return finalPath, stat, err
+	}
+	return "", nil, ImportFileNotFoundError{
+		Source: from,
+		Path:   path,
+	}
+}
+
+// locateIn looks up path in each of the provided roots, returning the first
+// file found, and a boolean indicating whether the file exists.
+func (f *FileSet) locateIn(roots []string, path string) (string, fs.FileInfo, bool, error) {
+	src := f.sourceFS()
+	for _, root := range roots {
+		finalPath, stat, err := f.locate(src.join(root, path))
+		if err == nil {
+			return finalPath, stat, true, nil
+		}
+		if _, ok := err.(SourceFileNotFoundError); !ok {
+			return "", nil, false, err
+		}
+	}
+	return "", nil, false, nil
+}
+
+// Files returns all files loaded into the FileSet. Imported files appear
+// before files importing them.
+func (f *FileSet) Files() []*File {
+	return append([]*File{}, f.fileOrder...)
+}
+
+// FileByPath takes a path and returns the File loaded from it, along with a
+// boolean indicating whether such file has been loaded. Relative paths are
+// resolved against the current working directory, and the .yarp extension
+// may be omitted.
+func (f *FileSet) FileByPath(path string) (*File, bool) {
+	if file, ok := f.files[path]; ok {
+		return file, true
+	}
+	s, _, err := f.locate(path)
+	if err != nil {
+		return nil, false
+	}
+	file, ok := f.files[s]
+	return file, ok
 }
 
 // FindMessage takes a message name (e.g. SomethingRequest) or FQN (e.g.
 // package.SomethingRequest) and returns a Message along with a boolean
 // indicating whether the provided name could be resolved to a message.
 func (f *FileSet) FindMessage(name string) (*Message, bool) {
-	n := name
-	if !strings.ContainsRune(n, '.') {
-		// N should be present in the package we're processing.
-		n = fmt.Sprintf("%s.%s", f.packageName, n)
-	}
-
-	m, ok := f.messages[n]
+	// Short names should be present in the package we're processing.
+	m, ok := f.messages[f.dealias(FQN(name).Qualify(f.packageName))]
 	return m, ok
 }
 
@@ -225,29 +531,24 @@ func (f FileSet) Package() string {
 	return f.packageName
 }
 
-// FromSamePackage takes a name and returns whether it is declared by the
-// package declared in the loaded source files.
+// FromSamePackage takes a message or service name and returns whether it is
+// declared by the package declared in the loaded source files.
 func (f FileSet) FromSamePackage(name string) bool {
-	n := name
-	if !strings.ContainsRune(n, '.') {
-		// N should be present in the package we're processing.
-		n = fmt.Sprintf("%s.%s", f.packageName, n)
-	}
-	m, ok := f.messages[n]
-	if !ok {
+	// Short names should be present in the package we're processing.
+	n := FQN(name).Qualify(f.packageName)
+	_, isMessage := f.messages[n]
+	_, isService := f.services[n]
+	if !isMessage && !isService {
 		return false
 	}
-	return strings.TrimSuffix(n, "."+m.Name) == f.packageName
+	return n.Package() == f.packageName
 }
 
 // SplitComponents splits a given name into a package and message name. In case
 // the provided name does not include a package, pkgName is returned as an empty
 // string.
+// See also: FQN
 func SplitComponents(n string) (pkgName, messageName string) {
-	components := strings.Split(n, ".")
-	if len(components) == 1 {
-		return "", components[0]
-	}
-
-	return strings.Join(components[0:len(components)-1], "."), components[len(components)-1]
+	f := FQN(n)
+	return f.Package(), f.Name()
 }
@@ -1,9 +1,17 @@
 package idl
 
 import (
+	"crypto/sha256"
 	"fmt"
-	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFileSet(t *testing.T) {
@@ -12,3 +20,251 @@ func TestFileSet(t *testing.T) {
 	require.NoError(t, err)
 	fmt.Printf("%#v\n", fs)
 }
+
+func TestFileSetLinksMethods(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/fixture/test.yarp"))
+	services := slices.Collect(fs.AllServices())
+	require.Len(t, services, 1)
+	m := services[0].Methods[0]
+	req, _ := fs.FindMessage("RandomBytesRequest")
+	res, _ := fs.FindMessage("RandomBytesResponse")
+	require.Equal(t, req, m.Argument.Target)
+	require.Equal(t, res, m.Return.Target)
+
+	m = services[0].Methods[1]
+	require.True(t, m.Argument.IsVoid())
+	require.Equal(t, "io.libyarp.common", m.Return.Package)
+	require.Equal(t, "Notification", m.Return.Name)
+}
+
+func TestFileSetFileMetadata(t *testing.T) {
+	src, err := os.ReadFile("./test/fixture/requests.yarp")
+	require.NoError(t, err)
+	f, err := ParseSource(src, ParseOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, f.SourcePath)
+
+	fs := NewFileSet()
+	_, f, err = fs.findAndLoad("./test/fixture/requests")
+	require.NoError(t, err)
+	abs, err := filepath.Abs("./test/fixture/requests.yarp")
+	require.NoError(t, err)
+	assert.Equal(t, abs, f.SourcePath)
+	assert.Equal(t, sha256.Sum256(src), f.Checksum)
+	assert.Len(t, f.ChecksumHex(), 64)
+	assert.False(t, f.ModTime.IsZero())
+}
+
+func TestFileSetCircularImport(t *testing.T) {
+	fs := NewFileSet()
+	err := fs.Load("./test/cycle/a.yarp")
+	var cycle CircularImportError
+	require.ErrorAs(t, err, &cycle)
+	var names []string
+	for _, p := range cycle.Chain {
+		names = append(names, filepath.Base(p))
+	}
+	assert.Equal(t, []string{"a.yarp", "b.yarp", "c.yarp", "a.yarp"}, names)
+	assert.Contains(t, err.Error(), "a.yarp imports ")
+}
+
+func TestFileSetDiamondImport(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/diamond/main.yarp"))
+	require.NoError(t, fs.Load("./test/diamond/main.yarp"))
+	assert.Len(t, slices.Collect(fs.AllMessages()), 4)
+	assert.Empty(t, fs.Validate())
+}
+
+func TestFileSetOrigin(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/diamond/main.yarp"))
+
+	base, ok := fs.FindMessage("Base")
+	require.True(t, ok)
+	assert.Equal(t, "base.yarp", filepath.Base(base.SourceFile))
+
+	o, ok := fs.Origin("org.example.diamond.Base")
+	require.True(t, ok)
+	assert.Equal(t, base.SourceFile, o.Path())
+	assert.Equal(t, base.Offset, o.Offset)
+	var names []string
+	for _, p := range o.ImportChain {
+		names = append(names, filepath.Base(p))
+	}
+	assert.Equal(t, []string{"main.yarp", "left.yarp", "base.yarp"}, names)
+
+	o, ok = fs.Origin("Top")
+	require.True(t, ok)
+	assert.Len(t, o.ImportChain, 1)
+
+	_, ok = fs.Origin("Missing")
+	assert.False(t, ok)
+}
+
+func TestFileSetFiles(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/diamond/main.yarp"))
+
+	var names []string
+	for _, f := range fs.Files() {
+		names = append(names, filepath.Base(f.SourcePath))
+	}
+	assert.Equal(t, []string{"base.yarp", "left.yarp", "right.yarp", "main.yarp"}, names)
+
+	f, ok := fs.FileByPath("./test/diamond/left")
+	require.True(t, ok)
+	assert.Equal(t, []string{"base"}, f.ImportedFiles)
+	f2, ok := fs.FileByPath(f.SourcePath)
+	require.True(t, ok)
+	assert.Same(t, f, f2)
+
+	_, ok = fs.FileByPath("./test/diamond/missing.yarp")
+	assert.False(t, ok)
+}
+
+func TestFileSetMultiplePackages(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/resolve/main.yarp"))
+	require.NoError(t, fs.Load("./test/resolve/services.yarp"))
+	assert.Equal(t, "org.example.main", fs.Package())
+
+	var names []string
+	for _, p := range fs.Packages() {
+		names = append(names, p.Name())
+	}
+	assert.Equal(t, []string{"org.example.types", "org.example.main"}, names)
+
+	types, ok := fs.PackageByName("org.example.types")
+	require.True(t, ok)
+	assert.Len(t, types.Messages(), 3)
+	require.Len(t, types.Services(), 1)
+	assert.Equal(t, "Lookups", types.Services()[0].Name)
+	lookup, ok := types.MessageByName("Lookup")
+	require.True(t, ok)
+	assert.Equal(t, lookup, types.Services()[0].Methods[0].Argument.Target)
+
+	main, ok := fs.PackageByName("org.example.main")
+	require.True(t, ok)
+	assert.Equal(t, slices.Collect(fs.AllMessages()), main.Messages())
+	assert.Equal(t, slices.Collect(fs.AllServices()), main.Services())
+
+	_, ok = fs.PackageByName("org.example.unknown")
+	assert.False(t, ok)
+}
+
+func TestFileSetIncludePaths(t *testing.T) {
+	fs := NewFileSet()
+	err := fs.Load("./test/include/app/order.yarp")
+	var notFound ImportFileNotFoundError
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, "common/types", notFound.Path)
+
+	fs = NewFileSet()
+	require.Error(t, fs.AddIncludePath("./test/include/missing"))
+	require.NoError(t, fs.AddIncludePath("./test/include/shared"))
+	require.NoError(t, fs.Load("./test/include/app/order.yarp"))
+	assert.Empty(t, fs.Validate())
+	_, ok := fs.FindMessage("org.example.common.Money")
+	assert.True(t, ok)
+}
+
+func TestFileSetFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"schemas/main.yarp":        {Data: []byte("package org.example.embed;\n\nimport \"./types\";\nimport \"common/money\";\n\nmessage Order {\n    item Item = 0;\n    total org.example.common.Money = 1;\n}\n")},
+		"schemas/types.yarp":       {Data: []byte("package org.example.embed;\n\nmessage Item {\n    name string = 0;\n}\n")},
+		"shared/common/money.yarp": {Data: []byte("package org.example.common;\n\nmessage Money {\n    cents int64 = 0;\n}\n")},
+	}
+	fs := NewFileSetFS(fsys)
+	require.NoError(t, fs.AddIncludePath("shared"))
+	require.NoError(t, fs.Load("schemas/main.yarp"))
+	assert.Empty(t, fs.Validate())
+	assert.Len(t, slices.Collect(fs.AllMessages()), 2)
+
+	var paths []string
+	for p := range fs.loadedFiles {
+		paths = append(paths, p)
+	}
+	assert.ElementsMatch(t, []string{"schemas/main.yarp", "schemas/types.yarp", "shared/common/money.yarp"}, paths)
+
+	err := NewFileSetFS(fsys).Load("../escape.yarp")
+	assert.Error(t, err)
+
+	fs = NewFileSetFS(os.DirFS("test"))
+	require.NoError(t, fs.Load("fixture/test"))
+	assert.Len(t, slices.Collect(fs.AllServices()), 1)
+}
+
+func TestFileSetLoadDir(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.LoadDir("./test/diamond"))
+	var names []string
+	for m := range fs.AllMessages() {
+		names = append(names, m.Name)
+	}
+	assert.Equal(t, []string{"Base", "Left", "Right", "Top"}, names)
+
+	fs = NewFileSet()
+	require.NoError(t, fs.LoadGlob("./test/resolve/*.yarp"))
+	assert.Len(t, fs.Packages(), 2)
+
+	fs = NewFileSetFS(os.DirFS("test"))
+	require.NoError(t, fs.AddIncludePath("include/shared"))
+	require.NoError(t, fs.LoadDir("include"))
+	assert.Len(t, fs.Packages(), 2)
+	assert.Empty(t, fs.Validate())
+}
+
+// writeImportTree writes n source files to dir, in which each file imports
+// up to two files declared after it, returning the path of the entry file.
+func writeImportTree(tb testing.TB, dir string, n int) string {
+	for i := 0; i < n; i++ {
+		var b strings.Builder
+		fmt.Fprintf(&b, "package bench.tree;\n\n")
+		for _, c := range []int{2*i + 1, 2*i + 2} {
+			if c < n {
+				fmt.Fprintf(&b, "import \"./file%d\";\n", c)
+			}
+		}
+		fmt.Fprintf(&b, "\nmessage Message%d {\n    id int64 = 0;\n    name string = 1;\n", i)
+		for j, c := range []int{2*i + 1, 2*i + 2} {
+			if c < n {
+				fmt.Fprintf(&b, "    @optional child%d Message%d = %d;\n", j, c, j+2)
+			}
+		}
+		fmt.Fprintf(&b, "}\n")
+		path := filepath.Join(dir, fmt.Sprintf("file%d.yarp", i))
+		require.NoError(tb, os.WriteFile(path, []byte(b.String()), 0o644))
+	}
+	return filepath.Join(dir, "file0.yarp")
+}
+
+func TestFileSetParallelLoadIsDeterministic(t *testing.T) {
+	entry := writeImportTree(t, t.TempDir(), 64)
+	var expected []string
+	for i := 0; i < 5; i++ {
+		fs := NewFileSet()
+		require.NoError(t, fs.Load(entry))
+		assert.Empty(t, fs.Validate())
+		var names []string
+		for m := range fs.AllMessages() {
+			names = append(names, m.Name)
+		}
+		require.Len(t, names, 64)
+		if expected == nil {
+			expected = names
+		}
+		assert.Equal(t, expected, names)
+	}
+}
+
+func BenchmarkFileSetLoad500(b *testing.B) {
+	entry := writeImportTree(b, b.TempDir(), 500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := NewFileSet().Load(entry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package idl
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fingerprint returns a canonical hash of a message, which only changes when
+// its name, or names, indexes, types, or annotations of its fields change.
+// Comments, formatting, and the order in which fields are declared do not
+// affect the result. Referenced messages are identified by their FQN in case
+// msg has been resolved (see FileSet.Resolve), and by their name as written
+// otherwise.
+func Fingerprint(msg *Message) [sha256.Size]byte {
+	var b strings.Builder
+	canonicalMessage(&b, msg.Name, msg)
+	return sha256.Sum256([]byte(b.String()))
+}
+
+// ServiceFingerprint returns a canonical hash of s, which must be known by the
+// FileSet. It only changes when the name of s, or names, annotations,
+// argument and return types of its methods change, and references messages
+// by their FQN.
+func (f *FileSet) ServiceFingerprint(s *Service) [sha256.Size]byte {
+	var b strings.Builder
+	f.canonicalService(&b, s.Name, s)
+	return sha256.Sum256([]byte(b.String()))
+}
+
+// Fingerprint returns a canonical hash of all messages and services known by
+// the FileSet, computed as described by Fingerprint. Types are resolved
+// before the hash is computed, and declarations are hashed in order of their
+// FQNs, making the result independent of the order in which files were
+// loaded.
+func (f *FileSet) Fingerprint() [sha256.Size]byte {
+	f.Resolve()
+	var entries []string
+	for fqn, m := range f.messages {
+		var b strings.Builder
+		canonicalMessage(&b, fqn.String(), m)
+		entries = append(entries, b.String())
+	}
+	for fqn, s := range f.services {
+		var b strings.Builder
+		f.canonicalService(&b, fqn.String(), s)
+		entries = append(entries, b.String())
+	}
+	sort.Strings(entries)
+	return sha256.Sum256([]byte(strings.Join(entries, "\n")))
+}
+
+func canonicalMessage(b *strings.Builder, name string, m *Message) {
+	fmt.Fprintf(b, "message %s", name)
+	canonicalAnnotations(b, m.Annotations)
+	canonicalItems(b, m.Fields)
+}
+
+func canonicalItems(b *strings.Builder, items []FieldItem) {
+	sorted := append([]FieldItem{}, items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return fieldIndex(sorted[i]) < fieldIndex(sorted[j])
+	})
+	b.WriteString("{")
+	for _, item := range sorted {
+		switch v := item.(type) {
+		case Field:
+			fmt.Fprintf(b, "%d:%s:%s", v.Index, v.Name, canonicalType(v.Type))
+			canonicalAnnotations(b, v.Annotations)
+		case OneOfField:
+			fmt.Fprintf(b, "%d:oneof", v.Index)
+			canonicalAnnotations(b, v.Annotations)
+			canonicalItems(b, v.Items)
+		}
+		b.WriteString(";")
+	}
+	b.WriteString("}")
+}
+
+func canonicalType(t Type) string {
+	switch v := t.(type) {
+	case Primitive:
+		return v.Kind.Keyword()
+	case Array:
+		return "array<" + canonicalType(v.Of) + ">"
+	case Map:
+		return "map<" + v.Key.Keyword() + "," + canonicalType(v.Value) + ">"
+	case Resolved:
+		return v.Name.String()
+	case Unresolved:
+		return v.Name
+	}
+	return "?"
+}
+
+func canonicalAnnotations(b *strings.Builder, a AnnotationCollection) {
+	if len(a) == 0 {
+		return
+	}
+	names := make([]string, len(a))
+	for i, v := range a {
+		names[i] = fmt.Sprintf("@%s(%q)", v.Name, v.Value)
+	}
+	sort.Strings(names)
+	b.WriteString(strings.Join(names, ""))
+}
+
+func (f *FileSet) canonicalService(b *strings.Builder, name string, s *Service) {
+	fmt.Fprintf(b, "service %s", name)
+	canonicalAnnotations(b, s.Annotations)
+	methods := append([]Method{}, s.Methods...)
+	sort.SliceStable(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+	b.WriteString("{")
+	for _, m := range methods {
+		fmt.Fprintf(b, "%s(%s)->%s", m.Name, f.canonicalRef(m.Argument), f.canonicalRef(m.Return))
+		canonicalAnnotations(b, m.Annotations)
+		b.WriteString(";")
+	}
+	b.WriteString("}")
+}
+
+func (f *FileSet) canonicalRef(t TypeRef) string {
+	s := t.String()
+	if t.Target != nil {
+		s = NewFQN(f.packageOf(t.Target), t.Target.Name).String()
+	}
+	if t.Streaming {
+		s = "stream " + s
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package idl
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fingerprintOf(t *testing.T, src string) ([32]byte, [32]byte) {
+	fs := NewFileSetFS(fstest.MapFS{"main.yarp": {Data: []byte(src)}})
+	require.NoError(t, fs.Load("main.yarp"))
+	m, ok := fs.FindMessage("User")
+	require.True(t, ok)
+	return Fingerprint(m), fs.Fingerprint()
+}
+
+func TestFingerprint(t *testing.T) {
+	base := "package a;\n\nmessage User {\n    id int64 = 0;\n    @optional name string = 1;\n}\n\nservice Users {\n    get(User) -> User;\n}\n"
+	msg, set := fingerprintOf(t, base)
+
+	same := []string{
+		"package a;\n# Users of the system.\nmessage User {\n    @optional\n    name   string = 1; # display name\n    id int64 = 0;\n}\nservice Users { get(User) -> User; }\n",
+	}
+	for _, src := range same {
+		m, s := fingerprintOf(t, src)
+		assert.Equal(t, msg, m, src)
+		assert.Equal(t, set, s, src)
+	}
+
+	differentMessage := []string{
+		"package a;\n\nmessage User {\n    id int32 = 0;\n    @optional name string = 1;\n}\n",
+		"package a;\n\nmessage User {\n    id int64 = 2;\n    @optional name string = 1;\n}\n",
+		"package a;\n\nmessage User {\n    uid int64 = 0;\n    @optional name string = 1;\n}\n",
+		"package a;\n\nmessage User {\n    id int64 = 0;\n    name string = 1;\n}\n",
+	}
+	for _, src := range differentMessage {
+		m, _ := fingerprintOf(t, src)
+		assert.NotEqual(t, msg, m, src)
+	}
+
+	_, s := fingerprintOf(t, "package a;\n\nmessage User {\n    id int64 = 0;\n    @optional name string = 1;\n}\n\nservice Users {\n    get(User) -> stream User;\n}\n")
+	assert.NotEqual(t, set, s)
+}
+
+func TestServiceFingerprint(t *testing.T) {
+	of := func(src string) [32]byte {
+		fs := NewFileSetFS(fstest.MapFS{"main.yarp": {Data: []byte(src)}})
+		require.NoError(t, fs.Load("main.yarp"))
+		for _, s := range fs.Symbols() {
+			if svc := s.Service(); svc != nil {
+				return fs.ServiceFingerprint(svc)
+			}
+		}
+		t.Fatal("no service found")
+		return [32]byte{}
+	}
+	base := of("package a;\nmessage User { id int64 = 0; }\nservice Users {\n    get(User) -> User;\n    list() -> stream User;\n}\n")
+	assert.Equal(t, base, of("package a;\nmessage User { id int64 = 0; }\n# Users.\nservice Users {\n    list() -> stream User;\n    get(User) -> User;\n}\n"))
+	assert.NotEqual(t, base, of("package a;\nmessage User { id int64 = 0; }\nservice Users {\n    get(User) -> User;\n    list() -> User;\n}\n"))
+	assert.NotEqual(t, base, of("package b;\nmessage User { id int64 = 0; }\nservice Users {\n    get(User) -> User;\n    list() -> stream User;\n}\n"))
+}
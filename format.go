@@ -0,0 +1,395 @@
+package idl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatIndent contains the string used to indent each nesting level of
+// formatted sources.
+const formatIndent = "    "
+
+// FormatOptions represents optional behaviour for FormatWithOptions.
+type FormatOptions struct {
+	// AlignIndexes indicates whether the "= N" indexes of consecutive fields
+	// are vertically aligned. Fields are consecutive when declared on
+	// adjacent lines of the same body, without blank lines between them.
+	AlignIndexes bool
+
+	// KeepGroups indicates whether single blank lines separating groups of
+	// fields or methods within bodies are retained. Otherwise, such lines
+	// are removed, except when following comments or annotations, whose
+	// attachment depends on them.
+	KeepGroups bool
+}
+
+// Format takes the contents of a source file and returns it in canonical form:
+// declarations are indented by four spaces per nesting level, tokens are
+// separated by single spaces where required, statements sharing a line are
+// split, and runs of blank lines are collapsed into one. Comments, pragmas and
+// annotations are retained in place, and Format(Format(src)) always equals
+// Format(src). An error is returned in case src cannot be parsed, or, as a
+// safeguard, in case the formatted output would not contain the exact tokens
+// of src. Format is equivalent to FormatWithOptions with KeepGroups set.
+func Format(src []byte) ([]byte, error) {
+	return FormatWithOptions(src, FormatOptions{KeepGroups: true})
+}
+
+// FormatWithOptions works like Format, but allows callers to customize the
+// resulting layout through a FormatOptions value.
+func FormatWithOptions(src []byte, opts FormatOptions) ([]byte, error) {
+	f, err := ParseSource(src, ParseOptions{RetainTokens: true})
+	if err != nil {
+		return nil, err
+	}
+	out := formatLines(src, splitLines(f.Tokens), opts)
+
+	g, err := ParseSource(out, ParseOptions{RetainTokens: true})
+	if err != nil || !sameTokens(f.Tokens, g.Tokens) {
+		return nil, fmt.Errorf("format: formatted output does not preserve the tokens of the source")
+	}
+	return out, nil
+}
+
+// CheckFormatted returns whether src is formatted according to Format, along
+// with a line-oriented diff between src and its formatted form, which is empty
+// in case it is formatted. An error is returned in case src cannot be parsed.
+func CheckFormatted(src []byte) (bool, string, error) {
+	return CheckFormattedWithOptions(src, FormatOptions{KeepGroups: true})
+}
+
+// CheckFormattedWithOptions works like CheckFormatted, comparing src against
+// the output of FormatWithOptions.
+func CheckFormattedWithOptions(src []byte, opts FormatOptions) (bool, string, error) {
+	out, err := FormatWithOptions(src, opts)
+	if err != nil {
+		return false, "", err
+	}
+	if bytes.Equal(src, out) {
+		return true, "", nil
+	}
+	return false, lineDiff(src, out), nil
+}
+
+// splitLines groups tokens into lines. A nil entry represents a blank line.
+// Lines are additionally broken after '{' and ';', and before '}', unless
+// followed by a line break or a trailing comment, or forming an empty '{}'.
+func splitLines(tokens []Token) [][]Token {
+	var lines [][]Token
+	var cur []Token
+	flush := func() {
+		if len(cur) > 0 {
+			lines = append(lines, cur)
+			cur = nil
+		}
+	}
+	for i, t := range tokens {
+		switch t.Type {
+		case EOF:
+			continue
+		case LineBreak:
+			if len(cur) == 0 {
+				lines = append(lines, nil)
+			}
+			flush()
+			continue
+		case CloseCurly:
+			if len(cur) == 0 || !cur[len(cur)-1].is(OpenCurly) {
+				flush()
+			}
+		}
+		cur = append(cur, t)
+		if t.Type == OpenCurly || t.Type == Semi {
+			if next := tokens[i+1]; !next.is(LineBreak) && !next.is(Comment) && !next.is(Pragma) && !next.is(EOF) && !(t.is(OpenCurly) && next.is(CloseCurly)) {
+				flush()
+			}
+		}
+	}
+	flush()
+	return lines
+}
+
+// formattedLine represents a line to be emitted by formatLines.
+type formattedLine struct {
+	tokens []Token
+	depth  int
+
+	// blank indicates whether the line is preceded by a blank line.
+	blank bool
+}
+
+// formatLines renders lines produced by splitLines, indenting them according
+// to their nesting level.
+func formatLines(src []byte, lines [][]Token, opts FormatOptions) []byte {
+	var out []formattedLine
+	depth := 0
+	blank := false
+	for _, line := range lines {
+		if line == nil {
+			blank = true
+			continue
+		}
+		closes := line[0].is(CloseCurly)
+		if closes && depth > 0 {
+			depth--
+		}
+		if blank && len(out) > 0 {
+			prev := out[len(out)-1]
+			switch {
+			case prev.tokens[len(prev.tokens)-1].is(OpenCurly), closes:
+				blank = false
+			case !opts.KeepGroups && depth > 0 && lastCode(prev.tokens).is(Semi):
+				blank = false
+			}
+		}
+		out = append(out, formattedLine{tokens: line, depth: depth, blank: blank && len(out) > 0})
+		blank = false
+
+		for i, t := range line {
+			if i == 0 && closes {
+				continue
+			}
+			switch t.Type {
+			case OpenCurly:
+				depth++
+			case CloseCurly:
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+	}
+
+	// widths contains, for each line, the width of the text preceding its
+	// index, to which it is padded, or zero in case it is not aligned.
+	widths := make([]int, len(out))
+	if opts.AlignIndexes {
+		for i := 0; i < len(out); {
+			if indexAt(out[i]) < 0 {
+				i++
+				continue
+			}
+			j, width := i, 0
+			for ; j < len(out) && indexAt(out[j]) >= 0 && out[j].depth == out[i].depth && (j == i || !out[j].blank); j++ {
+				width = max(width, utf8.RuneCountInString(joinTokens(src, out[j].tokens[:indexAt(out[j])])))
+			}
+			for ; i < j; i++ {
+				widths[i] = width
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	for i, line := range out {
+		if line.blank {
+			b.WriteByte('\n')
+		}
+		b.WriteString(strings.Repeat(formatIndent, line.depth))
+		if k := indexAt(line); widths[i] > 0 && k >= 0 {
+			head := joinTokens(src, line.tokens[:k])
+			b.WriteString(head)
+			b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(head)+1))
+			b.WriteString(joinTokens(src, line.tokens[k:]))
+		} else {
+			b.WriteString(joinTokens(src, line.tokens))
+		}
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// joinTokens returns the text of tokens, separated according to spaced.
+func joinTokens(src []byte, tokens []Token) string {
+	var b strings.Builder
+	for i, t := range tokens {
+		if i > 0 && spaced(tokens[i-1], t) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tokenText(src, t))
+	}
+	return b.String()
+}
+
+// lastCode returns the last token of line that is not a comment.
+func lastCode(line []Token) Token {
+	for i := len(line) - 1; i >= 0; i-- {
+		if !line[i].is(Comment) && !line[i].is(Pragma) {
+			return line[i]
+		}
+	}
+	return Token{}
+}
+
+// indexAt returns the position of the '=' token preceding the index of the
+// field declared by l, or -1 in case l does not declare a field.
+func indexAt(l formattedLine) int {
+	if l.depth == 0 || l.tokens[0].is(CloseCurly) {
+		return -1
+	}
+	for i := 1; i+2 < len(l.tokens); i++ {
+		if l.tokens[i].is(Equal) && l.tokens[i+1].is(Number) && l.tokens[i+2].is(Semi) {
+			return i
+		}
+	}
+	return -1
+}
+
+// spaced returns whether a space separates tokens a and b, in this order.
+func spaced(a, b Token) bool {
+	switch b.Type {
+	case Comma, Semi, CloseParen, CloseAngled, Dot:
+		return false
+	case OpenParen:
+		if a.is(Identifier) || a.is(Annotation) {
+			return false
+		}
+	case OpenAngled:
+		if a.is(Identifier) {
+			return false
+		}
+	case CloseCurly:
+		if a.is(OpenCurly) {
+			return false
+		}
+	}
+	switch a.Type {
+	case OpenParen, OpenAngled, Dot:
+		return false
+	}
+	return true
+}
+
+// tokenText returns the text of t as written in src. Trailing whitespace is
+// removed from comments.
+func tokenText(src []byte, t Token) string {
+	text := string(src[t.Start:t.End])
+	if t.is(Comment) || t.is(Pragma) {
+		text = strings.TrimRight(text, " \t\r")
+	}
+	return text
+}
+
+// sameTokens returns whether a and b contain the same tokens, disregarding
+// line breaks.
+func sameTokens(a, b []Token) bool {
+	filter := func(tokens []Token) []Token {
+		var r []Token
+		for _, t := range tokens {
+			if !t.is(LineBreak) {
+				r = append(r, t)
+			}
+		}
+		return r
+	}
+	a, b = filter(a), filter(b)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Type != b[i].Type || a[i].Value != b[i].Value {
+			return false
+		}
+	}
+	return true
+}
+
+// lineDiff returns a unified diff between a and b, with three lines of
+// context around each change.
+func lineDiff(a, b []byte) string {
+	x, y := splitAfterLines(a), splitAfterLines(b)
+
+	// lcs[i][j] contains the length of the longest common subsequence of
+	// x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type op struct {
+		kind byte
+		text string
+		i, j int
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			ops = append(ops, op{' ', x[i], i, j})
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', x[i], i, j})
+			i++
+		default:
+			ops = append(ops, op{'+', y[j], i, j})
+			j++
+		}
+	}
+
+	const context = 3
+	var out strings.Builder
+	out.WriteString("--- original\n+++ formatted\n")
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+		start := max(k-context, 0)
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end = min(end+context, len(ops))
+				break
+			}
+			end = run
+		}
+		var removed, added int
+		for _, o := range ops[start:end] {
+			if o.kind != '+' {
+				removed++
+			}
+			if o.kind != '-' {
+				added++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", ops[start].i+1, removed, ops[start].j+1, added)
+		for _, o := range ops[start:end] {
+			out.WriteByte(o.kind)
+			out.WriteString(o.text)
+			if !strings.HasSuffix(o.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		k = end
+	}
+	return out.String()
+}
+
+// splitAfterLines splits b into lines, retaining their line breaks.
+func splitAfterLines(b []byte) []string {
+	lines := strings.SplitAfter(string(b), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,146 @@
+package idl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// retainedTokens returns the comments, pragmas, and annotations of src.
+func retainedTokens(t *testing.T, src []byte) []string {
+	tokens, err := Scan(strings.NewReader(string(src)))
+	require.NoError(t, err)
+	var r []string
+	for _, tok := range tokens {
+		switch tok.Type {
+		case Comment, Pragma, Annotation:
+			r = append(r, tok.Type.String()+" "+tok.Value)
+		}
+	}
+	return r
+}
+
+func TestFormatGolden(t *testing.T) {
+	inputs, err := filepath.Glob("test/format/*.yarp")
+	require.NoError(t, err)
+	require.NotEmpty(t, inputs)
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			src, err := os.ReadFile(input)
+			require.NoError(t, err)
+			golden, err := os.ReadFile(strings.TrimSuffix(input, ".yarp") + ".golden")
+			require.NoError(t, err)
+
+			out, err := Format(src)
+			require.NoError(t, err)
+			assert.Equal(t, string(golden), string(out))
+			assert.Equal(t, retainedTokens(t, src), retainedTokens(t, out))
+
+			ok, diff, err := CheckFormatted(golden)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Empty(t, diff)
+		})
+	}
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	sources, err := filepath.Glob("test/*/*.yarp")
+	require.NoError(t, err)
+	for _, path := range sources {
+		src, err := os.ReadFile(path)
+		require.NoError(t, err)
+		once, err := Format(src)
+		if _, perr := ParseSource(src, ParseOptions{}); perr != nil {
+			assert.Error(t, err, path)
+			continue
+		}
+		require.NoError(t, err, path)
+		twice, err := Format(once)
+		require.NoError(t, err, path)
+		assert.Equal(t, string(once), string(twice), path)
+		assert.Equal(t, retainedTokens(t, src), retainedTokens(t, once), path)
+	}
+}
+
+func TestCheckFormatted(t *testing.T) {
+	ok, diff, err := CheckFormatted([]byte("package foo;\n\nmessage Foo {\n  bar int32=0;\n}\n"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, `--- original
++++ formatted
+@@ -1,5 +1,5 @@
+ package foo;
+ 
+ message Foo {
+-  bar int32=0;
++    bar int32 = 0;
+ }
+`, diff)
+
+	_, _, err = CheckFormatted([]byte("package foo;\nmessage {"))
+	assert.Error(t, err)
+}
+
+func TestFormatWithOptions(t *testing.T) {
+	src, err := os.ReadFile("test/format/align/fields.yarp")
+	require.NoError(t, err)
+
+	out, err := FormatWithOptions(src, FormatOptions{AlignIndexes: true, KeepGroups: true})
+	require.NoError(t, err)
+	assert.Equal(t, `package org.example.format;
+
+message Customer {
+    id int64                  = 0;
+    name string               = 1;
+    @optional nickname string = 2;
+
+    # Contact details.
+    email string                = 3;
+    phone_numbers array<string> = 4; # mobile first
+
+    addresses map<string, string> = 5;
+    oneof {
+        card string              = 7;
+        invoice_reference string = 8;
+    } = 6;
+    # Unrelated note.
+
+    active bool = 9;
+}
+`, string(out))
+
+	out, err = FormatWithOptions(src, FormatOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `package org.example.format;
+
+message Customer {
+    id int64 = 0;
+    name string = 1;
+    @optional nickname string = 2;
+    # Contact details.
+    email string = 3;
+    phone_numbers array<string> = 4; # mobile first
+    addresses map<string, string> = 5;
+    oneof {
+        card string = 7;
+        invoice_reference string = 8;
+    } = 6;
+    # Unrelated note.
+
+    active bool = 9;
+}
+`, string(out))
+
+	for _, opts := range []FormatOptions{{}, {AlignIndexes: true}, {KeepGroups: true}, {AlignIndexes: true, KeepGroups: true}} {
+		once, err := FormatWithOptions(src, opts)
+		require.NoError(t, err)
+		ok, diff, err := CheckFormattedWithOptions(once, opts)
+		require.NoError(t, err)
+		assert.True(t, ok, diff)
+	}
+}
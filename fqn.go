@@ -0,0 +1,52 @@
+package idl
+
+import "strings"
+
+// FQN represents a fully-qualified name of a declaration, such as
+// org.example.contacts.Contact. Values may also hold short names (e.g.
+// Contact), which can be qualified through Qualify.
+type FQN string
+
+// NewFQN returns a FQN composed of the provided package and name. In case pkg
+// is empty, the resulting FQN is not qualified.
+func NewFQN(pkg, name string) FQN {
+	if pkg == "" {
+		return FQN(name)
+	}
+	return FQN(pkg + "." + name)
+}
+
+// Package returns the package component of the FQN, or an empty string in case
+// it is not qualified.
+func (f FQN) Package() string {
+	i := strings.LastIndexByte(string(f), '.')
+	if i == -1 {
+		return ""
+	}
+	return string(f[:i])
+}
+
+// Name returns the name component of the FQN.
+func (f FQN) Name() string {
+	i := strings.LastIndexByte(string(f), '.')
+	return string(f[i+1:])
+}
+
+// IsQualified returns whether the FQN includes a package component.
+func (f FQN) IsQualified() bool {
+	return strings.IndexByte(string(f), '.') != -1
+}
+
+// Qualify returns the FQN qualified with the provided package, in case it is
+// not yet qualified. Qualified values are returned unchanged.
+func (f FQN) Qualify(pkg string) FQN {
+	if f.IsQualified() {
+		return f
+	}
+	return NewFQN(pkg, string(f))
+}
+
+func (f FQN) String() string { return string(f) }
+
+// FQN returns the name referenced by the TypeRef as a FQN.
+func (t TypeRef) FQN() FQN { return NewFQN(t.Package, t.Name) }
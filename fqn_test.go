@@ -0,0 +1,22 @@
+package idl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFQN(t *testing.T) {
+	f := FQN("io.libyarp.common.Notification")
+	assert.True(t, f.IsQualified())
+	assert.Equal(t, "io.libyarp.common", f.Package())
+	assert.Equal(t, "Notification", f.Name())
+	assert.Equal(t, f, f.Qualify("other"))
+
+	s := FQN("Notification")
+	assert.False(t, s.IsQualified())
+	assert.Equal(t, "", s.Package())
+	assert.Equal(t, "Notification", s.Name())
+	assert.Equal(t, f, s.Qualify("io.libyarp.common"))
+	assert.Equal(t, s, NewFQN("", "Notification"))
+}
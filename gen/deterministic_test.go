@@ -0,0 +1,69 @@
+package gen_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	_ "github.com/libyarp/idl/gen/diagram"
+	_ "github.com/libyarp/idl/gen/godesc"
+	_ "github.com/libyarp/idl/gen/htmldoc"
+	_ "github.com/libyarp/idl/gen/inventory"
+	_ "github.com/libyarp/idl/gen/openapi"
+	_ "github.com/libyarp/idl/gen/tsfake"
+	_ "github.com/libyarp/idl/gen/tsvalidate"
+	_ "github.com/libyarp/idl/gen/typescript"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDeterministic runs every generator repeatedly over freshly loaded
+// FileSets, ensuring they produce identical output. Since map iteration order
+// is randomized, generators ranging over maps without sorting keys are
+// expected to fail this test. Output must not depend on the directory
+// sources are loaded from either, so each run alternates between two copies
+// of the sources.
+func TestDeterministic(t *testing.T) {
+	sources := []string{
+		"constraints/user.yarp",
+		"example/order.yarp",
+		"openapi/contacts.yarp",
+		"stdimport/main.yarp",
+		"typescript/main.yarp",
+	}
+	var roots []string
+	for i := 0; i < 2; i++ {
+		root := t.TempDir()
+		for _, src := range sources {
+			dir := filepath.Dir(src)
+			require.NoError(t, os.CopyFS(filepath.Join(root, dir), os.DirFS(filepath.Join("../test", dir))))
+		}
+		roots = append(roots, root)
+	}
+	opts := gen.Options{Timestamp: time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)}
+	for _, name := range gen.Names() {
+		if strings.HasPrefix(name, "test-") {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			run := func(root string) []gen.OutputFile {
+				fs := idl.NewFileSet()
+				for _, src := range sources {
+					require.NoError(t, fs.Load(filepath.Join(root, src)))
+				}
+				files, err := gen.Run(fs, opts, name)
+				require.NoError(t, err)
+				return files
+			}
+			first := run(roots[0])
+			require.NotEmpty(t, first)
+			for i := 0; i < 10; i++ {
+				assert.Equal(t, first, run(roots[i%2]))
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Package diagram implements a generator emitting dependency diagrams of a
+// FileSet, in either Graphviz DOT or Mermaid notation. Importing the package
+// registers the generator under the name "diagram".
+//
+// Diagrams contain edges from each message to the messages referenced by its
+// fields, and from each file to the files it imports. Messages are labeled
+// by their FQN, and files by their path relative to the directory containing
+// all loaded local files. Import edges are drawn as dashed lines.
+//
+// The generator accepts the following parameters:
+//
+//   - format: either "dot" or "mermaid". Defaults to "dot".
+//   - graph: either "messages", "files", or "all". Defaults to "all".
+//   - packages: comma-separated list of packages to include. Messages and
+//     files of other packages, along with edges leading to them, are
+//     omitted. Defaults to all packages.
+//   - output: path of the emitted file. Defaults to "dependencies.dot" or
+//     "dependencies.mmd", according to format.
+package diagram
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+)
+
+// Name contains the name under which the generator is registered.
+const Name = "diagram"
+
+func init() {
+	gen.Register(Generator{})
+}
+
+// Generator emits a dependency diagram.
+type Generator struct{}
+
+// Name implements gen.Generator.
+func (Generator) Name() string { return Name }
+
+type node struct {
+	id    string
+	label string
+	file  bool
+}
+
+type edge struct {
+	from, to string
+	imports  bool
+}
+
+// Generate implements gen.Generator.
+func (Generator) Generate(fs *idl.FileSet, opts gen.Options) ([]gen.OutputFile, error) {
+	format := opts.Parameter("format", "dot")
+	ext := map[string]string{"dot": "dot", "mermaid": "mmd"}[format]
+	if ext == "" {
+		return nil, fmt.Errorf("invalid format parameter %q: expected dot or mermaid", format)
+	}
+	graph := opts.Parameter("graph", "all")
+	if graph != "all" && graph != "messages" && graph != "files" {
+		return nil, fmt.Errorf("invalid graph parameter %q: expected messages, files, or all", graph)
+	}
+	var packages map[string]bool
+	if p := opts.Parameter("packages", ""); p != "" {
+		packages = map[string]bool{}
+		for _, name := range strings.Split(p, ",") {
+			packages[strings.TrimSpace(name)] = true
+		}
+	}
+	included := func(pkg string) bool { return packages == nil || packages[pkg] }
+
+	deps := fs.DependencyGraph()
+	var (
+		nodes []node
+		edges []edge
+	)
+	if graph != "files" {
+		ids := map[*idl.Message]string{}
+		var messages []*idl.Message
+		for _, s := range fs.Symbols() {
+			if m := s.Message(); m != nil && included(s.Name.Package()) {
+				ids[m] = "m" + strconv.Itoa(len(ids))
+				messages = append(messages, m)
+				nodes = append(nodes, node{id: ids[m], label: s.Name.String()})
+			}
+		}
+		for _, m := range messages {
+			for _, d := range deps.Messages.DependenciesOf(m) {
+				if from, to := ids[m], ids[d]; from != "" && to != "" {
+					edges = append(edges, edge{from: from, to: to})
+				}
+			}
+		}
+	}
+	if graph != "messages" {
+		ids := map[*idl.File]string{}
+		files := deps.Files.Nodes()
+		labels := fileLabels(files)
+		for i, f := range files {
+			if included(f.Package) {
+				ids[f] = "f" + strconv.Itoa(len(ids))
+				nodes = append(nodes, node{id: ids[f], label: labels[i], file: true})
+			}
+		}
+		for _, f := range files {
+			for _, d := range deps.Files.DependenciesOf(f) {
+				if from, to := ids[f], ids[d]; from != "" && to != "" {
+					edges = append(edges, edge{from: from, to: to, imports: true})
+				}
+			}
+		}
+	}
+
+	var content string
+	if format == "dot" {
+		content = dot(nodes, edges)
+	} else {
+		content = mermaid(nodes, edges)
+	}
+	return []gen.OutputFile{{Path: opts.Parameter("output", "dependencies."+ext), Content: []byte(content)}}, nil
+}
+
+// fileLabels returns the paths of files relative to their common directory,
+// using forward slashes. Paths of standard library and remote files are kept
+// as they are.
+func fileLabels(files []*idl.File) []string {
+	local := func(p string) bool {
+		return !strings.HasPrefix(p, idl.StdPrefix) && !strings.HasPrefix(p, "https://")
+	}
+	paths := make([]string, len(files))
+	common := ""
+	found := false
+	for i, f := range files {
+		paths[i] = filepath.ToSlash(f.SourcePath)
+		if !local(paths[i]) {
+			continue
+		}
+		dir := paths[i][:strings.LastIndexByte(paths[i], '/')+1]
+		switch {
+		case !found:
+			common, found = dir, true
+		default:
+			for !strings.HasPrefix(dir, common) {
+				common = common[:strings.LastIndexByte(strings.TrimSuffix(common, "/"), '/')+1]
+			}
+		}
+	}
+	for i := range paths {
+		if local(paths[i]) {
+			paths[i] = strings.TrimPrefix(paths[i], common)
+		}
+	}
+	return paths
+}
+
+func dot(nodes []node, edges []edge) string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n    rankdir=LR;\n    node [shape=box];\n")
+	for _, n := range nodes {
+		if n.file {
+			fmt.Fprintf(&b, "    %s [label=%s, shape=note];\n", n.id, strconv.Quote(n.label))
+		} else {
+			fmt.Fprintf(&b, "    %s [label=%s];\n", n.id, strconv.Quote(n.label))
+		}
+	}
+	for _, e := range edges {
+		if e.imports {
+			fmt.Fprintf(&b, "    %s -> %s [style=dashed];\n", e.from, e.to)
+		} else {
+			fmt.Fprintf(&b, "    %s -> %s;\n", e.from, e.to)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func mermaid(nodes []node, edges []edge) string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, n := range nodes {
+		label := strings.ReplaceAll(n.label, `"`, "#quot;")
+		if n.file {
+			fmt.Fprintf(&b, "    %s[/\"%s\"/]\n", n.id, label)
+		} else {
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", n.id, label)
+		}
+	}
+	for _, e := range edges {
+		if e.imports {
+			fmt.Fprintf(&b, "    %s -.-> %s\n", e.from, e.to)
+		} else {
+			fmt.Fprintf(&b, "    %s --> %s\n", e.from, e.to)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package diagram
+
+import (
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/typescript/main.yarp"))
+
+	files, err := gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "dependencies.dot", files[0].Path)
+	assert.Equal(t, `digraph dependencies {
+    rankdir=LR;
+    node [shape=box];
+    m0 [label="org.example.types.Address"];
+    m1 [label="org.example.shop.Order"];
+    m2 [label="org.example.shop.Item"];
+    m3 [label="org.example.shop.Voucher"];
+    f0 [label="types.yarp", shape=note];
+    f1 [label="main.yarp", shape=note];
+    m1 -> m2;
+    m1 -> m0;
+    m1 -> m3;
+    f1 -> f0 [style=dashed];
+}
+`, string(files[0].Content))
+
+	files, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"format": "mermaid", "packages": "org.example.shop"}}, Name)
+	require.NoError(t, err)
+	assert.Equal(t, "dependencies.mmd", files[0].Path)
+	assert.Equal(t, `flowchart LR
+    m0["org.example.shop.Order"]
+    m1["org.example.shop.Item"]
+    m2["org.example.shop.Voucher"]
+    f0[/"main.yarp"/]
+    m0 --> m1
+    m0 --> m2
+`, string(files[0].Content))
+
+	files, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"graph": "files"}}, Name)
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[0].Content), "m0")
+
+	_, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"format": "svg"}}, Name)
+	assert.ErrorContains(t, err, `invalid format parameter "svg"`)
+}
+
+func TestFileLabels(t *testing.T) {
+	files := []*idl.File{
+		{SourcePath: "/src/schemas/main.yarp"},
+		{SourcePath: "yarp/std/time.yarp"},
+		{SourcePath: "/src/schemas/common/types.yarp"},
+		{SourcePath: "https://example.org/schemas/money.yarp"},
+	}
+	assert.Equal(t, []string{
+		"main.yarp",
+		"yarp/std/time.yarp",
+		"common/types.yarp",
+		"https://example.org/schemas/money.yarp",
+	}, fileLabels(files))
+}
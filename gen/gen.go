@@ -0,0 +1,226 @@
+// Package gen implements a framework for code generators consuming YARP
+// schemas. Generators are registered by name, and executed by Run over a
+// FileSet that has already been resolved and validated, sharing option
+// handling and output management.
+//
+// Generators must produce byte-identical output for identical inputs and
+// options: declarations are emitted in the order they are loaded, maps are
+// iterated in sorted order, and times are taken from Options.Timestamp.
+package gen
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libyarp/idl"
+)
+
+// OutputFile represents a file produced by a Generator.
+type OutputFile struct {
+	// Path contains the slash-separated path of the file, relative to the
+	// output directory.
+	Path string
+
+	// Content contains the contents of the file.
+	Content []byte
+}
+
+// Options represents configuration provided to generators.
+type Options struct {
+	// Parameters contains generator-specific options, such as the name of
+	// the package in which generated code is placed, keyed by name.
+	Parameters map[string]string
+
+	// Checks contains the checks used to validate the FileSet before running
+	// generators. In case it is empty, idl.DefaultChecks is used.
+	Checks []idl.Check
+
+	// TemplateDir contains the path of a directory holding templates that
+	// replace the ones embedded by generators. See LoadTemplates.
+	TemplateDir string
+
+	// Timestamp contains the time reported by generators embedding the time
+	// at which output was produced. Generators never read the clock, and
+	// omit such timestamps in case Timestamp is zero, so that identical
+	// inputs produce identical outputs.
+	Timestamp time.Time
+
+	// Layout determines how output is partitioned, and where produced files
+	// are placed. The zero value places files as produced by generators.
+	Layout Layout
+}
+
+// Parameter returns the value of the parameter identified by name, or def,
+// in case it is not set.
+func (o Options) Parameter(name, def string) string {
+	if v, ok := o.Parameters[name]; ok {
+		return v
+	}
+	return def
+}
+
+// Generator produces output files from a FileSet.
+type Generator interface {
+	// Name returns the name identifying the generator, such as "go".
+	Name() string
+
+	// Generate produces output files from a resolved and validated FileSet.
+	Generate(fs *idl.FileSet, opts Options) ([]OutputFile, error)
+}
+
+// Checker is implemented by generators requiring checks beyond the ones
+// provided through Options.Checks, such as detection of identifiers colliding
+// with keywords of their target language. Run executes these checks before
+// any generator.
+type Checker interface {
+	Checks() []idl.Check
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Generator{}
+)
+
+// Register makes a Generator available to Run under its name. It panics in
+// case a generator with the same name has already been registered.
+func Register(g Generator) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[g.Name()]; ok {
+		panic("gen: generator " + g.Name() + " registered twice")
+	}
+	registry[g.Name()] = g
+}
+
+// Lookup returns the Generator registered under the provided name, along with
+// a boolean indicating whether it exists.
+func Lookup(name string) (Generator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	g, ok := registry[name]
+	return g, ok
+}
+
+// Names returns the names of all registered generators, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for n := range registry {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UnknownGeneratorError indicates that Run was requested to execute a
+// generator that has not been registered.
+type UnknownGeneratorError struct{ Name string }
+
+func (u UnknownGeneratorError) Error() string {
+	return fmt.Sprintf("unknown generator %s", u.Name)
+}
+
+// OutputConflictError indicates that two generators, or a single generator,
+// produced multiple files under the same path.
+type OutputConflictError struct{ Path, First, Second string }
+
+func (o OutputConflictError) Error() string {
+	return fmt.Sprintf("%s: produced by both %s and %s", o.Path, o.First, o.Second)
+}
+
+// Run validates fs, and executes the generators registered under the
+// provided names, in order, returning all files they produced. In case no
+// names are provided, all registered generators are executed, sorted by name.
+// Checks provided by generators implementing Checker are executed alongside
+// the ones of opts. Validation errors are returned as idl.Diagnostics, and
+// prevent generators from running. Output paths are mapped according to
+// opts.Layout and cleaned, and must be relative and unique.
+func Run(fs *idl.FileSet, opts Options, names ...string) ([]OutputFile, error) {
+	if len(names) == 0 {
+		names = Names()
+	}
+	generators := make([]Generator, len(names))
+	for i, n := range names {
+		g, ok := Lookup(n)
+		if !ok {
+			return nil, UnknownGeneratorError{Name: n}
+		}
+		generators[i] = g
+	}
+	return RunGenerators(fs, opts, generators...)
+}
+
+// RunGenerators is similar to Run, but executes the provided generators,
+// which need not be registered.
+func RunGenerators(fs *idl.FileSet, opts Options, generators ...Generator) ([]OutputFile, error) {
+	checks := opts.Checks
+	if len(checks) == 0 {
+		checks = idl.DefaultChecks
+	}
+	for _, g := range generators {
+		if c, ok := g.(Checker); ok {
+			checks = append(append([]idl.Check{}, checks...), c.Checks()...)
+		}
+	}
+	if diags := uniqueDiagnostics(fs.Validate(checks...)); diags.HasErrors() {
+		return nil, diags.Errors()
+	}
+
+	var out []OutputFile
+	producedBy := map[string]string{}
+	for _, g := range generators {
+		files, err := opts.Layout.generate(fs, g, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", g.Name(), err)
+		}
+		for _, f := range files {
+			// Paths may be provided by external plugins: backslashes are
+			// rejected, as they separate path elements on Windows.
+			p := path.Clean(f.Path)
+			if strings.Contains(p, `\`) || !filepath.IsLocal(filepath.FromSlash(p)) {
+				return nil, fmt.Errorf("%s: output path %s escapes output directory", g.Name(), f.Path)
+			}
+			if prev, ok := producedBy[p]; ok {
+				return nil, OutputConflictError{Path: p, First: prev, Second: g.Name()}
+			}
+			producedBy[p] = g.Name()
+			out = append(out, OutputFile{Path: p, Content: f.Content})
+		}
+	}
+	return out, nil
+}
+
+// WriteFiles writes files under dir, creating directories as required.
+func WriteFiles(dir string, files []OutputFile) error {
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, f.Content, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueDiagnostics removes repeated diagnostics from diags, which are
+// emitted when generators sharing a target provide the same checks.
+func uniqueDiagnostics(diags idl.Diagnostics) idl.Diagnostics {
+	seen := map[string]bool{}
+	var r idl.Diagnostics
+	for _, d := range diags {
+		if k := d.Code + " " + d.Error(); !seen[k] {
+			seen[k] = true
+			r = append(r, d)
+		}
+	}
+	return r
+}
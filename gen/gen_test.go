@@ -0,0 +1,86 @@
+package gen
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type namesGenerator struct {
+	name string
+	path string
+}
+
+func (n namesGenerator) Name() string { return n.name }
+
+func (n namesGenerator) Generate(fs *idl.FileSet, opts Options) ([]OutputFile, error) {
+	var names []string
+	for m := range fs.AllMessages() {
+		names = append(names, m.Name)
+	}
+	sep := opts.Parameter("separator", "\n")
+	return []OutputFile{{Path: n.path, Content: []byte(strings.Join(names, sep))}}, nil
+}
+
+type failingGenerator struct{}
+
+func (failingGenerator) Name() string { return "test-failing" }
+
+func (failingGenerator) Generate(*idl.FileSet, Options) ([]OutputFile, error) {
+	return nil, errors.New("boom")
+}
+
+func init() {
+	Register(namesGenerator{name: "test-names", path: "out/./names.txt"})
+	Register(namesGenerator{name: "test-conflict", path: "out/names.txt"})
+	Register(namesGenerator{name: "test-escape", path: "../names.txt"})
+	Register(namesGenerator{name: "test-escape-windows", path: `..\..\names.txt`})
+	Register(failingGenerator{})
+}
+
+func TestRun(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/diamond/main.yarp"))
+
+	assert.Subset(t, Names(), []string{"test-conflict", "test-escape", "test-failing", "test-names"})
+	assert.Panics(t, func() { Register(failingGenerator{}) })
+
+	files, err := Run(fs, Options{Parameters: map[string]string{"separator": ","}}, "test-names")
+	require.NoError(t, err)
+	assert.Equal(t, []OutputFile{{Path: "out/names.txt", Content: []byte("Base,Left,Right,Top")}}, files)
+
+	dir := t.TempDir()
+	require.NoError(t, WriteFiles(dir, files))
+	data, err := os.ReadFile(filepath.Join(dir, "out", "names.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "Base,Left,Right,Top", string(data))
+
+	_, err = Run(fs, Options{}, "test-names", "test-conflict")
+	var conflict OutputConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, OutputConflictError{Path: "out/names.txt", First: "test-names", Second: "test-conflict"}, conflict)
+
+	_, err = Run(fs, Options{}, "test-escape")
+	assert.ErrorContains(t, err, "escapes output directory")
+	_, err = Run(fs, Options{}, "test-escape-windows")
+	assert.ErrorContains(t, err, "escapes output directory")
+
+	_, err = Run(fs, Options{}, "test-failing")
+	assert.EqualError(t, err, "test-failing: boom")
+
+	_, err = Run(fs, Options{}, "missing")
+	assert.Equal(t, UnknownGeneratorError{Name: "missing"}, err)
+
+	invalid := idl.NewFileSet()
+	require.NoError(t, invalid.Load("../test/validate/invalid.yarp"))
+	_, err = Run(invalid, Options{}, "test-names")
+	var diags idl.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	assert.True(t, diags.HasErrors())
+}
@@ -0,0 +1,231 @@
+// Package godesc implements a generator emitting a Go source file that embeds
+// the compiled descriptor of a FileSet, as produced by
+// idl.FileSet.MarshalDescriptor, allowing Go programs to access their schemas
+// at runtime without shipping source files. The emitted file registers the
+// descriptor in registry.Default when its package is initialized, and
+// declares a table describing the methods of all services, through which
+// transport middlewares can make routing and retry decisions. Importing
+// the package registers the generator under the name "go-descriptor".
+//
+// The generator implements gen.Partitioner: when output is split, each
+// emitted file embeds the descriptor of the files of its unit, along with the
+// files they import, and describes the methods of services declared by the
+// unit.
+//
+// The generator accepts the following parameters:
+//
+//   - output: path of the emitted file. Defaults to "descriptor.yarp.go".
+//   - package: name of the Go package of the emitted file. Defaults to the
+//     last component of the FileSet's primary package, or of the package of
+//     the unit when output is split.
+package godesc
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/libyarp/idl/yarpreflect"
+)
+
+// Name contains the name under which the generator is registered.
+const Name = "go-descriptor"
+
+// bytesPerLine contains the amount of descriptor bytes written on each line
+// of the emitted slice literal.
+const bytesPerLine = 16
+
+func init() {
+	gen.Register(Generator{})
+}
+
+// Generator emits a Go file declaring a YARPDescriptor function, which returns
+// the compiled descriptor of the FileSet, and registering the descriptor in
+// registry.Default. The descriptor can be turned back into a FileSet through
+// idl.LoadDescriptor.
+type Generator struct{}
+
+// Name implements gen.Generator.
+func (Generator) Name() string { return Name }
+
+// Generate implements gen.Generator.
+func (Generator) Generate(fs *idl.FileSet, opts gen.Options) ([]gen.OutputFile, error) {
+	data, err := fs.MarshalDescriptor()
+	if err != nil {
+		return nil, err
+	}
+	return generate(fs, fs.Package(), data, nil, opts)
+}
+
+// GenerateUnit implements gen.Partitioner, embedding the descriptor of the
+// files of u, along with the files they import, and describing the methods
+// of services declared by u.
+func (Generator) GenerateUnit(fs *idl.FileSet, u gen.Unit, opts gen.Options) ([]gen.OutputFile, error) {
+	data, err := fs.MarshalDescriptorOf(u.Files...)
+	if err != nil {
+		return nil, err
+	}
+	return generate(fs, u.Package, data, u.Contains, opts)
+}
+
+// generate emits the file embedding data, the descriptor of package pkg,
+// describing methods of services for which include returns true, or of all
+// services, in case include is nil.
+func generate(fs *idl.FileSet, pkg string, data []byte, include func(idl.Symbol) bool, opts gen.Options) ([]gen.OutputFile, error) {
+	goPkg := opts.Parameter("package", defaultPackage(pkg))
+	if !token.IsIdentifier(goPkg) {
+		return nil, fmt.Errorf("invalid package parameter %q", goPkg)
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by the YARP Go descriptor generator. DO NOT EDIT.\n\n")
+	b.WriteString("package " + goPkg + "\n\n")
+	schema, err := yarpreflect.Build(fs)
+	if err != nil {
+		return nil, err
+	}
+	var methods []*yarpreflect.MethodDescriptor
+	for _, m := range schema.Methods() {
+		if include != nil {
+			if sym, ok := fs.FindSymbol(m.Service().FullName().String()); !ok || !include(sym) {
+				continue
+			}
+		}
+		methods = append(methods, m)
+	}
+	var std []string
+	imports := []string{"github.com/libyarp/idl/registry"}
+	if len(methods) > 0 {
+		imports = append(imports, "github.com/libyarp/idl/yarpreflect")
+		for _, m := range methods {
+			if m.Options().Timeout > 0 {
+				std = append(std, "time")
+				break
+			}
+		}
+	}
+	writeImports(&b, std, imports)
+	fmt.Fprintf(&b, "// yarpDescriptor contains the compiled descriptor of %s.\n", pkg)
+	b.WriteString("var yarpDescriptor = []byte{\n")
+	for i := 0; i < len(data); i += bytesPerLine {
+		line := data[i:min(i+bytesPerLine, len(data))]
+		b.WriteString("\t")
+		for j, c := range line {
+			if j > 0 {
+				b.WriteString(" ")
+			}
+			fmt.Fprintf(&b, "0x%02x,", c)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n\n")
+	b.WriteString("// YARPDescriptor returns a copy of the compiled descriptor of the schemas\n")
+	b.WriteString("// this package was generated from, which can be loaded through\n")
+	b.WriteString("// idl.LoadDescriptor.\n")
+	b.WriteString("func YARPDescriptor() []byte {\n\treturn append([]byte(nil), yarpDescriptor...)\n}\n\n")
+	b.WriteString("func init() {\n\tregistry.MustRegisterDescriptor(yarpDescriptor)\n}\n")
+	if len(methods) > 0 {
+		writeMethods(&b, methods)
+	}
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, err
+	}
+	return []gen.OutputFile{{Path: opts.Parameter("output", "descriptor.yarp.go"), Content: src}}, nil
+}
+
+// writeImports emits an import declaration listing packages of the standard
+// library, followed by other packages, each group sorted.
+func writeImports(b *strings.Builder, groups ...[]string) {
+	b.WriteString("import (\n")
+	first := true
+	for _, g := range groups {
+		if len(g) == 0 {
+			continue
+		}
+		if !first {
+			b.WriteString("\n")
+		}
+		first = false
+		sort.Strings(g)
+		for _, imp := range g {
+			fmt.Fprintf(b, "\t%q\n", imp)
+		}
+	}
+	b.WriteString(")\n\n")
+}
+
+// writeMethods emits the YARPMethods function, returning a table describing
+// the provided methods.
+func writeMethods(b *strings.Builder, methods []*yarpreflect.MethodDescriptor) {
+	b.WriteString("\n// yarpMethods describes the methods of all services of the schemas.\n")
+	b.WriteString("var yarpMethods = []yarpreflect.MethodInfo{\n")
+	for _, m := range methods {
+		info := m.Info()
+		fmt.Fprintf(b, "\t{\n\t\tFullName: %q,\n", info.FullName)
+		if info.Argument != "" {
+			fmt.Fprintf(b, "\t\tArgument: %q,\n", info.Argument)
+		}
+		if info.Return != "" {
+			fmt.Fprintf(b, "\t\tReturn: %q,\n", info.Return)
+		}
+		if info.Streaming {
+			b.WriteString("\t\tStreaming: true,\n")
+		}
+		if info.Timeout > 0 {
+			fmt.Fprintf(b, "\t\tTimeout: %s,\n", durationLiteral(info.Timeout))
+		}
+		if info.Idempotent {
+			b.WriteString("\t\tIdempotent: true,\n")
+		}
+		b.WriteString("\t},\n")
+	}
+	b.WriteString("}\n\n")
+	b.WriteString("// YARPMethods returns a copy of the table describing the methods of all\n")
+	b.WriteString("// services of the schemas this package was generated from, sorted by name.\n")
+	b.WriteString("func YARPMethods() []yarpreflect.MethodInfo {\n\treturn append([]yarpreflect.MethodInfo(nil), yarpMethods...)\n}\n")
+}
+
+// durationLiteral returns a Go expression representing d, in the largest unit
+// dividing it.
+func durationLiteral(d time.Duration) string {
+	units := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"time.Hour", time.Hour},
+		{"time.Minute", time.Minute},
+		{"time.Second", time.Second},
+		{"time.Millisecond", time.Millisecond},
+		{"time.Microsecond", time.Microsecond},
+	}
+	for _, u := range units {
+		if d%u.d == 0 {
+			return fmt.Sprintf("%d * %s", d/u.d, u.name)
+		}
+	}
+	return fmt.Sprintf("%d * time.Nanosecond", d)
+}
+
+// defaultPackage returns the Go package name derived from a YARP package,
+// which is its last component, lowercased and stripped of characters invalid
+// in Go identifiers.
+func defaultPackage(pkg string) string {
+	last := strings.ToLower(pkg[strings.LastIndexByte(pkg, '.')+1:])
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, last)
+	if name == "" || !token.IsIdentifier(name) {
+		return "schema"
+	}
+	return name
+}
@@ -0,0 +1,154 @@
+package godesc
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/typescript/main.yarp"))
+
+	files, err := gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "descriptor.yarp.go", files[0].Path)
+
+	file, err := parser.ParseFile(token.NewFileSet(), files[0].Path, files[0].Content, parser.ParseComments)
+	require.NoError(t, err)
+	assert.Equal(t, "shop", file.Name.Name)
+	assert.Contains(t, string(files[0].Content), "registry.MustRegisterDescriptor(yarpDescriptor)")
+
+	// Decode the embedded descriptor back into a FileSet.
+	loaded, err := idl.LoadDescriptor(embeddedDescriptor(t, files[0]))
+	require.NoError(t, err)
+	_, ok := loaded.FindMessage("org.example.shop.Order")
+	assert.True(t, ok)
+
+	files, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"package": "shoppb", "output": "shoppb/schema.go"}}, Name)
+	require.NoError(t, err)
+	assert.Equal(t, "shoppb/schema.go", files[0].Path)
+	assert.Contains(t, string(files[0].Content), "package shoppb\n")
+
+	_, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"package": "shop-pb"}}, Name)
+	assert.ErrorContains(t, err, `invalid package parameter "shop-pb"`)
+}
+
+// embeddedDescriptor returns the descriptor embedded by the provided file.
+func embeddedDescriptor(t *testing.T, f gen.OutputFile) []byte {
+	file, err := parser.ParseFile(token.NewFileSet(), f.Path, f.Content, 0)
+	require.NoError(t, err)
+	var data []byte
+	ast.Inspect(file, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.INT {
+			v, err := strconv.ParseUint(lit.Value, 0, 8)
+			require.NoError(t, err)
+			data = append(data, byte(v))
+		}
+		return true
+	})
+	return data
+}
+
+func TestGeneratorSplit(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/typescript/main.yarp"))
+
+	files, err := gen.Run(fs, gen.Options{Layout: gen.Layout{Split: gen.SplitPackage}}, Name)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "org/example/types/descriptor.yarp.go", files[0].Path)
+	assert.Equal(t, "org/example/shop/descriptor.yarp.go", files[1].Path)
+	assert.Contains(t, string(files[0].Content), "package types\n")
+	assert.Contains(t, string(files[1].Content), "package shop\n")
+	assert.NotContains(t, string(files[0].Content), "YARPMethods")
+	assert.Contains(t, string(files[1].Content), "YARPMethods")
+
+	// Descriptors of units include imported files, but not importing ones.
+	shop, err := idl.LoadDescriptor(embeddedDescriptor(t, files[1]))
+	require.NoError(t, err)
+	assert.Equal(t, "org.example.shop", shop.Package())
+	assert.Len(t, shop.Files(), 2)
+	types, err := idl.LoadDescriptor(embeddedDescriptor(t, files[0]))
+	require.NoError(t, err)
+	assert.Equal(t, "org.example.types", types.Package())
+	assert.Len(t, types.Files(), 1)
+	_, ok := types.FindMessage("org.example.shop.Order")
+	assert.False(t, ok)
+}
+
+func TestGeneratorStd(t *testing.T) {
+	run := func(split gen.Split) ([]gen.OutputFile, string) {
+		dir := filepath.Join(t.TempDir(), "schemas")
+		require.NoError(t, os.CopyFS(dir, os.DirFS("../../test/stdimport")))
+		fs := idl.NewFileSet()
+		require.NoError(t, fs.Load(filepath.Join(dir, "main.yarp")))
+		files, err := gen.Run(fs, gen.Options{Layout: gen.Layout{Split: split}}, Name)
+		require.NoError(t, err)
+		return files, dir
+	}
+	for _, split := range []gen.Split{gen.SplitNone, gen.SplitPackage} {
+		files, dir := run(split)
+		for _, f := range files {
+			data := embeddedDescriptor(t, f)
+			assert.NotContains(t, string(data), dir, f.Path)
+			loaded, err := idl.LoadDescriptor(data)
+			require.NoError(t, err)
+			for _, l := range loaded.Files() {
+				assert.False(t, filepath.IsAbs(l.SourcePath), l.SourcePath)
+			}
+		}
+		// Output does not depend on the location of sources.
+		again, _ := run(split)
+		assert.Equal(t, files, again)
+	}
+}
+
+func TestDefaultPackage(t *testing.T) {
+	assert.Equal(t, "v1", defaultPackage("com.example.contacts.v1"))
+	assert.Equal(t, "shop", defaultPackage("Shop"))
+	assert.Equal(t, "schema", defaultPackage("org.example.2024"))
+}
+
+func TestGeneratorMethods(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/methods/orders.yarp"))
+	files, err := gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	src := string(files[0].Content)
+	assert.Contains(t, src, "\t\"time\"\n")
+	assert.Contains(t, src, `		FullName:   "org.example.orders.Orders.get",
+		Argument:   "org.example.orders.Order",
+		Return:     "org.example.orders.Order",
+		Timeout:    1500 * time.Millisecond,
+		Idempotent: true,
+`)
+	assert.Contains(t, src, "func YARPMethods() []yarpreflect.MethodInfo {")
+	_, err = parser.ParseFile(token.NewFileSet(), files[0].Path, files[0].Content, 0)
+	require.NoError(t, err)
+
+	// Files without services do not declare method tables.
+	fs = idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/dynamic/order.yarp"))
+	files, err = gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	assert.NotContains(t, string(files[0].Content), "YARPMethods")
+}
+
+func TestDurationLiteral(t *testing.T) {
+	assert.Equal(t, "2 * time.Hour", durationLiteral(2*time.Hour))
+	assert.Equal(t, "90 * time.Second", durationLiteral(90*time.Second))
+	assert.Equal(t, "1500 * time.Millisecond", durationLiteral(1500*time.Millisecond))
+	assert.Equal(t, "7 * time.Nanosecond", durationLiteral(7))
+}
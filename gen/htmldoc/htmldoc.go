@@ -0,0 +1,295 @@
+// Package htmldoc implements a generator emitting a static HTML
+// documentation site for a FileSet. Importing the package registers the
+// generator under the name "html".
+//
+// The site contains an index.html page listing packages and providing a
+// symbol search backed by search-index.json, and one page per package. Each
+// message, service, field, and method is given an anchor named after its
+// FQN, such as org.example.Order.id, and type references link to the
+// declarations they refer to. Deprecated declarations are flagged by a badge
+// describing the reason provided to @deprecated, if any.
+//
+// The generator accepts the following parameters:
+//
+//   - title: title of the site. Defaults to "API Reference".
+//
+// In case gen.Options.Timestamp is set, pages mention the date on which they
+// were generated.
+//
+// Templates defined by layout.tmpl, index.tmpl, and package.tmpl can be
+// replaced through gen.Options.TemplateDir.
+package htmldoc
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"time"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+)
+
+// Name contains the name under which the generator is registered.
+const Name = "html"
+
+//go:embed *.tmpl
+var templates embed.FS
+
+func init() {
+	gen.Register(Generator{})
+}
+
+// Generator emits a static HTML documentation site.
+type Generator struct{}
+
+// Name implements gen.Generator.
+func (Generator) Name() string { return Name }
+
+// Generate implements gen.Generator.
+func (Generator) Generate(fs *idl.FileSet, opts gen.Options) ([]gen.OutputFile, error) {
+	tmpl, err := gen.LoadHTMLTemplates(templates, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	s := newSite(fs, opts.Parameter("title", "API Reference"))
+	var generated string
+	if !opts.Timestamp.IsZero() {
+		generated = opts.Timestamp.UTC().Format(time.DateOnly)
+	}
+
+	var files []gen.OutputFile
+	render := func(path, name string, data any) error {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+			return err
+		}
+		files = append(files, gen.OutputFile{Path: path, Content: buf.Bytes()})
+		return nil
+	}
+	if err = render("index.html", "index.tmpl", pageData{Site: s.title, Title: s.title, Generated: generated, Packages: s.packages}); err != nil {
+		return nil, err
+	}
+	for i := range s.packages {
+		p := &s.packages[i]
+		data := pageData{Site: s.title, Title: p.Name + " - " + s.title, Generated: generated, Package: p}
+		if err = render(p.File, "package.tmpl", data); err != nil {
+			return nil, err
+		}
+	}
+	index, err := json.MarshalIndent(s.index, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, gen.OutputFile{Path: "search-index.json", Content: append(index, '\n')})
+	return files, nil
+}
+
+type pageData struct {
+	Site      string
+	Title     string
+	Generated string
+	Packages  []packageDoc
+	Package   *packageDoc
+}
+
+type packageDoc struct {
+	Name     string
+	File     string
+	Messages []messageDoc
+	Services []serviceDoc
+}
+
+// deprecation holds the deprecation state of a declaration.
+type deprecation struct {
+	Deprecated        bool
+	DeprecationReason string
+}
+
+type messageDoc struct {
+	deprecation
+	Name   string
+	Anchor string
+	Doc    []string
+	Fields []fieldDoc
+}
+
+type fieldDoc struct {
+	deprecation
+	Name     string
+	Anchor   string
+	Index    int
+	Type     template.HTML
+	OneOf    bool
+	Optional bool
+	Repeated bool
+	Doc      []string
+}
+
+type serviceDoc struct {
+	deprecation
+	Name    string
+	Anchor  string
+	Doc     []string
+	Methods []methodDoc
+}
+
+type methodDoc struct {
+	deprecation
+	Name      string
+	Anchor    string
+	Argument  template.HTML
+	Return    template.HTML
+	Streaming bool
+	Doc       []string
+}
+
+// IndexEntry represents a symbol listed by search-index.json.
+type IndexEntry struct {
+	Name       string `json:"name"`
+	Kind       string `json:"kind"`
+	URL        string `json:"url"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+}
+
+type site struct {
+	title        string
+	packages     []packageDoc
+	index        []IndexEntry
+	names        map[*idl.Message]idl.FQN
+	deprecations map[string]deprecation
+}
+
+func newSite(fs *idl.FileSet, title string) *site {
+	s := &site{title: title, names: map[*idl.Message]idl.FQN{}, deprecations: map[string]deprecation{}}
+	for _, d := range fs.Deprecations() {
+		s.deprecations[d.Symbol] = deprecation{Deprecated: true, DeprecationReason: d.Reason}
+	}
+	for _, sym := range fs.Symbols() {
+		if m := sym.Message(); m != nil {
+			s.names[m] = sym.Name
+		}
+	}
+	for _, p := range fs.Packages() {
+		doc := packageDoc{Name: p.Name(), File: pageOf(p.Name())}
+		for _, m := range p.Messages() {
+			doc.Messages = append(doc.Messages, s.message(p.Name(), m))
+		}
+		for _, svc := range p.Services() {
+			doc.Services = append(doc.Services, s.service(p.Name(), svc))
+		}
+		s.packages = append(s.packages, doc)
+	}
+	return s
+}
+
+func pageOf(pkg string) string {
+	return pkg + ".html"
+}
+
+// add lists the symbol under name, declared by pkg, in the search index.
+func (s *site) add(pkg, name, kind string) {
+	s.index = append(s.index, IndexEntry{
+		Name:       name,
+		Kind:       kind,
+		URL:        pageOf(pkg) + "#" + name,
+		Deprecated: s.deprecations[name].Deprecated,
+	})
+}
+
+func (s *site) message(pkg string, m *idl.Message) messageDoc {
+	fqn := idl.NewFQN(pkg, m.Name).String()
+	s.add(pkg, fqn, "message")
+	doc := messageDoc{deprecation: s.deprecations[fqn], Name: m.Name, Anchor: fqn, Doc: m.Comments}
+	var visit func(items []idl.FieldItem, oneOf bool)
+	visit = func(items []idl.FieldItem, oneOf bool) {
+		for _, item := range items {
+			switch v := item.(type) {
+			case idl.Field:
+				name := fqn + "." + v.Name
+				s.add(pkg, name, "field")
+				_, optional := v.Annotations.FindByName(idl.OptionalAnnotation)
+				_, repeated := v.Annotations.FindByName(idl.RepeatedAnnotation)
+				doc.Fields = append(doc.Fields, fieldDoc{
+					deprecation: s.deprecations[name],
+					Name:        v.Name,
+					Anchor:      name,
+					Index:       v.Index,
+					Type:        s.typeHTML(pkg, v.Type),
+					OneOf:       oneOf,
+					Optional:    optional,
+					Repeated:    repeated,
+					Doc:         v.Comments,
+				})
+			case idl.OneOfField:
+				visit(v.Items, true)
+			}
+		}
+	}
+	visit(m.Fields, false)
+	return doc
+}
+
+func (s *site) service(pkg string, svc *idl.Service) serviceDoc {
+	fqn := idl.NewFQN(pkg, svc.Name).String()
+	s.add(pkg, fqn, "service")
+	doc := serviceDoc{deprecation: s.deprecations[fqn], Name: svc.Name, Anchor: fqn, Doc: svc.Comments}
+	for _, m := range svc.Methods {
+		name := fqn + "." + m.Name
+		s.add(pkg, name, "method")
+		doc.Methods = append(doc.Methods, methodDoc{
+			deprecation: s.deprecations[name],
+			Name:        m.Name,
+			Anchor:      name,
+			Argument:    s.refHTML(pkg, m.Argument),
+			Return:      s.refHTML(pkg, m.Return),
+			Streaming:   m.Return.Streaming,
+			Doc:         m.Comments,
+		})
+	}
+	return doc
+}
+
+// link returns a link to the declaration of the message under name, labeled
+// relative to pkg.
+func link(pkg string, name idl.FQN) string {
+	label := name.String()
+	if name.Package() == pkg {
+		label = name.Name()
+	}
+	return fmt.Sprintf(`<a href="%s#%s">%s</a>`,
+		html.EscapeString(pageOf(name.Package())), html.EscapeString(name.String()), html.EscapeString(label))
+}
+
+func (s *site) typeHTML(pkg string, t idl.Type) template.HTML {
+	var render func(t idl.Type) string
+	render = func(t idl.Type) string {
+		switch v := t.(type) {
+		case idl.Primitive:
+			return html.EscapeString(v.Kind.Keyword())
+		case idl.Array:
+			return "array&lt;" + render(v.Of) + "&gt;"
+		case idl.Map:
+			return "map&lt;" + html.EscapeString(v.Key.Keyword()) + ", " + render(v.Value) + "&gt;"
+		case idl.Resolved:
+			return link(pkg, v.Name)
+		case idl.Unresolved:
+			return html.EscapeString(v.Name)
+		}
+		return ""
+	}
+	return template.HTML(render(t))
+}
+
+func (s *site) refHTML(pkg string, t idl.TypeRef) template.HTML {
+	if t.IsVoid() {
+		return "void"
+	}
+	if name, ok := s.names[t.Target]; ok {
+		return template.HTML(link(pkg, name))
+	}
+	return template.HTML(html.EscapeString(t.String()))
+}
@@ -0,0 +1,45 @@
+package htmldoc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/typescript/main.yarp"))
+	files, err := gen.Run(fs, gen.Options{Parameters: map[string]string{"title": "Shop <API>"}}, Name)
+	require.NoError(t, err)
+
+	contents := map[string]string{}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+		contents[f.Path] = string(f.Content)
+	}
+	assert.Equal(t, []string{"index.html", "org.example.types.html", "org.example.shop.html", "search-index.json"}, paths)
+
+	index := contents["index.html"]
+	assert.Contains(t, index, "<title>Shop &lt;API&gt;</title>")
+	assert.Contains(t, index, `<li><a href="org.example.shop.html">org.example.shop</a></li>`)
+
+	shop := contents["org.example.shop.html"]
+	assert.Contains(t, shop, `<section id="org.example.shop.Order">`)
+	assert.Contains(t, shop, `<tr id="org.example.shop.Order.shipping">`)
+	assert.Contains(t, shop, `<td class="type"><a href="org.example.types.html#org.example.types.Address">org.example.types.Address</a></td>`)
+	assert.Contains(t, shop, "<td><code>items</code> <em>repeated</em></td>\n<td class=\"type\"><a href=\"org.example.shop.html#org.example.shop.Item\">Item</a></td>")
+	assert.Contains(t, shop, `<td class="type">stream <a href="org.example.shop.html#org.example.shop.Order">Order</a></td>`)
+	assert.Contains(t, shop, `<code>notes</code> <span class="deprecated" title="">deprecated</span>`)
+	assert.Contains(t, shop, `<td class="type">void</td>`)
+	assert.Contains(t, shop, "<p>Order represents a purchase made by a customer.</p>")
+
+	var entries []IndexEntry
+	require.NoError(t, json.Unmarshal([]byte(contents["search-index.json"]), &entries))
+	assert.Contains(t, entries, IndexEntry{Name: "org.example.types.Address.number", Kind: "field", URL: "org.example.types.html#org.example.types.Address.number"})
+	assert.Contains(t, entries, IndexEntry{Name: "org.example.shop.Orders.clear", Kind: "method", URL: "org.example.shop.html#org.example.shop.Orders.clear", Deprecated: true})
+}
@@ -0,0 +1,162 @@
+// Package inventory implements a generator listing every field declared by a
+// FileSet, along with its type and encoding properties, for auditing
+// purposes. Importing the package registers the generator under the name
+// "inventory".
+//
+// Each entry describes the package and message declaring a field, its name,
+// type, and index, whether it is a case of a oneof, and whether it is
+// optional, repeated, or deprecated. Entries follow the order in which
+// messages and fields are declared, as returned by idl.FileSet.Symbols.
+//
+// The generator accepts the following parameters:
+//
+//   - format: either "csv" or "json". Defaults to "csv".
+//   - output: path of the emitted file. Defaults to "inventory.csv" or
+//     "inventory.json", according to format.
+//
+// The generator implements gen.Partitioner, allowing inventories to be
+// emitted for each package or source file through gen.Options.Layout.
+package inventory
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+)
+
+// Name contains the name under which the generator is registered.
+const Name = "inventory"
+
+func init() {
+	gen.Register(Generator{})
+}
+
+// Generator emits an inventory of all fields of a FileSet.
+type Generator struct{}
+
+// Name implements gen.Generator.
+func (Generator) Name() string { return Name }
+
+// Entry describes a single field.
+type Entry struct {
+	Package    string `json:"package"`
+	Message    string `json:"message"`
+	Field      string `json:"field"`
+	Type       string `json:"type"`
+	Index      int    `json:"index"`
+	OneOf      bool   `json:"oneof"`
+	Optional   bool   `json:"optional"`
+	Repeated   bool   `json:"repeated"`
+	Deprecated bool   `json:"deprecated"`
+}
+
+var header = []string{"package", "message", "field", "type", "index", "oneof", "optional", "repeated", "deprecated"}
+
+// Generate implements gen.Generator.
+func (Generator) Generate(fs *idl.FileSet, opts gen.Options) ([]gen.OutputFile, error) {
+	return generate(fs, nil, opts)
+}
+
+// GenerateUnit implements gen.Partitioner, listing fields of messages
+// declared by u.
+func (Generator) GenerateUnit(fs *idl.FileSet, u gen.Unit, opts gen.Options) ([]gen.OutputFile, error) {
+	return generate(fs, u.Contains, opts)
+}
+
+func generate(fs *idl.FileSet, include func(idl.Symbol) bool, opts gen.Options) ([]gen.OutputFile, error) {
+	format := opts.Parameter("format", "csv")
+	if format != "csv" && format != "json" {
+		return nil, fmt.Errorf("invalid format parameter %q: expected csv or json", format)
+	}
+	entries := entries(fs, include)
+
+	var buf bytes.Buffer
+	if format == "json" {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(append(data, '\n'))
+	} else {
+		w := csv.NewWriter(&buf)
+		_ = w.Write(header)
+		for _, e := range entries {
+			_ = w.Write([]string{
+				e.Package, e.Message, e.Field, e.Type, strconv.Itoa(e.Index),
+				strconv.FormatBool(e.OneOf), strconv.FormatBool(e.Optional),
+				strconv.FormatBool(e.Repeated), strconv.FormatBool(e.Deprecated),
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+	}
+	return []gen.OutputFile{{Path: opts.Parameter("output", "inventory."+format), Content: buf.Bytes()}}, nil
+}
+
+// Entries returns entries describing every field declared by fs.
+func Entries(fs *idl.FileSet) []Entry {
+	return entries(fs, nil)
+}
+
+// entries returns entries describing fields of messages for which include
+// returns true, or of all messages, in case include is nil.
+func entries(fs *idl.FileSet, include func(idl.Symbol) bool) []Entry {
+	entries := []Entry{}
+	for _, s := range fs.Symbols() {
+		m := s.Message()
+		if m == nil || include != nil && !include(s) {
+			continue
+		}
+		var visit func(items []idl.FieldItem, oneOf bool)
+		visit = func(items []idl.FieldItem, oneOf bool) {
+			for _, item := range items {
+				switch v := item.(type) {
+				case idl.Field:
+					_, optional := v.Annotations.FindByName(idl.OptionalAnnotation)
+					_, repeated := v.Annotations.FindByName(idl.RepeatedAnnotation)
+					_, deprecated := v.Annotations.FindByName(idl.DeprecatedAnnotation)
+					entries = append(entries, Entry{
+						Package:    s.Name.Package(),
+						Message:    s.Name.Name(),
+						Field:      v.Name,
+						Type:       typeName(v.Type),
+						Index:      v.Index,
+						OneOf:      oneOf,
+						Optional:   optional,
+						Repeated:   repeated,
+						Deprecated: deprecated,
+					})
+				case idl.OneOfField:
+					visit(v.Items, true)
+				}
+			}
+		}
+		visit(m.Fields, false)
+	}
+	return entries
+}
+
+// typeName returns t as written in source files, with references to
+// messages replaced by their FQN when resolved.
+func typeName(t idl.Type) string {
+	switch v := t.(type) {
+	case idl.Primitive:
+		return v.Kind.Keyword()
+	case idl.Array:
+		return "array<" + typeName(v.Of) + ">"
+	case idl.Map:
+		return "map<" + v.Key.Keyword() + ", " + typeName(v.Value) + ">"
+	case idl.Resolved:
+		return v.Name.String()
+	case idl.Unresolved:
+		return v.Name
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package inventory
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/typescript/main.yarp"))
+
+	files, err := gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "inventory.csv", files[0].Path)
+	lines := strings.Split(strings.TrimSpace(string(files[0].Content)), "\n")
+	assert.Equal(t, []string{
+		"package,message,field,type,index,oneof,optional,repeated,deprecated",
+		"org.example.types,Address,street,string,0,false,false,false,false",
+		"org.example.types,Address,number,uint32,1,false,true,false,false",
+		"org.example.shop,Order,id,int64,0,false,false,false,false",
+		"org.example.shop,Order,items,org.example.shop.Item,1,false,false,true,false",
+		"org.example.shop,Order,shipping,org.example.types.Address,2,false,false,false,false",
+		`org.example.shop,Order,quantities,"map<uint64, int32>",3,false,false,false,false`,
+		"org.example.shop,Order,card,string,5,true,false,false,false",
+		"org.example.shop,Order,voucher,org.example.shop.Voucher,6,true,false,false,false",
+		"org.example.shop,Order,notes,array<string>,7,false,false,false,true",
+	}, lines[:10])
+
+	files, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"format": "json"}}, Name)
+	require.NoError(t, err)
+	assert.Equal(t, "inventory.json", files[0].Path)
+	var entries []Entry
+	require.NoError(t, json.Unmarshal(files[0].Content, &entries))
+	assert.Equal(t, Entries(fs), entries)
+
+	_, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"format": "xml"}}, Name)
+	assert.ErrorContains(t, err, `invalid format parameter "xml"`)
+}
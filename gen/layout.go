@@ -0,0 +1,209 @@
+package gen
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/libyarp/idl"
+)
+
+// Split determines how the output of a generator is partitioned.
+type Split string
+
+const (
+	// SplitNone produces output for the whole FileSet at once.
+	SplitNone Split = ""
+	// SplitPackage produces output for each package.
+	SplitPackage Split = "package"
+	// SplitSource produces output for each source file.
+	SplitSource Split = "source"
+)
+
+// Layout maps the output of generators onto paths, allowing generated files
+// to be placed according to the packages and source files they originate
+// from.
+//
+// Path contains a template of output paths, in which the following
+// placeholders are replaced:
+//
+//   - {path}: path of the file as produced by the generator, such as
+//     schema.d.ts, with its extension replaced by Suffix, if set.
+//   - {ext}: extension of {path}, such as .d.ts.
+//   - {package}: package of the unit, such as org.example.
+//   - {package_dir}: package of the unit, as a path, such as org/example.
+//   - {source_dir}: directory of the source file of the unit, relative to
+//     Root.
+//   - {source_name}: name of the source file of the unit, without its .yarp
+//     extension.
+//
+// Package placeholders require a split, and source placeholders require
+// SplitSource. In case Path is empty, it defaults to "{path}" when output
+// is not split, "{package_dir}/{path}" for SplitPackage, and
+// "{source_dir}/{source_name}{ext}" for SplitSource.
+type Layout struct {
+	// Split determines how output is partitioned. Splitting output requires
+	// generators to implement Partitioner.
+	Split Split
+
+	// Path contains the template of output paths.
+	Path string
+
+	// Suffix replaces the extension of produced files, such as ".gen.d.ts".
+	Suffix string
+
+	// Root contains the directory against which source files are made
+	// relative. In case it is empty, {source_dir} is replaced by ".".
+	Root string
+}
+
+// Unit represents the part of a FileSet a Partitioner produces output for.
+type Unit struct {
+	// Package contains the package declared by files of the unit.
+	Package string
+
+	// Files contains the source files composing the unit, in the order they
+	// were loaded.
+	Files []*idl.File
+}
+
+// Contains returns whether s is declared by a file of the unit.
+func (u Unit) Contains(s idl.Symbol) bool {
+	for _, f := range u.Files {
+		if f == s.File {
+			return true
+		}
+	}
+	return false
+}
+
+// Partitioner is implemented by generators able to produce output for parts
+// of a FileSet, as required by layouts splitting output.
+type Partitioner interface {
+	Generator
+
+	// GenerateUnit produces output for declarations of u, which may
+	// reference declarations of other units.
+	GenerateUnit(fs *idl.FileSet, u Unit, opts Options) ([]OutputFile, error)
+}
+
+var placeholder = regexp.MustCompile(`\{[a-z_]*\}`)
+
+// units returns the units of fs according to split.
+func units(fs *idl.FileSet, split Split) ([]Unit, error) {
+	switch split {
+	case SplitPackage:
+		var r []Unit
+		index := map[string]int{}
+		for _, f := range fs.Files() {
+			i, ok := index[f.Package]
+			if !ok {
+				i, index[f.Package] = len(r), len(r)
+				r = append(r, Unit{Package: f.Package})
+			}
+			r[i].Files = append(r[i].Files, f)
+		}
+		return r, nil
+	case SplitSource:
+		var r []Unit
+		for _, f := range fs.Files() {
+			r = append(r, Unit{Package: f.Package, Files: []*idl.File{f}})
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("invalid split %q", split)
+}
+
+// generate executes g according to the layout, returning files under their
+// mapped paths.
+func (l Layout) generate(fs *idl.FileSet, g Generator, opts Options) ([]OutputFile, error) {
+	if l.Split == SplitNone {
+		files, err := g.Generate(fs, opts)
+		if err != nil {
+			return nil, err
+		}
+		return l.place(files, nil)
+	}
+	p, ok := g.(Partitioner)
+	if !ok {
+		return nil, fmt.Errorf("output cannot be split by %s", l.Split)
+	}
+	us, err := units(fs, l.Split)
+	if err != nil {
+		return nil, err
+	}
+	var out []OutputFile
+	for i := range us {
+		files, err := p.GenerateUnit(fs, us[i], opts)
+		if err != nil {
+			return nil, err
+		}
+		if files, err = l.place(files, &us[i]); err != nil {
+			return nil, err
+		}
+		out = append(out, files...)
+	}
+	return out, nil
+}
+
+// place maps the paths of files produced for u, or for the whole FileSet, in
+// case u is nil.
+func (l Layout) place(files []OutputFile, u *Unit) ([]OutputFile, error) {
+	tmpl := l.Path
+	if tmpl == "" {
+		switch l.Split {
+		case SplitPackage:
+			tmpl = "{package_dir}/{path}"
+		case SplitSource:
+			tmpl = "{source_dir}/{source_name}{ext}"
+		default:
+			tmpl = "{path}"
+		}
+	}
+	vars := map[string]string{}
+	if u != nil {
+		vars["package"] = u.Package
+		vars["package_dir"] = strings.ReplaceAll(u.Package, ".", "/")
+		if l.Split == SplitSource {
+			src := u.Files[0].SourcePath
+			vars["source_dir"] = "."
+			if l.Root != "" {
+				rel, err := filepath.Rel(l.Root, filepath.Dir(src))
+				if err != nil {
+					return nil, err
+				}
+				vars["source_dir"] = filepath.ToSlash(rel)
+			}
+			vars["source_name"] = strings.TrimSuffix(filepath.Base(src), ".yarp")
+		}
+	}
+
+	r := make([]OutputFile, len(files))
+	for i, f := range files {
+		dir, base := path.Split(f.Path)
+		ext := ""
+		if j := strings.Index(base, "."); j > 0 {
+			base, ext = base[:j], base[j:]
+		}
+		if l.Suffix != "" {
+			ext = l.Suffix
+		}
+		vars["path"], vars["ext"] = dir+base+ext, ext
+
+		var missing string
+		p := placeholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+			v, ok := vars[m[1:len(m)-1]]
+			if !ok && missing == "" {
+				missing = m
+			}
+			return v
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("layout path %s: placeholder %s is not available", tmpl, missing)
+		}
+		r[i] = OutputFile{Path: p, Content: f.Content}
+	}
+	return r, nil
+}
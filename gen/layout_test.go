@@ -0,0 +1,85 @@
+package gen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type unitsGenerator struct{}
+
+func (unitsGenerator) Name() string { return "test-units" }
+
+func (u unitsGenerator) Generate(fs *idl.FileSet, opts Options) ([]OutputFile, error) {
+	return u.GenerateUnit(fs, Unit{Files: fs.Files()}, opts)
+}
+
+func (unitsGenerator) GenerateUnit(fs *idl.FileSet, u Unit, _ Options) ([]OutputFile, error) {
+	var names []string
+	for _, s := range fs.Symbols() {
+		if u.Contains(s) {
+			names = append(names, s.Name.Name())
+		}
+	}
+	return []OutputFile{{Path: "out/symbols.txt", Content: []byte(strings.Join(names, ","))}}, nil
+}
+
+func init() {
+	Register(unitsGenerator{})
+}
+
+func TestLayout(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.LoadDir("../test/layout"))
+	root, err := filepath.Abs("..")
+	require.NoError(t, err)
+
+	run := func(l Layout, name string) map[string]string {
+		files, err := Run(fs, Options{Layout: l}, name)
+		require.NoError(t, err)
+		r := map[string]string{}
+		for _, f := range files {
+			r[f.Path] = string(f.Content)
+		}
+		return r
+	}
+
+	assert.Equal(t, map[string]string{
+		"out/symbols.gen.txt": "Item,User,Order,Orders",
+	}, run(Layout{Suffix: ".gen.txt"}, "test-units"))
+
+	assert.Equal(t, map[string]string{
+		"org/example/orders/out/symbols.txt": "Item,Order,Orders",
+		"org/example/users/out/symbols.txt":  "User",
+	}, run(Layout{Split: SplitPackage}, "test-units"))
+
+	assert.Equal(t, map[string]string{
+		"gen/org.example.orders.txt": "Item,Order,Orders",
+		"gen/org.example.users.txt":  "User",
+	}, run(Layout{Split: SplitPackage, Path: "gen/{package}{ext}"}, "test-units"))
+
+	assert.Equal(t, map[string]string{
+		"test/layout/orders/items.ts":  "Item",
+		"test/layout/orders/orders.ts": "Order,Orders",
+		"test/layout/users/users.ts":   "User",
+	}, run(Layout{Split: SplitSource, Suffix: ".ts", Root: root}, "test-units"))
+
+	assert.Equal(t, map[string]string{
+		"items.txt":  "Item",
+		"orders.txt": "Order,Orders",
+		"users.txt":  "User",
+	}, run(Layout{Split: SplitSource}, "test-units"))
+
+	_, err = Run(fs, Options{Layout: Layout{Split: SplitPackage}}, "test-names")
+	assert.EqualError(t, err, "test-names: output cannot be split by package")
+
+	_, err = Run(fs, Options{Layout: Layout{Path: "{package_dir}/{path}"}}, "test-units")
+	assert.EqualError(t, err, "test-units: layout path {package_dir}/{path}: placeholder {package_dir} is not available")
+
+	_, err = Run(fs, Options{Layout: Layout{Split: "message"}}, "test-units")
+	assert.EqualError(t, err, `test-units: invalid split "message"`)
+}
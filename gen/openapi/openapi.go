@@ -0,0 +1,279 @@
+// Package openapi implements a generator transforming services into an
+// OpenAPI 3.1 document. Importing the package registers the generator under
+// the name "openapi".
+//
+// Each method is mapped to an operation according to its @http annotation,
+// such as @http(GET, "/contacts/{id}"). Methods without the annotation are
+// mapped to POST /<package>.<service>/<method>. Path parameters are taken
+// from fields of the method argument sharing their name; remaining fields are
+// provided as query parameters for GET, HEAD, and DELETE operations, while
+// other operations take the argument as a JSON request body. Methods
+// returning void respond with 204, and streaming methods respond with
+// newline-delimited JSON. Messages are described under components/schemas,
+// keyed by their FQN.
+//
+// The generator accepts the following parameters:
+//
+//   - output: path of the emitted file. Defaults to "openapi.json".
+//   - title: title of the API. Defaults to "API".
+//   - version: version of the API. Defaults to "0.0.0".
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+)
+
+// Name contains the name under which the generator is registered.
+const Name = "openapi"
+
+// HTTPAnnotation contains the name of annotations mapping a method to an
+// HTTP verb and path.
+const HTTPAnnotation = "http"
+
+// Version contains the version of the OpenAPI specification documents
+// conform to.
+const Version = "3.1.0"
+
+const schemaPrefix = "#/components/schemas/"
+
+func init() {
+	gen.Register(Generator{})
+}
+
+// Generator emits an OpenAPI document describing all services of a FileSet.
+type Generator struct{}
+
+// Name implements gen.Generator.
+func (Generator) Name() string { return Name }
+
+type document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       info                `json:"info"`
+	Paths      map[string]pathItem `json:"paths"`
+	Components components          `json:"components"`
+	Tags       []tag               `json:"tags,omitempty"`
+}
+
+type info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type components struct {
+	Schemas map[string]json.RawMessage `json:"schemas"`
+}
+
+type tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type pathItem map[string]*operation
+
+type operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+	Parameters  []parameter         `json:"parameters,omitempty"`
+	RequestBody *requestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]response `json:"responses"`
+}
+
+type parameter struct {
+	Name     string          `json:"name"`
+	In       string          `json:"in"`
+	Required bool            `json:"required"`
+	Schema   json.RawMessage `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema json.RawMessage `json:"schema"`
+}
+
+var pathParameter = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Generate implements gen.Generator.
+func (Generator) Generate(fs *idl.FileSet, opts gen.Options) ([]gen.OutputFile, error) {
+	doc := document{
+		OpenAPI: Version,
+		Info: info{
+			Title:   opts.Parameter("title", "API"),
+			Version: opts.Parameter("version", "0.0.0"),
+		},
+		Paths: map[string]pathItem{},
+	}
+	names := map[*idl.Message]idl.FQN{}
+	for _, s := range fs.Symbols() {
+		if m := s.Message(); m != nil {
+			names[m] = s.Name
+		}
+	}
+
+	referenced := map[idl.FQN]*idl.Message{}
+	type pending struct {
+		svc    idl.Symbol
+		method idl.Method
+	}
+	var methods []pending
+	for _, s := range fs.Symbols() {
+		svc := s.Service()
+		if svc == nil {
+			continue
+		}
+		doc.Tags = append(doc.Tags, tag{Name: s.Name.String(), Description: strings.Join(svc.Comments, " ")})
+		for _, m := range svc.Methods {
+			for _, ref := range []idl.TypeRef{m.Argument, m.Return} {
+				if n, ok := names[ref.Target]; ok {
+					referenced[n] = ref.Target
+				}
+			}
+			methods = append(methods, pending{s, m})
+		}
+	}
+	schemas, err := idl.JSONSchemaDefinitions(referenced, schemaPrefix)
+	if err != nil {
+		return nil, err
+	}
+	doc.Components.Schemas = schemas
+
+	for _, p := range methods {
+		verb, path, err := route(p.svc.Name, p.method)
+		if err != nil {
+			return nil, err
+		}
+		op, err := newOperation(p.svc.Name, p.method, verb, path, names, schemas)
+		if err != nil {
+			return nil, err
+		}
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = pathItem{}
+			doc.Paths[path] = item
+		}
+		if _, ok = item[verb]; ok {
+			return nil, fmt.Errorf("%s.%s: operation %s %s is already declared", p.svc.Name, p.method.Name, strings.ToUpper(verb), path)
+		}
+		item[verb] = op
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []gen.OutputFile{{Path: opts.Parameter("output", "openapi.json"), Content: append(data, '\n')}}, nil
+}
+
+// route returns the lowercase HTTP verb and path of method m of service svc.
+func route(svc idl.FQN, m idl.Method) (string, string, error) {
+	a, ok := m.Annotations.FindByName(HTTPAnnotation)
+	if !ok {
+		return "post", "/" + svc.String() + "/" + m.Name, nil
+	}
+	if len(a.Value) != 2 || !strings.HasPrefix(a.Value[1], "/") {
+		return "", "", fmt.Errorf("%s.%s: @http expects a verb and a path starting with /", svc, m.Name)
+	}
+	verb := strings.ToLower(a.Value[0])
+	switch verb {
+	case "get", "put", "post", "delete", "patch", "head", "options":
+	default:
+		return "", "", fmt.Errorf("%s.%s: invalid HTTP verb %s", svc, m.Name, a.Value[0])
+	}
+	return verb, a.Value[1], nil
+}
+
+func newOperation(svc idl.FQN, m idl.Method, verb, path string, names map[*idl.Message]idl.FQN, schemas map[string]json.RawMessage) (*operation, error) {
+	_, deprecated := m.Annotations.FindByName(idl.DeprecatedAnnotation)
+	op := &operation{
+		OperationID: svc.Name() + "." + m.Name,
+		Summary:     strings.Join(m.Comments, " "),
+		Tags:        []string{svc.String()},
+		Deprecated:  deprecated,
+		Responses:   map[string]response{},
+	}
+
+	var properties map[string]json.RawMessage
+	required := map[string]bool{}
+	if argName, ok := names[m.Argument.Target]; ok {
+		var def struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+			Required   []string                   `json:"required"`
+		}
+		if err := json.Unmarshal(schemas[argName.String()], &def); err != nil {
+			return nil, err
+		}
+		properties = def.Properties
+		for _, r := range def.Required {
+			required[r] = true
+		}
+	}
+
+	inPath := map[string]bool{}
+	for _, match := range pathParameter.FindAllStringSubmatch(path, -1) {
+		name := match[1]
+		schema, ok := properties[name]
+		if !ok {
+			return nil, fmt.Errorf("%s.%s: path parameter %s is not a field of %s", svc, m.Name, name, m.Argument)
+		}
+		inPath[name] = true
+		op.Parameters = append(op.Parameters, parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+
+	switch {
+	case m.Argument.IsVoid():
+	case verb == "get" || verb == "head" || verb == "delete":
+		fields := make([]string, 0, len(properties))
+		for name := range properties {
+			if !inPath[name] {
+				fields = append(fields, name)
+			}
+		}
+		sort.Strings(fields)
+		for _, name := range fields {
+			op.Parameters = append(op.Parameters, parameter{Name: name, In: "query", Required: required[name], Schema: properties[name]})
+		}
+	default:
+		op.RequestBody = &requestBody{
+			Required: true,
+			Content:  map[string]mediaType{"application/json": {Schema: ref(names[m.Argument.Target])}},
+		}
+	}
+
+	switch {
+	case m.Return.IsVoid():
+		op.Responses["204"] = response{Description: "No content"}
+	case m.Return.Streaming:
+		op.Responses["200"] = response{
+			Description: "Stream of " + names[m.Return.Target].Name() + " values",
+			Content:     map[string]mediaType{"application/x-ndjson": {Schema: ref(names[m.Return.Target])}},
+		}
+	default:
+		op.Responses["200"] = response{
+			Description: names[m.Return.Target].Name(),
+			Content:     map[string]mediaType{"application/json": {Schema: ref(names[m.Return.Target])}},
+		}
+	}
+	return op, nil
+}
+
+func ref(name idl.FQN) json.RawMessage {
+	data, _ := json.Marshal(map[string]string{"$ref": schemaPrefix + name.String()})
+	return data
+}
@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/openapi/contacts.yarp"))
+
+	files, err := gen.Run(fs, gen.Options{Parameters: map[string]string{"title": "Contacts", "version": "1.0.0"}}, Name)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "openapi.json", files[0].Path)
+	expected, err := os.ReadFile("../../test/openapi/openapi.json")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(files[0].Content))
+}
+
+func TestGeneratorInvalidRoutes(t *testing.T) {
+	for name, method := range map[string]string{
+		"verb":      `@http(FETCH, "/contacts") get(Query) -> Query;`,
+		"path":      `@http(GET, "contacts") get(Query) -> Query;`,
+		"parameter": `@http(GET, "/contacts/{name}") get(Query) -> Query;`,
+		"duplicate": `@http(GET, "/contacts") a(Query) -> Query; @http(GET, "/contacts") b(Query) -> Query;`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := "package a;\n\nmessage Query {\n    id int64 = 0;\n}\n\nservice S {\n    " + method + "\n}\n"
+			require.NoError(t, os.WriteFile(dir+"/a.yarp", []byte(src), 0o644))
+			fs := idl.NewFileSet()
+			require.NoError(t, fs.Load(dir+"/a.yarp"))
+			_, err := gen.Run(fs, gen.Options{}, Name)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package gen
+
+import (
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"text/template"
+)
+
+// TemplatePattern matches the names of files holding templates.
+const TemplatePattern = "*.tmpl"
+
+// LoadTemplates parses templates embedded by a generator into defaults, and
+// then the ones present in Options.TemplateDir, if any. Each file defines a
+// template named after it, and files may define further templates through
+// {{define}}. Templates defined by files in TemplateDir replace the default
+// ones sharing their name, allowing teams to adjust parts of the emitted
+// code, such as headers, without forking the generator. funcs is made
+// available to all templates.
+func LoadTemplates(defaults fs.FS, opts Options, funcs template.FuncMap) (*template.Template, error) {
+	t, err := template.New("").Funcs(funcs).ParseFS(defaults, TemplatePattern)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := templateOverrides(opts)
+	if err != nil || overrides == nil {
+		return t, err
+	}
+	return t.ParseFS(overrides, TemplatePattern)
+}
+
+// LoadHTMLTemplates is like LoadTemplates, for generators emitting HTML, in
+// which values are escaped according to their context.
+func LoadHTMLTemplates(defaults fs.FS, opts Options, funcs htmltemplate.FuncMap) (*htmltemplate.Template, error) {
+	t, err := htmltemplate.New("").Funcs(funcs).ParseFS(defaults, TemplatePattern)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := templateOverrides(opts)
+	if err != nil || overrides == nil {
+		return t, err
+	}
+	return t.ParseFS(overrides, TemplatePattern)
+}
+
+// templateOverrides returns the filesystem holding templates that replace
+// default ones, or nil, in case there are none.
+func templateOverrides(opts Options) (fs.FS, error) {
+	if opts.TemplateDir == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(opts.TemplateDir); err != nil {
+		return nil, err
+	}
+	overrides := os.DirFS(opts.TemplateDir)
+	matches, err := fs.Glob(overrides, TemplatePattern)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+	return overrides, nil
+}
@@ -0,0 +1,44 @@
+package gen
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	defaults := fstest.MapFS{
+		"file.tmpl": {Data: []byte(`{{block "header" .}}// default{{end}} {{upper .}}`)},
+	}
+	funcs := template.FuncMap{"upper": strings.ToUpper}
+	render := func(opts Options) string {
+		tmpl, err := LoadTemplates(defaults, opts, funcs)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, tmpl.ExecuteTemplate(&buf, "file.tmpl", "body"))
+		return buf.String()
+	}
+	assert.Equal(t, "// default BODY", render(Options{}))
+
+	dir := t.TempDir()
+	assert.Equal(t, "// default BODY", render(Options{TemplateDir: dir}))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "header.tmpl"), []byte(`{{define "header"}}// Copyright Example Inc.{{end}}`), 0o644))
+	assert.Equal(t, "// Copyright Example Inc. BODY", render(Options{TemplateDir: dir}))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.tmpl"), []byte(`{{.}}`), 0o644))
+	assert.Equal(t, "body", render(Options{TemplateDir: dir}))
+
+	_, err := LoadTemplates(defaults, Options{TemplateDir: filepath.Join(dir, "missing")}, funcs)
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte(`{{`), 0o644))
+	_, err = LoadTemplates(defaults, Options{TemplateDir: dir}, funcs)
+	assert.Error(t, err)
+}
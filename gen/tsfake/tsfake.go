@@ -0,0 +1,188 @@
+// Package tsfake implements a generator emitting in-memory fakes of service
+// clients declared by the typescript generator, allowing tests to exercise
+// code depending on services without hand-written mocks. Importing the
+// package registers the generator under the name "typescript-fakes".
+//
+// Each service is given a class named after it, prefixed by Fake and suffixed
+// by Client, which implements the client interface, records received calls,
+// and answers them with canned responses. Streaming methods may be answered
+// by arrays or by FakeStream values, through which tests provide values as
+// they go. Former names of methods, declared through @renamed_from, are
+// implemented as deprecated aliases recording calls under the current name.
+//
+// The generator accepts the following parameters:
+//
+//   - output: path of the emitted file. Defaults to "fakes.ts".
+//   - schema: module declaring the client interfaces, as emitted by the
+//     typescript generator. Defaults to "./schema".
+//
+// Templates named "header", "runtime", and "service" can be replaced through
+// gen.Options.TemplateDir. Services are provided as values with Name, Class,
+// Interface, MethodUnion, and Methods.
+package tsfake
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/libyarp/idl/names"
+)
+
+// Name contains the name under which the generator is registered.
+const Name = "typescript-fakes"
+
+// templateName contains the name of the template rendering the emitted file.
+const templateName = "tsfake.ts.tmpl"
+
+//go:embed tsfake.ts.tmpl
+var templates embed.FS
+
+func init() {
+	gen.Register(Generator{})
+}
+
+// Generator emits a single TypeScript module holding a fake for each service
+// of a FileSet.
+type Generator struct{}
+
+// Name implements gen.Generator.
+func (Generator) Name() string { return Name }
+
+// Checks implements gen.Checker, reporting declarations renamed due to
+// collisions with TypeScript keywords.
+func (Generator) Checks() []idl.Check {
+	return []idl.Check{names.KeywordCheck(names.TypeScriptTypes, names.JSON)}
+}
+
+// Generate implements gen.Generator.
+func (Generator) Generate(fs *idl.FileSet, opts gen.Options) ([]gen.OutputFile, error) {
+	tmpl, err := gen.LoadTemplates(templates, opts, template.FuncMap{"join": strings.Join})
+	if err != nil {
+		return nil, err
+	}
+	data, err := newFileData(fs, opts.Parameter("schema", "./schema"))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = tmpl.ExecuteTemplate(&buf, templateName, data); err != nil {
+		return nil, err
+	}
+	return []gen.OutputFile{{Path: opts.Parameter("output", "fakes.ts"), Content: buf.Bytes()}}, nil
+}
+
+type fileData struct {
+	Schema   string
+	Roots    []string
+	Services []serviceData
+}
+
+type serviceData struct {
+	Name        string
+	Class       string
+	Interface   string
+	MethodUnion string
+	Methods     []methodData
+}
+
+type methodData struct {
+	Name      string
+	Argument  string
+	Result    string
+	Streaming bool
+
+	// RenamedFrom contains the former names of the method, implemented as
+	// deprecated aliases delegating to it.
+	RenamedFrom []string
+}
+
+// Request returns the type of requests of the method.
+func (m methodData) Request() string {
+	if m.Argument == "" {
+		return "void"
+	}
+	return m.Argument
+}
+
+// Response returns the type of canned responses of the method.
+func (m methodData) Response() string {
+	switch {
+	case m.Result == "":
+		return "void"
+	case m.Streaming:
+		return "StreamSource<" + m.Result + ">"
+	}
+	return m.Result
+}
+
+// Return returns the type returned by the method.
+func (m methodData) Return() string {
+	if m.Streaming {
+		return "AsyncIterable<" + m.Result + ">"
+	}
+	return "Promise<" + m.Response() + ">"
+}
+
+func newFileData(fs *idl.FileSet, schema string) (fileData, error) {
+	data := fileData{Schema: schema}
+	fqns := map[*idl.Message]idl.FQN{}
+	for _, s := range fs.Symbols() {
+		if msg := s.Message(); msg != nil {
+			fqns[msg] = s.Name
+		}
+	}
+	roots := map[string]bool{}
+	use := func(n idl.FQN) string {
+		q := names.TypeScriptTypes.Qualified(n)
+		roots[strings.SplitN(q, ".", 2)[0]] = true
+		return q
+	}
+	ref := func(pkg string, t idl.TypeRef) string {
+		if t.IsVoid() {
+			return ""
+		}
+		if n, ok := fqns[t.Target]; ok {
+			return use(n)
+		}
+		return use(t.FQN().Qualify(pkg))
+	}
+
+	classes := map[string]idl.FQN{}
+	for _, p := range fs.Packages() {
+		for _, svc := range p.Services() {
+			fqn := idl.NewFQN(p.Name(), svc.Name)
+			sd := serviceData{Name: svc.Name, Class: "Fake" + svc.Name + "Client", Interface: use(idl.FQN(p.Name())) + "." + svc.Name + "Client"}
+			if prev, ok := classes[sd.Class]; ok {
+				return data, fmt.Errorf("fakes of %s and %s would both be named %s", prev, fqn, sd.Class)
+			}
+			classes[sd.Class] = fqn
+			var union []string
+			for _, m := range svc.Methods {
+				sd.Methods = append(sd.Methods, methodData{
+					Name:        m.Name,
+					Argument:    ref(p.Name(), m.Argument),
+					Result:      ref(p.Name(), m.Return),
+					Streaming:   m.Return.Streaming,
+					RenamedFrom: m.Annotations.RenamedFrom(),
+				})
+				union = append(union, fmt.Sprintf("%q", m.Name))
+			}
+			sd.MethodUnion = strings.Join(union, " | ")
+			if sd.MethodUnion == "" {
+				sd.MethodUnion = "never"
+			}
+			data.Services = append(data.Services, sd)
+		}
+	}
+	for r := range roots {
+		data.Roots = append(data.Roots, r)
+	}
+	sort.Strings(data.Roots)
+	return data, nil
+}
@@ -0,0 +1,56 @@
+package tsfake
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/typescript/main.yarp"))
+
+	files, err := gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "fakes.ts", files[0].Path)
+	expected, err := os.ReadFile("../../test/tsfake/fakes.ts")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(files[0].Content))
+
+	files, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"schema": "../types/shop", "output": "test/fakes.ts"}}, Name)
+	require.NoError(t, err)
+	assert.Equal(t, "test/fakes.ts", files[0].Path)
+	assert.Contains(t, string(files[0].Content), `import type { org } from "../types/shop";`)
+}
+
+func TestGeneratorConflicts(t *testing.T) {
+	fs := idl.NewFileSetFS(fstest.MapFS{
+		"a.yarp": {Data: []byte("package a;\nimport \"b\";\nmessage M { id int32 = 0; }\nservice Users { get(M) -> M; }\n")},
+		"b.yarp": {Data: []byte("package b;\nmessage M { id int32 = 0; }\nservice Users { get(M) -> M; }\n")},
+	})
+	require.NoError(t, fs.Load("a.yarp"))
+	_, err := gen.Run(fs, gen.Options{}, Name)
+	assert.ErrorContains(t, err, "would both be named FakeUsersClient")
+}
+
+func TestGeneratorRenamedFrom(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/renamed/shop.yarp"))
+	files, err := gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	assert.Contains(t, string(files[0].Content), `
+    /**
+     * Renamed to get.
+     * @deprecated
+     */
+    fetch(request: org.example.shop.Order): Promise<org.example.shop.Order> {
+        return this.get(request);
+    }
+`)
+}
@@ -0,0 +1,394 @@
+// Package tsvalidate implements a generator emitting TypeScript functions
+// checking values of messages, as declared by the typescript generator,
+// against the constraints declared by their fields through @min, @max, @len,
+// @pattern, and @required. See idl.FieldConstraints for the semantics of each
+// annotation, and package validator for the same checks performed in Go.
+// Importing the package registers the generator under the name
+// "typescript-validators".
+//
+// Each message is given a function named after its fully-qualified name in
+// camel case, prefixed by validate, such as validateOrgExampleUser, which
+// returns the violations found within a value, including values of the
+// messages it references. Violations are identified by paths made of field
+// names, such as items[2].sku, and carry the messages reported by package
+// validator. Patterns are evaluated as JavaScript regular expressions, whose
+// syntax matches the one of package regexp for common expressions.
+//
+// The generator accepts the following parameters:
+//
+//   - output: path of the emitted file. Defaults to "validators.ts".
+//   - schema: module declaring the message interfaces, as emitted by the
+//     typescript generator. Defaults to "./schema".
+//   - int64: TypeScript type used for 64-bit integers, which must match the
+//     one used by the typescript generator; one of "bigint" (default),
+//     "number", or "string".
+package tsvalidate
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/libyarp/idl/names"
+)
+
+// Name contains the name under which the generator is registered.
+const Name = "typescript-validators"
+
+func init() {
+	gen.Register(Generator{})
+}
+
+// Generator emits a single TypeScript module holding a validation function
+// for each message of a FileSet.
+type Generator struct{}
+
+// Name implements gen.Generator.
+func (Generator) Name() string { return Name }
+
+// Checks implements gen.Checker, reporting declarations renamed due to
+// collisions with TypeScript keywords.
+func (Generator) Checks() []idl.Check {
+	return []idl.Check{names.KeywordCheck(names.TypeScriptTypes, names.JSON)}
+}
+
+// runtime contains declarations shared by validation functions.
+const runtime = `/**
+ * Violation describes a value breaking a constraint.
+ */
+export interface Violation {
+    /**
+     * Identifies the offending value, such as items[2].sku.
+     */
+    path: string;
+    /**
+     * Name of the annotation declaring the constraint.
+     */
+    rule: string;
+    /**
+     * Human-readable description of the problem.
+     */
+    message: string;
+}
+
+function join(path: string, name: string): string {
+    return path === "" ? name : path + "." + name;
+}
+`
+
+// Generate implements gen.Generator.
+func (Generator) Generate(fs *idl.FileSet, opts gen.Options) ([]gen.OutputFile, error) {
+	int64Type := opts.Parameter("int64", "bigint")
+	switch int64Type {
+	case "bigint", "number", "string":
+	default:
+		return nil, fmt.Errorf("invalid int64 parameter %q", int64Type)
+	}
+	e := &emitter{int64Type: int64Type, funcs: map[*idl.Message]string{}, roots: map[string]bool{}}
+	fqns := map[string]idl.FQN{}
+	var messages []*idl.Message
+	for _, p := range fs.Packages() {
+		for _, msg := range p.Messages() {
+			fqn := idl.NewFQN(p.Name(), msg.Name)
+			name := names.TypeScript.Name("validate." + fqn.String())
+			if prev, ok := fqns[name]; ok {
+				return nil, fmt.Errorf("validators of %s and %s would both be named %s", prev, fqn, name)
+			}
+			fqns[name] = fqn
+			e.funcs[msg] = name
+			messages = append(messages, msg)
+		}
+	}
+
+	var body strings.Builder
+	for _, msg := range messages {
+		if err := e.message(&body, msg, fqns[e.funcs[msg]]); err != nil {
+			return nil, err
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by the YARP TypeScript validators generator. DO NOT EDIT.\n")
+	if len(e.roots) > 0 {
+		roots := make([]string, 0, len(e.roots))
+		for r := range e.roots {
+			roots = append(roots, r)
+		}
+		sort.Strings(roots)
+		fmt.Fprintf(&b, "\nimport type { %s } from %q;\n", strings.Join(roots, ", "), opts.Parameter("schema", "./schema"))
+	}
+	b.WriteString("\n" + runtime)
+	for i, p := range e.patterns {
+		if i == 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "const pattern%d = new RegExp(%s, \"u\");\n", i, strconv.Quote(p))
+	}
+	b.WriteString(body.String())
+	return []gen.OutputFile{{Path: opts.Parameter("output", "validators.ts"), Content: []byte(b.String())}}, nil
+}
+
+// emitter renders validation functions.
+type emitter struct {
+	int64Type string
+	funcs     map[*idl.Message]string
+	roots     map[string]bool
+	patterns  []string
+}
+
+// field represents a field of a message, along with its constraints.
+type field struct {
+	idl.Field
+	key         string
+	typ         idl.Type
+	optional    bool
+	constraints idl.Constraints
+}
+
+// message emits the validation function of msg, declared as fqn.
+func (e *emitter) message(b *strings.Builder, msg *idl.Message, fqn idl.FQN) error {
+	keys, err := names.JSON.Fields(msg)
+	if err != nil {
+		return err
+	}
+	var fields []field
+	var visit func(items []idl.FieldItem, oneof bool) error
+	visit = func(items []idl.FieldItem, oneof bool) error {
+		for _, item := range items {
+			switch f := item.(type) {
+			case idl.Field:
+				c, err := idl.FieldConstraints(f)
+				if err != nil {
+					return fmt.Errorf("%s: %w", fqn, err)
+				}
+				t := f.Type
+				if _, ok := f.Annotations.FindByName(idl.RepeatedAnnotation); ok {
+					if _, isArray := t.(idl.Array); !isArray {
+						t = idl.Array{Of: t}
+					}
+				}
+				_, optional := f.Annotations.FindByName(idl.OptionalAnnotation)
+				fields = append(fields, field{Field: f, key: keys[f.Name], typ: t, optional: optional || oneof, constraints: c})
+			case idl.OneOfField:
+				if err := visit(f.Items, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := visit(msg.Fields, false); err != nil {
+		return err
+	}
+
+	q := names.TypeScriptTypes.Qualified(fqn)
+	e.roots[strings.SplitN(q, ".", 2)[0]] = true
+	fmt.Fprintf(b, "\n/**\n * Returns the violations of constraints found within value, of type\n * %s. path is prepended to their paths.\n */\n", fqn)
+	fmt.Fprintf(b, "export function %s(value: %s, path = \"\"): Violation[] {\n", e.funcs[msg], q)
+	b.WriteString("    const violations: Violation[] = [];\n")
+	for _, f := range fields {
+		checks := e.top(f, "v", "p")
+		if len(checks) == 0 && !f.constraints.Required {
+			continue
+		}
+		fmt.Fprintf(b, "    {\n        const v = value[%s];\n        const p = join(path, %s);\n", strconv.Quote(f.key), strconv.Quote(f.Name))
+		if f.constraints.Required {
+			b.WriteString("        if (v === undefined || v === null) {\n")
+			b.WriteString("            " + violation("p", idl.RequiredAnnotation, `"is required"`) + "\n")
+			if len(checks) > 0 {
+				b.WriteString("        } else {\n")
+				writeLines(b, checks, 3)
+			}
+			b.WriteString("        }\n")
+		} else {
+			b.WriteString("        if (v !== undefined && v !== null) {\n")
+			writeLines(b, checks, 3)
+			b.WriteString("        }\n")
+		}
+		b.WriteString("    }\n")
+	}
+	b.WriteString("    return violations;\n}\n")
+	return nil
+}
+
+// top returns the statements checking v, the value of f, whose path is held
+// by p.
+func (e *emitter) top(f field, v, p string) []string {
+	var r []string
+	c := f.constraints
+	if c.Required && !f.optional {
+		if zero := e.zero(f.typ, v); zero != "" {
+			r = append(r, "if ("+zero+") {", "    "+violation(p, idl.RequiredAnnotation, `"is required"`), "}")
+		}
+	}
+	if c.Len != nil {
+		r = append(r, "const n = "+length(f.typ, v)+";")
+		if c.Len.Min == c.Len.Max {
+			r = append(r,
+				fmt.Sprintf("if (n !== %d) {", c.Len.Min),
+				"    "+violation(p, idl.LenAnnotation, fmt.Sprintf("`length ${n} differs from %d`", c.Len.Min)),
+				"}")
+		} else {
+			r = append(r,
+				fmt.Sprintf("if (n < %d || n > %d) {", c.Len.Min, c.Len.Max),
+				"    "+violation(p, idl.LenAnnotation, fmt.Sprintf("`length ${n} is outside range [%d, %d]`", c.Len.Min, c.Len.Max)),
+				"}")
+		}
+	}
+	return append(r, e.value(f, f.typ, v, p, 0)...)
+}
+
+// value returns the statements checking v, a value of type t or an element
+// of the value of f, against constraints applying to values rather than to
+// the field itself. depth distinguishes variables of nested loops.
+func (e *emitter) value(f field, t idl.Type, v, p string, depth int) []string {
+	c := f.constraints
+	switch t := t.(type) {
+	case idl.Primitive:
+		var r []string
+		if c.Min != nil || c.Max != nil {
+			num := v
+			if t.Kind.BitWidth() == 64 && !t.Kind.IsFloat() && e.int64Type == "string" {
+				num = "BigInt(" + v + ")"
+			}
+			var bounds []string
+			if c.Min != nil {
+				lit := e.bound(t.Kind, c.Min)
+				bounds = append(bounds,
+					fmt.Sprintf("if (%s < %s) {", num, lit),
+					"    "+violation(p, idl.MinAnnotation, fmt.Sprintf("`value ${%s} is less than %s`", v, c.Min.RatString())),
+					"}")
+			}
+			if c.Max != nil {
+				lit := e.bound(t.Kind, c.Max)
+				bounds = append(bounds,
+					fmt.Sprintf("if (%s > %s) {", num, lit),
+					"    "+violation(p, idl.MaxAnnotation, fmt.Sprintf("`value ${%s} is greater than %s`", v, c.Max.RatString())),
+					"}")
+			}
+			if t.Kind.IsFloat() {
+				name, bound := idl.MinAnnotation, c.Min
+				if bound == nil {
+					name, bound = idl.MaxAnnotation, c.Max
+				}
+				r = append(r,
+					fmt.Sprintf("if (Number.isNaN(%s)) {", v),
+					"    "+violation(p, name, strconv.Quote("value NaN cannot be compared to "+bound.RatString())),
+					"} else {")
+				for _, l := range bounds {
+					r = append(r, "    "+l)
+				}
+				r = append(r, "}")
+			} else {
+				r = append(r, bounds...)
+			}
+		}
+		if c.Pattern != nil {
+			name := fmt.Sprintf("pattern%d", len(e.patterns))
+			e.patterns = append(e.patterns, c.Pattern.String())
+			r = append(r,
+				fmt.Sprintf("if (!%s.test(%s)) {", name, v),
+				"    "+violation(p, idl.PatternAnnotation, fmt.Sprintf("`value ${JSON.stringify(%s)} does not match ${%s.source}`", v, name)),
+				"}")
+		}
+		return r
+	case idl.Array:
+		if of, ok := t.Of.(idl.Primitive); ok && of.Kind == idl.Uint8 {
+			return nil
+		}
+		i, elem, path := fmt.Sprintf("i%d", depth), fmt.Sprintf("e%d", depth), fmt.Sprintf("p%d", depth)
+		checks := e.value(f, t.Of, elem, path, depth+1)
+		if len(checks) == 0 {
+			return nil
+		}
+		r := []string{
+			fmt.Sprintf("for (let %s = 0; %s < %s.length; %s++) {", i, i, v, i),
+			fmt.Sprintf("    const %s = %s[%s];", elem, v, i),
+			fmt.Sprintf("    const %s = `${%s}[${%s}]`;", path, p, i),
+		}
+		for _, l := range checks {
+			r = append(r, "    "+l)
+		}
+		return append(r, "}")
+	case idl.Map:
+		// Constraints apply to map values only through their own messages.
+		rt, ok := t.Value.(idl.Resolved)
+		if !ok {
+			return nil
+		}
+		k := fmt.Sprintf("k%d", depth)
+		return []string{
+			fmt.Sprintf("for (const %s of Object.keys(%s).sort()) {", k, v),
+			fmt.Sprintf("    violations.push(...%s(%s[%s], `${%s}[${%s}]`));", e.funcs[rt.Message], v, k, p, k),
+			"}",
+		}
+	case idl.Resolved:
+		return []string{fmt.Sprintf("violations.push(...%s(%s, %s));", e.funcs[t.Message], v, p)}
+	}
+	return nil
+}
+
+// bound returns the literal representing v, a bound of values of kind p.
+func (e *emitter) bound(p idl.PrimitiveType, v *big.Rat) string {
+	if p.IsFloat() {
+		f, _ := v.Float64()
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	if p.BitWidth() == 64 && e.int64Type != "number" {
+		return v.Num().String() + "n"
+	}
+	return v.Num().String()
+}
+
+// zero returns an expression determining whether v, a value of type t, is
+// the zero value of t, or an empty string in case values of t cannot be zero.
+func (e *emitter) zero(t idl.Type, v string) string {
+	switch t := t.(type) {
+	case idl.Primitive:
+		switch {
+		case t.Kind == idl.Bool:
+			return v + " === false"
+		case t.Kind == idl.String:
+			return v + ` === ""`
+		case t.Kind.BitWidth() == 64 && !t.Kind.IsFloat() && e.int64Type == "bigint":
+			return v + " === 0n"
+		case t.Kind.BitWidth() == 64 && !t.Kind.IsFloat() && e.int64Type == "string":
+			return "BigInt(" + v + ") === 0n"
+		}
+		return v + " === 0"
+	case idl.Array:
+		return v + ".length === 0"
+	case idl.Map:
+		return "Object.keys(" + v + ").length === 0"
+	}
+	return ""
+}
+
+// length returns an expression evaluating to the length of v, a value of
+// type t, measuring strings in characters.
+func length(t idl.Type, v string) string {
+	switch t.(type) {
+	case idl.Map:
+		return "Object.keys(" + v + ").length"
+	case idl.Primitive:
+		return "[..." + v + "].length"
+	}
+	return v + ".length"
+}
+
+// violation returns a statement recording a violation of rule by the value
+// whose path is held by p, described by the expression message.
+func violation(p, rule, message string) string {
+	return fmt.Sprintf("violations.push({ path: %s, rule: %q, message: %s });", p, rule, message)
+}
+
+// writeLines writes lines indented by depth levels.
+func writeLines(b *strings.Builder, lines []string, depth int) {
+	for _, l := range lines {
+		b.WriteString(strings.Repeat("    ", depth) + l + "\n")
+	}
+}
@@ -0,0 +1,78 @@
+package tsvalidate
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/constraints/user.yarp"))
+
+	files, err := gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "validators.ts", files[0].Path)
+	expected, err := os.ReadFile("../../test/tsvalidate/validators.ts")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(files[0].Content))
+
+	files, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"schema": "../types/accounts", "output": "check/validators.ts", "int64": "string"}}, Name)
+	require.NoError(t, err)
+	assert.Equal(t, "check/validators.ts", files[0].Path)
+	src := string(files[0].Content)
+	assert.Contains(t, src, `import type { org } from "../types/accounts";`)
+	assert.Contains(t, src, "if (BigInt(v) < 1n) {")
+
+	files, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"int64": "number"}}, Name)
+	require.NoError(t, err)
+	assert.Contains(t, string(files[0].Content), "if (v < 1) {")
+
+	_, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"int64": "long"}}, Name)
+	assert.ErrorContains(t, err, `invalid int64 parameter "long"`)
+}
+
+func TestGeneratorNested(t *testing.T) {
+	fs := idl.NewFileSetFS(fstest.MapFS{
+		"a.yarp": {Data: []byte("package a;\nmessage Order {\n    @required @len(1, 10) items map<string, Item> = 0;\n    @len(2) codes array<array<uint8>> = 1;\n    @required paid bool = 2;\n}\nmessage Item {\n    @min(\"0.5\") weight float32 = 0;\n}\n")},
+	})
+	require.NoError(t, fs.Load("a.yarp"))
+	files, err := gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	src := string(files[0].Content)
+	assert.Contains(t, src, `            if (Object.keys(v).length === 0) {
+                violations.push({ path: p, rule: "required", message: "is required" });
+            }
+            const n = Object.keys(v).length;
+            if (n < 1 || n > 10) {
+                violations.push({ path: p, rule: "len", message: `+"`length ${n} is outside range [1, 10]`"+` });
+            }
+            for (const k0 of Object.keys(v).sort()) {
+                violations.push(...validateAItem(v[k0], `+"`${p}[${k0}]`"+`));
+            }
+`)
+	assert.Contains(t, src, "            if (n !== 2) {\n")
+	assert.NotContains(t, src, "for (let i0")
+	assert.Contains(t, src, "            if (v === false) {\n")
+	assert.Contains(t, src, `            if (Number.isNaN(v)) {
+                violations.push({ path: p, rule: "min", message: "value NaN cannot be compared to 1/2" });
+            } else {
+                if (v < 0.5) {
+`)
+}
+
+func TestGeneratorConflicts(t *testing.T) {
+	fs := idl.NewFileSetFS(fstest.MapFS{
+		"a.yarp": {Data: []byte("package a;\nimport \"b\";\nmessage b_m { id int32 = 0; }\n")},
+		"b.yarp": {Data: []byte("package a.b;\nmessage M { id int32 = 0; }\n")},
+	})
+	require.NoError(t, fs.Load("a.yarp"))
+	_, err := gen.Run(fs, gen.Options{}, Name)
+	assert.ErrorContains(t, err, "would both be named validateABM")
+}
@@ -0,0 +1,324 @@
+// Package typescript implements a generator emitting TypeScript declarations
+// for messages and service clients. Importing the package registers the
+// generator under the name "typescript".
+//
+// The generator accepts the following parameters:
+//
+//   - output: path of the emitted file. Defaults to "schema.d.ts".
+//   - int64: TypeScript type used for 64-bit integers; one of "bigint"
+//     (default), "number", or "string".
+//
+// Properties are named after the JSON keys of fields, which may be overridden
+// through @json.name annotations; see names.JSON. Namespaces and interfaces
+// colliding with TypeScript keywords are suffixed by an underscore; see
+// names.TypeScriptTypes.
+//
+// Former names of messages and methods, declared through @renamed_from, are
+// emitted as deprecated aliases: type aliases for messages, and additional
+// client methods for methods. Former names of fields are not emitted, since
+// properties are named after the JSON keys used on the wire.
+//
+// Templates named "header", "message", "alias", and "service" can be replaced
+// through gen.Options.TemplateDir. Messages are provided as values with Name,
+// Doc, Deprecated, and Fields; aliases as values with Name and Target;
+// services as values with Name, Doc, Deprecated, and Methods. The jsdoc
+// function renders documentation comments.
+package typescript
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/libyarp/idl/names"
+)
+
+// Name contains the name under which the generator is registered.
+const Name = "typescript"
+
+// templateName contains the name of the template rendering the emitted file.
+// It defines the "header", "message", "alias", and "service" templates, which
+// can be replaced individually through gen.Options.TemplateDir.
+const templateName = "typescript.d.ts.tmpl"
+
+//go:embed typescript.d.ts.tmpl
+var templates embed.FS
+
+func init() {
+	gen.Register(Generator{})
+}
+
+// Generator emits a TypeScript declaration file containing a namespace for
+// each package of a FileSet, or of a unit when output is split. Messages are
+// represented as interfaces, and services as interfaces named after them,
+// suffixed by Client.
+type Generator struct{}
+
+// Name implements gen.Generator.
+func (Generator) Name() string { return Name }
+
+// Checks implements gen.Checker, reporting declarations renamed due to
+// collisions with TypeScript keywords.
+func (Generator) Checks() []idl.Check {
+	return []idl.Check{names.KeywordCheck(names.TypeScriptTypes, names.JSON)}
+}
+
+// Generate implements gen.Generator.
+func (Generator) Generate(fs *idl.FileSet, opts gen.Options) ([]gen.OutputFile, error) {
+	return generate(fs, nil, opts)
+}
+
+// GenerateUnit implements gen.Partitioner, declaring messages and services
+// declared by u. Namespaces are ambient, so types of other units are
+// referenced by their qualified names, and resolve as long as files emitted
+// for those units are part of the same TypeScript project.
+func (Generator) GenerateUnit(fs *idl.FileSet, u gen.Unit, opts gen.Options) ([]gen.OutputFile, error) {
+	return generate(fs, u.Contains, opts)
+}
+
+func generate(fs *idl.FileSet, include func(idl.Symbol) bool, opts gen.Options) ([]gen.OutputFile, error) {
+	int64Type := opts.Parameter("int64", "bigint")
+	switch int64Type {
+	case "bigint", "number", "string":
+	default:
+		return nil, fmt.Errorf("invalid int64 parameter %q", int64Type)
+	}
+	tmpl, err := gen.LoadTemplates(templates, opts, template.FuncMap{"jsdoc": jsdoc})
+	if err != nil {
+		return nil, err
+	}
+	data, err := newFileData(fs, int64Type, include)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = tmpl.ExecuteTemplate(&buf, templateName, data); err != nil {
+		return nil, err
+	}
+	return []gen.OutputFile{{Path: opts.Parameter("output", "schema.d.ts"), Content: buf.Bytes()}}, nil
+}
+
+type fileData struct {
+	Packages []packageData
+}
+
+type packageData struct {
+	Name     string
+	Messages []messageData
+	Aliases  []aliasData
+	Services []serviceData
+}
+
+// aliasData represents a deprecated alias of a message, named after one of
+// its former names.
+type aliasData struct {
+	Name   string
+	Target string
+}
+
+type messageData struct {
+	Name       string
+	Doc        []string
+	Deprecated bool
+	Fields     []fieldData
+}
+
+type fieldData struct {
+	Name       string
+	Type       string
+	Optional   bool
+	Doc        []string
+	Deprecated bool
+}
+
+type serviceData struct {
+	Name       string
+	Doc        []string
+	Deprecated bool
+	Methods    []methodData
+}
+
+type methodData struct {
+	Name       string
+	Argument   string
+	Return     string
+	Doc        []string
+	Deprecated bool
+}
+
+// newFileData returns the data of the emitted file, declaring messages and
+// services for which include returns true, or all of them, in case include is
+// nil. In the former case, packages without such declarations are omitted.
+func newFileData(fs *idl.FileSet, int64Type string, include func(idl.Symbol) bool) (fileData, error) {
+	var data fileData
+	fqns := map[*idl.Message]idl.FQN{}
+	included := map[idl.Declaration]bool{}
+	for _, s := range fs.Symbols() {
+		if msg := s.Message(); msg != nil {
+			fqns[msg] = s.Name
+		}
+		included[s.Declaration] = include == nil || include(s)
+	}
+	for _, p := range fs.Packages() {
+		m := mapper{pkg: p.Name(), int64Type: int64Type, fqns: fqns}
+		pkg := packageData{Name: names.TypeScriptTypes.Qualified(idl.FQN(p.Name()))}
+		for _, msg := range p.Messages() {
+			if !included[msg] {
+				continue
+			}
+			md := messageData{Name: names.TypeScriptTypes.Name(msg.Name), Doc: msg.Comments, Deprecated: deprecated(msg.Annotations)}
+			fieldNames, err := names.JSON.Fields(msg)
+			if err != nil {
+				return data, err
+			}
+			md.Fields = m.fields(msg.Fields, fieldNames, false)
+			pkg.Messages = append(pkg.Messages, md)
+			for _, old := range msg.Annotations.RenamedFrom() {
+				pkg.Aliases = append(pkg.Aliases, aliasData{Name: names.TypeScriptTypes.Name(old), Target: md.Name})
+			}
+		}
+		for _, svc := range p.Services() {
+			if !included[svc] {
+				continue
+			}
+			sd := serviceData{Name: svc.Name, Doc: svc.Comments, Deprecated: deprecated(svc.Annotations)}
+			for _, method := range svc.Methods {
+				md := methodData{
+					Name:       method.Name,
+					Argument:   m.ref(method.Argument),
+					Return:     m.returnType(method.Return),
+					Doc:        method.Comments,
+					Deprecated: deprecated(method.Annotations),
+				}
+				sd.Methods = append(sd.Methods, md)
+				for _, old := range method.Annotations.RenamedFrom() {
+					alias := md
+					alias.Name, alias.Doc, alias.Deprecated = old, []string{"Renamed to " + md.Name + "."}, true
+					sd.Methods = append(sd.Methods, alias)
+				}
+			}
+			pkg.Services = append(pkg.Services, sd)
+		}
+		if include == nil || len(pkg.Messages) > 0 || len(pkg.Services) > 0 {
+			data.Packages = append(data.Packages, pkg)
+		}
+	}
+	return data, nil
+}
+
+// mapper maps YARP types to TypeScript, from within a given package.
+type mapper struct {
+	pkg       string
+	int64Type string
+	fqns      map[*idl.Message]idl.FQN
+}
+
+// fields flattens items into fieldData values, named after fieldNames. Cases
+// of oneof fields are emitted as optional properties.
+func (m mapper) fields(items []idl.FieldItem, fieldNames map[string]string, inOneOf bool) []fieldData {
+	var r []fieldData
+	for _, item := range items {
+		switch v := item.(type) {
+		case idl.Field:
+			t := m.typeOf(v.Type)
+			if _, ok := v.Annotations.FindByName(idl.RepeatedAnnotation); ok {
+				if _, isArray := v.Type.(idl.Array); !isArray {
+					t += "[]"
+				}
+			}
+			_, optional := v.Annotations.FindByName(idl.OptionalAnnotation)
+			r = append(r, fieldData{
+				Name:       fieldNames[v.Name],
+				Type:       t,
+				Optional:   optional || inOneOf,
+				Doc:        v.Comments,
+				Deprecated: deprecated(v.Annotations),
+			})
+		case idl.OneOfField:
+			r = append(r, m.fields(v.Items, fieldNames, true)...)
+		}
+	}
+	return r
+}
+
+func (m mapper) typeOf(t idl.Type) string {
+	switch v := t.(type) {
+	case idl.Primitive:
+		return m.primitive(v.Kind)
+	case idl.Array:
+		return m.typeOf(v.Of) + "[]"
+	case idl.Map:
+		key := m.primitive(v.Key)
+		if key == "bigint" || key == "boolean" {
+			key = "string"
+		}
+		return fmt.Sprintf("Record<%s, %s>", key, m.typeOf(v.Value))
+	case idl.Resolved:
+		return m.name(v.Name)
+	}
+	return "unknown"
+}
+
+func (m mapper) primitive(p idl.PrimitiveType) string {
+	if p.BitWidth() == 64 && !p.IsFloat() {
+		return m.int64Type
+	}
+	info, _ := p.Info()
+	return info.TypeScriptType
+}
+
+// name returns n relative to the mapper's package.
+func (m mapper) name(n idl.FQN) string {
+	if n.Package() == m.pkg {
+		return names.TypeScriptTypes.Name(n.Name())
+	}
+	return names.TypeScriptTypes.Qualified(n)
+}
+
+func (m mapper) ref(t idl.TypeRef) string {
+	if t.IsVoid() {
+		return ""
+	}
+	if n, ok := m.fqns[t.Target]; ok {
+		return m.name(n)
+	}
+	return m.name(t.FQN().Qualify(m.pkg))
+}
+
+func (m mapper) returnType(t idl.TypeRef) string {
+	switch {
+	case t.IsVoid():
+		return "Promise<void>"
+	case t.Streaming:
+		return "AsyncIterable<" + m.ref(t) + ">"
+	}
+	return "Promise<" + m.ref(t) + ">"
+}
+
+func deprecated(a idl.AnnotationCollection) bool {
+	_, ok := a.FindByName(idl.DeprecatedAnnotation)
+	return ok
+}
+
+// jsdoc renders lines as a JSDoc comment indented by indent, including a
+// @deprecated tag when requested. An empty string is returned when there is
+// nothing to document.
+func jsdoc(indent string, lines []string, deprecated bool) string {
+	if deprecated {
+		lines = append(append([]string{}, lines...), "@deprecated")
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(indent + "/**\n")
+	for _, l := range lines {
+		b.WriteString(strings.TrimRight(indent+" * "+l, " ") + "\n")
+	}
+	b.WriteString(indent + " */\n")
+	return b.String()
+}
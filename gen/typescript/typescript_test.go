@@ -0,0 +1,128 @@
+package typescript
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/libyarp/idl/names"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/typescript/main.yarp"))
+
+	files, err := gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "schema.d.ts", files[0].Path)
+	expected, err := os.ReadFile("../../test/typescript/schema.d.ts")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(files[0].Content))
+
+	files, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"int64": "number", "output": "types/shop.d.ts"}}, Name)
+	require.NoError(t, err)
+	assert.Equal(t, "types/shop.d.ts", files[0].Path)
+	assert.Contains(t, string(files[0].Content), "        id: number;\n")
+	assert.Contains(t, string(files[0].Content), "quantities: Record<number, number>;")
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "header.tmpl"), []byte("{{define \"header\"}}// Copyright Example Inc.\n{{end}}"), 0o644))
+	files, err = gen.Run(fs, gen.Options{TemplateDir: dir}, Name)
+	require.NoError(t, err)
+	assert.Equal(t, "// Copyright Example Inc.\n"+string(expected[strings.IndexByte(string(expected), '\n')+1:]), string(files[0].Content))
+
+	_, err = gen.Run(fs, gen.Options{Parameters: map[string]string{"int64": "long"}}, Name)
+	assert.ErrorContains(t, err, `invalid int64 parameter "long"`)
+}
+
+func TestGeneratorSplit(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/typescript/main.yarp"))
+
+	root, err := filepath.Abs("../../test/typescript")
+	require.NoError(t, err)
+	files, err := gen.Run(fs, gen.Options{Layout: gen.Layout{Split: gen.SplitSource, Root: root}}, Name)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "types.d.ts", files[0].Path)
+	assert.Equal(t, "main.d.ts", files[1].Path)
+
+	// Each file declares the namespace of its unit, and together they match
+	// the output for the whole FileSet.
+	expected, err := os.ReadFile("../../test/typescript/schema.d.ts")
+	require.NoError(t, err)
+	header, types, _ := strings.Cut(string(files[0].Content), "\n")
+	_, shop, _ := strings.Cut(string(files[1].Content), "\n")
+	assert.Equal(t, string(expected), header+"\n"+types+shop)
+	assert.Contains(t, types, "export declare namespace org.example.types {")
+	assert.NotContains(t, types, "org.example.shop")
+	assert.Contains(t, shop, "shipping: org.example.types.Address;")
+}
+
+func TestGeneratorFieldNames(t *testing.T) {
+	fs := idl.NewFileSetFS(fstest.MapFS{"main.yarp": {Data: []byte(`package org.example;
+
+message Contact {
+    @json.name("fullName")
+    name string = 0;
+    fullName string = 1;
+}
+`)}})
+	require.NoError(t, fs.Load("main.yarp"))
+	_, err := gen.Run(fs, gen.Options{}, Name)
+	assert.ErrorIs(t, err, names.CollisionError{Message: "Contact", Name: "fullName", First: "name", Second: "fullName"})
+}
+
+func TestGeneratorKeywords(t *testing.T) {
+	fs := idl.NewFileSetFS(fstest.MapFS{"main.yarp": {Data: []byte(`package org.example.default;
+
+message object {
+    class string = 0;
+}
+
+service Objects {
+    get(object) -> object;
+}
+`)}})
+	require.NoError(t, fs.Load("main.yarp"))
+	files, err := gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	src := string(files[0].Content)
+	assert.Contains(t, src, "export declare namespace org.example.default_ {")
+	assert.Contains(t, src, "export interface object_ {\n        class: string;\n")
+	assert.Contains(t, src, "get(request: object_): Promise<object_>;")
+
+	diags := fs.Validate(Generator{}.Checks()...)
+	require.Len(t, diags, 2)
+	assert.Equal(t, names.CodeKeywordCollision, diags[0].Code)
+}
+
+func TestGeneratorRenamedFrom(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../../test/renamed/shop.yarp"))
+	files, err := gen.Run(fs, gen.Options{}, Name)
+	require.NoError(t, err)
+	src := string(files[0].Content)
+	assert.Contains(t, src, `
+    /**
+     * Renamed to Order.
+     * @deprecated
+     */
+    export type Purchase = Order;
+`)
+	assert.Contains(t, src, `
+        get(request: Order): Promise<Order>;
+        /**
+         * Renamed to get.
+         * @deprecated
+         */
+        fetch(request: Order): Promise<Order>;
+`)
+}
@@ -0,0 +1,59 @@
+package idl
+
+import (
+	"sort"
+	"strings"
+)
+
+// GeneratorOptions represents options targeting a single generator, declared
+// through annotations namespaced by the generator's target, such as
+// @go.package("contactspb") or @ts.enum_as_union. Values are keyed by option
+// name, excluding the target prefix.
+type GeneratorOptions map[string][]string
+
+// GeneratorOptions returns options declared for the provided target, such as
+// "go" or "json". In case an option is declared more than once, the last
+// declaration wins.
+func (a AnnotationCollection) GeneratorOptions(target string) GeneratorOptions {
+	opts := GeneratorOptions{}
+	for _, v := range a {
+		if name, ok := strings.CutPrefix(v.Name, target+"."); ok && name != "" {
+			opts[name] = v.Value
+		}
+	}
+	return opts
+}
+
+// GeneratorTargets returns the sorted list of targets for which namespaced
+// annotations are present.
+func (a AnnotationCollection) GeneratorTargets() []string {
+	seen := map[string]bool{}
+	var targets []string
+	for _, v := range a {
+		target, _, ok := strings.Cut(v.Name, ".")
+		if ok && target != "" && !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// String returns the single value of the option identified by name, along
+// with a boolean indicating whether the option is present with exactly one
+// value.
+func (o GeneratorOptions) String(name string) (string, bool) {
+	v, ok := o[name]
+	if !ok || len(v) != 1 {
+		return "", false
+	}
+	return v[0], true
+}
+
+// Bool returns whether the option identified by name is set, either as a flag
+// without values, such as @ts.enum_as_union, or with a single "true" value.
+func (o GeneratorOptions) Bool(name string) bool {
+	v, ok := o[name]
+	return ok && (len(v) == 0 || len(v) == 1 && v[0] == "true")
+}
@@ -0,0 +1,46 @@
+package idl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratorOptions(t *testing.T) {
+	src := `package org.example;
+
+@go.package("contactspb")
+@ts.enum_as_union
+message Contact {
+    @json.name("fullName")
+    @go.tags("a", "b")
+    name string = 0;
+    @ts.readonly("false")
+    id int64 = 1;
+}
+`
+	file, err := ParseSource([]byte(src), ParseOptions{})
+	require.NoError(t, err)
+	m, ok := file.MessageByName("Contact")
+	require.True(t, ok)
+
+	assert.Equal(t, []string{"go", "ts"}, m.Annotations.GeneratorTargets())
+	pkg, ok := m.Annotations.GeneratorOptions("go").String("package")
+	assert.True(t, ok)
+	assert.Equal(t, "contactspb", pkg)
+	assert.True(t, m.Annotations.GeneratorOptions("ts").Bool("enum_as_union"))
+	assert.Empty(t, m.Annotations.GeneratorOptions("json"))
+
+	name := m.Fields[0].(Field)
+	jsonName, ok := name.Annotations.GeneratorOptions("json").String("name")
+	assert.True(t, ok)
+	assert.Equal(t, "fullName", jsonName)
+	_, ok = name.Annotations.GeneratorOptions("go").String("tags")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"a", "b"}, name.Annotations.GeneratorOptions("go")["tags"])
+
+	id := m.Fields[1].(Field)
+	assert.False(t, id.Annotations.GeneratorOptions("ts").Bool("readonly"))
+	assert.Empty(t, id.Annotations.GeneratorOptions("go"))
+}
@@ -0,0 +1,145 @@
+package idl
+
+// Graph represents a directed graph of dependencies between nodes of type T.
+// An edge from a to b indicates a depends on b.
+type Graph[T comparable] struct {
+	nodes      []T
+	edges      map[T][]T
+	dependents map[T][]T
+}
+
+func newGraph[T comparable]() *Graph[T] {
+	return &Graph[T]{
+		edges:      map[T][]T{},
+		dependents: map[T][]T{},
+	}
+}
+
+func (g *Graph[T]) addNode(n T) {
+	if _, ok := g.edges[n]; ok {
+		return
+	}
+	g.nodes = append(g.nodes, n)
+	g.edges[n] = nil
+}
+
+func (g *Graph[T]) addEdge(from, to T) {
+	for _, n := range g.edges[from] {
+		if n == to {
+			return
+		}
+	}
+	g.edges[from] = append(g.edges[from], to)
+	g.dependents[to] = append(g.dependents[to], from)
+}
+
+// Nodes returns all nodes of the graph, in the order they were added.
+func (g *Graph[T]) Nodes() []T {
+	return append([]T{}, g.nodes...)
+}
+
+// DependenciesOf returns the nodes n directly depends on.
+func (g *Graph[T]) DependenciesOf(n T) []T {
+	return append([]T{}, g.edges[n]...)
+}
+
+// DependentsOf returns the nodes directly depending on n.
+func (g *Graph[T]) DependentsOf(n T) []T {
+	return append([]T{}, g.dependents[n]...)
+}
+
+// Affected returns n along with all nodes depending on it, directly or
+// transitively. That is, the set of nodes that must be invalidated once n
+// changes.
+func (g *Graph[T]) Affected(n T) []T {
+	seen := map[T]bool{n: true}
+	queue := []T{n}
+	for i := 0; i < len(queue); i++ {
+		for _, d := range g.dependents[queue[i]] {
+			if !seen[d] {
+				seen[d] = true
+				queue = append(queue, d)
+			}
+		}
+	}
+	return queue
+}
+
+// TopoSort returns all nodes of the graph ordered so that every node appears
+// after the nodes it depends on, along with a boolean indicating whether the
+// graph is acyclic. Nodes taking part in a cycle are kept together, ordered
+// as they were added to the graph.
+func (g *Graph[T]) TopoSort() ([]T, bool) {
+	order := map[T]int{}
+	for i, n := range g.nodes {
+		order[n] = i
+	}
+	acyclic := true
+	result := make([]T, 0, len(g.nodes))
+	for _, c := range stronglyConnected(g.nodes, g.DependenciesOf) {
+		if len(c) > 1 || g.dependsOnItself(c[0]) {
+			acyclic = false
+		}
+		for i := 1; i < len(c); i++ {
+			for j := i; j > 0 && order[c[j]] < order[c[j-1]]; j-- {
+				c[j], c[j-1] = c[j-1], c[j]
+			}
+		}
+		result = append(result, c...)
+	}
+	return result, acyclic
+}
+
+// Cycles returns one cycle for each group of nodes depending on each other.
+// Each cycle starts and ends with the same node.
+func (g *Graph[T]) Cycles() [][]T {
+	return findCycles(g.nodes, g.DependenciesOf)
+}
+
+func (g *Graph[T]) dependsOnItself(n T) bool {
+	for _, m := range g.edges[n] {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
+
+// DependencyGraph holds dependency graphs computed from a FileSet.
+type DependencyGraph struct {
+	// Files contains an edge from each file to every file it imports.
+	Files *Graph[*File]
+
+	// Messages contains an edge from each message to every message
+	// referenced by its fields.
+	Messages *Graph[*Message]
+}
+
+// DependencyGraph returns graphs describing dependencies between files and
+// between messages loaded into the FileSet. Types are resolved before the
+// graph is computed; references that cannot be resolved are ignored.
+func (f *FileSet) DependencyGraph() DependencyGraph {
+	f.Resolve()
+	files := newGraph[*File]()
+	for _, file := range f.fileOrder {
+		files.addNode(file)
+	}
+	for _, file := range f.fileOrder {
+		for _, p := range f.imports[file] {
+			if imported, ok := f.files[p]; ok {
+				files.addEdge(file, imported)
+			}
+		}
+	}
+
+	messages := newGraph[*Message]()
+	for _, m := range f.allMessages {
+		messages.addNode(m)
+	}
+	for _, m := range f.allMessages {
+		for _, e := range messageEdges(m) {
+			messages.addEdge(m, e.to)
+		}
+	}
+	return DependencyGraph{Files: files, Messages: messages}
+}
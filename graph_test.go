@@ -0,0 +1,69 @@
+package idl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fileNames(files []*File) []string {
+	var r []string
+	for _, f := range files {
+		r = append(r, filepath.Base(f.SourcePath))
+	}
+	return r
+}
+
+func messageNames(messages []*Message) []string {
+	var r []string
+	for _, m := range messages {
+		r = append(r, m.Name)
+	}
+	return r
+}
+
+func TestDependencyGraphFiles(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/diamond/main.yarp"))
+	g := fs.DependencyGraph()
+
+	order, acyclic := g.Files.TopoSort()
+	assert.True(t, acyclic)
+	assert.Equal(t, []string{"base.yarp", "left.yarp", "right.yarp", "main.yarp"}, fileNames(order))
+	assert.Empty(t, g.Files.Cycles())
+
+	base, ok := fs.FileByPath("./test/diamond/base.yarp")
+	require.True(t, ok)
+	assert.Equal(t, []string{"left.yarp", "right.yarp"}, fileNames(g.Files.DependentsOf(base)))
+	assert.Equal(t, []string{"base.yarp", "left.yarp", "right.yarp", "main.yarp"}, fileNames(g.Files.Affected(base)))
+
+	main, ok := fs.FileByPath("./test/diamond/main.yarp")
+	require.True(t, ok)
+	assert.Equal(t, []string{"left.yarp", "right.yarp"}, fileNames(g.Files.DependenciesOf(main)))
+	assert.Equal(t, []string{"main.yarp"}, fileNames(g.Files.Affected(main)))
+
+	messages, _ := g.Messages.TopoSort()
+	assert.Equal(t, []string{"Base", "Left", "Right", "Top"}, messageNames(messages))
+}
+
+func TestDependencyGraphMessageCycles(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/recursive.yarp"))
+	g := fs.DependencyGraph()
+
+	order, acyclic := g.Messages.TopoSort()
+	assert.False(t, acyclic)
+	assert.Equal(t, []string{"Node", "Impossible", "Ping", "Pong"}, messageNames(order))
+
+	var cycles [][]string
+	for _, c := range g.Messages.Cycles() {
+		cycles = append(cycles, messageNames(c))
+	}
+	assert.Equal(t, [][]string{
+		{"Node", "Node"},
+		{"Impossible", "Impossible"},
+		{"Ping", "Pong", "Ping"},
+	}, cycles)
+}
@@ -0,0 +1,128 @@
+package idl
+
+import "iter"
+
+// All returns an iterator over all declarations present in the File, in the
+// order they appear in the source.
+func (f *File) All() iter.Seq[Declaration] {
+	return func(yield func(Declaration) bool) {
+		for _, d := range f.Tree {
+			if !yield(d) {
+				return
+			}
+		}
+	}
+}
+
+// AllMessages returns an iterator over all messages declared by the File.
+func (f *File) AllMessages() iter.Seq[*Message] {
+	return OfType[*Message](f.All())
+}
+
+// AllServices returns an iterator over all services declared by the File.
+func (f *File) AllServices() iter.Seq[*Service] {
+	return OfType[*Service](f.All())
+}
+
+// AllFields returns an iterator over all items declared by the Message, being
+// either Field or OneOfField values.
+func (m *Message) AllFields() iter.Seq[FieldItem] {
+	return func(yield func(FieldItem) bool) {
+		for _, f := range m.Fields {
+			if !yield(f) {
+				return
+			}
+		}
+	}
+}
+
+// AllMethods returns an iterator over all methods declared by the Service.
+func (s *Service) AllMethods() iter.Seq[*Method] {
+	return func(yield func(*Method) bool) {
+		for i := range s.Methods {
+			if !yield(&s.Methods[i]) {
+				return
+			}
+		}
+	}
+}
+
+// AllMessages returns an iterator over all messages provided by the package
+// being processed by the FileSet.
+func (f *FileSet) AllMessages() iter.Seq[*Message] {
+	return func(yield func(*Message) bool) {
+		v, ok := f.packages[f.packageName]
+		if !ok {
+			return
+		}
+		for _, m := range v.messages {
+			if !yield(m) {
+				return
+			}
+		}
+	}
+}
+
+// AllServices returns an iterator over all services provided by the package
+// being processed by the FileSet.
+func (f *FileSet) AllServices() iter.Seq[*Service] {
+	return func(yield func(*Service) bool) {
+		v, ok := f.packages[f.packageName]
+		if !ok {
+			return
+		}
+		for _, s := range v.services {
+			if !yield(s) {
+				return
+			}
+		}
+	}
+}
+
+// Filter takes an iterator and a predicate, and returns an iterator yielding
+// only values for which the predicate returns true.
+func Filter[T any](seq iter.Seq[T], fn func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if fn(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// OfType takes an iterator and returns an iterator yielding only values of type
+// T. For instance, OfType[Field](msg.AllFields()) yields only plain fields of
+// a message, skipping OneOfField values.
+func OfType[T any, V any](seq iter.Seq[V]) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if t, ok := any(v).(T); ok && !yield(t) {
+				return
+			}
+		}
+	}
+}
+
+// Annotated returns a predicate for Filter matching nodes containing an
+// annotation with the provided name. For instance,
+// Filter(msg.AllFields(), Annotated[FieldItem](OptionalAnnotation)).
+func Annotated[T Node](name string) func(T) bool {
+	return func(v T) bool {
+		var a AnnotationCollection
+		switch n := any(v).(type) {
+		case Field:
+			a = n.Annotations
+		case OneOfField:
+			a = n.Annotations
+		case *Message:
+			a = n.Annotations
+		case *Service:
+			a = n.Annotations
+		case *Method:
+			a = n.Annotations
+		}
+		_, ok := a.FindByName(name)
+		return ok
+	}
+}
@@ -0,0 +1,38 @@
+package idl
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIterators(t *testing.T) {
+	f, err := ParseSource([]byte(file), ParseOptions{})
+	require.NoError(t, err)
+
+	var names []string
+	for m := range f.AllMessages() {
+		names = append(names, m.Name)
+	}
+	assert.Equal(t, f.DeclaredMessages, names)
+
+	external := Filter(f.AllServices(), func(s *Service) bool {
+		return strings.Contains(s.Name, "External")
+	})
+	services := slices.Collect(external)
+	require.Len(t, services, 1)
+	assert.Equal(t, "ServiceUsingExternalTypes", services[0].Name)
+
+	msg, ok := f.MessageByName("RandomBytesResponse")
+	require.True(t, ok)
+	repeated := slices.Collect(Filter(msg.AllFields(), Annotated[FieldItem](RepeatedAnnotation)))
+	require.Len(t, repeated, 1)
+	assert.Equal(t, "data", repeated[0].(Field).Name)
+
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/fixture/test.yarp"))
+	assert.Equal(t, fs.Messages, slices.Collect(fs.AllMessages()))
+}
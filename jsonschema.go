@@ -0,0 +1,236 @@
+package idl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// JSONSchemaDialect contains the URI of the JSON Schema dialect used by
+// ExportJSONSchema.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// ExportJSONSchema returns a JSON Schema (draft 2020-12) describing the JSON
+// representation of m, which must have been resolved by a FileSet. Messages
+// referenced by m are described under $defs, keyed by their FQN, and
+// references to m itself point to the root schema.
+//
+// Fields are required unless annotated with @optional, and cases of a oneof
+// are mutually exclusive, one of them being required. 64-bit integers are
+// represented as strings, as described by PrimitiveInfo.JSONType, arrays of
+// uint8 as base64-encoded strings, and map keys are always strings. Fields are
+// named as described by JSONFieldName.
+func ExportJSONSchema(m *Message) ([]byte, error) {
+	e := newJSONSchemaExporter("#/$defs/")
+	e.root = m
+	schema, err := e.message(m)
+	if err != nil {
+		return nil, err
+	}
+	schema["$schema"] = JSONSchemaDialect
+	schema["title"] = m.Name
+	if len(e.defs) > 0 {
+		schema["$defs"] = e.defs
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// JSONSchemaDefinitions returns JSON Schemas describing the provided messages
+// and all messages they reference, keyed by FQN, as used by ExportJSONSchema.
+// References between schemas are made through refPrefix followed by the FQN
+// of the referenced message, such as #/components/schemas/ in OpenAPI
+// documents.
+func JSONSchemaDefinitions(messages map[FQN]*Message, refPrefix string) (map[string]json.RawMessage, error) {
+	e := newJSONSchemaExporter(refPrefix)
+	names := make([]string, 0, len(messages))
+	for name, m := range messages {
+		e.names[m] = name.String()
+		names = append(names, name.String())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := e.ref(Resolved{Name: FQN(name), Message: messages[FQN(name)]}); err != nil {
+			return nil, err
+		}
+	}
+	r := make(map[string]json.RawMessage, len(e.defs))
+	for name, def := range e.defs {
+		data, err := json.Marshal(def)
+		if err != nil {
+			return nil, err
+		}
+		r[name] = data
+	}
+	return r, nil
+}
+
+type jsonSchemaExporter struct {
+	root   *Message
+	prefix string
+	names  map[*Message]string
+	defs   map[string]any
+}
+
+func newJSONSchemaExporter(prefix string) *jsonSchemaExporter {
+	return &jsonSchemaExporter{prefix: prefix, names: map[*Message]string{}, defs: map[string]any{}}
+}
+
+func (e *jsonSchemaExporter) message(m *Message) (map[string]any, error) {
+	properties := map[string]any{}
+	required := []string{}
+	var oneOfs []any
+	for _, item := range m.Fields {
+		switch v := item.(type) {
+		case Field:
+			s, err := e.field(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", m.Name, v.Name, err)
+			}
+			name := JSONFieldName(v)
+			properties[name] = s
+			if _, ok := v.Annotations.FindByName(OptionalAnnotation); !ok {
+				required = append(required, name)
+			}
+		case OneOfField:
+			var cases []any
+			for _, f := range allFields(v.Items) {
+				s, err := e.field(f)
+				if err != nil {
+					return nil, fmt.Errorf("%s.%s: %w", m.Name, f.Name, err)
+				}
+				properties[JSONFieldName(f)] = s
+				cases = append(cases, map[string]any{"required": []string{JSONFieldName(f)}})
+			}
+			oneOfs = append(oneOfs, map[string]any{"oneOf": cases})
+		}
+	}
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	switch len(oneOfs) {
+	case 0:
+	case 1:
+		schema["oneOf"] = oneOfs[0].(map[string]any)["oneOf"]
+	default:
+		schema["allOf"] = oneOfs
+	}
+	annotateJSONSchema(schema, m.Comments, m.Annotations)
+	return schema, nil
+}
+
+func (e *jsonSchemaExporter) field(f Field) (map[string]any, error) {
+	s, err := e.typeSchema(f.Type)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := f.Annotations.FindByName(RepeatedAnnotation); ok {
+		if _, isArray := f.Type.(Array); !isArray {
+			s = map[string]any{"type": "array", "items": s}
+		}
+	}
+	annotateJSONSchema(s, f.Comments, f.Annotations)
+	return s, nil
+}
+
+func (e *jsonSchemaExporter) typeSchema(t Type) (map[string]any, error) {
+	switch v := t.(type) {
+	case Primitive:
+		return primitiveJSONSchema(v.Kind), nil
+	case Array:
+		if p, ok := v.Of.(Primitive); ok && p.Kind == Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := e.typeSchema(v.Of)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case Map:
+		value, err := e.typeSchema(v.Value)
+		if err != nil {
+			return nil, err
+		}
+		s := map[string]any{"type": "object", "additionalProperties": value}
+		if v.Key != String && v.Key != Bool && !v.Key.IsFloat() {
+			s["propertyNames"] = map[string]any{"pattern": integerPattern(v.Key.IsSigned())}
+		}
+		return s, nil
+	case Resolved:
+		return e.ref(v)
+	case Unresolved:
+		return nil, fmt.Errorf("unresolved type %s", v.Name)
+	}
+	return nil, fmt.Errorf("unsupported type %T", t)
+}
+
+func (e *jsonSchemaExporter) ref(r Resolved) (map[string]any, error) {
+	if r.Message == e.root {
+		return map[string]any{"$ref": "#"}, nil
+	}
+	name, ok := e.names[r.Message]
+	if !ok {
+		name = r.Name.String()
+		e.names[r.Message] = name
+	}
+	if _, ok = e.defs[name]; !ok {
+		e.defs[name] = nil // Reserve the name while resolving recursive references.
+		s, err := e.message(r.Message)
+		if err != nil {
+			return nil, err
+		}
+		s["title"] = r.Message.Name
+		e.defs[name] = s
+	}
+	return map[string]any{"$ref": e.prefix + name}, nil
+}
+
+func primitiveJSONSchema(p PrimitiveType) map[string]any {
+	info, _ := p.Info()
+	s := map[string]any{"type": info.JSONType}
+	switch {
+	case info.JSONType == "string" && info.BitWidth == 64:
+		s["pattern"] = integerPattern(info.Signed)
+	case info.JSONType == "integer" && info.Signed:
+		s["minimum"] = int64(-1) << (info.BitWidth - 1)
+		s["maximum"] = int64(1)<<(info.BitWidth-1) - 1
+	case info.JSONType == "integer":
+		s["minimum"] = 0
+		s["maximum"] = uint64(math.MaxUint64) >> (64 - info.BitWidth)
+	}
+	return s
+}
+
+// JSONNameOption contains the name of the option of the "json" generator
+// target overriding the name under which a field is represented in JSON, as
+// in @json.name("fullName").
+const JSONNameOption = "name"
+
+// JSONFieldName returns the name of the member representing f in JSON
+// documents: the value of its @json.name annotation, if any, or its name.
+func JSONFieldName(f Field) string {
+	if n, ok := f.Annotations.GeneratorOptions("json").String(JSONNameOption); ok {
+		return n
+	}
+	return f.Name
+}
+
+func integerPattern(signed bool) string {
+	if signed {
+		return "^-?[0-9]+$"
+	}
+	return "^[0-9]+$"
+}
+
+func annotateJSONSchema(s map[string]any, comments []string, a AnnotationCollection) {
+	if len(comments) > 0 {
+		s["description"] = strings.Join(comments, " ")
+	}
+	if _, ok := a.FindByName(DeprecatedAnnotation); ok {
+		s["deprecated"] = true
+	}
+}
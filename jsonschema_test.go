@@ -0,0 +1,68 @@
+package idl
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportJSONSchema(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/typescript/main.yarp"))
+	require.Empty(t, fs.Resolve())
+	order, ok := fs.FindMessage("Order")
+	require.True(t, ok)
+	data, err := ExportJSONSchema(order)
+	require.NoError(t, err)
+	expected, err := os.ReadFile("./test/jsonschema/order.json")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(data))
+
+	fs = NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/recursive.yarp"))
+	fs.Resolve()
+	node, ok := fs.FindMessage("Node")
+	require.True(t, ok)
+	data, err = ExportJSONSchema(node)
+	require.NoError(t, err)
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(data, &schema))
+	props := schema["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "array", "items": map[string]any{"$ref": "#"}}, props["children"])
+	assert.Equal(t, []any{"value", "children"}, schema["required"])
+	assert.NotContains(t, schema, "$defs")
+
+	ping, ok := fs.FindMessage("Ping")
+	require.True(t, ok)
+	data, err = ExportJSONSchema(ping)
+	require.NoError(t, err)
+	schema = nil
+	require.NoError(t, json.Unmarshal(data, &schema))
+	pong := schema["$defs"].(map[string]any)["org.example.recursive.Pong"].(map[string]any)
+	assert.Equal(t, map[string]any{"$ref": "#"}, pong["properties"].(map[string]any)["ping"])
+
+	_, err = ExportJSONSchema(&Message{Name: "Broken", Fields: []FieldItem{Field{Name: "missing", Type: Unresolved{Name: "Missing"}}}})
+	assert.EqualError(t, err, "Broken.missing: unresolved type Missing")
+
+	data, err = ExportJSONSchema(&Message{Name: "Blob", Fields: []FieldItem{
+		Field{Name: "data", Type: Array{Of: Primitive{Kind: Uint8}}, Annotations: AnnotationCollection{{Name: "json.name", Value: []string{"payload"}}}},
+	}})
+	require.NoError(t, err)
+	schema = nil
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, map[string]any{"payload": map[string]any{"type": "string", "contentEncoding": "base64"}}, schema["properties"])
+	assert.Equal(t, []any{"payload"}, schema["required"])
+
+	fs = NewFileSet()
+	require.NoError(t, fs.Load("./test/typescript/main.yarp"))
+	fs.Resolve()
+	order, _ = fs.FindMessage("Order")
+	defs, err := JSONSchemaDefinitions(map[FQN]*Message{"org.example.shop.Order": order}, "#/components/schemas/")
+	require.NoError(t, err)
+	assert.Len(t, defs, 4)
+	assert.Contains(t, string(defs["org.example.shop.Order"]), `"$ref":"#/components/schemas/org.example.types.Address"`)
+	assert.Contains(t, string(defs["org.example.types.Address"]), `"title":"Address"`)
+}
@@ -0,0 +1,16 @@
+package idl
+
+// checkLimits returns an error in case loading the file under path, imported
+// through chain, exceeds limits configured through SetOptions.
+func (f *FileSet) checkLimits(chain []string, path string) error {
+	if max := f.options.MaxImportDepth; max > 0 && len(chain) > max {
+		return ImportDepthExceededError{
+			Chain: append(append([]string{}, chain...), path),
+			Max:   max,
+		}
+	}
+	if max := f.options.MaxFiles; max > 0 && len(f.loadedFiles) >= max {
+		return TooManyFilesError{Path: path, Max: max}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package idl
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetLimits(t *testing.T) {
+	entry := writeImportTree(t, t.TempDir(), 64)
+
+	fs := NewFileSet()
+	fs.SetOptions(FileSetOptions{MaxImportDepth: 3})
+	err := fs.Load(entry)
+	var depth ImportDepthExceededError
+	require.ErrorAs(t, err, &depth)
+	assert.Equal(t, 3, depth.Max)
+	assert.Len(t, depth.Chain, 5)
+	assert.Contains(t, err.Error(), "import depth exceeds limit of 3")
+
+	fs = NewFileSet()
+	fs.SetOptions(FileSetOptions{MaxFiles: 10})
+	err = fs.Load(entry)
+	var files TooManyFilesError
+	require.ErrorAs(t, err, &files)
+	assert.Equal(t, 10, files.Max)
+	assert.Len(t, fs.loadedFiles, 10)
+
+	fs = NewFileSet()
+	fs.SetOptions(FileSetOptions{MaxImportDepth: 6, MaxFiles: 64})
+	require.NoError(t, fs.Load(entry))
+	assert.Len(t, slices.Collect(fs.AllMessages()), 64)
+}
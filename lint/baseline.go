@@ -0,0 +1,115 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/libyarp/idl"
+)
+
+// Baseline represents a set of pre-existing findings, allowing linters to be
+// adopted incrementally by large schemas: findings present in the baseline
+// are still reported, but are not expected to fail builds. Findings are
+// matched by rule, file, and message, disregarding their position, so
+// baselines remain valid as files are edited around them.
+type Baseline struct {
+	// Root contains the directory paths of findings are relative to. It is
+	// set to the directory of the file a baseline is loaded from.
+	Root string `json:"-"`
+
+	// Findings contains pre-existing findings, sorted by file, rule, and
+	// message. Repeated findings are listed once for each occurrence.
+	Findings []BaselineFinding `json:"findings"`
+}
+
+// BaselineFinding represents a single finding of a Baseline.
+type BaselineFinding struct {
+	Rule    string `json:"rule"`
+	File    string `json:"file"`
+	Message string `json:"message"`
+}
+
+// NewBaseline returns a Baseline containing diags, whose paths are recorded
+// relative to root.
+func NewBaseline(root string, diags idl.Diagnostics) Baseline {
+	b := Baseline{Root: root, Findings: []BaselineFinding{}}
+	for _, d := range diags {
+		b.Findings = append(b.Findings, b.finding(d))
+	}
+	sort.Slice(b.Findings, func(i, j int) bool {
+		x, y := b.Findings[i], b.Findings[j]
+		if x.File != y.File {
+			return x.File < y.File
+		}
+		if x.Rule != y.Rule {
+			return x.Rule < y.Rule
+		}
+		return x.Message < y.Message
+	})
+	return b
+}
+
+// LoadBaseline reads the baseline file under the provided path. Unknown keys
+// are rejected.
+func LoadBaseline(path string) (Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Baseline{}, err
+	}
+	var b Baseline
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b); err != nil {
+		return Baseline{}, err
+	}
+	b.Root = filepath.Dir(path)
+	return b, nil
+}
+
+// Marshal returns the JSON representation of b, to be written into a
+// baseline file located at b.Root.
+func (b Baseline) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Split separates diags into findings absent from the baseline, which should
+// fail builds, and known ones, present in it. Each finding of the baseline
+// matches a single diagnostic.
+func (b Baseline) Split(diags idl.Diagnostics) (fresh, known idl.Diagnostics) {
+	remaining := map[BaselineFinding]int{}
+	for _, f := range b.Findings {
+		remaining[f]++
+	}
+	for _, d := range diags {
+		f := b.finding(d)
+		if remaining[f] > 0 {
+			remaining[f]--
+			known = append(known, d)
+		} else {
+			fresh = append(fresh, d)
+		}
+	}
+	return fresh, known
+}
+
+// finding returns d as a BaselineFinding, with its path relative to b.Root.
+func (b Baseline) finding(d idl.Diagnostic) BaselineFinding {
+	path := d.Location.File
+	if b.Root != "" && path != "" {
+		if root, err := filepath.Abs(b.Root); err == nil {
+			if abs, err := filepath.Abs(path); err == nil {
+				if rel, err := filepath.Rel(root, abs); err == nil {
+					path = rel
+				}
+			}
+		}
+	}
+	return BaselineFinding{Rule: d.Code, File: filepath.ToSlash(path), Message: d.Message}
+}
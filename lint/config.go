@@ -0,0 +1,62 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Config represents the configuration of a Linter.
+type Config struct {
+	// Rules contains the configuration of rules, keyed by ID. Rules absent
+	// from Rules run with their defaults.
+	Rules map[string]RuleConfig `json:"rules,omitempty"`
+
+	// Baseline optionally contains the path of a baseline file, whose
+	// findings are reported by Linter.Check as known ones. LoadConfig
+	// resolves relative paths against the directory of the configuration
+	// file.
+	Baseline string `json:"baseline,omitempty"`
+}
+
+// RuleConfig represents the configuration of a single rule.
+type RuleConfig struct {
+	// Enabled determines whether the rule runs. In case it is nil, all rules
+	// but optional ones run.
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Severity optionally overrides the severity of diagnostics reported by
+	// the rule, and contains either "error", "warning", or "info".
+	Severity string `json:"severity,omitempty"`
+
+	// Options contains rule-specific options, keyed by name.
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// ParseConfig parses a JSON-encoded configuration. Unknown keys are rejected.
+func ParseConfig(data []byte) (Config, error) {
+	var c Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// LoadConfig reads and parses the configuration file under the provided path.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	c, err := ParseConfig(data)
+	if err != nil {
+		return Config{}, err
+	}
+	if c.Baseline != "" && !filepath.IsAbs(c.Baseline) {
+		c.Baseline = filepath.Join(filepath.Dir(path), c.Baseline)
+	}
+	return c, nil
+}
@@ -0,0 +1,52 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/libyarp/idl"
+)
+
+func init() {
+	Register(Rule{
+		ID:       "require-doc",
+		Doc:      "Messages, fields, services, and methods are documented.",
+		Severity: idl.SeverityWarning,
+		Optional: true,
+		Options:  []string{"kinds", "packages"},
+		Run:      requireDoc,
+	})
+}
+
+// requireDoc reports declarations lacking documentation comments. The kinds
+// option lists the kinds of declarations to be checked, separated by commas,
+// and defaults to all of them. The packages option is either "primary",
+// restricting the rule to the package of the FileSet, or "all".
+func requireDoc(p *Pass) error {
+	kinds := map[string]bool{}
+	for _, k := range strings.Split(p.Option("kinds", "message,field,service,method"), ",") {
+		switch k = strings.TrimSpace(k); k {
+		case "message", "field", "service", "method":
+			kinds[k] = true
+		default:
+			return fmt.Errorf("invalid kind %q", k)
+		}
+	}
+	scope := p.Option("packages", "primary")
+	if scope != "primary" && scope != "all" {
+		return fmt.Errorf("invalid packages option %q: expected primary or all", scope)
+	}
+
+	walk(p.FileSet, func(d declaration) {
+		if !kinds[d.kind] || scope == "primary" && d.file.Package != p.FileSet.Package() {
+			return
+		}
+		for _, c := range d.comments {
+			if strings.TrimSpace(c) != "" {
+				return
+			}
+		}
+		p.Report(d.file, d.offset, "%s %s is not documented", d.kind, d.symbol)
+	})
+	return nil
+}
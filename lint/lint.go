@@ -0,0 +1,230 @@
+// Package lint runs rules enforcing schema hygiene, such as naming
+// conventions, over FileSets. Rules are registered by ID, and executed by a
+// Linter, configured from Go through Config, or from a JSON file through
+// LoadConfig:
+//
+//	{
+//	  "rules": {
+//	    "field-name": {"enabled": false},
+//	    "message-name": {"severity": "error"}
+//	  },
+//	  "baseline": "lint-baseline.json"
+//	}
+//
+// Severities of rules may be overridden, and findings pre-dating the adoption
+// of the linter may be recorded in a Baseline, so they are reported without
+// failing builds. Linter.Check separates findings through the configured
+// baseline.
+//
+// Findings are reported as idl.Diagnostics, whose Code contains the ID of the
+// rule reporting them. Individual findings may be acknowledged in-source by a
+// pragma preceding the declaration they concern, listing the IDs of rules to
+// be silenced, or none to silence all of them:
+//
+//	# yarp:nolint field-name
+//	legacyName int32 = 0;
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/libyarp/idl"
+)
+
+// Rule represents a lint rule.
+type Rule struct {
+	// ID contains a short, stable identifier of the rule, such as
+	// "field-name", used as the Code of reported diagnostics.
+	ID string
+
+	// Doc contains a one-line description of the rule.
+	Doc string
+
+	// Severity contains the severity of reported diagnostics.
+	Severity idl.Severity
+
+	// Optional indicates whether the rule only runs when enabled by
+	// configuration.
+	Optional bool
+
+	// Options contains the names of options accepted by the rule.
+	Options []string
+
+	// Run inspects p.FileSet, reporting findings through p. Errors indicate
+	// the rule could not run, such as due to invalid options.
+	Run func(p *Pass) error
+}
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]Rule{}
+)
+
+// Register makes a rule available to linters. It panics in case a rule with
+// the same ID is already registered.
+func Register(r Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if _, ok := rules[r.ID]; ok {
+		panic(fmt.Sprintf("lint: rule %s registered twice", r.ID))
+	}
+	rules[r.ID] = r
+}
+
+// Lookup returns the rule registered under id, along with a boolean
+// indicating whether it exists.
+func Lookup(id string) (Rule, bool) {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	r, ok := rules[id]
+	return r, ok
+}
+
+// Rules returns all registered rules, sorted by ID.
+func Rules() []Rule {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	r := make([]Rule, 0, len(rules))
+	for _, v := range rules {
+		r = append(r, v)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].ID < r[j].ID })
+	return r
+}
+
+// Pass holds the state of a rule running over a FileSet.
+type Pass struct {
+	// FileSet contains the resolved FileSet being inspected.
+	FileSet *idl.FileSet
+
+	// Rule contains the running rule.
+	Rule Rule
+
+	options map[string]string
+	diags   idl.Diagnostics
+}
+
+// Option returns the value of the option identified by name, or def, in case
+// it is not configured.
+func (p *Pass) Option(name, def string) string {
+	if v, ok := p.options[name]; ok {
+		return v
+	}
+	return def
+}
+
+// Report records a finding at offset o of file.
+func (p *Pass) Report(file *idl.File, o idl.Offset, format string, args ...any) {
+	var path string
+	if file != nil {
+		path = file.SourcePath
+	}
+	p.diags = append(p.diags, idl.Diagnostic{
+		Severity: p.Rule.Severity,
+		Code:     p.Rule.ID,
+		Message:  fmt.Sprintf(format, args...),
+		Location: idl.Location{File: path, Offset: o},
+	})
+}
+
+// Linter runs a configured set of rules.
+type Linter struct {
+	rules    []Rule
+	options  map[string]map[string]string
+	baseline Baseline
+}
+
+// New returns a Linter running registered rules according to c, loading its
+// baseline, if any. An error is returned in case c refers to unknown rules or
+// options, to invalid severities, or to a baseline that cannot be loaded.
+func New(c Config) (*Linter, error) {
+	for id := range c.Rules {
+		if _, ok := Lookup(id); !ok {
+			return nil, fmt.Errorf("unknown rule %s", id)
+		}
+	}
+	l := &Linter{options: map[string]map[string]string{}}
+	for _, r := range Rules() {
+		rc := c.Rules[r.ID]
+	options:
+		for name := range rc.Options {
+			for _, o := range r.Options {
+				if o == name {
+					continue options
+				}
+			}
+			return nil, fmt.Errorf("rule %s: unknown option %s", r.ID, name)
+		}
+		if rc.Severity != "" {
+			sev, err := idl.ParseSeverity(rc.Severity)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+			}
+			r.Severity = sev
+		}
+		enabled := !r.Optional
+		if rc.Enabled != nil {
+			enabled = *rc.Enabled
+		}
+		if !enabled {
+			continue
+		}
+		l.rules = append(l.rules, r)
+		l.options[r.ID] = rc.Options
+	}
+	if c.Baseline != "" {
+		b, err := LoadBaseline(c.Baseline)
+		if err != nil {
+			return nil, fmt.Errorf("baseline: %w", err)
+		}
+		l.baseline = b
+	}
+	return l, nil
+}
+
+// Rules returns the rules run by the linter, sorted by ID.
+func (l *Linter) Rules() []Rule {
+	return append([]Rule{}, l.rules...)
+}
+
+// Run resolves fs and runs all rules over it, returning their findings
+// sorted by location. Findings acknowledged by "# yarp:nolint" pragmas
+// preceding declarations are omitted. Problems reported by Resolve are not
+// included, and should be checked through idl.FileSet.Validate.
+func (l *Linter) Run(fs *idl.FileSet) (idl.Diagnostics, error) {
+	fs.Resolve()
+	var diags idl.Diagnostics
+	for _, r := range l.rules {
+		p := &Pass{FileSet: fs, Rule: r, options: l.options[r.ID]}
+		if err := r.Run(p); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+		diags = append(diags, p.diags...)
+	}
+	diags = unsuppressed(fs, diags)
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i].Location, diags[j].Location
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Offset.StartsAt.Line != b.Offset.StartsAt.Line {
+			return a.Offset.StartsAt.Line < b.Offset.StartsAt.Line
+		}
+		return a.Offset.StartsAt.Column < b.Offset.StartsAt.Column
+	})
+	return diags, nil
+}
+
+// Check works like Run, but separates findings into fresh ones, which should
+// fail builds, and known ones, recorded in the baseline of the linter. In case
+// no baseline is configured, all findings are fresh.
+func (l *Linter) Check(fs *idl.FileSet) (fresh, known idl.Diagnostics, err error) {
+	diags, err := l.Run(fs)
+	if err != nil {
+		return nil, nil, err
+	}
+	fresh, known = l.baseline.Split(diags)
+	return fresh, known, nil
+}
@@ -0,0 +1,310 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	Register(Rule{
+		ID:       "test-messages",
+		Doc:      "Reports every message.",
+		Severity: idl.SeverityInfo,
+		Optional: true,
+		Options:  []string{"prefix", "fail"},
+		Run: func(p *Pass) error {
+			if p.Option("fail", "") != "" {
+				return errors.New("boom")
+			}
+			walk(p.FileSet, func(d declaration) {
+				if d.kind == "message" {
+					p.Report(d.file, d.offset, "%s%s", p.Option("prefix", ""), d.symbol)
+				}
+			})
+			return nil
+		},
+	})
+}
+
+func findings(diags idl.Diagnostics) []string {
+	var r []string
+	for _, d := range diags {
+		r = append(r, fmt.Sprintf("%d:%d %s %s: %s", d.Location.Offset.StartsAt.Line, d.Location.Offset.StartsAt.Column, d.Severity, d.Code, d.Message))
+	}
+	return r
+}
+
+func TestLinter(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/lint/naming.yarp"))
+
+	l, err := New(Config{})
+	require.NoError(t, err)
+	var ids []string
+	for _, r := range l.Rules() {
+		ids = append(ids, r.ID)
+	}
+	assert.Equal(t, []string{"field-name", "message-name", "method-name", "service-name"}, ids)
+
+	diags, err := l.Run(fs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"3:3 warning message-name: message order_item should be named OrderItem",
+		"5:7 warning field-name: field unitPrice should be named unit_price",
+		"7:11 warning field-name: field GiftCard should be named gift_card",
+		"11:3 warning service-name: service inventory should be named Inventory",
+		"13:7 warning method-name: method ListItems should be named list_items",
+	}, findings(diags))
+
+	c, err := LoadConfig("../test/lint/lint.json")
+	require.NoError(t, err)
+	enabled := true
+	c.Rules["test-messages"] = RuleConfig{Enabled: &enabled, Options: map[string]string{"prefix": "found "}}
+	l, err = New(c)
+	require.NoError(t, err)
+	diags, err = l.Run(fs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"3:3 warning message-name: message order_item should be named OrderItem",
+		"3:3 info test-messages: found org.example.lint.order_item",
+		"11:3 warning service-name: service inventory should be named Inventory",
+		"13:7 warning method-name: method ListItems should be named list_items",
+	}, findings(diags))
+
+	c.Rules["test-messages"] = RuleConfig{Enabled: &enabled, Options: map[string]string{"fail": "1"}}
+	l, err = New(c)
+	require.NoError(t, err)
+	_, err = l.Run(fs)
+	assert.EqualError(t, err, "rule test-messages: boom")
+}
+
+func TestNamingStyle(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/lint/naming.yarp"))
+	disabled := false
+	l, err := New(Config{Rules: map[string]RuleConfig{
+		"message-name": {Enabled: &disabled},
+		"service-name": {Enabled: &disabled},
+		"method-name":  {Severity: "error", Options: map[string]string{"style": "camel"}},
+		"field-name":   {Options: map[string]string{"style": "screaming_snake"}},
+	}})
+	require.NoError(t, err)
+	diags, err := l.Run(fs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"4:7 warning field-name: field id should be named ID",
+		"5:7 warning field-name: field unitPrice should be named UNIT_PRICE",
+		"7:11 warning field-name: field GiftCard should be named GIFT_CARD",
+		"13:7 error method-name: method ListItems should be named listItems",
+	}, findings(diags))
+
+	l, err = New(Config{Rules: map[string]RuleConfig{"field-name": {Options: map[string]string{"style": "kebab"}}}})
+	require.NoError(t, err)
+	_, err = l.Run(fs)
+	assert.EqualError(t, err, `rule field-name: invalid style "kebab"`)
+}
+
+func TestConfig(t *testing.T) {
+	_, err := New(Config{Rules: map[string]RuleConfig{"missing": {}}})
+	assert.EqualError(t, err, "unknown rule missing")
+
+	_, err = New(Config{Rules: map[string]RuleConfig{"field-name": {Options: map[string]string{"case": "camel"}}}})
+	assert.EqualError(t, err, "rule field-name: unknown option case")
+
+	_, err = New(Config{Rules: map[string]RuleConfig{"field-name": {Severity: "fatal"}}})
+	assert.EqualError(t, err, `rule field-name: invalid severity "fatal"`)
+
+	_, err = ParseConfig([]byte(`{"rules": {}, "extra": true}`))
+	assert.ErrorContains(t, err, `unknown field "extra"`)
+
+	assert.Panics(t, func() { Register(Rule{ID: "field-name"}) })
+}
+
+func TestRequireDoc(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/lint/doc.yarp"))
+	run := func(options map[string]string) []string {
+		enabled := true
+		c := Config{Rules: map[string]RuleConfig{}}
+		for _, r := range Rules() {
+			disabled := false
+			c.Rules[r.ID] = RuleConfig{Enabled: &disabled}
+		}
+		c.Rules["require-doc"] = RuleConfig{Enabled: &enabled, Options: options}
+		l, err := New(c)
+		require.NoError(t, err)
+		diags, err := l.Run(fs)
+		require.NoError(t, err)
+		return findings(diags)
+	}
+
+	assert.Equal(t, []string{
+		"9:7 warning require-doc: field org.example.docs.Order.total is not documented",
+		"10:7 warning require-doc: field org.example.docs.Order.address is not documented",
+		"13:3 warning require-doc: service org.example.docs.Orders is not documented",
+		"16:7 warning require-doc: method org.example.docs.Orders.cancel is not documented",
+	}, run(nil))
+
+	assert.Equal(t, []string{
+		"13:3 warning require-doc: service org.example.docs.Orders is not documented",
+		"16:7 warning require-doc: method org.example.docs.Orders.cancel is not documented",
+	}, run(map[string]string{"kinds": "service, method"}))
+
+	assert.Equal(t, []string{
+		"13:3 warning require-doc: service org.example.docs.Orders is not documented",
+		"3:3 warning require-doc: message org.example.docs.types.Address is not documented",
+	}, run(map[string]string{"kinds": "message,service", "packages": "all"}))
+
+	enabled := true
+	l, err := New(Config{Rules: map[string]RuleConfig{"require-doc": {Enabled: &enabled, Options: map[string]string{"kinds": "enum"}}}})
+	require.NoError(t, err)
+	_, err = l.Run(fs)
+	assert.EqualError(t, err, `rule require-doc: invalid kind "enum"`)
+}
+
+func TestNolint(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/lint/nolint.yarp"))
+	enabled := true
+	l, err := New(Config{Rules: map[string]RuleConfig{"require-doc": {Enabled: &enabled}}})
+	require.NoError(t, err)
+	diags, err := l.Run(fs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"6:7 warning require-doc: field org.example.lint.order_item.id is not documented",
+		"13:7 warning field-name: field quantityOrdered should be named quantity_ordered",
+		"13:7 warning require-doc: field org.example.lint.order_item.quantityOrdered is not documented",
+		"21:3 warning require-doc: service org.example.lint.Orders is not documented",
+		"23:7 warning method-name: method PlaceOrder should be named place_order",
+		"23:7 warning require-doc: method org.example.lint.Orders.PlaceOrder is not documented",
+	}, findings(diags))
+}
+
+func TestSpelling(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/lint/spelling.yarp"))
+	run := func(options map[string]string) ([]string, error) {
+		enabled := true
+		l, err := New(Config{Rules: map[string]RuleConfig{
+			"message-name": {Enabled: new(bool)},
+			"spelling":     {Enabled: &enabled, Options: options},
+		}})
+		require.NoError(t, err)
+		diags, err := l.Run(fs)
+		return findings(diags), err
+	}
+
+	r, err := run(map[string]string{"dictionary": "../test/lint/words.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`4:3 warning spelling: unknown word "custmer" in documentation of message org.example.lint.Order`,
+		`6:7 warning spelling: unknown word "ordr" in documentation of field org.example.lint.Order.id`,
+		`12:3 warning spelling: unknown word "ordrs" in documentation of service org.example.lint.Orders`,
+		`12:3 warning spelling: unknown word "custmers" in documentation of service org.example.lint.Orders`,
+	}, r)
+
+	words, err := LoadWordList("../test/lint/words.txt")
+	require.NoError(t, err)
+	RegisterDictionary("test-words", words)
+	r, err = run(map[string]string{"dictionary": "test-words", "allow": "Custmer, custmers, ordr"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`12:3 warning spelling: unknown word "ordrs" in documentation of service org.example.lint.Orders`,
+	}, r)
+
+	_, err = run(nil)
+	assert.EqualError(t, err, "rule spelling: dictionary option is required")
+
+	_, err = run(map[string]string{"dictionary": "../test/lint/missing.txt"})
+	assert.ErrorContains(t, err, "rule spelling: open ../test/lint/missing.txt")
+}
+
+func TestBaseline(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/lint/naming.yarp"))
+	l, err := New(Config{})
+	require.NoError(t, err)
+	diags, err := l.Run(fs)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	b := NewBaseline("../test", diags[1:3])
+	assert.Equal(t, []BaselineFinding{
+		{Rule: "field-name", File: "lint/naming.yarp", Message: "field GiftCard should be named gift_card"},
+		{Rule: "field-name", File: "lint/naming.yarp", Message: "field unitPrice should be named unit_price"},
+	}, b.Findings)
+	data, err := NewBaseline(dir, diags[1:3]).Marshal()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "baseline.json"), data, 0o644))
+
+	loaded, err := LoadBaseline(filepath.Join(dir, "baseline.json"))
+	require.NoError(t, err)
+	assert.Equal(t, dir, loaded.Root)
+	fresh, known := loaded.Split(diags)
+	assert.Equal(t, []string{
+		"3:3 warning message-name: message order_item should be named OrderItem",
+		"11:3 warning service-name: service inventory should be named Inventory",
+		"13:7 warning method-name: method ListItems should be named list_items",
+	}, findings(fresh))
+	assert.Equal(t, []string{
+		"5:7 warning field-name: field unitPrice should be named unit_price",
+		"7:11 warning field-name: field GiftCard should be named gift_card",
+	}, findings(known))
+
+	fresh, known = loaded.Split(append(diags, diags[1]))
+	assert.Len(t, fresh, 4)
+	assert.Len(t, known, 2)
+
+	// Configured baselines are resolved against the configuration file, and
+	// applied by Check.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lint.json"), []byte(`{"baseline": "baseline.json"}`), 0o644))
+	c, err := LoadConfig(filepath.Join(dir, "lint.json"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "baseline.json"), c.Baseline)
+	l, err = New(c)
+	require.NoError(t, err)
+	fresh, known, err = l.Check(fs)
+	require.NoError(t, err)
+	assert.Len(t, fresh, 3)
+	assert.Equal(t, findings(diags[1:3]), findings(known))
+
+	l, err = New(Config{})
+	require.NoError(t, err)
+	fresh, known, err = l.Check(fs)
+	require.NoError(t, err)
+	assert.Equal(t, diags, fresh)
+	assert.Empty(t, known)
+
+	_, err = New(Config{Baseline: filepath.Join(dir, "missing.json")})
+	assert.ErrorContains(t, err, "baseline: open ")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.json"), []byte(`{"entries": []}`), 0o644))
+	_, err = LoadBaseline(filepath.Join(dir, "invalid.json"))
+	assert.ErrorContains(t, err, `unknown field "entries"`)
+}
+
+func TestSeverityOverride(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/lint/naming.yarp"))
+	c, err := ParseConfig([]byte(`{"rules": {"message-name": {"severity": "error"}, "field-name": {"severity": "info"}}}`))
+	require.NoError(t, err)
+	l, err := New(c)
+	require.NoError(t, err)
+	diags, err := l.Run(fs)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"3:3 error message-name: message order_item should be named OrderItem",
+		"5:7 info field-name: field unitPrice should be named unit_price",
+		"7:11 info field-name: field GiftCard should be named gift_card",
+		"11:3 warning service-name: service inventory should be named Inventory",
+		"13:7 warning method-name: method ListItems should be named list_items",
+	}, findings(diags))
+	assert.True(t, diags.HasErrors())
+}
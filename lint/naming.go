@@ -0,0 +1,59 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/names"
+)
+
+// namingStyle describes a naming convention accepted by the style option of
+// naming rules.
+type namingStyle struct {
+	name  string
+	re    *regexp.Regexp
+	style names.Style
+}
+
+// namingStyles contains naming conventions accepted by naming rules, keyed by
+// the value of their style option.
+var namingStyles = map[string]namingStyle{
+	"pascal":          {"PascalCase", regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`), names.Pascal},
+	"camel":           {"camelCase", regexp.MustCompile(`^[a-z][A-Za-z0-9]*$`), names.Camel},
+	"snake":           {"snake_case", regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`), names.Snake},
+	"screaming_snake": {"SCREAMING_SNAKE_CASE", regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`), names.ScreamingSnake},
+}
+
+func init() {
+	Register(namingRule("message-name", "message", "pascal"))
+	Register(namingRule("service-name", "service", "pascal"))
+	Register(namingRule("field-name", "field", "snake"))
+	Register(namingRule("method-name", "method", "snake"))
+}
+
+// namingRule returns a rule requiring names of declarations of a given kind
+// to follow a naming convention, suggesting converted names. The convention
+// is determined by the style option, defaulting to def, and is one of pascal,
+// camel, snake, or screaming_snake.
+func namingRule(id, kind, def string) Rule {
+	return Rule{
+		ID:       id,
+		Doc:      "Names of " + kind + "s are written in " + namingStyles[def].name + ", or the configured style.",
+		Severity: idl.SeverityWarning,
+		Options:  []string{"style"},
+		Run: func(p *Pass) error {
+			s, ok := namingStyles[p.Option("style", def)]
+			if !ok {
+				return fmt.Errorf("invalid style %q", p.Option("style", def))
+			}
+			c := names.Convention{Style: s.style}
+			walk(p.FileSet, func(d declaration) {
+				if d.kind == kind && !s.re.MatchString(d.name) {
+					p.Report(d.file, d.offset, "%s %s should be named %s", kind, d.name, c.Name(d.name))
+				}
+			})
+			return nil
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/libyarp/idl"
+)
+
+// nolintPragma contains the name of the pragma acknowledging findings of
+// declarations, written as "# yarp:nolint rule_id" right before them. Rule
+// IDs are separated by commas or spaces; omitting them acknowledges findings
+// of all rules.
+const nolintPragma = "nolint"
+
+// suppression represents a declaration whose findings are acknowledged by a
+// nolint pragma.
+type suppression struct {
+	file   string
+	offset idl.Offset
+
+	// rules contains the IDs of acknowledged rules, and is empty in case all
+	// rules are acknowledged.
+	rules map[string]bool
+}
+
+// covers returns whether s acknowledges d.
+func (s suppression) covers(d idl.Diagnostic) bool {
+	if d.Location.File != s.file || d.Location.Offset.Start < s.offset.Start || d.Location.Offset.End > s.offset.End {
+		return false
+	}
+	return len(s.rules) == 0 || s.rules[d.Code]
+}
+
+// suppressions returns all declarations of fs annotated with nolint pragmas.
+func suppressions(fs *idl.FileSet) []suppression {
+	var r []suppression
+	walk(fs, func(d declaration) {
+		for _, p := range d.pragmas {
+			name, args, _ := strings.Cut(p, " ")
+			if name != nolintPragma {
+				continue
+			}
+			s := suppression{offset: d.offset, rules: map[string]bool{}}
+			if d.file != nil {
+				s.file = d.file.SourcePath
+			}
+			for _, id := range strings.FieldsFunc(args, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+				s.rules[id] = true
+			}
+			r = append(r, s)
+		}
+	})
+	return r
+}
+
+// unsuppressed returns diags not acknowledged by nolint pragmas of fs.
+func unsuppressed(fs *idl.FileSet, diags idl.Diagnostics) idl.Diagnostics {
+	sup := suppressions(fs)
+	if len(sup) == 0 {
+		return diags
+	}
+	var r idl.Diagnostics
+diags:
+	for _, d := range diags {
+		for _, s := range sup {
+			if s.covers(d) {
+				continue diags
+			}
+		}
+		r = append(r, d)
+	}
+	return r
+}
@@ -0,0 +1,151 @@
+package lint
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/libyarp/idl"
+)
+
+func init() {
+	Register(Rule{
+		ID:       "spelling",
+		Doc:      "Documentation comments are spelled correctly.",
+		Severity: idl.SeverityWarning,
+		Optional: true,
+		Options:  []string{"dictionary", "allow"},
+		Run:      spelling,
+	})
+}
+
+// Dictionary represents a set of correctly spelled words used by the spelling
+// rule.
+type Dictionary interface {
+	// Contains returns whether word, in lower case, is spelled correctly.
+	Contains(word string) bool
+}
+
+// WordList implements Dictionary through a set of lower case words.
+type WordList map[string]bool
+
+// Contains implements Dictionary.
+func (w WordList) Contains(word string) bool { return w[word] }
+
+// LoadWordList reads a word list from the file under the provided path,
+// containing one word per line. Blank lines and lines starting with '#' are
+// ignored.
+func LoadWordList(path string) (WordList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	w := WordList{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			w[strings.ToLower(line)] = true
+		}
+	}
+	return w, s.Err()
+}
+
+var (
+	dictionariesMu sync.RWMutex
+	dictionaries   = map[string]Dictionary{}
+)
+
+// RegisterDictionary makes a dictionary available to the spelling rule under
+// name, replacing any dictionary previously registered under it.
+func RegisterDictionary(name string, d Dictionary) {
+	dictionariesMu.Lock()
+	defer dictionariesMu.Unlock()
+	dictionaries[name] = d
+}
+
+// dictionary returns the dictionary registered under name, or loads a word
+// list from the file under name otherwise.
+func dictionary(name string) (Dictionary, error) {
+	dictionariesMu.RLock()
+	d, ok := dictionaries[name]
+	dictionariesMu.RUnlock()
+	if ok {
+		return d, nil
+	}
+	return LoadWordList(name)
+}
+
+// spelling reports words of documentation comments absent from a dictionary.
+// The dictionary option contains either the name of a dictionary registered
+// through RegisterDictionary, or the path of a word list, and is required.
+// The allow option lists additional words, separated by commas. Names of
+// declarations, along with words containing digits or mixing cases, such as
+// identifiers and acronyms, are always accepted, as are possessives of
+// accepted words.
+func spelling(p *Pass) error {
+	name := p.Option("dictionary", "")
+	if name == "" {
+		return fmt.Errorf("dictionary option is required")
+	}
+	dict, err := dictionary(name)
+	if err != nil {
+		return err
+	}
+	allowed := map[string]bool{}
+	for _, w := range strings.Split(p.Option("allow", ""), ",") {
+		allowed[strings.ToLower(strings.TrimSpace(w))] = true
+	}
+	walk(p.FileSet, func(d declaration) {
+		allowed[strings.ToLower(d.name)] = true
+	})
+
+	walk(p.FileSet, func(d declaration) {
+		reported := map[string]bool{}
+		for _, c := range d.comments {
+			for _, w := range words(c) {
+				lower := strings.ToLower(w)
+				stem := strings.TrimSuffix(lower, "'s")
+				if reported[lower] || allowed[lower] || allowed[stem] || dict.Contains(lower) || dict.Contains(stem) {
+					continue
+				}
+				reported[lower] = true
+				p.Report(d.file, d.offset, "unknown word %q in documentation of %s %s", w, d.kind, d.symbol)
+			}
+		}
+	})
+	return nil
+}
+
+// words returns words of text to be spell-checked, skipping the ones within
+// backticks or URLs, and the ones containing digits or upper case letters
+// other than their first.
+func words(text string) []string {
+	var r []string
+	for i, segment := range strings.Split(text, "`") {
+		if i%2 == 1 {
+			continue
+		}
+		for _, field := range strings.Fields(segment) {
+			if strings.Contains(field, "://") {
+				continue
+			}
+			for _, w := range strings.FieldsFunc(field, func(c rune) bool {
+				return !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '\''
+			}) {
+				w = strings.Trim(w, "'")
+				_, size := utf8.DecodeRuneInString(w)
+				if w == "" || strings.IndexFunc(w, unicode.IsDigit) >= 0 || strings.IndexFunc(w[size:], unicode.IsUpper) >= 0 {
+					continue
+				}
+				r = append(r, w)
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,49 @@
+package lint
+
+import "github.com/libyarp/idl"
+
+// declaration describes a message, field, service, or method visited by
+// walk.
+type declaration struct {
+	// kind contains either "message", "field", "service", or "method".
+	kind string
+
+	// name contains the name of the declaration, and symbol its FQN, or the
+	// FQN of its message or service followed by its name.
+	name, symbol string
+
+	file        *idl.File
+	offset      idl.Offset
+	comments    []string
+	pragmas     []string
+	annotations idl.AnnotationCollection
+}
+
+// walk calls fn for every message, field, service, and method of fs, in
+// declaration order. Fields include cases of oneofs, which also carry the
+// pragmas of their oneof.
+func walk(fs *idl.FileSet, fn func(d declaration)) {
+	for _, sym := range fs.Symbols() {
+		switch d := sym.Declaration.(type) {
+		case *idl.Message:
+			fn(declaration{kind: "message", name: d.Name, symbol: sym.Name.String(), file: sym.File, offset: d.Offset, comments: d.Comments, pragmas: d.Pragmas, annotations: d.Annotations})
+			var visit func(items []idl.FieldItem, pragmas []string)
+			visit = func(items []idl.FieldItem, pragmas []string) {
+				for _, item := range items {
+					switch f := item.(type) {
+					case idl.Field:
+						fn(declaration{kind: "field", name: f.Name, symbol: sym.Name.String() + "." + f.Name, file: sym.File, offset: f.Offset, comments: f.Comments, pragmas: append(pragmas[:len(pragmas):len(pragmas)], f.Pragmas...), annotations: f.Annotations})
+					case idl.OneOfField:
+						visit(f.Items, f.Pragmas)
+					}
+				}
+			}
+			visit(d.Fields, nil)
+		case *idl.Service:
+			fn(declaration{kind: "service", name: d.Name, symbol: sym.Name.String(), file: sym.File, offset: d.Offset, comments: d.Comments, pragmas: d.Pragmas, annotations: d.Annotations})
+			for _, m := range d.Methods {
+				fn(declaration{kind: "method", name: m.Name, symbol: sym.Name.String() + "." + m.Name, file: sym.File, offset: m.Offset, comments: m.Comments, pragmas: m.Pragmas, annotations: m.Annotations})
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package idl
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// SourceExtension contains the extension used by YARP source files.
+const SourceExtension = ".yarp"
+
+// LoadDir discovers every source file under the provided directory and its
+// subdirectories, and loads them into the FileSet. Files are loaded in lexical
+// order of their paths, making the result deterministic. Directories whose
+// names begin with a dot are skipped.
+func (f *FileSet) LoadDir(dir string) error {
+	src := f.sourceFS()
+	root, err := src.abs(dir)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	err = src.walkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(p, SourceExtension) {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return f.loadAll(paths)
+}
+
+// LoadGlob loads every source file matching the provided pattern, using the
+// syntax accepted by filepath.Match (or path.Match, for FileSets created
+// through NewFileSetFS). Files are loaded in lexical order of their paths.
+func (f *FileSet) LoadGlob(pattern string) error {
+	matches, err := f.sourceFS().glob(pattern)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, p := range matches {
+		if strings.HasSuffix(p, SourceExtension) {
+			paths = append(paths, p)
+		}
+	}
+	return f.loadAll(paths)
+}
+
+func (f *FileSet) loadAll(paths []string) error {
+	sort.Strings(paths)
+	var diags Diagnostics
+	for _, p := range paths {
+		err := f.Load(p)
+		var d Diagnostics
+		if errors.As(err, &d) && f.options.ContinueOnError {
+			for _, v := range d {
+				diags = appendLoadDiagnostic(diags, v)
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(diags) > 0 {
+		return diags
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package idl
+
+import "errors"
+
+// CodeLoadError identifies diagnostics emitted for files that could not be
+// loaded or registered when FileSetOptions.ContinueOnError is set.
+const CodeLoadError = "load-error"
+
+// fail handles a problem found while loading files. Unless
+// FileSetOptions.ContinueOnError is set, err is returned as is. Otherwise, it
+// is recorded as a Diagnostic at loc, and nil is returned so that loading can
+// proceed.
+func (f *FileSet) fail(err error, loc Location) error {
+	if !f.options.ContinueOnError {
+		return err
+	}
+	var syntax SyntaxError
+	var parse ParseError
+	switch {
+	case errors.As(err, &syntax):
+		loc.Offset.StartsAt = Position{Line: syntax.Line, Column: syntax.Column}
+	case errors.As(err, &parse):
+		loc.Offset.StartsAt = Position{Line: parse.Token.Line, Column: parse.Token.Column}
+	}
+	d := Diagnostic{
+		Severity: SeverityError,
+		Code:     CodeLoadError,
+		Message:  err.Error(),
+		Location: loc,
+	}
+	f.loadDiags = appendLoadDiagnostic(f.loadDiags, d)
+	return nil
+}
+
+// appendLoadDiagnostic appends d to diags, unless an identical diagnostic is
+// already present, as files imported multiple times fail the same way.
+func appendLoadDiagnostic(diags Diagnostics, d Diagnostic) Diagnostics {
+	for _, prev := range diags {
+		if prev.Message == d.Message && prev.Location == d.Location {
+			return diags
+		}
+	}
+	return append(diags, d)
+}
@@ -0,0 +1,46 @@
+package idl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetContinueOnError(t *testing.T) {
+	fs := NewFileSet()
+	err := fs.Load("./test/load_errors/main.yarp")
+	var notFound ImportFileNotFoundError
+	require.ErrorAs(t, err, &notFound)
+
+	fs = NewFileSet()
+	fs.SetOptions(FileSetOptions{ContinueOnError: true})
+	err = fs.Load("./test/load_errors/main.yarp")
+	var diags Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Len(t, diags, 3)
+	for _, d := range diags {
+		assert.Equal(t, CodeLoadError, d.Code)
+		assert.Equal(t, SeverityError, d.Severity)
+	}
+	assert.Equal(t, "main.yarp", filepath.Base(diags[0].Location.File))
+	assert.Contains(t, diags[0].Message, "no such file")
+	assert.Equal(t, "broken.yarp", filepath.Base(diags[1].Location.File))
+	assert.Equal(t, 4, diags[1].Location.Offset.StartsAt.Line)
+	assert.Equal(t, "main.yarp", filepath.Base(diags[2].Location.File))
+	assert.Equal(t, "duplicated definition of org.example.errors.Order", diags[2].Message)
+	assert.Equal(t, 8, diags[2].Location.Offset.StartsAt.Line)
+
+	var names []string
+	for m := range fs.AllMessages() {
+		names = append(names, m.Name)
+	}
+	assert.Equal(t, []string{"Order", "Invoice", "Customer"}, names)
+
+	fs = NewFileSet()
+	fs.SetOptions(FileSetOptions{ContinueOnError: true})
+	err = fs.LoadGlob("./test/load_errors/*.yarp")
+	require.ErrorAs(t, err, &diags)
+	assert.Len(t, diags, 3)
+}
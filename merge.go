@@ -0,0 +1,55 @@
+package idl
+
+// Merge adds all files loaded into other to the FileSet. Files loaded by both
+// sets are only added once, as long as their contents match. In case other
+// declares a symbol already declared by a different file of the FileSet, or
+// contains a different version of a file already loaded, a MergeConflictError
+// is returned, and the FileSet is left unchanged. Declarations are copied, so
+// that both sets can be resolved independently.
+func (f *FileSet) Merge(other *FileSet) error {
+	var incoming []*File
+	declared := map[FQN]string{}
+	for _, file := range other.fileOrder {
+		if existing, ok := f.files[file.SourcePath]; ok {
+			if existing.Checksum != file.Checksum {
+				return MergeConflictError{Name: file.SourcePath, Existing: file.SourcePath, Incoming: file.SourcePath}
+			}
+			continue
+		}
+		names := make([]string, 0, len(file.DeclaredMessages)+len(file.DeclaredServices))
+		names = append(append(names, file.DeclaredMessages...), file.DeclaredServices...)
+		for _, n := range names {
+			fqn := NewFQN(file.Package, n)
+			if sym, ok := f.FindSymbol(fqn.String()); ok {
+				return MergeConflictError{Name: fqn.String(), Existing: sym.File.SourcePath, Incoming: file.SourcePath}
+			}
+			if path, ok := declared[fqn]; ok {
+				return MergeConflictError{Name: fqn.String(), Existing: path, Incoming: file.SourcePath}
+			}
+			declared[fqn] = file.SourcePath
+		}
+		incoming = append(incoming, file)
+	}
+
+	if f.packageName == "" {
+		f.packageName = other.packageName
+	}
+	if f.loadedFiles == nil {
+		f.loadedFiles = map[string]bool{}
+	}
+	for _, file := range incoming {
+		c := file.Clone()
+		f.loadedFiles[c.SourcePath] = true
+		if err := f.register(c, other.importChains[file]); err != nil {
+			return err
+		}
+		if imports, ok := other.imports[file]; ok {
+			if f.imports == nil {
+				f.imports = map[*File][]string{}
+			}
+			f.imports[c] = append([]string{}, imports...)
+		}
+	}
+	f.linkMethods()
+	return nil
+}
@@ -0,0 +1,62 @@
+package idl
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetMerge(t *testing.T) {
+	left := NewFileSet()
+	require.NoError(t, left.Load("./test/diamond/left.yarp"))
+	right := NewFileSet()
+	require.NoError(t, right.Load("./test/diamond/right.yarp"))
+	require.Empty(t, right.Validate())
+
+	require.NoError(t, left.Merge(right))
+	assert.Len(t, left.Files(), 3)
+	assert.Len(t, slices.Collect(left.AllMessages()), 3)
+	assert.Empty(t, left.Validate())
+
+	// Resolving the merged set must not affect the original one.
+	r, _ := right.FindMessage("Right")
+	m, _ := left.FindMessage("Right")
+	assert.NotSame(t, r, m)
+	base, _ := left.FindMessage("Base")
+	assert.Same(t, base, m.Fields[0].(Field).Type.(Resolved).Message)
+
+	g := left.DependencyGraph()
+	order, _ := g.Files.TopoSort()
+	assert.Equal(t, []string{"base.yarp", "left.yarp", "right.yarp"}, fileNames(order))
+}
+
+func TestFileSetMergeConflicts(t *testing.T) {
+	a := NewFileSetFS(fstest.MapFS{
+		"a.yarp": {Data: []byte("package org.example;\n\nmessage Error {\n    code int32 = 0;\n}\n")},
+	})
+	require.NoError(t, a.Load("a.yarp"))
+
+	b := NewFileSetFS(fstest.MapFS{
+		"b.yarp": {Data: []byte("package org.example;\n\nmessage Other {\n    code int32 = 0;\n}\n")},
+		"c.yarp": {Data: []byte("package org.example;\n\nmessage Error {\n    reason string = 0;\n}\n")},
+	})
+	require.NoError(t, b.Load("b.yarp"))
+	require.NoError(t, b.Load("c.yarp"))
+	err := a.Merge(b)
+	var conflict MergeConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "merge conflict: org.example.Error is declared by both a.yarp and c.yarp", err.Error())
+	assert.Len(t, a.Files(), 1)
+	_, ok := a.FindMessage("Other")
+	assert.False(t, ok)
+
+	changed := NewFileSetFS(fstest.MapFS{
+		"a.yarp": {Data: []byte("package org.example;\n\nmessage Error {\n    code int64 = 0;\n}\n")},
+	})
+	require.NoError(t, changed.Load("a.yarp"))
+	require.ErrorAs(t, a.Merge(changed), &conflict)
+	assert.Equal(t, "a.yarp", conflict.Name)
+}
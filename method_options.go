@@ -0,0 +1,90 @@
+package idl
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// TimeoutAnnotation contains the name of @timeout annotations, which
+	// define how long a call to a method may take, as a duration accepted by
+	// time.ParseDuration. For instance, @timeout("1.5s").
+	TimeoutAnnotation = "timeout"
+
+	// IdempotentAnnotation contains the name of @idempotent annotations, which
+	// indicate that calling a method multiple times with the same argument
+	// has the same effect as calling it once, making it safe to be retried.
+	IdempotentAnnotation = "idempotent"
+
+	// CodeInvalidMethodOption identifies diagnostics emitted for malformed
+	// @timeout and @idempotent annotations.
+	CodeInvalidMethodOption = "invalid-method-option"
+)
+
+// MethodOptions represents options declared by a method through annotations,
+// which allow transports to make routing and retry decisions.
+type MethodOptions struct {
+	// Timeout contains the value of the @timeout annotation, or zero.
+	Timeout time.Duration
+
+	// Idempotent indicates whether the method is annotated with @idempotent.
+	Idempotent bool
+}
+
+// Options returns the options declared by m, or an error in case they are
+// malformed.
+func (m Method) Options() (MethodOptions, error) {
+	o, problems := methodOptions(m)
+	if len(problems) > 0 {
+		return MethodOptions{}, fmt.Errorf("method %s: %s", m.Name, problems[0].message)
+	}
+	return o, nil
+}
+
+func methodOptions(m Method) (MethodOptions, []constraintProblem) {
+	var o MethodOptions
+	var problems []constraintProblem
+	if a, ok := m.Annotations.FindByName(TimeoutAnnotation); ok {
+		var err error
+		switch {
+		case len(a.Value) != 1:
+			err = fmt.Errorf("expected a single value")
+		default:
+			if o.Timeout, err = time.ParseDuration(a.Value[0]); err == nil && o.Timeout <= 0 {
+				err = fmt.Errorf("duration must be positive")
+			} else if err != nil {
+				err = fmt.Errorf("invalid duration %q", a.Value[0])
+			}
+		}
+		if err != nil {
+			problems = append(problems, constraintProblem{a.Offset, "@" + a.Name + ": " + err.Error()})
+			o.Timeout = 0
+		}
+	}
+	if a, ok := m.Annotations.FindByName(IdempotentAnnotation); ok {
+		if len(a.Value) > 0 {
+			problems = append(problems, constraintProblem{a.Offset, "@" + a.Name + ": takes no values"})
+		}
+		o.Idempotent = true
+	}
+	return o, problems
+}
+
+func checkMethodOptions(f *FileSet) Diagnostics {
+	var diags Diagnostics
+	for _, s := range f.allServices {
+		file := f.declaredIn[s]
+		for _, m := range s.Methods {
+			_, problems := methodOptions(m)
+			for _, p := range problems {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     CodeInvalidMethodOption,
+					Message:  fmt.Sprintf("method %s of %s: %s", m.Name, s.Name, p.message),
+					Location: locationOf(file, p.offset),
+				})
+			}
+		}
+	}
+	return diags
+}
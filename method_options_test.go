@@ -0,0 +1,38 @@
+package idl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodOptions(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/methods/orders.yarp"))
+	orders, ok := fs.FindService("Orders")
+	require.True(t, ok)
+
+	o, err := orders.Methods[0].Options()
+	require.NoError(t, err)
+	assert.Equal(t, MethodOptions{Timeout: 1500 * time.Millisecond, Idempotent: true}, o)
+	o, err = orders.Methods[2].Options()
+	require.NoError(t, err)
+	assert.Equal(t, MethodOptions{}, o)
+
+	assert.Empty(t, fs.Validate())
+
+	fs = NewFileSet()
+	require.NoError(t, fs.Load("./test/methods/invalid.yarp"))
+	var messages []string
+	for _, d := range fs.Validate() {
+		assert.Equal(t, CodeInvalidMethodOption, d.Code)
+		messages = append(messages, d.Message)
+	}
+	assert.Equal(t, []string{
+		`method a of Broken: @timeout: invalid duration "soon"`,
+		"method b of Broken: @timeout: duration must be positive",
+		"method b of Broken: @idempotent: takes no values",
+	}, messages)
+}
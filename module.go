@@ -0,0 +1,266 @@
+package idl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// ModuleFile contains the name of files holding a module manifest.
+	ModuleFile = "yarp.mod"
+
+	// LockFile contains the name of files holding checksums of remote
+	// dependencies of a module.
+	LockFile = "yarp.lock"
+)
+
+// Module represents a module manifest, read from a yarp.mod file such as:
+//
+//	package org.example.app
+//
+//	include ../shared
+//	require schemas.example.com/common v1.2.0
+//	alias common com.example.common.v1
+//
+// Includes are added as include paths, relative to the directory containing
+// the manifest. Imports starting with the path of a requirement are fetched
+// from https://<path>/<version>/<rest of the import>.yarp. Aliases are
+// defined through FileSet.AliasPackage, and keyed by alias.
+type Module struct {
+	Package  string
+	Includes []string
+	Requires []Requirement
+	Aliases  map[string]string
+}
+
+// Requirement represents an external schema dependency of a Module. Version
+// contains either an exact version, or a Constraint to be resolved through
+// FileSet.ResolveRequirements.
+type Requirement struct {
+	Path    string
+	Version string
+}
+
+// URL returns the URL of the file provided by the requirement under the
+// provided path, relative to the root of the requirement.
+func (r Requirement) URL(path string) string {
+	return "https://" + r.Path + "/" + r.Version + "/" + path + SourceExtension
+}
+
+// ParseModule parses the contents of a yarp.mod file.
+func ParseModule(src []byte) (*Module, error) {
+	m := &Module{}
+	err := parseDirectives(src, func(line int, args []string) error {
+		switch {
+		case args[0] == "package" && len(args) == 2:
+			if m.Package != "" {
+				return SyntaxError{Message: "duplicated package directive", Line: line, Column: 1}
+			}
+			m.Package = args[1]
+		case args[0] == "include" && len(args) == 2:
+			m.Includes = append(m.Includes, args[1])
+		case args[0] == "require" && len(args) == 3:
+			m.Requires = append(m.Requires, Requirement{Path: strings.TrimSuffix(args[1], "/"), Version: args[2]})
+		case args[0] == "alias" && len(args) == 3:
+			if m.Aliases == nil {
+				m.Aliases = map[string]string{}
+			}
+			if _, ok := m.Aliases[args[1]]; ok {
+				return SyntaxError{Message: fmt.Sprintf("duplicated alias %s", args[1]), Line: line, Column: 1}
+			}
+			m.Aliases[args[1]] = args[2]
+		default:
+			return SyntaxError{Message: fmt.Sprintf("invalid directive %q", strings.Join(args, " ")), Line: line, Column: 1}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if m.Package == "" {
+		return nil, errors.New("missing package directive")
+	}
+	return m, nil
+}
+
+// resolve returns the URL of the file referenced by an import matching one of
+// the module's requirements. Requirements must refer to exact versions; see
+// FileSet.ResolveRequirements.
+func (m *Module) resolve(path string) (string, bool, error) {
+	if m == nil {
+		return "", false, nil
+	}
+	for _, r := range m.Requires {
+		rest, ok := strings.CutPrefix(path, r.Path+"/")
+		if !ok {
+			continue
+		}
+		if c, err := ParseConstraint(r.Version); err != nil {
+			return "", false, err
+		} else if _, ok := c.Exact(); !ok {
+			return "", false, fmt.Errorf("%s: requirement %s has not been resolved to a single version", r.Path, r.Version)
+		}
+		return r.URL(rest), true, nil
+	}
+	return "", false, nil
+}
+
+// vendorPath returns the path, relative to a vendor directory, in which the
+// file under the provided URL is stored once vendored.
+func (m *Module) vendorPath(url string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	for _, r := range m.Requires {
+		if rest, ok := strings.CutPrefix(url, "https://"+r.Path+"/"+r.Version+"/"); ok {
+			return r.Path + "/" + rest, true
+		}
+	}
+	return "", false
+}
+
+// Lockfile represents the contents of a yarp.lock file, holding hex-encoded
+// SHA-256 checksums of remote source files, keyed by their URL.
+type Lockfile struct {
+	Checksums map[string]string
+}
+
+// ParseLockfile parses the contents of a yarp.lock file. Each line contains a
+// URL followed by its checksum, prefixed by sha256:.
+func ParseLockfile(src []byte) (Lockfile, error) {
+	l := Lockfile{Checksums: map[string]string{}}
+	err := parseDirectives(src, func(line int, args []string) error {
+		sum, ok := strings.CutPrefix(args[len(args)-1], "sha256:")
+		if len(args) != 2 || !ok {
+			return SyntaxError{Message: fmt.Sprintf("invalid entry %q", strings.Join(args, " ")), Line: line, Column: 1}
+		}
+		if raw, err := hex.DecodeString(sum); err != nil || len(raw) != 32 {
+			return SyntaxError{Message: fmt.Sprintf("invalid checksum %q", sum), Line: line, Column: 1}
+		}
+		l.Checksums[args[0]] = sum
+		return nil
+	})
+	return l, err
+}
+
+// Format returns the contents of a yarp.lock file representing l. Entries are
+// sorted by URL.
+func (l Lockfile) Format() []byte {
+	urls := make([]string, 0, len(l.Checksums))
+	for u := range l.Checksums {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	var buf bytes.Buffer
+	for _, u := range urls {
+		fmt.Fprintf(&buf, "%s sha256:%s\n", u, l.Checksums[u])
+	}
+	return buf.Bytes()
+}
+
+// parseDirectives calls fn for each non-empty line of src, providing its
+// whitespace-separated fields. Text following a # is ignored.
+func parseDirectives(src []byte, fn func(line int, args []string) error) error {
+	s := bufio.NewScanner(bytes.NewReader(src))
+	line := 0
+	for s.Scan() {
+		line++
+		text, _, _ := strings.Cut(s.Text(), "#")
+		args := strings.Fields(text)
+		if len(args) == 0 {
+			continue
+		}
+		if err := fn(line, args); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+// UseModule reads the yarp.mod file in the provided directory, and configures
+// the FileSet accordingly: the module's package becomes the primary package,
+// its includes are added as include paths, its aliases are defined, and its
+// requirements are used to resolve imports. Checksums present in a yarp.lock
+// file alongside the manifest are pinned. Remote files lacking a pinned
+// checksum, including all of them in case no lock file exists, fail to load
+// unless UpdateLock has been called.
+func (f *FileSet) UseModule(dir string) error {
+	src := f.sourceFS()
+	data, err := src.readFile(src.join(dir, ModuleFile))
+	if err != nil {
+		return err
+	}
+	m, err := ParseModule(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src.join(dir, ModuleFile), err)
+	}
+	for _, inc := range m.Includes {
+		if err = f.AddIncludePath(src.join(dir, inc)); err != nil {
+			return err
+		}
+	}
+	for alias, pkg := range m.Aliases {
+		if err = f.AliasPackage(alias, pkg); err != nil {
+			return err
+		}
+	}
+
+	data, err = src.readFile(src.join(dir, LockFile))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// Without a lock file, no checksum is pinned.
+	case err != nil:
+		return err
+	default:
+		lock, err := ParseLockfile(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", src.join(dir, LockFile), err)
+		}
+		for u, sum := range lock.Checksums {
+			if err = f.Pin(u, sum); err != nil {
+				return err
+			}
+		}
+	}
+	f.module = m
+	f.packageName = m.Package
+	return nil
+}
+
+// Module returns the Module configured through UseModule, or nil.
+func (f *FileSet) Module() *Module {
+	return f.module
+}
+
+// UpdateLock allows remote files lacking a pinned checksum to be loaded,
+// trusting them on first use. Their checksums can then be recorded through
+// WriteLock. It must be called before loading files.
+func (f *FileSet) UpdateLock() {
+	f.unpinned = true
+}
+
+// WriteLock writes the Lockfile returned by Lock to the yarp.lock file of
+// the directory dir, which usually holds the module in use.
+func (f *FileSet) WriteLock(dir string) error {
+	return os.WriteFile(filepath.Join(dir, LockFile), f.Lock().Format(), 0o644)
+}
+
+// Lock returns a Lockfile containing the checksums of all remote files loaded
+// into the FileSet.
+func (f *FileSet) Lock() Lockfile {
+	l := Lockfile{Checksums: map[string]string{}}
+	for _, file := range f.fileOrder {
+		if isRemote(file.SourcePath) {
+			l.Checksums[file.SourcePath] = file.ChecksumHex()
+		}
+	}
+	return l
+}
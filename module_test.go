@@ -0,0 +1,121 @@
+package idl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModule(t *testing.T) {
+	m, err := ParseModule([]byte("# Application schemas\npackage org.example.app\n\ninclude ../shared\nrequire schemas.example.com/common v1.2.0 # pinned\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "org.example.app", m.Package)
+	assert.Equal(t, []string{"../shared"}, m.Includes)
+	assert.Equal(t, []Requirement{{Path: "schemas.example.com/common", Version: "v1.2.0"}}, m.Requires)
+	assert.Equal(t, "https://schemas.example.com/common/v1.2.0/money.yarp", m.Requires[0].URL("money"))
+
+	_, err = ParseModule([]byte("package a\nrequire b\n"))
+	var syntax SyntaxError
+	require.ErrorAs(t, err, &syntax)
+	assert.Equal(t, 2, syntax.Line)
+
+	_, err = ParseModule([]byte("include ../shared\n"))
+	assert.Error(t, err)
+
+	m, err = ParseModule([]byte("package a\nalias common com.example.common.v1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"common": "com.example.common.v1"}, m.Aliases)
+	_, err = ParseModule([]byte("package a\nalias common b\nalias common c\n"))
+	require.ErrorAs(t, err, &syntax)
+	assert.Equal(t, 3, syntax.Line)
+}
+
+func TestParseLockfile(t *testing.T) {
+	src := "https://a.example.com/v1/a.yarp sha256:" + checksumOf("a") + "\n" +
+		"https://b.example.com/v1/b.yarp sha256:" + checksumOf("b") + "\n"
+	l, err := ParseLockfile([]byte(src))
+	require.NoError(t, err)
+	assert.Len(t, l.Checksums, 2)
+	assert.Equal(t, src, string(l.Format()))
+
+	_, err = ParseLockfile([]byte("https://a.example.com/v1/a.yarp md5:abc\n"))
+	assert.Error(t, err)
+}
+
+func TestFileSetUseModule(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schemas/v1.0.0/types.yarp":
+			_, _ = w.Write([]byte(remoteTypes))
+		case "/schemas/v1.0.0/money.yarp":
+			_, _ = w.Write([]byte(remoteMoney))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	fsys := fstest.MapFS{
+		"app/yarp.mod":             {Data: []byte("package org.example.app\ninclude ../shared\nrequire " + host + "/schemas v1.0.0\n")},
+		"app/order.yarp":           {Data: []byte("package org.example.app;\n\nimport \"" + host + "/schemas/types\";\nimport \"common/types\";\n\nmessage Order {\n    price org.example.remote.Price = 0;\n    total org.example.common.Money = 1;\n}\n")},
+		"shared/common/types.yarp": {Data: []byte("package org.example.common;\n\nmessage Money {\n    cents int64 = 0;\n}\n")},
+	}
+	load := func(update bool) (*FileSet, error) {
+		fs := NewFileSetFS(fsys)
+		fs.SetFetcher(HTTPFetcher{Client: srv.Client()})
+		if update {
+			fs.UpdateLock()
+		}
+		if err := fs.UseModule("app"); err != nil {
+			return nil, err
+		}
+		return fs, fs.Load("app/order.yarp")
+	}
+
+	// Without a lock file, remote files are rejected, unless the lock file is
+	// being updated.
+	_, err := load(false)
+	var notPinned RemoteImportNotPinnedError
+	require.ErrorAs(t, err, &notPinned)
+	assert.Equal(t, srv.URL+"/schemas/v1.0.0/types.yarp", notPinned.URL)
+
+	fs, err := load(true)
+	require.NoError(t, err)
+	assert.Equal(t, "org.example.app", fs.Module().Package)
+	assert.Empty(t, fs.Validate())
+	lock := fs.Lock()
+	assert.Equal(t, map[string]string{
+		srv.URL + "/schemas/v1.0.0/types.yarp": checksumOf(remoteTypes),
+		srv.URL + "/schemas/v1.0.0/money.yarp": checksumOf(remoteMoney),
+	}, lock.Checksums)
+
+	dir := t.TempDir()
+	require.NoError(t, fs.WriteLock(dir))
+	data, err := os.ReadFile(filepath.Join(dir, LockFile))
+	require.NoError(t, err)
+	assert.Equal(t, lock.Format(), data)
+
+	fsys["app/yarp.lock"] = &fstest.MapFile{Data: data}
+	_, err = load(false)
+	require.NoError(t, err)
+
+	lock.Checksums[srv.URL+"/schemas/v1.0.0/money.yarp"] = checksumOf("tampered")
+	fsys["app/yarp.lock"] = &fstest.MapFile{Data: lock.Format()}
+	_, err = load(false)
+	var mismatch ChecksumMismatchError
+	require.ErrorAs(t, err, &mismatch)
+
+	dst := t.TempDir()
+	require.NoError(t, fs.Vendor(dst))
+	assert.FileExists(t, filepath.Join(dst, host, "schemas", "types.yarp"))
+	assert.FileExists(t, filepath.Join(dst, host, "schemas", "money.yarp"))
+	assert.FileExists(t, filepath.Join(dst, "common", "types.yarp"))
+}
@@ -0,0 +1,107 @@
+package names
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/libyarp/idl"
+)
+
+// CodeKeywordCollision identifies diagnostics emitted by the check returned
+// by KeywordCheck.
+const CodeKeywordCollision = "keyword-collision"
+
+// GoKeywords contains the keywords of Go.
+var GoKeywords = wordSet(`break case chan const continue default defer else
+	fallthrough for func go goto if import interface map package range return
+	select struct switch type var`)
+
+// TypeScriptKeywords contains words that cannot name TypeScript types and
+// namespaces: reserved words, including the ones reserved in strict mode, and
+// names of predefined types.
+var TypeScriptKeywords = wordSet(`any await bigint boolean break case catch
+	class const continue debugger default delete do else enum export extends
+	false finally for function if implements import in instanceof interface
+	let never new null number object package private protected public return
+	static string super switch symbol this throw true try typeof undefined
+	unknown var void while with yield`)
+
+func wordSet(words string) map[string]bool {
+	r := map[string]bool{}
+	for _, w := range strings.Fields(words) {
+		r[w] = true
+	}
+	return r
+}
+
+// KeywordCheck returns a Check reporting identifiers colliding with keywords
+// of a target language. types names messages, services, and components of
+// package names, while members names fields and methods. Identifiers renamed
+// by the conventions are reported as warnings mentioning the name in use,
+// while overrides colliding with keywords are reported as errors.
+func KeywordCheck(types, members Convention) idl.Check {
+	return idl.Check{
+		Code: CodeKeywordCollision,
+		Run: func(fs *idl.FileSet) idl.Diagnostics {
+			var diags idl.Diagnostics
+			report := func(c Convention, what, id, name string, file *idl.File, o idl.Offset) {
+				d := idl.Diagnostic{
+					Severity: idl.SeverityWarning,
+					Code:     CodeKeywordCollision,
+					Message:  fmt.Sprintf("%s %s is a reserved word in %s, and is renamed to %s", what, id, c.Target, name),
+					Location: idl.Location{File: file.SourcePath, Offset: o},
+				}
+				if c.Keywords[name] {
+					d.Severity = idl.SeverityError
+					d.Message = fmt.Sprintf("%s %s is named %s, which is a reserved word in %s", what, id, name, c.Target)
+				}
+				diags = append(diags, d)
+			}
+			check := func(c Convention, what, id string, file *idl.File, o idl.Offset) {
+				if c.Keywords[c.convert(id)] {
+					report(c, what, id, c.Name(id), file, o)
+				}
+			}
+			for _, file := range fs.Files() {
+				for _, decl := range file.Tree {
+					switch d := decl.(type) {
+					case *idl.Package:
+						for _, part := range strings.Split(d.Name, ".") {
+							check(types, "package component", part, file, d.Offset)
+						}
+					case *idl.Message:
+						check(types, "message", d.Name, file, d.Offset)
+						for _, f := range fieldsOf(d.Fields) {
+							if n := members.Field(f); n != members.Name(f.Name) {
+								if members.Keywords[n] {
+									report(members, "field", f.Name, n, file, f.Offset)
+								}
+								continue
+							}
+							check(members, "field", f.Name, file, f.Offset)
+						}
+					case *idl.Service:
+						check(types, "service", d.Name, file, d.Offset)
+						for _, m := range d.Methods {
+							check(members, "method", m.Name, file, m.Offset)
+						}
+					}
+				}
+			}
+			return diags
+		},
+	}
+}
+
+func fieldsOf(items []idl.FieldItem) []idl.Field {
+	var r []idl.Field
+	for _, item := range items {
+		switch v := item.(type) {
+		case idl.Field:
+			r = append(r, v)
+		case idl.OneOfField:
+			r = append(r, fieldsOf(v.Items)...)
+		}
+	}
+	return r
+}
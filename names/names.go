@@ -0,0 +1,229 @@
+// Package names converts YARP identifiers into the naming conventions of
+// target languages, allowing generators to agree on how declarations are
+// named. Identifiers are split into words regardless of whether they are
+// written in snake_case or CamelCase, and rendered according to a Convention.
+//
+// Fields may override their converted name through a name option of the
+// convention's target, such as @go.name("URL") or @json.name("fullName"); see
+// idl.GeneratorOptions.
+package names
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/libyarp/idl"
+)
+
+// Style determines how words composing an identifier are rendered.
+type Style int
+
+const (
+	// Preserve keeps identifiers as declared.
+	Preserve Style = iota
+	// Pascal capitalizes every word, as in OrderItem.
+	Pascal
+	// Camel capitalizes every word but the first, as in orderItem.
+	Camel
+	// Snake joins lowercase words with underscores, as in order_item.
+	Snake
+	// ScreamingSnake joins uppercase words with underscores, as in
+	// ORDER_ITEM.
+	ScreamingSnake
+	// Kebab joins lowercase words with dashes, as in order-item.
+	Kebab
+)
+
+// Convention represents the naming convention of a target.
+type Convention struct {
+	// Target contains the namespace of annotations overriding names, such as
+	// "go" for @go.name.
+	Target string
+
+	// Style determines how identifiers are rendered.
+	Style Style
+
+	// Initialisms contains words, in uppercase, rendered in uppercase when
+	// capitalized, such as ID in UserID.
+	Initialisms map[string]bool
+
+	// Keywords contains words reserved by the target language. Converted
+	// names colliding with a keyword are suffixed by an underscore, as in
+	// class_.
+	Keywords map[string]bool
+}
+
+// CommonInitialisms contains initialisms conventionally written in uppercase
+// by Go code.
+var CommonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true,
+	"DNS": true, "EOF": true, "GUID": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ID": true, "IP": true, "JSON": true, "QPS": true,
+	"RAM": true, "RPC": true, "SLA": true, "SMTP": true, "SQL": true,
+	"SSH": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true,
+	"UI": true, "UID": true, "UUID": true, "URI": true, "URL": true,
+	"UTF8": true, "VM": true, "XML": true, "XSRF": true, "XSS": true,
+}
+
+var (
+	// Go names exported Go identifiers, such as UserID.
+	Go = Convention{Target: "go", Style: Pascal, Initialisms: CommonInitialisms}
+
+	// TypeScript names TypeScript members, such as userId.
+	TypeScript = Convention{Target: "ts", Style: Camel}
+
+	// TypeScriptTypes names TypeScript types and namespaces, which retain the
+	// names of declarations, unless they collide with reserved words.
+	TypeScriptTypes = Convention{Target: "ts", Style: Preserve, Keywords: TypeScriptKeywords}
+
+	// JSON names keys of JSON objects, which retain the names of fields as
+	// declared.
+	JSON = Convention{Target: "json", Style: Preserve}
+)
+
+// NameOption contains the name of the generator option overriding the name
+// of a field.
+const NameOption = "name"
+
+// Words splits id into lowercase words. Underscores, dashes, and dots separate
+// words, as do transitions from lowercase letters to uppercase ones. A run of
+// uppercase letters forms a single word, except for its last letter when
+// followed by a lowercase one: HTTPServer becomes http and server. Digits are
+// kept with the preceding word.
+func Words(id string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(id)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-' || r == '.' || unicode.IsSpace(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(word) > 0:
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// Name converts id according to the convention, suffixing it by an
+// underscore in case it collides with a keyword of the target.
+func (c Convention) Name(id string) string {
+	n := c.convert(id)
+	if c.Keywords[n] {
+		n += "_"
+	}
+	return n
+}
+
+func (c Convention) convert(id string) string {
+	if c.Style == Preserve {
+		return id
+	}
+	words := Words(id)
+	for i, w := range words {
+		switch c.Style {
+		case Pascal:
+			words[i] = c.capitalize(w)
+		case Camel:
+			if i > 0 {
+				words[i] = c.capitalize(w)
+			}
+		case ScreamingSnake:
+			words[i] = strings.ToUpper(w)
+		}
+	}
+	switch c.Style {
+	case Snake, ScreamingSnake:
+		return strings.Join(words, "_")
+	case Kebab:
+		return strings.Join(words, "-")
+	}
+	return strings.Join(words, "")
+}
+
+func (c Convention) capitalize(w string) string {
+	if upper := strings.ToUpper(w); c.Initialisms[upper] {
+		return upper
+	}
+	r := []rune(w)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// Qualified converts each component of a fully-qualified name, such as
+// org.example.Order, according to the convention.
+func (c Convention) Qualified(n idl.FQN) string {
+	parts := strings.Split(n.String(), ".")
+	for i, p := range parts {
+		parts[i] = c.Name(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Field returns the name of f according to the convention, honouring
+// overrides declared through the convention's target. Overrides are used as
+// declared, even when colliding with keywords.
+func (c Convention) Field(f idl.Field) string {
+	if n, ok := f.Annotations.GeneratorOptions(c.Target).String(NameOption); ok {
+		return n
+	}
+	return c.Name(f.Name)
+}
+
+// CollisionError indicates that two fields of a message are converted into the
+// same name.
+type CollisionError struct {
+	Message       string
+	Name          string
+	First, Second string
+}
+
+func (e CollisionError) Error() string {
+	return fmt.Sprintf("%s: fields %s and %s are both named %s", e.Message, e.First, e.Second, e.Name)
+}
+
+// Fields returns the names of all fields of m, including cases of oneofs,
+// keyed by their declared name. A CollisionError is returned in case two
+// fields are converted into the same name.
+func (c Convention) Fields(m *idl.Message) (map[string]string, error) {
+	names := map[string]string{}
+	declaredAs := map[string]string{}
+	var visit func(items []idl.FieldItem) error
+	visit = func(items []idl.FieldItem) error {
+		for _, item := range items {
+			switch v := item.(type) {
+			case idl.Field:
+				n := c.Field(v)
+				if prev, ok := declaredAs[n]; ok {
+					return CollisionError{Message: m.Name, Name: n, First: prev, Second: v.Name}
+				}
+				declaredAs[n], names[v.Name] = v.Name, n
+			case idl.OneOfField:
+				if err := visit(v.Items); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := visit(m.Fields); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
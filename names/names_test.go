@@ -0,0 +1,107 @@
+package names
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWords(t *testing.T) {
+	assert.Equal(t, []string{"user", "id"}, Words("user_id"))
+	assert.Equal(t, []string{"user", "id"}, Words("UserID"))
+	assert.Equal(t, []string{"http", "server"}, Words("HTTPServer"))
+	assert.Equal(t, []string{"address2", "line"}, Words("address2Line"))
+	assert.Equal(t, []string{"get", "contact", "by", "id"}, Words("getContact_byID"))
+	assert.Empty(t, Words("__"))
+}
+
+func TestConventionName(t *testing.T) {
+	assert.Equal(t, "UserID", Go.Name("user_id"))
+	assert.Equal(t, "HTTPServerURL", Go.Name("http_server_url"))
+	assert.Equal(t, "userId", TypeScript.Name("user_id"))
+	assert.Equal(t, "urlPath", TypeScript.Name("URLPath"))
+	assert.Equal(t, "user_id", JSON.Name("user_id"))
+	assert.Equal(t, "order_item", Convention{Style: Snake}.Name("OrderItem"))
+	assert.Equal(t, "ORDER_ITEM", Convention{Style: ScreamingSnake}.Name("orderItem"))
+	assert.Equal(t, "order-item", Convention{Style: Kebab}.Name("OrderItem"))
+}
+
+func TestConventionFields(t *testing.T) {
+	file, err := idl.ParseSource([]byte(`package org.example;
+
+message Contact {
+    user_id int64 = 0;
+    @go.name("Mail")
+    @json.name("emailAddress")
+    email string = 1;
+    oneof {
+        home_url string = 3;
+        work_url string = 4;
+    } = 2;
+}
+
+message Clash {
+    user_id int64 = 0;
+    userId int64 = 1;
+}
+`), idl.ParseOptions{})
+	require.NoError(t, err)
+	contact, _ := file.MessageByName("Contact")
+
+	fields, err := Go.Fields(contact)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"user_id": "UserID", "email": "Mail", "home_url": "HomeURL", "work_url": "WorkURL"}, fields)
+
+	fields, err = JSON.Fields(contact)
+	require.NoError(t, err)
+	assert.Equal(t, "emailAddress", fields["email"])
+	assert.Equal(t, "user_id", fields["user_id"])
+
+	clash, _ := file.MessageByName("Clash")
+	_, err = JSON.Fields(clash)
+	assert.NoError(t, err)
+	_, err = TypeScript.Fields(clash)
+	assert.Equal(t, CollisionError{Message: "Clash", Name: "userId", First: "user_id", Second: "userId"}, err)
+	assert.EqualError(t, err, "Clash: fields user_id and userId are both named userId")
+}
+
+func TestKeywords(t *testing.T) {
+	assert.Equal(t, "class_", TypeScriptTypes.Name("class"))
+	assert.Equal(t, "Class", TypeScriptTypes.Name("Class"))
+	assert.Equal(t, "org.example.default_.Order", TypeScriptTypes.Qualified("org.example.default.Order"))
+	assert.Equal(t, "type_", Convention{Style: Snake, Keywords: GoKeywords}.Name("Type"))
+	assert.Equal(t, "Type", Go.Name("type"))
+}
+
+func TestKeywordCheck(t *testing.T) {
+	fs := idl.NewFileSetFS(fstest.MapFS{"main.yarp": {Data: []byte(`package org.example.default;
+
+message symbol {
+    @ts.name("delete")
+    id int64 = 0;
+    @ts.name("remove")
+    new bool = 1;
+    class int32 = 2;
+}
+
+service Users {
+    delete(symbol) -> symbol;
+}
+`)}})
+	require.NoError(t, fs.Load("main.yarp"))
+	members := Convention{Target: "ts", Style: Camel, Keywords: TypeScriptKeywords}
+	var messages []string
+	for _, d := range fs.Validate(KeywordCheck(TypeScriptTypes, members)) {
+		messages = append(messages, d.Severity.String()+": "+d.Message)
+	}
+	assert.Equal(t, []string{
+		"warning: package component default is a reserved word in ts, and is renamed to default_",
+		"warning: message symbol is a reserved word in ts, and is renamed to symbol_",
+		"error: field id is named delete, which is a reserved word in ts",
+		"warning: field class is a reserved word in ts, and is renamed to class_",
+		"warning: method delete is a reserved word in ts, and is renamed to delete_",
+	}, messages)
+}
@@ -0,0 +1,81 @@
+package idl
+
+// Node represents any structure parsed from a source file. The set of types
+// implementing Node is closed, and limited to this package.
+type Node interface {
+	// Span returns the Offset in which the node appears in its source file.
+	Span() Offset
+	node()
+}
+
+// Declaration represents a top-level structure of a source file. File.Tree
+// holds values of types *Package, *Import, *Message, and *Service.
+type Declaration interface {
+	Node
+	declaration()
+}
+
+// FieldItem represents an item declared within a Message or OneOfField, being
+// either a Field, or a OneOfField.
+type FieldItem interface {
+	Node
+	fieldItem()
+}
+
+func (p Package) Span() Offset         { return p.Offset }
+func (i Import) Span() Offset          { return i.Offset }
+func (m Message) Span() Offset         { return m.Offset }
+func (s Service) Span() Offset         { return s.Offset }
+func (m Method) Span() Offset          { return m.Offset }
+func (f Field) Span() Offset           { return f.Offset }
+func (o OneOfField) Span() Offset      { return o.Offset }
+func (a AnnotationValue) Span() Offset { return a.Offset }
+
+func (Package) node()         {}
+func (Import) node()          {}
+func (Message) node()         {}
+func (Service) node()         {}
+func (Method) node()          {}
+func (Field) node()           {}
+func (OneOfField) node()      {}
+func (AnnotationValue) node() {}
+
+func (Package) declaration() {}
+func (Import) declaration()  {}
+func (Message) declaration() {}
+func (Service) declaration() {}
+
+func (Field) fieldItem()      {}
+func (OneOfField) fieldItem() {}
+
+// AnyTree returns File.Tree as a slice of untyped values.
+//
+// Deprecated: File.Tree is now a []Declaration, and can be used directly.
+func (f File) AnyTree() []any {
+	return toAny(f.Tree)
+}
+
+// AnyFields returns Message.Fields as a slice of untyped values.
+//
+// Deprecated: Message.Fields is now a []FieldItem, and can be used directly.
+func (m Message) AnyFields() []any {
+	return toAny(m.Fields)
+}
+
+// AnyItems returns OneOfField.Items as a slice of untyped values.
+//
+// Deprecated: OneOfField.Items is now a []FieldItem, and can be used directly.
+func (o OneOfField) AnyItems() []any {
+	return toAny(o.Items)
+}
+
+func toAny[T any](v []T) []any {
+	if v == nil {
+		return nil
+	}
+	r := make([]any, len(v))
+	for i, e := range v {
+		r[i] = e
+	}
+	return r
+}
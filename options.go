@@ -0,0 +1,40 @@
+package idl
+
+// FileSetOptions configures how a FileSet loads files. Limits protect
+// programs compiling untrusted sources against runaway import graphs, and are
+// disabled by zero values.
+type FileSetOptions struct {
+	// MaxImportDepth limits the length of import chains, in which the file
+	// provided to Load has depth zero, and files it imports have depth one.
+	MaxImportDepth int
+
+	// MaxFiles limits the amount of files loaded into the FileSet, including
+	// the ones provided to Load.
+	MaxFiles int
+
+	// ContinueOnError makes Load and related methods proceed past files that
+	// cannot be loaded or registered, returning all problems found as
+	// Diagnostics with CodeLoadError instead of stopping at the first one.
+	ContinueOnError bool
+
+	// SearchPath lists directories searched for imports after include paths.
+	// When nil, directories listed by the YARPPATH environment variable are
+	// used instead, unless the FileSet reads files from a fs.FS. An empty,
+	// non-nil list disables the search path.
+	SearchPath []string
+
+	// Progress, when set, is called after each file is loaded.
+	Progress ProgressFunc
+}
+
+// ProgressFunc is called by a FileSet after loading the file under path. n
+// contains the amount of files loaded so far by the current call to Load, and
+// total the amount of files it is expected to load, which may grow as more
+// imports are discovered.
+type ProgressFunc func(path string, n, total int)
+
+// SetOptions configures subsequent calls to Load and related methods.
+func (f *FileSet) SetOptions(opts FileSetOptions) {
+	f.options = opts
+	f.searchRoots = nil
+}
@@ -0,0 +1,45 @@
+package idl
+
+// Origin describes where a declaration registered by a FileSet comes from.
+type Origin struct {
+	// File represents the source file declaring the symbol.
+	File *File
+
+	// Offset represents the position of the declaration within File.
+	Offset Offset
+
+	// ImportChain contains the paths of files that caused File to be loaded,
+	// from the file passed to FileSet.Load up to File itself.
+	ImportChain []string
+}
+
+// Path returns the path of the file declaring the symbol.
+func (o Origin) Path() string { return o.File.SourcePath }
+
+// Location returns a Location pointing to the declaration.
+func (o Origin) Location() Location {
+	return Location{File: o.File.SourcePath, Offset: o.Offset}
+}
+
+// Origin takes a message or service name (e.g. SomethingRequest) or FQN (e.g.
+// package.SomethingRequest) and returns its Origin, along with a boolean
+// indicating whether the name could be resolved. Short names are resolved
+// within the primary package.
+func (f *FileSet) Origin(name string) (Origin, bool) {
+	fqn := FQN(name).Qualify(f.packageName)
+	var d Declaration
+	var offset Offset
+	if m, ok := f.messages[fqn]; ok {
+		d, offset = m, m.Offset
+	} else if s, ok := f.services[fqn]; ok {
+		d, offset = s, s.Offset
+	} else {
+		return Origin{}, false
+	}
+	file := f.declaredIn[d]
+	return Origin{
+		File:        file,
+		Offset:      offset,
+		ImportChain: append([]string{}, f.importChains[file]...),
+	}, true
+}
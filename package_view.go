@@ -0,0 +1,72 @@
+package idl
+
+// PackageView provides access to structures declared by a single package
+// loaded into a FileSet.
+type PackageView struct {
+	name     string
+	messages []*Message
+	services []*Service
+}
+
+// Name returns the name of the package.
+func (p *PackageView) Name() string { return p.name }
+
+// Messages returns all messages declared by the package, in the order they
+// were loaded.
+func (p *PackageView) Messages() []*Message { return p.messages }
+
+// Services returns all services declared by the package, in the order they
+// were loaded.
+func (p *PackageView) Services() []*Service { return p.services }
+
+// MessageByName takes a short name and returns a Message declared by the
+// package, along with a boolean indicating whether the message exists.
+func (p *PackageView) MessageByName(name string) (*Message, bool) {
+	for _, m := range p.messages {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// ServiceByName takes a short name and returns a Service declared by the
+// package, along with a boolean indicating whether the service exists.
+func (p *PackageView) ServiceByName(name string) (*Service, bool) {
+	for _, s := range p.services {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func (f *FileSet) packageView(name string) *PackageView {
+	if f.packages == nil {
+		f.packages = map[string]*PackageView{}
+	}
+	if v, ok := f.packages[name]; ok {
+		return v
+	}
+	v := &PackageView{name: name}
+	f.packages[name] = v
+	f.packageOrder = append(f.packageOrder, name)
+	return v
+}
+
+// Packages returns views of all packages loaded into the FileSet, in the order
+// they were first found.
+func (f *FileSet) Packages() []*PackageView {
+	r := make([]*PackageView, len(f.packageOrder))
+	for i, n := range f.packageOrder {
+		r[i] = f.packages[n]
+	}
+	return r
+}
+
+// PackageByName returns a view of the package with the provided name, along
+// with a boolean indicating whether the package has been loaded.
+func (f *FileSet) PackageByName(name string) (*PackageView, bool) {
+	v, ok := f.packages[name]
+	return v, ok
+}
@@ -1,6 +1,7 @@
 package idl
 
 import (
+	"bytes"
 	"fmt"
 	"strconv"
 	"strings"
@@ -14,10 +15,13 @@ type Position struct {
 
 // Offset represents the offset in which a given structure appears in the source
 // file. It includes Position for both the point in which it starts, and the
-// point in which it ends.
+// point in which it ends. Start and End contain the byte offsets delimiting the
+// structure in the source; End is exclusive.
 type Offset struct {
 	StartsAt Position
 	EndsAt   Position
+	Start    int
+	End      int
 }
 
 // Package represents a `package` declaration in a source file.
@@ -37,8 +41,13 @@ type Message struct {
 	Offset      Offset
 	Name        string
 	Comments    []string
+	Pragmas     []string
 	Annotations AnnotationCollection
-	Fields      []any
+	Fields      []FieldItem
+
+	// SourceFile contains the path of the file declaring the message. It is
+	// set once the message is registered by a FileSet.
+	SourceFile string
 }
 
 // Service represents a single `service` declared in a source file.
@@ -46,8 +55,13 @@ type Service struct {
 	Offset      Offset
 	Name        string
 	Comments    []string
+	Pragmas     []string
 	Annotations AnnotationCollection
 	Methods     []Method
+
+	// SourceFile contains the path of the file declaring the service. It is
+	// set once the service is registered by a FileSet.
+	SourceFile string
 }
 
 // AnnotationValue represents a single @annotation value present in a source
@@ -89,20 +103,72 @@ func (a AnnotationCollection) FindByName(name string) (*AnnotationValue, bool) {
 
 // Method represents a Service's method
 type Method struct {
-	Offset          Offset
-	Name            string
-	Comments        []string
-	Annotations     AnnotationCollection
-	ArgumentType    string
-	ReturnType      string
+	Offset      Offset
+	Name        string
+	Comments    []string
+	Pragmas     []string
+	Annotations AnnotationCollection
+
+	// Argument represents the type taken by the method. A void TypeRef
+	// indicates the method takes no arguments.
+	Argument TypeRef
+
+	// Return represents the type returned by the method. A void TypeRef
+	// indicates the method returns no value.
+	Return TypeRef
+
+	// Deprecated: Use Argument instead.
+	ArgumentType string
+
+	// Deprecated: Use Return instead.
+	ReturnType string
+
+	// Deprecated: Use Return.Streaming instead.
 	ReturnStreaming bool
 }
 
+// TypeRef represents a reference to a type made by a Method signature.
+type TypeRef struct {
+	// Offset represents the position of the type name in the source file.
+	Offset Offset
+
+	// Package contains the package qualifying the referenced name, or an
+	// empty string in case the reference is not qualified.
+	Package string
+
+	// Name contains the referenced type name, or an empty string in case the
+	// reference represents void.
+	Name string
+
+	// Streaming indicates whether values are streamed. Only return types
+	// may be streamed.
+	Streaming bool
+
+	// Target points to the Message the reference resolves to, when known.
+	Target *Message
+}
+
+// IsVoid returns whether the reference represents the absence of a type.
+func (t TypeRef) IsVoid() bool { return t.Name == "" }
+
+// String returns the referenced name as written in the source file, or
+// "void" in case the reference is void.
+func (t TypeRef) String() string {
+	if t.IsVoid() {
+		return "void"
+	}
+	if t.Package == "" {
+		return t.Name
+	}
+	return t.Package + "." + t.Name
+}
+
 // Field represents a Message's field
 type Field struct {
 	Offset      Offset
 	Name        string
 	Comments    []string
+	Pragmas     []string
 	Annotations AnnotationCollection
 	Type        Type
 	Index       int
@@ -112,22 +178,81 @@ type Field struct {
 type OneOfField struct {
 	Offset      Offset
 	Comments    []string
+	Pragmas     []string
 	Annotations AnnotationCollection
 	Index       int
-	Items       []any
+	Items       []FieldItem
 }
 
 type parser struct {
-	annotations AnnotationCollection
-	comments    []string
-	file        *File
-	tokens      *tokenList
+	annotations  AnnotationCollection
+	comments     []string
+	pragmas      []string
+	file         *File
+	tokens       *tokenList
+	retainTokens bool
+}
+
+// ParseOptions represents optional behaviour for ParseWithOptions.
+type ParseOptions struct {
+	// RetainTokens indicates whether the resulting File must keep the list of
+	// tokens it was parsed from, along with the range of tokens composing each
+	// node. See File.Tokens and File.TokensOf.
+	RetainTokens bool
+
+	// Pool optionally provides a Pool used to recycle buffers across calls to
+	// ParseSource. Buffers are only recycled when RetainTokens is false.
+	Pool *Pool
 }
 
 // Parse takes a list of Token and returns either a File, or an error.
 func Parse(tokens []Token) (*File, error) {
+	return ParseWithOptions(tokens, ParseOptions{})
+}
+
+// ParseSource takes the contents of a source file, scans, and parses it,
+// returning either a File, or an error. Differently from Parse, the returned
+// File retains the provided source, allowing File.TextOf to be used.
+func ParseSource(src []byte, opts ParseOptions) (*File, error) {
+	if opts.Pool != nil && !opts.RetainTokens {
+		tokens, scratch, err := opts.Pool.scan(src)
+		if err != nil {
+			return nil, err
+		}
+		defer opts.Pool.put(scratch)
+		f, err := ParseWithOptions(tokens, opts)
+		if err != nil {
+			return nil, err
+		}
+		f.Source = src
+		return f, nil
+	}
+
+	tokens, err := Scan(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	f, err := ParseWithOptions(tokens, opts)
+	if err != nil {
+		return nil, err
+	}
+	f.Source = src
+	return f, nil
+}
+
+// ParseWithOptions works like Parse, but allows callers to customize the
+// parser's behaviour through a ParseOptions value.
+func ParseWithOptions(tokens []Token, opts ParseOptions) (*File, error) {
 	p := newParser(tokens)
-	return p.run()
+	p.retainTokens = opts.RetainTokens
+	f, err := p.run()
+	if err != nil {
+		return nil, err
+	}
+	if opts.RetainTokens {
+		f.Tokens = tokens
+	}
+	return f, nil
 }
 
 func newParser(tokens []Token) *parser {
@@ -176,6 +301,7 @@ func (p *parser) messageOrService() error {
 }
 
 func (p *parser) message() error {
+	from := p.tokens.current
 	start := p.tokens.advance() // consume "message"
 	if !p.tokens.peek().is(Identifier) {
 		return p.tokens.error("expected identifier")
@@ -193,6 +319,7 @@ func (p *parser) message() error {
 		Offset:      Offset{},
 		Name:        name.Value,
 		Comments:    p.comments,
+		Pragmas:     p.pragmas,
 		Annotations: p.annotations,
 		Fields:      nil,
 	}
@@ -208,11 +335,12 @@ func (p *parser) message() error {
 	}
 	end := p.tokens.advance() // consume curly
 	m.Offset = offsetBetween(start, end)
-	p.file.push(m)
+	p.mark(m.Offset, from)
+	p.file.push(&m)
 	return nil
 }
 
-func (p *parser) parseStructureField(arr *[]any, allowOneOf bool) error {
+func (p *parser) parseStructureField(arr *[]FieldItem, allowOneOf bool) error {
 	if !p.tokens.peek().is(Identifier) {
 		return p.tokens.error("expected identifier")
 	}
@@ -223,6 +351,7 @@ func (p *parser) parseStructureField(arr *[]any, allowOneOf bool) error {
 		return p.parseOneOf(arr)
 	}
 
+	from := p.tokens.current
 	fName := p.tokens.advance() // consume name
 	fType, err := p.parseType()
 	if err != nil {
@@ -236,26 +365,31 @@ func (p *parser) parseStructureField(arr *[]any, allowOneOf bool) error {
 		return p.tokens.error("expected ';'")
 	}
 	end := p.tokens.advance()
-	*arr = append(*arr, Field{
+	f := Field{
 		Offset:      offsetBetween(fName, end),
 		Name:        fName.Value,
 		Comments:    p.comments,
+		Pragmas:     p.pragmas,
 		Annotations: p.annotations,
 		Type:        fType,
 		Index:       fIndex,
-	})
+	}
+	p.mark(f.Offset, from)
+	*arr = append(*arr, f)
 	p.flushMeta()
 	return nil
 }
 
-func (p *parser) parseOneOf(arr *[]any) error {
+func (p *parser) parseOneOf(arr *[]FieldItem) error {
+	from := p.tokens.current
 	start := p.tokens.advance()
 	if !p.tokens.peek().is(OpenCurly) {
 		return p.tokens.error("expected '{'")
 	}
 	p.tokens.advance() // consume curly
-	var items []any
+	var items []FieldItem
 	comments := p.comments
+	pragmas := p.pragmas
 	annotations := p.annotations
 	p.flushMeta()
 	for !p.tokens.peek().is(CloseCurly) {
@@ -274,13 +408,16 @@ func (p *parser) parseOneOf(arr *[]any) error {
 		return p.tokens.error("expected ';'")
 	}
 	end := p.tokens.advance()
-	*arr = append(*arr, OneOfField{
+	o := OneOfField{
 		Offset:      offsetBetween(start, end),
 		Comments:    comments,
+		Pragmas:     pragmas,
 		Annotations: annotations,
 		Index:       idx,
 		Items:       items,
-	})
+	}
+	p.mark(o.Offset, from)
+	*arr = append(*arr, o)
 	return nil
 }
 
@@ -293,14 +430,19 @@ func (p *parser) parseOne(or func() error) error {
 		}
 		p.tokens.advance()
 	case Annotation:
+		from := p.tokens.current
 		start := p.tokens.advance()
 		end := start
 		var vals []string
 		if p.tokens.peek().is(OpenParen) {
 			var val []string
+			p.tokens.advance() // consume paren
 			for !p.tokens.peek().is(CloseParen) {
+				if p.tokens.peek().is(EOF) {
+					return p.tokens.error("expected ')'")
+				}
 				if p.tokens.peek().is(Comma) {
-					if len(vals) == 0 {
+					if len(val) == 0 {
 						return p.tokens.error("expected value")
 					}
 					vals = append(vals, strings.Join(val, " "))
@@ -316,17 +458,25 @@ func (p *parser) parseOne(or func() error) error {
 			end = p.tokens.advance()
 		}
 
-		p.annotations = append(p.annotations, AnnotationValue{
+		a := AnnotationValue{
 			Offset: offsetBetween(start, end),
 			Name:   start.Value,
 			Value:  vals,
-		})
+		}
+		p.mark(a.Offset, from)
+		p.annotations = append(p.annotations, a)
 	case Comment:
 		push := p.tokens.peekPrevious().is(LineBreak)
 		cmm := p.tokens.advance().Value
 		if push {
 			p.comments = append(p.comments, cmm)
 		}
+	case Pragma:
+		push := p.tokens.peekPrevious().is(LineBreak)
+		pragma := p.tokens.advance().Value
+		if push {
+			p.pragmas = append(p.pragmas, pragma)
+		}
 	default:
 		return or()
 	}
@@ -335,7 +485,7 @@ func (p *parser) parseOne(or func() error) error {
 }
 
 func (p *parser) parsePackage() error {
-	for p.tokens.peek().is(LineBreak) || p.tokens.peek().is(Comment) {
+	for p.tokens.peek().is(LineBreak) || p.tokens.peek().is(Comment) || p.tokens.peek().is(Pragma) {
 		p.tokens.advance()
 	}
 	if !p.tokens.peek().is(Identifier) {
@@ -344,6 +494,7 @@ func (p *parser) parsePackage() error {
 	if p.tokens.peek().Value != "package" {
 		return p.tokens.error("unexpected %s, expected package identifier", p.tokens.peek().Value)
 	}
+	from := p.tokens.current
 	start := p.tokens.advance() // consume package
 
 	pName := []string{p.tokens.advance().Value}
@@ -354,10 +505,12 @@ func (p *parser) parsePackage() error {
 		return p.tokens.error("expected ';'")
 	}
 	end := p.tokens.advance()
-	p.file.push(Package{
+	pkg := Package{
 		Offset: offsetBetween(start, end),
 		Name:   strings.Join(pName, ""),
-	})
+	}
+	p.mark(pkg.Offset, from)
+	p.file.push(&pkg)
 
 	return nil
 }
@@ -373,6 +526,8 @@ func (p *parser) parseImports() error {
 				p.tokens.advance()
 			} else if p.tokens.peek().is(Comment) {
 				p.comments = append(p.comments, p.tokens.advance().Value)
+			} else if p.tokens.peek().is(Pragma) {
+				p.pragmas = append(p.pragmas, p.tokens.advance().Value)
 			} else {
 				break
 			}
@@ -387,6 +542,7 @@ func (p *parser) parseImports() error {
 		}
 
 		p.flushMeta()
+		from := p.tokens.current
 		start := p.tokens.advance() // consume import
 
 		if !p.tokens.peek().is(StringElement) {
@@ -400,10 +556,12 @@ func (p *parser) parseImports() error {
 			return p.tokens.error("expected ';'")
 		}
 		end := p.tokens.advance() // consume semi
-		p.file.push(Import{
+		imp := Import{
 			Offset: offsetBetween(start, end),
 			Path:   path,
-		})
+		}
+		p.mark(imp.Offset, from)
+		p.file.push(&imp)
 	}
 }
 
@@ -446,23 +604,18 @@ func offsetBetween(a, b Token) Offset {
 			Line:   b.Line,
 			Column: b.Column,
 		},
+		Start: a.Start,
+		End:   b.End,
 	}
 }
 
-var stringToPrimitive = map[string]PrimitiveType{
-	"string":  String,
-	"uint8":   Uint8,
-	"uint16":  Uint16,
-	"uint32":  Uint32,
-	"uint64":  Uint64,
-	"int8":    Int8,
-	"int16":   Int16,
-	"int32":   Int32,
-	"int64":   Int64,
-	"float32": Float32,
-	"float64": Float64,
-	"bool":    Bool,
-}
+var stringToPrimitive = func() map[string]PrimitiveType {
+	r := make(map[string]PrimitiveType, len(primitiveInfo))
+	for k, v := range primitiveInfo {
+		r[v.Keyword] = k
+	}
+	return r
+}()
 
 func (p *parser) parseType() (Type, error) {
 	if !p.tokens.peek().is(Identifier) {
@@ -559,13 +712,25 @@ func (p *parser) parseIndex() (int, error) {
 	return strconv.Atoi(p.tokens.advance().Value)
 }
 
+// mark records the range of tokens between from and the current position as
+// composing the node located at the provided Offset, in case the parser has
+// been configured to retain tokens.
+func (p *parser) mark(o Offset, from int) {
+	if !p.retainTokens {
+		return
+	}
+	p.file.markTokens(o, TokenRange{Start: from, End: p.tokens.current})
+}
+
 func (p *parser) flushMeta() {
 	p.comments = []string{}
+	p.pragmas = nil
 	p.annotations = AnnotationCollection{}
 }
 
 func (p *parser) service() error {
-	start := p.tokens.advance() // consume "message"
+	from := p.tokens.current
+	start := p.tokens.advance() // consume "service"
 	if !p.tokens.peek().is(Identifier) {
 		return p.tokens.error("expected identifier")
 	}
@@ -584,6 +749,7 @@ func (p *parser) service() error {
 		Offset:      Offset{},
 		Name:        name.Value,
 		Comments:    p.comments,
+		Pragmas:     p.pragmas,
 		Annotations: p.annotations,
 		Methods:     nil,
 	}
@@ -595,7 +761,8 @@ func (p *parser) service() error {
 	}
 	end := p.tokens.advance()
 	s.Offset = offsetBetween(start, end)
-	p.file.push(s)
+	p.mark(s.Offset, from)
+	p.file.push(&s)
 	return nil
 }
 
@@ -604,36 +771,31 @@ func (p *parser) parseMethod(s *Service) func() error {
 		if !p.tokens.peek().is(Identifier) {
 			return p.tokens.error("expected identifier")
 		}
+		from := p.tokens.current
 		name := p.tokens.advance()
 		if !p.tokens.peek().is(OpenParen) {
 			return p.tokens.error("expected '('")
 		}
 		p.tokens.advance() // consume paren
-		reqType := "void"
 		if !p.tokens.peek().is(Identifier) && !p.tokens.peek().is(CloseParen) {
 			return p.tokens.error("expected identifier or ')'")
 		}
-
-		for p.tokens.peek().is(Identifier) || p.tokens.peek().is(Dot) {
-			if reqType == "void" {
-				reqType = p.tokens.advance().Value
-			} else {
-				reqType += p.tokens.advance().Value
-			}
+		var arg TypeRef
+		if p.tokens.peek().is(Identifier) {
+			arg = p.parseTypeRef()
 		}
 
 		if !p.tokens.peek().is(CloseParen) {
 			return p.tokens.error("expected ')'")
 		}
 		p.tokens.advance() // consume paren
-		retType := "void"
-		stream := false
+		var ret TypeRef
 		if !p.tokens.peek().is(Semi) {
-			retType = ""
 			if !p.tokens.peek().is(Arrow) {
 				return p.tokens.error("expected '->'")
 			}
 			p.tokens.advance() // consume arrow
+			stream := false
 			if p.tokens.peek().is(Identifier) && p.tokens.peek().Value == "stream" {
 				p.tokens.advance() // consume stream
 				stream = true
@@ -642,25 +804,47 @@ func (p *parser) parseMethod(s *Service) func() error {
 			if !p.tokens.peek().is(Identifier) {
 				return p.tokens.error("expected identifier")
 			}
-			for p.tokens.peek().is(Identifier) || p.tokens.peek().is(Dot) {
-				retType += p.tokens.advance().Value
-			}
+			ret = p.parseTypeRef()
+			ret.Streaming = stream
 		}
 
 		if !p.tokens.peek().is(Semi) {
 			return p.tokens.error("expected ';'")
 		}
 		end := p.tokens.advance()
-		s.Methods = append(s.Methods, Method{
+		m := Method{
 			Offset:          offsetBetween(name, end),
 			Name:            name.Value,
 			Comments:        p.comments,
+			Pragmas:         p.pragmas,
 			Annotations:     p.annotations,
-			ArgumentType:    reqType,
-			ReturnType:      retType,
-			ReturnStreaming: stream,
-		})
+			Argument:        arg,
+			Return:          ret,
+			ArgumentType:    arg.String(),
+			ReturnType:      ret.String(),
+			ReturnStreaming: ret.Streaming,
+		}
+		p.mark(m.Offset, from)
+		s.Methods = append(s.Methods, m)
 		p.flushMeta()
 		return nil
 	}
 }
+
+// parseTypeRef consumes a (possibly qualified) type name referenced by a
+// method signature.
+func (p *parser) parseTypeRef() TypeRef {
+	start := p.tokens.peek()
+	end := start
+	var v []string
+	for p.tokens.peek().is(Identifier) || p.tokens.peek().is(Dot) {
+		end = p.tokens.advance()
+		v = append(v, end.Value)
+	}
+	pkg, name := SplitComponents(strings.Join(v, ""))
+	return TypeRef{
+		Offset:  offsetBetween(start, end),
+		Package: pkg,
+		Name:    name,
+	}
+}
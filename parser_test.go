@@ -1,6 +1,7 @@
 package idl
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"os"
@@ -134,6 +135,11 @@ func returnType(name string) func(t *testing.T, m Method) {
 	}
 }
 
+func withoutOffset(r TypeRef) TypeRef {
+	r.Offset = Offset{}
+	return r
+}
+
 func TestParserDocsExample(t *testing.T) {
 	f, err := os.Open("./test/fixture/contacts.yarp")
 	require.NoError(t, err)
@@ -149,15 +155,15 @@ func TestParserDocsExample(t *testing.T) {
 
 	t.Run("package", func(t *testing.T) {
 		v := ff.Tree[0]
-		require.IsType(t, Package{}, v)
-		vv := v.(Package)
+		require.IsType(t, &Package{}, v)
+		vv := v.(*Package)
 		assert.Equal(t, "org.example.contacts", vv.Name)
 	})
 
 	t.Run("Contact", func(t *testing.T) {
 		v := ff.Tree[1]
-		require.IsType(t, Message{}, v)
-		vv := v.(Message)
+		require.IsType(t, &Message{}, v)
+		vv := v.(*Message)
 		assert.Equal(t, "Contact", vv.Name)
 		assert.Equal(t, "Contact represent a single person in the address list.", joinComments(vv.Comments))
 		assert.Empty(t, vv.Annotations)
@@ -171,8 +177,8 @@ func TestParserDocsExample(t *testing.T) {
 
 	t.Run("Company", func(t *testing.T) {
 		v := ff.Tree[2]
-		require.IsType(t, Message{}, v)
-		vv := v.(Message)
+		require.IsType(t, &Message{}, v)
+		vv := v.(*Message)
 		assert.Equal(t, "Company", vv.Name)
 		assert.Equal(t, "Company represents a company in which a person works at.", joinComments(vv.Comments))
 		assertField(t, vv.Fields[0], name("name"), tString())
@@ -181,8 +187,8 @@ func TestParserDocsExample(t *testing.T) {
 
 	t.Run("GetContactRequest", func(t *testing.T) {
 		v := ff.Tree[3]
-		require.IsType(t, Message{}, v)
-		vv := v.(Message)
+		require.IsType(t, &Message{}, v)
+		vv := v.(*Message)
 		assert.Equal(t, "GetContactRequest", vv.Name)
 		assert.Equal(t, "GetContactRequest represents a request to obtain a specific contact through a given id.", joinComments(vv.Comments))
 		assertField(t, vv.Fields[0], name("id"), tInt64())
@@ -190,8 +196,8 @@ func TestParserDocsExample(t *testing.T) {
 
 	t.Run("GetContactResponse", func(t *testing.T) {
 		v := ff.Tree[4]
-		require.IsType(t, Message{}, v)
-		vv := v.(Message)
+		require.IsType(t, &Message{}, v)
+		vv := v.(*Message)
 		assert.Equal(t, "GetContactResponse", vv.Name)
 		assert.Equal(t, "GetContactResponse represents the result of a GetContactRequest. An absent `contact` indicates that no contact under the provided id exists.", joinComments(vv.Comments))
 		assertField(t, vv.Fields[0], name("contact"), tStruct("Contact"), optional())
@@ -199,11 +205,160 @@ func TestParserDocsExample(t *testing.T) {
 
 	t.Run("ContactsService", func(t *testing.T) {
 		v := ff.Tree[5]
-		require.IsType(t, Service{}, v)
-		vv := v.(Service)
+		require.IsType(t, &Service{}, v)
+		vv := v.(*Service)
 		assert.Equal(t, "ContactsService", vv.Name)
 		assertMethod(t, vv.Methods[0], methodName("upsert_contact"), argumentType("Contact"), returnType("void"))
 		assertMethod(t, vv.Methods[1], methodName("list_contacts"), argumentType("void"), returnType("Contact"), streams())
 		assertMethod(t, vv.Methods[2], methodName("get_contact"), argumentType("GetContactRequest"), returnType("GetContactResponse"))
+		assert.Equal(t, TypeRef{Name: "Contact", Streaming: true}, withoutOffset(vv.Methods[1].Return))
+		assert.True(t, vv.Methods[1].Argument.IsVoid())
+	})
+}
+
+func TestParserRetainTokens(t *testing.T) {
+	tokens, err := Scan(strings.NewReader(file))
+	require.NoError(t, err)
+	tree, err := ParseWithOptions(tokens, ParseOptions{RetainTokens: true})
+	require.NoError(t, err)
+	assert.Equal(t, tokens, tree.Tokens)
+
+	msg, ok := tree.MessageByName("RandomBytesRequest")
+	require.True(t, ok)
+	toks, ok := tree.TokensOf(msg)
+	require.True(t, ok)
+	assert.Equal(t, "message", toks[0].Value)
+	assert.Equal(t, CloseCurly, toks[len(toks)-1].Type)
+
+	toks, ok = tree.TokensOf(msg.Fields[0])
+	require.True(t, ok)
+	assert.Equal(t, "desired_length", toks[0].Value)
+	assert.Equal(t, Semi, toks[len(toks)-1].Type)
+
+	plain, err := Parse(tokens)
+	require.NoError(t, err)
+	assert.Nil(t, plain.Tokens)
+	_, ok = plain.TokensOf(msg)
+	assert.False(t, ok)
+}
+
+func TestFileTextOf(t *testing.T) {
+	tree, err := ParseSource([]byte(file), ParseOptions{})
+	require.NoError(t, err)
+
+	msg, ok := tree.MessageByName("RandomBytesResponse")
+	require.True(t, ok)
+	text, ok := tree.TextOf(msg)
+	require.True(t, ok)
+	assert.Equal(t, "message RandomBytesResponse {\n    @repeated data uint8 = 0;\n}", text)
+
+	text, ok = tree.TextOf(msg.Fields[0])
+	require.True(t, ok)
+	assert.Equal(t, "data uint8 = 0;", text)
+
+	text, ok = tree.TextOf(msg.Fields[0].(Field).Annotations[0])
+	require.True(t, ok)
+	assert.Equal(t, "@repeated", text)
+
+	tokens, err := Scan(strings.NewReader(file))
+	require.NoError(t, err)
+	plain, err := Parse(tokens)
+	require.NoError(t, err)
+	_, ok = plain.TextOf(msg)
+	assert.False(t, ok)
+}
+
+func TestParserPragmas(t *testing.T) {
+	tree, err := ParseSource([]byte(`package foo;
+
+# Documented.
+# yarp:nolint field-name
+message Foo {
+    #yarp:nolint
+    Bar int32 = 0;
+    # yarp:nolint
+    oneof {
+        Baz string = 2;
+    } = 1;
+    qux int32 = 3; # yarp:nolint
+}
+
+# yarp:deprecated
+
+service Svc {
+    # yarp:nolint method-name
+    Get();
+}
+`), ParseOptions{})
+	require.NoError(t, err)
+
+	msg, ok := tree.MessageByName("Foo")
+	require.True(t, ok)
+	assert.Equal(t, []string{"Documented."}, msg.Comments)
+	assert.Equal(t, []string{"nolint field-name"}, msg.Pragmas)
+	assert.Equal(t, []string{"nolint"}, msg.Fields[0].(Field).Pragmas)
+	assert.Equal(t, []string{"nolint"}, msg.Fields[1].(OneOfField).Pragmas)
+	assert.Nil(t, msg.Fields[1].(OneOfField).Items[0].(Field).Pragmas)
+	assert.Nil(t, msg.Fields[2].(Field).Pragmas)
+
+	svc, ok := tree.ServiceByName("Svc")
+	require.True(t, ok)
+	assert.Nil(t, svc.Pragmas)
+	assert.Equal(t, []string{"nolint method-name"}, svc.Methods[0].Pragmas)
+}
+
+func TestParseSourcePooled(t *testing.T) {
+	pool := NewPool()
+	expected, err := ParseSource([]byte(file), ParseOptions{})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		f, err := ParseSource([]byte(file), ParseOptions{Pool: pool})
+		require.NoError(t, err)
+		assert.Equal(t, expected, f)
+	}
+	_, err = ParseSource([]byte("package foo;\n\"unterminated"), ParseOptions{Pool: pool})
+	assert.Error(t, err)
+}
+
+func TestParseSourcePooledAllocs(t *testing.T) {
+	src := benchmarkSource()
+	pool := NewPool()
+	plain := testing.AllocsPerRun(5, func() {
+		_, _ = ParseSource(src, ParseOptions{})
+	})
+	pooled := testing.AllocsPerRun(5, func() {
+		_, _ = ParseSource(src, ParseOptions{Pool: pool})
 	})
+	assert.Less(t, pooled, plain/2)
+}
+
+func benchmarkSource() []byte {
+	var b strings.Builder
+	b.WriteString("package bench;\n\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&b, "# Message%d documents a message.\nmessage Message%d {\n", i, i)
+		fmt.Fprintf(&b, "    @optional id int64 = 0;\n    name string = 1;\n    tags map<string, string> = 2;\n}\n\n")
+	}
+	return []byte(b.String())
+}
+
+func BenchmarkParseSource(b *testing.B) {
+	src := benchmarkSource()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseSource(src, ParseOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseSourcePooled(b *testing.B) {
+	src := benchmarkSource()
+	pool := NewPool()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseSource(src, ParseOptions{Pool: pool}); err != nil {
+			b.Fatal(err)
+		}
+	}
 }
@@ -0,0 +1,22 @@
+package idl
+
+// CasePolicy determines how a FileSet handles paths differing only in case
+// from the files they refer to, which can only be opened on case-insensitive
+// filesystems.
+type CasePolicy int
+
+const (
+	// CaseCanonicalize replaces such paths by the spelling used on disk, so
+	// that files imported through different spellings are loaded only once.
+	CaseCanonicalize CasePolicy = iota
+
+	// CaseStrict rejects such paths with a PathCaseMismatchError, ensuring
+	// sources load identically on case-sensitive filesystems.
+	CaseStrict
+)
+
+// SetCasePolicy defines how paths differing in case from files on disk are
+// handled. By default, CaseCanonicalize is used.
+func (f *FileSet) SetCasePolicy(p CasePolicy) {
+	f.casePolicy = p
+}
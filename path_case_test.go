@@ -0,0 +1,67 @@
+package idl
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// foldFS emulates a case-insensitive filesystem, in which all entries are
+// stored in lowercase.
+type foldFS struct{ fsys fstest.MapFS }
+
+func (f foldFS) Open(name string) (fs.File, error) {
+	return f.fsys.Open(strings.ToLower(name))
+}
+
+func TestFileSetCaseInsensitiveImports(t *testing.T) {
+	fsys := foldFS{fstest.MapFS{
+		"schemas/main.yarp":   {Data: []byte("package org.example.fold;\n\nimport \"./Common\";\nimport \"./other\";\n\nmessage Order {\n    total Money = 0;\n}\n")},
+		"schemas/other.yarp":  {Data: []byte("package org.example.fold;\n\nimport \"./common\";\n\nmessage Refund {\n    total Money = 0;\n}\n")},
+		"schemas/common.yarp": {Data: []byte("package org.example.fold;\n\nmessage Money {\n    cents int64 = 0;\n}\n")},
+	}}
+
+	fs := NewFileSetFS(fsys)
+	require.NoError(t, fs.Load("Schemas/Main.yarp"))
+	assert.Empty(t, fs.Validate())
+	assert.Len(t, slices.Collect(fs.AllMessages()), 3)
+	var paths []string
+	for _, f := range fs.Files() {
+		paths = append(paths, f.SourcePath)
+	}
+	assert.Equal(t, []string{"schemas/common.yarp", "schemas/other.yarp", "schemas/main.yarp"}, paths)
+
+	fs = NewFileSetFS(fsys)
+	fs.SetCasePolicy(CaseStrict)
+	err := fs.Load("schemas/main.yarp")
+	var mismatch PathCaseMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, "schemas/common.yarp", mismatch.Actual)
+}
+
+func TestFileSetSymlinkedImports(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "real"), 0o755))
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	write("real/common.yarp", "package org.example.link;\n\nmessage Money {\n    cents int64 = 0;\n}\n")
+	write("main.yarp", "package org.example.link;\n\nimport \"./real/common\";\nimport \"./link/common\";\n\nmessage Order {\n    total Money = 0;\n}\n")
+	if err := os.Symlink("real", filepath.Join(dir, "link")); err != nil {
+		t.Skipf("symbolic links unsupported: %s", err)
+	}
+
+	fs := NewFileSet()
+	require.NoError(t, fs.Load(filepath.Join(dir, "link", "..", "main.yarp")))
+	assert.Empty(t, fs.Validate())
+	assert.Len(t, fs.Files(), 2)
+	_, ok := fs.FileByPath(filepath.Join(dir, "link", "common.yarp"))
+	assert.True(t, ok)
+}
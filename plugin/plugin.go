@@ -0,0 +1,190 @@
+// Package plugin implements a protocol allowing generators to run as separate
+// executables, which may be written in any language.
+//
+// A plugin is executed once per generation. It reads a single JSON-encoded
+// Request from its standard input, and writes a single JSON-encoded Response
+// to its standard output. Requests carry the resolved FileSet as a descriptor,
+// as produced by idl.FileSet.MarshalDescriptor, along with the options
+// provided to the generator. Byte slices, such as descriptors and the
+// contents of files, are encoded in base64, as done by package encoding/json:
+//
+//	{"version": 1, "generator": "docs", "descriptor": "WUlETAE...", "parameters": {"title": "API"}}
+//	{"files": [{"path": "index.md", "content": "IyBBUEk..."}]}
+//
+// Plugins report generation failures through Response.Error, which is
+// returned by Generator.Generate. Plugins exiting with a non-zero status are
+// considered to have failed, and their standard error is included in the
+// reported error.
+//
+// Plugins written in Go can use Main or Serve to expose any gen.Generator.
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+)
+
+// ProtocolVersion contains the version of the protocol implemented by this
+// package.
+const ProtocolVersion = 1
+
+// Request represents the input of a plugin.
+type Request struct {
+	// Version contains the version of the protocol, ProtocolVersion.
+	Version int `json:"version"`
+
+	// Generator contains the name under which the plugin is configured.
+	Generator string `json:"generator"`
+
+	// Descriptor contains the descriptor of the FileSet to be generated.
+	Descriptor []byte `json:"descriptor"`
+
+	// Parameters contains generator-specific options. See gen.Options.
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// TemplateDir contains a directory holding templates overriding the ones
+	// embedded by the plugin. See gen.Options.
+	TemplateDir string `json:"template_dir,omitempty"`
+
+	// Timestamp contains the time to be embedded by the plugin, as seconds
+	// since the Unix epoch, or zero. See gen.Options.
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
+
+// Response represents the output of a plugin.
+type Response struct {
+	// Files contains the files produced by the plugin.
+	Files []File `json:"files,omitempty"`
+
+	// Error contains a description of the reason generation failed, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// File represents a file produced by a plugin.
+type File struct {
+	// Path contains the slash-separated path of the file, relative to the
+	// output directory.
+	Path string `json:"path"`
+
+	// Content contains the contents of the file.
+	Content []byte `json:"content"`
+}
+
+// Generator implements gen.Generator by executing a plugin.
+type Generator struct {
+	// Plugin contains the name identifying the plugin, as returned by Name.
+	Plugin string
+
+	// Command contains the path of the plugin executable, followed by its
+	// arguments. Paths without separators are looked up in PATH.
+	Command []string
+
+	// Dir contains the working directory of the plugin. In case it is empty,
+	// the plugin runs in the current directory.
+	Dir string
+}
+
+// Name implements gen.Generator.
+func (g Generator) Name() string { return g.Plugin }
+
+// Generate implements gen.Generator, executing the plugin over fs.
+func (g Generator) Generate(fs *idl.FileSet, opts gen.Options) ([]gen.OutputFile, error) {
+	if len(g.Command) == 0 {
+		return nil, errors.New("no plugin command")
+	}
+	desc, err := fs.MarshalDescriptor()
+	if err != nil {
+		return nil, err
+	}
+	req := Request{
+		Version:     ProtocolVersion,
+		Generator:   g.Plugin,
+		Descriptor:  desc,
+		Parameters:  opts.Parameters,
+		TemplateDir: opts.TemplateDir,
+	}
+	if !opts.Timestamp.IsZero() {
+		req.Timestamp = opts.Timestamp.Unix()
+	}
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(g.Command[0], g.Command[1:]...)
+	cmd.Dir = g.Dir
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	var res Response
+	if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		return nil, fmt.Errorf("invalid plugin response: %w", err)
+	}
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+	files := make([]gen.OutputFile, len(res.Files))
+	for i, f := range res.Files {
+		files[i] = gen.OutputFile{Path: f.Path, Content: f.Content}
+	}
+	return files, nil
+}
+
+// Serve reads a Request from r, executes g over the FileSet it describes, and
+// writes a Response to w. Errors returned by g are reported through the
+// Response, while other errors, such as malformed requests, are returned.
+func Serve(r io.Reader, w io.Writer, g gen.Generator) error {
+	var req Request
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	if req.Version != ProtocolVersion {
+		return fmt.Errorf("unsupported protocol version %d", req.Version)
+	}
+	fs, err := idl.LoadDescriptor(req.Descriptor)
+	if err != nil {
+		return err
+	}
+	opts := gen.Options{Parameters: req.Parameters, TemplateDir: req.TemplateDir}
+	if req.Timestamp != 0 {
+		opts.Timestamp = time.Unix(req.Timestamp, 0).UTC()
+	}
+
+	var res Response
+	files, err := g.Generate(fs, opts)
+	if err != nil {
+		res.Error = err.Error()
+	}
+	for _, f := range files {
+		res.Files = append(res.Files, File{Path: f.Path, Content: f.Content})
+	}
+	return json.NewEncoder(w).Encode(res)
+}
+
+// Main implements a plugin executable exposing g, serving a request read from
+// the standard input. Failures to serve the request are reported to the
+// standard error, and cause the process to exit with status 1.
+func Main(g gen.Generator) {
+	if err := Serve(os.Stdin, os.Stdout, g); err != nil {
+		fmt.Fprintln(os.Stderr, g.Name()+":", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type symbolsGenerator struct{}
+
+func (symbolsGenerator) Name() string { return "symbols" }
+
+func (symbolsGenerator) Generate(fs *idl.FileSet, opts gen.Options) ([]gen.OutputFile, error) {
+	if opts.Parameter("fail", "") != "" {
+		return nil, errors.New("boom")
+	}
+	var b strings.Builder
+	for _, s := range fs.Symbols() {
+		fmt.Fprintf(&b, "%s %s\n", s.Kind, s.Name)
+	}
+	if !opts.Timestamp.IsZero() {
+		b.WriteString(opts.Timestamp.Format(time.RFC3339) + "\n")
+	}
+	return []gen.OutputFile{{Path: opts.Parameter("output", "symbols.txt"), Content: []byte(b.String())}}, nil
+}
+
+// TestMain executes the test binary as a plugin in case YARP_TEST_PLUGIN is
+// set.
+func TestMain(m *testing.M) {
+	switch os.Getenv("YARP_TEST_PLUGIN") {
+	case "serve":
+		Main(symbolsGenerator{})
+		os.Exit(0)
+	case "garbage":
+		fmt.Print("not json")
+		os.Exit(0)
+	case "crash":
+		fmt.Fprintln(os.Stderr, "crashed")
+		os.Exit(2)
+	}
+	os.Exit(m.Run())
+}
+
+func TestGenerator(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/example/order.yarp"))
+	g := Generator{Plugin: "symbols", Command: []string{os.Args[0]}}
+	opts := gen.Options{
+		Parameters: map[string]string{"output": "out/symbols.txt"},
+		Timestamp:  time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC),
+	}
+
+	t.Setenv("YARP_TEST_PLUGIN", "serve")
+	files, err := gen.RunGenerators(fs, opts, g)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "out/symbols.txt", files[0].Path)
+	expected, err := symbolsGenerator{}.Generate(fs, opts)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected[0].Content), string(files[0].Content))
+
+	_, err = g.Generate(fs, gen.Options{Parameters: map[string]string{"fail": "1"}})
+	assert.EqualError(t, err, "boom")
+
+	t.Setenv("YARP_TEST_PLUGIN", "garbage")
+	_, err = g.Generate(fs, gen.Options{})
+	assert.ErrorContains(t, err, "invalid plugin response")
+
+	t.Setenv("YARP_TEST_PLUGIN", "crash")
+	_, err = g.Generate(fs, gen.Options{})
+	assert.EqualError(t, err, "exit status 2: crashed")
+
+	_, err = Generator{Plugin: "missing", Command: []string{"./does-not-exist"}}.Generate(fs, gen.Options{})
+	assert.Error(t, err)
+}
+
+func TestServe(t *testing.T) {
+	var out bytes.Buffer
+	err := Serve(strings.NewReader(`{"version": 2}`), &out, symbolsGenerator{})
+	assert.EqualError(t, err, "unsupported protocol version 2")
+
+	err = Serve(strings.NewReader(`{"version": 1, "descriptor": "AAAA"}`), &out, symbolsGenerator{})
+	assert.ErrorIs(t, err, idl.InvalidDescriptorError{Reason: "missing magic header"})
+
+	err = Serve(strings.NewReader(`{`), &out, symbolsGenerator{})
+	assert.ErrorContains(t, err, "invalid request")
+}
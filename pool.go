@@ -0,0 +1,74 @@
+package idl
+
+import "sync"
+
+// Pool recycles buffers used while scanning and parsing source files, reducing
+// allocations when parsing large amounts of files. Besides reusing token
+// buffers, a Pool interns token text such as identifiers and punctuation, so
+// values repeated across files share a single string. A Pool is safe for
+// concurrent use, and is used by providing it through ParseOptions.Pool.
+type Pool struct {
+	scratch sync.Pool
+}
+
+// NewPool returns a new, empty Pool.
+func NewPool() *Pool {
+	return &Pool{
+		scratch: sync.Pool{
+			New: func() any {
+				return &scanScratch{interned: map[string]string{}}
+			},
+		},
+	}
+}
+
+// scanScratch holds buffers used by a Scanner during a single scan.
+type scanScratch struct {
+	data    []rune
+	offsets []int
+	tokens  []Token
+
+	// interned and buf are kept across scans, so text seen by earlier scans
+	// is not allocated again.
+	interned map[string]string
+	buf      []byte
+}
+
+func (p *Pool) get() *scanScratch {
+	return p.scratch.Get().(*scanScratch)
+}
+
+func (p *Pool) put(s *scanScratch) {
+	clear(s.tokens)
+	s.data = s.data[:0]
+	s.offsets = s.offsets[:0]
+	s.tokens = s.tokens[:0]
+	p.scratch.Put(s)
+}
+
+// scan tokenizes src using buffers obtained from the pool. The returned
+// scratch must be returned to the pool once its tokens are no longer in use.
+func (p *Pool) scan(src []byte) ([]Token, *scanScratch, error) {
+	scratch := p.get()
+	for _, r := range string(src) {
+		scratch.data = append(scratch.data, r)
+	}
+	scratch.offsets = appendByteOffsets(scratch.offsets, scratch.data)
+	s := &Scanner{
+		tokens:  scratch.tokens,
+		data:    scratch.data,
+		offsets: scratch.offsets,
+		dataLen: len(src),
+
+		interned: scratch.interned,
+		buf:      scratch.buf,
+	}
+	tokens, err := s.Run()
+	scratch.tokens = s.tokens
+	scratch.buf = s.buf
+	if err != nil {
+		p.put(scratch)
+		return nil, nil, err
+	}
+	return tokens, scratch, nil
+}
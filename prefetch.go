@@ -0,0 +1,81 @@
+package idl
+
+import (
+	"io/fs"
+	"runtime"
+	"sync"
+)
+
+type prefetchResult struct {
+	file *File
+	err  error
+}
+
+// prefetch discovers all files reachable from file through imports, parsing
+// them concurrently with at most GOMAXPROCS files being parsed at a time.
+// Results are keyed by the path of each file, and later consumed by
+// processImports, which registers declarations sequentially so that the
+// resulting FileSet does not depend on scheduling order. Imports that cannot be
+// resolved are ignored here, and reported by processImports, as are files
+// exceeding limits configured through SetOptions.
+func (f *FileSet) prefetch(path string, file *File) map[string]prefetchResult {
+	results := map[string]prefetchResult{}
+	type job struct {
+		path string
+		stat fs.FileInfo
+	}
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	frontier := []struct {
+		path string
+		file *File
+	}{{path, file}}
+	seen := map[string]bool{path: true}
+
+	for depth := 1; len(frontier) > 0; depth++ {
+		if max := f.options.MaxImportDepth; max > 0 && depth > max {
+			break
+		}
+		var jobs []job
+		for _, item := range frontier {
+			for _, i := range item.file.importPaths() {
+				p, stat, err := f.resolveImport(item.path, i)
+				if err != nil || seen[p] || f.isLoaded(p) {
+					continue
+				}
+				if max := f.options.MaxFiles; max > 0 && len(f.loadedFiles)+len(results)+len(jobs) >= max {
+					break
+				}
+				seen[p] = true
+				jobs = append(jobs, job{p, stat})
+			}
+		}
+
+		parsed := make([]prefetchResult, len(jobs))
+		var wg sync.WaitGroup
+		for idx, j := range jobs {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(idx int, j job) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				file, err := f.loadFile(j.path, j.stat)
+				parsed[idx] = prefetchResult{file: file, err: err}
+			}(idx, j)
+		}
+		wg.Wait()
+
+		frontier = frontier[:0]
+		for idx, j := range jobs {
+			results[j.path] = parsed[idx]
+			if parsed[idx].err == nil {
+				frontier = append(frontier, struct {
+					path string
+					file *File
+				}{j.path, parsed[idx].file})
+			}
+		}
+	}
+	return results
+}
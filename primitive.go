@@ -0,0 +1,94 @@
+package idl
+
+// PrimitiveInfo describes properties of a PrimitiveType, along with the types
+// it maps to by default in languages targeted by generators.
+type PrimitiveInfo struct {
+	// Keyword contains the name used to refer to the type in source files.
+	Keyword string
+
+	// BitWidth contains the amount of bits used by the type, or zero for types
+	// with variable length.
+	BitWidth int
+
+	// Signed indicates whether the type represents signed numbers.
+	Signed bool
+
+	// Float indicates whether the type represents floating-point numbers.
+	Float bool
+
+	// ZeroValue contains a literal representation of the type's zero value.
+	ZeroValue string
+
+	// GoType contains the Go type the primitive maps to.
+	GoType string
+
+	// TypeScriptType contains the TypeScript type the primitive maps to.
+	TypeScriptType string
+
+	// JSONType contains the JSON type used to represent the primitive. 64-bit
+	// integers are represented as strings, since JSON numbers cannot hold them
+	// without loss of precision.
+	JSONType string
+}
+
+var primitiveInfo = map[PrimitiveType]PrimitiveInfo{
+	Uint8:   {Keyword: "uint8", BitWidth: 8, ZeroValue: "0", GoType: "uint8", TypeScriptType: "number", JSONType: "integer"},
+	Uint16:  {Keyword: "uint16", BitWidth: 16, ZeroValue: "0", GoType: "uint16", TypeScriptType: "number", JSONType: "integer"},
+	Uint32:  {Keyword: "uint32", BitWidth: 32, ZeroValue: "0", GoType: "uint32", TypeScriptType: "number", JSONType: "integer"},
+	Uint64:  {Keyword: "uint64", BitWidth: 64, ZeroValue: "0", GoType: "uint64", TypeScriptType: "bigint", JSONType: "string"},
+	Int8:    {Keyword: "int8", BitWidth: 8, Signed: true, ZeroValue: "0", GoType: "int8", TypeScriptType: "number", JSONType: "integer"},
+	Int16:   {Keyword: "int16", BitWidth: 16, Signed: true, ZeroValue: "0", GoType: "int16", TypeScriptType: "number", JSONType: "integer"},
+	Int32:   {Keyword: "int32", BitWidth: 32, Signed: true, ZeroValue: "0", GoType: "int32", TypeScriptType: "number", JSONType: "integer"},
+	Int64:   {Keyword: "int64", BitWidth: 64, Signed: true, ZeroValue: "0", GoType: "int64", TypeScriptType: "bigint", JSONType: "string"},
+	Float32: {Keyword: "float32", BitWidth: 32, Signed: true, Float: true, ZeroValue: "0", GoType: "float32", TypeScriptType: "number", JSONType: "number"},
+	Float64: {Keyword: "float64", BitWidth: 64, Signed: true, Float: true, ZeroValue: "0", GoType: "float64", TypeScriptType: "number", JSONType: "number"},
+	Bool:    {Keyword: "bool", BitWidth: 1, ZeroValue: "false", GoType: "bool", TypeScriptType: "boolean", JSONType: "boolean"},
+	String:  {Keyword: "string", ZeroValue: `""`, GoType: "string", TypeScriptType: "string", JSONType: "string"},
+}
+
+// PrimitiveTypes returns all primitive types that can be used in source files,
+// in declaration order.
+func PrimitiveTypes() []PrimitiveType {
+	var r []PrimitiveType
+	for p := Invalid; p <= String; p++ {
+		if _, ok := primitiveInfo[p]; ok {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// Info returns metadata about the PrimitiveType, along with a boolean
+// indicating whether the type can be used in source files.
+func (i PrimitiveType) Info() (PrimitiveInfo, bool) {
+	v, ok := primitiveInfo[i]
+	return v, ok
+}
+
+// Keyword returns the name used to refer to the type in source files.
+func (i PrimitiveType) Keyword() string { return primitiveInfo[i].Keyword }
+
+// BitWidth returns the amount of bits used by the type, or zero for types with
+// variable length.
+func (i PrimitiveType) BitWidth() int { return primitiveInfo[i].BitWidth }
+
+// IsSigned returns whether the type represents signed numbers.
+func (i PrimitiveType) IsSigned() bool { return primitiveInfo[i].Signed }
+
+// IsFloat returns whether the type represents floating-point numbers.
+func (i PrimitiveType) IsFloat() bool { return primitiveInfo[i].Float }
+
+// IsInteger returns whether the type represents integer numbers.
+func (i PrimitiveType) IsInteger() bool { return i >= Uint8 && i <= Int64 }
+
+// ZeroValue returns a literal representation of the type's zero value.
+func (i PrimitiveType) ZeroValue() string { return primitiveInfo[i].ZeroValue }
+
+// GoType returns the Go type the primitive maps to.
+func (i PrimitiveType) GoType() string { return primitiveInfo[i].GoType }
+
+// TypeScriptType returns the TypeScript type the primitive maps to.
+func (i PrimitiveType) TypeScriptType() string { return primitiveInfo[i].TypeScriptType }
+
+// JSONType returns the JSON type used to represent the primitive.
+func (i PrimitiveType) JSONType() string { return primitiveInfo[i].JSONType }
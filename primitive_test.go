@@ -0,0 +1,41 @@
+package idl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrimitiveInfo(t *testing.T) {
+	for _, p := range PrimitiveTypes() {
+		assert.Equal(t, p, stringToPrimitive[p.Keyword()])
+	}
+	assert.NotContains(t, PrimitiveTypes(), Struct)
+	assert.Equal(t, 64, Int64.BitWidth())
+	assert.True(t, Int8.IsSigned())
+	assert.False(t, Uint8.IsSigned())
+	assert.True(t, Uint8.IsInteger())
+	assert.False(t, Float32.IsInteger())
+	assert.Equal(t, "bigint", Uint64.TypeScriptType())
+	assert.Equal(t, `""`, String.ZeroValue())
+	_, ok := OneOf.Info()
+	assert.False(t, ok)
+}
+
+func TestWalkType(t *testing.T) {
+	typ := Map{Key: String, Value: Array{Of: Unresolved{Name: "pkg.Foo"}}}
+	var visited []TypeType
+	WalkType(typ, func(t Type) bool {
+		visited = append(visited, t.Type())
+		return true
+	})
+	assert.Equal(t, []TypeType{TypeMap, TypePrimitive, TypeArray, TypeUnresolved}, visited)
+	assert.Equal(t, []string{"pkg.Foo"}, ReferencedNames(typ))
+
+	visited = nil
+	WalkType(typ, func(t Type) bool {
+		visited = append(visited, t.Type())
+		return t.Type() != TypeArray
+	})
+	assert.Equal(t, []TypeType{TypeMap, TypePrimitive, TypeArray}, visited)
+}
@@ -0,0 +1,25 @@
+package idl
+
+// startProgress reports the entry file under path as loaded. Files discovered
+// by prefetch are expected to be loaded by the current call to Load.
+func (f *FileSet) startProgress(path string) {
+	f.progress.n, f.progress.total = 0, 1
+	for _, r := range f.prefetched {
+		if r.err == nil {
+			f.progress.total++
+		}
+	}
+	f.reportProgress(path)
+}
+
+// reportProgress reports the file under path as loaded to the ProgressFunc
+// configured through SetOptions, if any.
+func (f *FileSet) reportProgress(path string) {
+	f.progress.n++
+	if f.progress.n > f.progress.total {
+		f.progress.total = f.progress.n
+	}
+	if f.options.Progress != nil {
+		f.options.Progress(path, f.progress.n, f.progress.total)
+	}
+}
@@ -0,0 +1,41 @@
+package idl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetProgress(t *testing.T) {
+	type call struct {
+		path     string
+		n, total int
+	}
+	var calls []call
+	fs := NewFileSet()
+	fs.SetOptions(FileSetOptions{Progress: func(path string, n, total int) {
+		calls = append(calls, call{filepath.Base(path), n, total})
+	}})
+	require.NoError(t, fs.Load("./test/diamond/main.yarp"))
+	assert.Equal(t, []call{
+		{"main.yarp", 1, 4},
+		{"left.yarp", 2, 4},
+		{"base.yarp", 3, 4},
+		{"right.yarp", 4, 4},
+	}, calls)
+
+	entry := writeImportTree(t, t.TempDir(), 32)
+	calls = nil
+	fs = NewFileSet()
+	fs.SetOptions(FileSetOptions{Progress: func(path string, n, total int) {
+		calls = append(calls, call{path, n, total})
+	}})
+	require.NoError(t, fs.Load(entry))
+	require.Len(t, calls, 32)
+	for i, c := range calls {
+		assert.Equal(t, i+1, c.n)
+		assert.Equal(t, 32, c.total)
+	}
+}
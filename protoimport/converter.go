@@ -0,0 +1,372 @@
+package protoimport
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/libyarp/idl"
+)
+
+// scalars maps protobuf scalar types to their YARP equivalent.
+var scalars = map[string]string{
+	"double": "float64", "float": "float32",
+	"int32": "int32", "sint32": "int32", "sfixed32": "int32",
+	"int64": "int64", "sint64": "int64", "sfixed64": "int64",
+	"uint32": "uint32", "fixed32": "uint32",
+	"uint64": "uint64", "fixed64": "uint64",
+	"bool": "bool", "string": "string", "bytes": "array<uint8>",
+}
+
+// wellKnownPackage contains the package of protobuf well-known types.
+const wellKnownPackage = "google.protobuf"
+
+// wellKnown maps well-known types to their equivalent in the YARP standard
+// library, along with the import providing them.
+var wellKnown = map[string]struct{ name, path string }{
+	"Timestamp": {"yarp.std.Timestamp", "yarp/std/time"},
+	"Duration":  {"yarp.std.Duration", "yarp/std/time"},
+	"Any":       {"yarp.std.Any", "yarp/std/any"},
+	"Empty":     {"yarp.std.Empty", "yarp/std/empty"},
+}
+
+// wrappers maps well-known wrapper types to the scalar they wrap.
+var wrappers = map[string]string{
+	"DoubleValue": "double", "FloatValue": "float",
+	"Int64Value": "int64", "UInt64Value": "uint64",
+	"Int32Value": "int32", "UInt32Value": "uint32",
+	"BoolValue": "bool", "StringValue": "string", "BytesValue": "bytes",
+}
+
+type symbol struct {
+	pkg  string
+	name string
+	enum *protoEnum
+}
+
+type converter struct {
+	// symbols maps fully qualified protobuf names, including their leading
+	// dot, to the YARP declaration they are converted into.
+	symbols map[string]*symbol
+}
+
+// declare registers all messages and enums of f.
+func (c *converter) declare(f *protoFile) error {
+	declared := map[string]string{}
+	var walk func(scope, flat string, messages []*protoMessage, enums []*protoEnum) error
+	walk = func(scope, flat string, messages []*protoMessage, enums []*protoEnum) error {
+		for _, e := range enums {
+			c.symbols[scope+"."+e.name] = &symbol{pkg: f.pkg, name: "int32", enum: e}
+		}
+		for _, m := range messages {
+			full, name := scope+"."+m.name, flat+m.name
+			if other, ok := declared[name]; ok {
+				return fmt.Errorf("%s: %s and %s are both converted into %s", f.path, other[1:], full[1:], name)
+			}
+			declared[name] = full
+			c.symbols[full] = &symbol{pkg: f.pkg, name: name}
+			if err := walk(full, name, m.nested, m.enums); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk("."+f.pkg, "", f.messages, f.enums)
+}
+
+// fileConverter converts a single protoFile.
+type fileConverter struct {
+	*converter
+	file     *protoFile
+	buf      strings.Builder
+	imports  map[string]bool
+	warnings []string
+	enums    map[*protoEnum]bool
+}
+
+func (c *converter) file(f *protoFile) (*Result, error) {
+	fc := &fileConverter{converter: c, file: f, imports: map[string]bool{}, enums: map[*protoEnum]bool{}}
+	var body strings.Builder
+	var walk func(scope string, messages []*protoMessage) error
+	walk = func(scope string, messages []*protoMessage) error {
+		for _, m := range messages {
+			full := scope + "." + m.name
+			if err := fc.message(&body, full, m); err != nil {
+				return err
+			}
+			if err := walk(full, m.nested); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk("."+f.pkg, f.messages); err != nil {
+		return nil, err
+	}
+	for _, s := range f.services {
+		if err := fc.service(&body, s); err != nil {
+			return nil, err
+		}
+	}
+
+	var imports []string
+	for _, i := range f.imports {
+		if !strings.HasPrefix(i, "google/protobuf/") {
+			imports = append(imports, strings.TrimSuffix(i, ProtoExtension))
+		}
+	}
+	var std []string
+	for i := range fc.imports {
+		std = append(std, i)
+	}
+	sort.Strings(std)
+	imports = append(imports, std...)
+
+	fc.buf.WriteString("package " + f.pkg + ";\n")
+	if len(imports) > 0 {
+		fc.buf.WriteString("\n")
+		for _, i := range imports {
+			fc.buf.WriteString("import " + strconv.Quote(i) + ";\n")
+		}
+	}
+	fc.buf.WriteString(body.String())
+
+	src := []byte(fc.buf.String())
+	parsed, err := idl.ParseSource(src, idl.ParseOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: converted source is invalid: %w", f.path, err)
+	}
+	return &Result{
+		Path:     strings.TrimSuffix(f.path, ProtoExtension) + idl.SourceExtension,
+		Source:   src,
+		File:     parsed,
+		Warnings: fc.warnings,
+	}, nil
+}
+
+func (fc *fileConverter) warn(format string, args ...any) {
+	fc.warnings = append(fc.warnings, fc.file.path+": "+fmt.Sprintf(format, args...))
+}
+
+// lookup resolves a type reference made within scope following protobuf
+// scoping rules, in which the innermost declaration wins. ok is false when
+// the type is not declared by any of the converted files.
+func (fc *fileConverter) lookup(scope, ref string) (*symbol, string, bool) {
+	if strings.HasPrefix(ref, ".") {
+		s, ok := fc.symbols[ref]
+		return s, ref, ok
+	}
+	for {
+		full := scope + "." + ref
+		if s, ok := fc.symbols[full]; ok {
+			return s, full, true
+		}
+		if scope == "" {
+			return nil, "." + ref, false
+		}
+		scope = scope[:strings.LastIndexByte(scope, '.')]
+	}
+}
+
+// typeRef converts a reference to a message or enum into a YARP type name.
+// optional is true when the converted type is a well-known wrapper, and
+// should therefore be annotated as @optional.
+func (fc *fileConverter) typeRef(scope, ref string) (name string, enum *protoEnum, optional bool, err error) {
+	if t, ok := scalars[ref]; ok {
+		return t, nil, false, nil
+	}
+	s, full, ok := fc.lookup(scope, ref)
+	if ok {
+		if s.enum != nil {
+			return s.name, s.enum, false, nil
+		}
+		if s.pkg == fc.file.pkg {
+			return s.name, nil, false, nil
+		}
+		return s.pkg + "." + s.name, nil, false, nil
+	}
+
+	full = full[1:]
+	if local := strings.TrimPrefix(full, wellKnownPackage+"."); local != full {
+		if w, ok := wellKnown[local]; ok {
+			fc.imports[w.path] = true
+			return w.name, nil, false, nil
+		}
+		if w, ok := wrappers[local]; ok {
+			return scalars[w], nil, true, nil
+		}
+		return "", nil, false, fmt.Errorf("%s: well-known type %s has no YARP equivalent", fc.file.path, full)
+	}
+	return strings.TrimPrefix(ref, "."), nil, false, nil
+}
+
+func writeComments(b *strings.Builder, indent string, comments []string) {
+	for _, c := range comments {
+		if c == "" {
+			b.WriteString(indent + "#\n")
+		} else {
+			b.WriteString(indent + "# " + c + "\n")
+		}
+	}
+}
+
+func (fc *fileConverter) message(b *strings.Builder, full string, m *protoMessage) error {
+	b.WriteString("\n")
+	writeComments(b, "", m.comments)
+	if m.deprecated {
+		b.WriteString("@" + idl.DeprecatedAnnotation + "\n")
+	}
+	b.WriteString("message " + fc.symbols[full].name + " {\n")
+	index := 0
+	emitted := map[int]bool{}
+	for _, f := range m.fields {
+		if f.oneof < 0 {
+			if err := fc.field(b, "    ", full, f, &index, true); err != nil {
+				return err
+			}
+			continue
+		}
+		if emitted[f.oneof] {
+			continue
+		}
+		emitted[f.oneof] = true
+		writeComments(b, "    ", m.oneofs[f.oneof].comments)
+		b.WriteString("    oneof {\n")
+		oneofIndex := index
+		index++
+		for _, o := range m.fields {
+			if o.oneof != f.oneof {
+				continue
+			}
+			if err := fc.field(b, "        ", full, o, &index, false); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(b, "    } = %d;\n", oneofIndex)
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+// field writes f using the next available index. annotate indicates whether
+// @optional and @repeated may be applied, which is not the case for cases of
+// a oneof.
+func (fc *fileConverter) field(b *strings.Builder, indent, scope string, f *protoField, index *int, annotate bool) error {
+	var (
+		typ       string
+		enums     []*protoEnum
+		optional  = f.label == labelOptional
+		annotated []string
+	)
+	if f.key != "" {
+		key, ok := scalars[f.key]
+		if !ok || f.key == "bytes" || f.key == "double" || f.key == "float" {
+			return fmt.Errorf("%s: invalid map key type %s", fc.file.path, f.key)
+		}
+		value, enum, _, err := fc.typeRef(scope, f.value)
+		if err != nil {
+			return err
+		}
+		if enum != nil {
+			enums = append(enums, enum)
+		}
+		typ = "map<" + key + ", " + value + ">"
+	} else {
+		t, enum, wrapper, err := fc.typeRef(scope, f.typ)
+		if err != nil {
+			return err
+		}
+		if enum != nil {
+			enums = append(enums, enum)
+		}
+		_, scalar := scalars[f.typ]
+		// Singular message fields have explicit presence in proto3.
+		if wrapper || f.label == labelNone && !scalar && enum == nil && fc.file.syntax == "proto3" {
+			optional = true
+		}
+		typ = t
+	}
+
+	comments := f.comments
+	for _, e := range enums {
+		if !fc.enums[e] {
+			fc.enums[e] = true
+			fc.warn("enum %s is represented as int32", e.name)
+		}
+		values := make([]string, len(e.values))
+		for i, v := range e.values {
+			values[i] = v.name + " = " + strconv.Itoa(v.number)
+		}
+		comments = append(comments[:len(comments):len(comments)], e.name+" values: "+strings.Join(values, ", ")+".")
+	}
+	writeComments(b, indent, comments)
+
+	if f.deprecated {
+		annotated = append(annotated, "@"+idl.DeprecatedAnnotation)
+	}
+	if annotate && optional {
+		annotated = append(annotated, "@"+idl.OptionalAnnotation)
+	}
+	if annotate && f.label == labelRepeated {
+		annotated = append(annotated, "@"+idl.RepeatedAnnotation)
+	}
+	annotated = append(annotated, f.name, typ, "=", strconv.Itoa(*index))
+	b.WriteString(indent + strings.Join(annotated, " ") + ";\n")
+	*index++
+	return nil
+}
+
+func (fc *fileConverter) service(b *strings.Builder, s *protoService) error {
+	b.WriteString("\n")
+	writeComments(b, "", s.comments)
+	if s.deprecated {
+		b.WriteString("@" + idl.DeprecatedAnnotation + "\n")
+	}
+	b.WriteString("service " + s.name + " {\n")
+	scope := "." + fc.file.pkg
+	for _, m := range s.methods {
+		if m.clientStreaming {
+			fc.warn("client streaming of %s.%s is not supported, and was converted into a single argument", s.name, m.name)
+		}
+		input, err := fc.methodType(scope, m.input)
+		if err != nil {
+			return err
+		}
+		output, err := fc.methodType(scope, m.output)
+		if err != nil {
+			return err
+		}
+		writeComments(b, "    ", m.comments)
+		if m.deprecated {
+			b.WriteString("    @" + idl.DeprecatedAnnotation + "\n")
+		}
+		b.WriteString("    " + m.name + "(" + input + ")")
+		switch {
+		case output == "":
+			if m.serverStreaming {
+				return fmt.Errorf("%s: %s.%s streams google.protobuf.Empty values", fc.file.path, s.name, m.name)
+			}
+		case m.serverStreaming:
+			b.WriteString(" -> stream " + output)
+		default:
+			b.WriteString(" -> " + output)
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+// methodType converts an argument or return type of a method, representing
+// google.protobuf.Empty as an empty string.
+func (fc *fileConverter) methodType(scope, ref string) (string, error) {
+	if _, full, ok := fc.lookup(scope, ref); !ok && full == "."+wellKnownPackage+".Empty" {
+		return "", nil
+	}
+	t, enum, wrapper, err := fc.typeRef(scope, ref)
+	if err == nil && (enum != nil || wrapper) {
+		err = fmt.Errorf("%s: %s cannot be used as a method argument or return type", fc.file.path, ref)
+	}
+	return t, err
+}
@@ -0,0 +1,428 @@
+package protoimport
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Wire types of the protobuf encoding.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// scalarTypes maps values of FieldDescriptorProto.Type to the name of the
+// scalar type they represent.
+var scalarTypes = map[uint64]string{
+	1: "double", 2: "float", 3: "int64", 4: "uint64", 5: "int32",
+	6: "fixed64", 7: "fixed32", 8: "bool", 9: "string", 12: "bytes",
+	13: "uint32", 15: "sfixed32", 16: "sfixed64", 17: "sint32", 18: "sint64",
+}
+
+const (
+	typeGroup   = 10
+	typeMessage = 11
+	typeEnum    = 14
+)
+
+var errTruncated = errors.New("truncated descriptor")
+
+// decode invokes fn for each field of the encoded message data. For varint
+// and fixed-size fields v holds the value; for length-delimited fields b
+// holds the contents.
+func decode(data []byte, fn func(num int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errTruncated
+		}
+		data = data[n:]
+		num := int(key >> 3)
+		var (
+			v uint64
+			b []byte
+		)
+		switch key & 7 {
+		case wireVarint:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return errTruncated
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errTruncated
+			}
+			v, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errTruncated
+			}
+			v, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return errTruncated
+			}
+			b, data = data[n:n+int(size)], data[n+int(size):]
+		default:
+			return fmt.Errorf("unsupported wire type %d", key&7)
+		}
+		if err := fn(num, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeDescriptorSet reads an encoded google.protobuf.FileDescriptorSet.
+// Warnings are keyed by file path.
+func decodeDescriptorSet(data []byte) ([]*protoFile, map[string][]string, error) {
+	var (
+		files    []*protoFile
+		warnings = map[string][]string{}
+	)
+	err := decode(data, func(num int, _ uint64, b []byte) error {
+		if num != 1 {
+			return nil
+		}
+		d := &descriptorDecoder{comments: map[string][]string{}}
+		f, err := d.file(b)
+		if err != nil {
+			return err
+		}
+		files = append(files, f)
+		warnings[f.path] = d.warnings
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid descriptor set: %w", err)
+	}
+	return files, warnings, nil
+}
+
+type descriptorDecoder struct {
+	// comments maps source code paths, joined by dots, to the leading
+	// comments of the element they identify.
+	comments map[string][]string
+	syntax   string
+	warnings []string
+}
+
+func (d *descriptorDecoder) comment(path []int) []string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = strconv.Itoa(p)
+	}
+	return d.comments[strings.Join(parts, ".")]
+}
+
+func appendPath(path []int, elem ...int) []int {
+	return append(append([]int(nil), path...), elem...)
+}
+
+func (d *descriptorDecoder) file(data []byte) (*protoFile, error) {
+	f := &protoFile{syntax: "proto2"}
+	// Source code information is read first, as it may be encoded after
+	// the elements it describes.
+	err := decode(data, func(num int, _ uint64, b []byte) error {
+		switch num {
+		case 9:
+			return d.sourceCodeInfo(b)
+		case 12:
+			f.syntax = string(b)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.syntax = f.syntax
+	var messages, services int
+	err = decode(data, func(num int, _ uint64, b []byte) error {
+		var err error
+		switch num {
+		case 1:
+			f.path = string(b)
+		case 2:
+			f.pkg = string(b)
+		case 3:
+			f.imports = append(f.imports, string(b))
+		case 4:
+			var m *protoMessage
+			m, err = d.message(b, []int{4, messages})
+			f.messages = append(f.messages, m)
+			messages++
+		case 5:
+			var e *protoEnum
+			e, err = d.enum(b)
+			f.enums = append(f.enums, e)
+		case 6:
+			var s *protoService
+			s, err = d.service(b, []int{6, services})
+			f.services = append(f.services, s)
+			services++
+		case 7:
+			d.warnings = append(d.warnings, fmt.Sprintf("%s: extensions are not supported, and were ignored", f.path))
+		}
+		return err
+	})
+	return f, err
+}
+
+func (d *descriptorDecoder) sourceCodeInfo(data []byte) error {
+	return decode(data, func(num int, _ uint64, b []byte) error {
+		if num != 1 {
+			return nil
+		}
+		var (
+			path    []string
+			leading string
+		)
+		err := decode(b, func(num int, v uint64, b []byte) error {
+			switch num {
+			case 1:
+				if b == nil {
+					path = append(path, strconv.Itoa(int(v)))
+					return nil
+				}
+				for len(b) > 0 {
+					v, n := binary.Uvarint(b)
+					if n <= 0 {
+						return errTruncated
+					}
+					path, b = append(path, strconv.Itoa(int(v))), b[n:]
+				}
+			case 3:
+				leading = string(b)
+			}
+			return nil
+		})
+		if err != nil || leading == "" {
+			return err
+		}
+		var lines []string
+		for _, l := range strings.Split(strings.TrimRight(leading, "\n"), "\n") {
+			lines = append(lines, strings.TrimSpace(l))
+		}
+		d.comments[strings.Join(path, ".")] = lines
+		return nil
+	})
+}
+
+func (d *descriptorDecoder) message(data []byte, path []int) (*protoMessage, error) {
+	m := &protoMessage{comments: d.comment(path)}
+	var fields, nested, oneofs int
+	err := decode(data, func(num int, _ uint64, b []byte) error {
+		var err error
+		switch num {
+		case 1:
+			m.name = string(b)
+		case 2:
+			var f *protoField
+			f, err = d.field(b, appendPath(path, 2, fields))
+			m.fields = append(m.fields, f)
+			fields++
+		case 3:
+			var n *protoMessage
+			n, err = d.message(b, appendPath(path, 3, nested))
+			m.nested = append(m.nested, n)
+			nested++
+		case 4:
+			var e *protoEnum
+			e, err = d.enum(b)
+			m.enums = append(m.enums, e)
+		case 7:
+			err = decode(b, func(num int, v uint64, _ []byte) error {
+				switch num {
+				case 3:
+					m.deprecated = v != 0
+				case 7:
+					m.mapEntry = v != 0
+				}
+				return nil
+			})
+		case 8:
+			o := &protoOneof{comments: d.comment(appendPath(path, 8, oneofs))}
+			err = decode(b, func(num int, _ uint64, b []byte) error {
+				if num == 1 {
+					o.name = string(b)
+				}
+				return nil
+			})
+			m.oneofs = append(m.oneofs, o)
+			oneofs++
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, d.inlineMapEntries(m)
+}
+
+// inlineMapEntries replaces repeated fields referencing map entry messages
+// nested in m by map fields, and removes the entry messages.
+func (d *descriptorDecoder) inlineMapEntries(m *protoMessage) error {
+	entries := map[string]*protoMessage{}
+	nested := m.nested[:0]
+	for _, n := range m.nested {
+		if n.mapEntry {
+			entries[n.name] = n
+		} else {
+			nested = append(nested, n)
+		}
+	}
+	m.nested = nested
+	for _, f := range m.fields {
+		entry, ok := entries[f.typ[strings.LastIndexByte(f.typ, '.')+1:]]
+		if !ok || f.label != labelRepeated {
+			continue
+		}
+		if len(entry.fields) != 2 {
+			return fmt.Errorf("invalid map entry %s", entry.name)
+		}
+		f.label, f.typ, f.key, f.value = labelNone, "", entry.fields[0].typ, entry.fields[1].typ
+	}
+	return nil
+}
+
+func (d *descriptorDecoder) field(data []byte, path []int) (*protoField, error) {
+	f := &protoField{comments: d.comment(path), oneof: -1}
+	var (
+		typ            uint64
+		typeName       string
+		proto3Optional bool
+	)
+	err := decode(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			f.name = string(b)
+		case 4:
+			f.label = label(v)
+		case 5:
+			typ = v
+		case 6:
+			typeName = string(b)
+		case 8:
+			return decode(b, func(num int, v uint64, _ []byte) error {
+				if num == 3 {
+					f.deprecated = v != 0
+				}
+				return nil
+			})
+		case 9:
+			f.oneof = int(v)
+		case 17:
+			proto3Optional = v != 0
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case typ == typeGroup:
+		return nil, fmt.Errorf("field %s: groups are not supported", f.name)
+	case typ == typeMessage || typ == typeEnum:
+		f.typ = typeName
+	default:
+		f.typ = scalarTypes[typ]
+	}
+	if proto3Optional {
+		// Presence of proto3 optional fields is represented through
+		// synthetic oneofs, which are not relevant to YARP.
+		f.oneof, f.label = -1, labelOptional
+	} else if f.label == labelOptional && d.syntax != "proto2" {
+		f.label = labelNone
+	}
+	if f.oneof >= 0 {
+		f.label = labelNone
+	}
+	return f, nil
+}
+
+func (d *descriptorDecoder) enum(data []byte) (*protoEnum, error) {
+	e := &protoEnum{}
+	err := decode(data, func(num int, _ uint64, b []byte) error {
+		switch num {
+		case 1:
+			e.name = string(b)
+		case 2:
+			var v protoEnumValue
+			err := decode(b, func(num int, n uint64, b []byte) error {
+				switch num {
+				case 1:
+					v.name = string(b)
+				case 2:
+					v.number = int(int32(n))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			e.values = append(e.values, v)
+		}
+		return nil
+	})
+	return e, err
+}
+
+func (d *descriptorDecoder) service(data []byte, path []int) (*protoService, error) {
+	s := &protoService{comments: d.comment(path)}
+	var methods int
+	err := decode(data, func(num int, _ uint64, b []byte) error {
+		switch num {
+		case 1:
+			s.name = string(b)
+		case 2:
+			m, err := d.method(b, appendPath(path, 2, methods))
+			if err != nil {
+				return err
+			}
+			s.methods = append(s.methods, m)
+			methods++
+		case 3:
+			return decode(b, func(num int, v uint64, _ []byte) error {
+				if num == 33 {
+					s.deprecated = v != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return s, err
+}
+
+func (d *descriptorDecoder) method(data []byte, path []int) (*protoMethod, error) {
+	m := &protoMethod{comments: d.comment(path)}
+	err := decode(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			m.name = string(b)
+		case 2:
+			m.input = string(b)
+		case 3:
+			m.output = string(b)
+		case 4:
+			return decode(b, func(num int, v uint64, _ []byte) error {
+				if num == 33 {
+					m.deprecated = v != 0
+				}
+				return nil
+			})
+		case 5:
+			m.clientStreaming = v != 0
+		case 6:
+			m.serverStreaming = v != 0
+		}
+		return nil
+	})
+	return m, err
+}
@@ -0,0 +1,155 @@
+package protoimport
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/libyarp/idl"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenSymbol
+)
+
+type token struct {
+	kind   tokenKind
+	value  string
+	line   int
+	column int
+
+	// comments contains the comment block immediately preceding the token,
+	// with comment markers removed.
+	comments []string
+}
+
+// lex splits a .proto source into tokens. Comments are attached to the token
+// following them, unless separated from it by a blank line, or placed after
+// another token on the same line.
+func lex(src string) ([]token, error) {
+	var (
+		tokens   []token
+		comments []string
+		line     = 1
+		column   = 1
+		lastLine = 0
+		i        = 0
+
+		// content indicates whether the current line contains anything
+		// besides whitespace, and blank whether an empty line was found
+		// since the last comment.
+		content = false
+		blank   = false
+	)
+	advance := func(n int) {
+		for _, r := range src[i : i+n] {
+			if r == '\n' {
+				line++
+				column = 1
+			} else {
+				column++
+			}
+		}
+		i += n
+	}
+	addComment := func(text string, startLine int) {
+		if startLine == lastLine {
+			return
+		}
+		if blank {
+			comments, blank = nil, false
+		}
+		comments = append(comments, text)
+	}
+	emit := func(t token) {
+		if blank {
+			comments, blank = nil, false
+		}
+		t.comments, comments = comments, nil
+		tokens = append(tokens, t)
+		lastLine, content = line, true
+	}
+
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '\n':
+			if !content {
+				blank = true
+			}
+			content = false
+			advance(1)
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			advance(1)
+		case strings.HasPrefix(src[i:], "//"):
+			startLine := line
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			addComment(strings.TrimSpace(strings.TrimPrefix(src[i:i+end], "//")), startLine)
+			content = true
+			advance(end)
+		case strings.HasPrefix(src[i:], "/*"):
+			startLine := line
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, idl.SyntaxError{Message: "unterminated comment", Line: line, Column: column}
+			}
+			for _, l := range strings.Split(src[i+2:i+2+end], "\n") {
+				l = strings.TrimSpace(l)
+				l = strings.TrimSpace(strings.TrimPrefix(l, "*"))
+				if l != "" {
+					addComment(l, startLine)
+				}
+			}
+			content = true
+			advance(end + 4)
+		case c == '"' || c == '\'':
+			t := token{kind: tokenString, line: line, column: column}
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\n' {
+					return nil, idl.SyntaxError{Message: "unterminated string", Line: line, Column: column}
+				}
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				b.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, idl.SyntaxError{Message: "unterminated string", Line: line, Column: column}
+			}
+			t.value = b.String()
+			emit(t)
+			advance(j + 1 - i)
+		case isIdentStart(rune(c)) || unicode.IsDigit(rune(c)):
+			t := token{kind: tokenIdent, line: line, column: column}
+			if unicode.IsDigit(rune(c)) {
+				t.kind = tokenNumber
+			}
+			j := i
+			for j < len(src) && (isIdentStart(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '.' || t.kind == tokenNumber && (src[j] == '+' || src[j] == '-') && (src[j-1] == 'e' || src[j-1] == 'E')) {
+				j++
+			}
+			t.value = src[i:j]
+			emit(t)
+			advance(j - i)
+		default:
+			t := token{kind: tokenSymbol, value: string(c), line: line, column: column}
+			emit(t)
+			advance(1)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, line: line, column: column}), nil
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
@@ -0,0 +1,80 @@
+package protoimport
+
+// The types below represent the subset of protobuf definitions relevant to
+// the conversion, and are populated either from .proto sources or from
+// descriptors.
+
+type label int
+
+const (
+	labelNone label = iota
+	labelOptional
+	labelRequired
+	labelRepeated
+)
+
+type protoFile struct {
+	path     string
+	syntax   string
+	pkg      string
+	imports  []string
+	messages []*protoMessage
+	enums    []*protoEnum
+	services []*protoService
+}
+
+type protoMessage struct {
+	name       string
+	comments   []string
+	fields     []*protoField
+	oneofs     []*protoOneof
+	nested     []*protoMessage
+	enums      []*protoEnum
+	mapEntry   bool
+	deprecated bool
+}
+
+type protoOneof struct {
+	name     string
+	comments []string
+}
+
+type protoField struct {
+	name     string
+	comments []string
+	label    label
+	// typ contains either the name of a scalar type, such as "int32", or a
+	// reference to a message or enum.
+	typ        string
+	oneof      int
+	deprecated bool
+
+	// key and value are set for map fields, in which case typ is empty.
+	key, value string
+}
+
+type protoEnum struct {
+	name   string
+	values []protoEnumValue
+}
+
+type protoEnumValue struct {
+	name   string
+	number int
+}
+
+type protoService struct {
+	name       string
+	comments   []string
+	methods    []*protoMethod
+	deprecated bool
+}
+
+type protoMethod struct {
+	name            string
+	comments        []string
+	input, output   string
+	clientStreaming bool
+	serverStreaming bool
+	deprecated      bool
+}
@@ -0,0 +1,531 @@
+package protoimport
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/libyarp/idl"
+)
+
+// parser reads .proto sources into a protoFile. Options are skipped, except
+// for `deprecated`, and extensions are ignored.
+type parser struct {
+	tokens   []token
+	pos      int
+	warnings []string
+}
+
+func parseProto(path string, src []byte) (*protoFile, []string, error) {
+	tokens, err := lex(string(src))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p := &parser{tokens: tokens}
+	f, err := p.file(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, p.warnings, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return idl.SyntaxError{Message: fmt.Sprintf(format, args...), Line: t.line, Column: t.column}
+}
+
+func (p *parser) unexpected(t token, expected string) error {
+	if t.kind == tokenEOF {
+		return p.errorf(t, "expected %s, found end of file", expected)
+	}
+	return p.errorf(t, "expected %s, found %q", expected, t.value)
+}
+
+// accept consumes the next token in case it is the symbol or keyword v.
+func (p *parser) accept(v string) bool {
+	if t := p.peek(); (t.kind == tokenSymbol || t.kind == tokenIdent) && t.value == v {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(v string) error {
+	if t := p.peek(); !p.accept(v) {
+		return p.unexpected(t, strconv.Quote(v))
+	}
+	return nil
+}
+
+func (p *parser) ident() (token, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return t, p.unexpected(t, "identifier")
+	}
+	return t, nil
+}
+
+// typeName reads a type reference, which may be fully qualified through a
+// leading dot.
+func (p *parser) typeName() (string, error) {
+	prefix := ""
+	if p.accept(".") {
+		prefix = "."
+	}
+	t, err := p.ident()
+	return prefix + t.value, err
+}
+
+func (p *parser) str() (string, error) {
+	t := p.next()
+	if t.kind != tokenString {
+		return "", p.unexpected(t, "string")
+	}
+	return t.value, nil
+}
+
+func (p *parser) file(path string) (*protoFile, error) {
+	f := &protoFile{path: path, syntax: "proto2"}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tokenEOF:
+			return f, nil
+		case p.accept(";"):
+		case p.accept("syntax"), p.accept("edition"):
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			v, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			f.syntax = v
+			if err = p.expect(";"); err != nil {
+				return nil, err
+			}
+		case p.accept("package"):
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			f.pkg = name.value
+			if err = p.expect(";"); err != nil {
+				return nil, err
+			}
+		case p.accept("import"):
+			if !p.accept("public") {
+				p.accept("weak")
+			}
+			v, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			f.imports = append(f.imports, v)
+			if err = p.expect(";"); err != nil {
+				return nil, err
+			}
+		case p.accept("option"):
+			if _, err := p.option(); err != nil {
+				return nil, err
+			}
+		case p.accept("message"):
+			m, err := p.message(t)
+			if err != nil {
+				return nil, err
+			}
+			f.messages = append(f.messages, m)
+		case p.accept("enum"):
+			e, err := p.enum()
+			if err != nil {
+				return nil, err
+			}
+			f.enums = append(f.enums, e)
+		case p.accept("service"):
+			s, err := p.service(t)
+			if err != nil {
+				return nil, err
+			}
+			f.services = append(f.services, s)
+		case p.accept("extend"):
+			if err := p.skipExtend(t); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, p.unexpected(t, "declaration")
+		}
+	}
+}
+
+// option consumes an option statement following the `option` keyword, and
+// returns whether it sets `deprecated` to true.
+func (p *parser) option() (bool, error) {
+	name := p.peek()
+	depth := 0
+	var values []token
+	for {
+		t := p.next()
+		switch {
+		case t.kind == tokenEOF:
+			return false, p.unexpected(t, `";"`)
+		case t.value == "{" && t.kind == tokenSymbol:
+			depth++
+		case t.value == "}" && t.kind == tokenSymbol:
+			depth--
+		case t.value == ";" && t.kind == tokenSymbol && depth == 0:
+			return name.value == "deprecated" && len(values) == 3 && values[2].value == "true", nil
+		}
+		values = append(values, t)
+	}
+}
+
+// fieldOptions consumes a bracketed option list, if present, and returns
+// whether it sets `deprecated` to true.
+func (p *parser) fieldOptions() (bool, error) {
+	if !p.accept("[") {
+		return false, nil
+	}
+	deprecated, depth := false, 0
+	for {
+		t := p.next()
+		switch {
+		case t.kind == tokenEOF:
+			return false, p.unexpected(t, `"]"`)
+		case t.kind == tokenSymbol && (t.value == "{" || t.value == "["):
+			depth++
+		case t.kind == tokenSymbol && t.value == "}":
+			depth--
+		case t.kind == tokenSymbol && t.value == "]":
+			if depth == 0 {
+				return deprecated, nil
+			}
+			depth--
+		case t.kind == tokenIdent && t.value == "deprecated" && depth == 0:
+			if p.accept("=") {
+				deprecated = p.accept("true")
+			}
+		}
+	}
+}
+
+func (p *parser) skipExtend(start token) error {
+	p.warnings = append(p.warnings, fmt.Sprintf("line %d: extensions are not supported, and were ignored", start.line))
+	depth := 0
+	for {
+		t := p.next()
+		switch {
+		case t.kind == tokenEOF:
+			return p.unexpected(t, `"}"`)
+		case t.kind == tokenSymbol && t.value == "{":
+			depth++
+		case t.kind == tokenSymbol && t.value == "}":
+			if depth--; depth == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// skipStatement consumes tokens up to and including the next semicolon.
+func (p *parser) skipStatement() error {
+	for {
+		t := p.next()
+		if t.kind == tokenEOF {
+			return p.unexpected(t, `";"`)
+		}
+		if t.kind == tokenSymbol && t.value == ";" {
+			return nil
+		}
+	}
+}
+
+func (p *parser) message(start token) (*protoMessage, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	m := &protoMessage{name: name.value, comments: start.comments}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case p.accept("}"):
+			return m, nil
+		case p.accept(";"):
+		case p.accept("option"):
+			deprecated, err := p.option()
+			if err != nil {
+				return nil, err
+			}
+			m.deprecated = m.deprecated || deprecated
+		case p.accept("reserved"), p.accept("extensions"):
+			if err = p.skipStatement(); err != nil {
+				return nil, err
+			}
+		case p.accept("message"):
+			n, err := p.message(t)
+			if err != nil {
+				return nil, err
+			}
+			m.nested = append(m.nested, n)
+		case p.accept("enum"):
+			e, err := p.enum()
+			if err != nil {
+				return nil, err
+			}
+			m.enums = append(m.enums, e)
+		case p.accept("extend"):
+			if err = p.skipExtend(t); err != nil {
+				return nil, err
+			}
+		case p.accept("oneof"):
+			if err = p.oneof(m, t); err != nil {
+				return nil, err
+			}
+		default:
+			f, err := p.field(-1)
+			if err != nil {
+				return nil, err
+			}
+			m.fields = append(m.fields, f)
+		}
+	}
+}
+
+func (p *parser) oneof(m *protoMessage, start token) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	m.oneofs = append(m.oneofs, &protoOneof{name: name.value, comments: start.comments})
+	if err = p.expect("{"); err != nil {
+		return err
+	}
+	for {
+		switch {
+		case p.accept("}"):
+			return nil
+		case p.accept(";"):
+		case p.accept("option"):
+			if _, err = p.option(); err != nil {
+				return err
+			}
+		default:
+			f, err := p.field(len(m.oneofs) - 1)
+			if err != nil {
+				return err
+			}
+			m.fields = append(m.fields, f)
+		}
+	}
+}
+
+func (p *parser) field(oneof int) (*protoField, error) {
+	start := p.peek()
+	f := &protoField{comments: start.comments, oneof: oneof}
+	if oneof < 0 {
+		switch {
+		case p.accept("optional"):
+			f.label = labelOptional
+		case p.accept("required"):
+			f.label = labelRequired
+		case p.accept("repeated"):
+			f.label = labelRepeated
+		}
+	}
+	if p.peek().value == "group" {
+		return nil, p.errorf(p.peek(), "groups are not supported")
+	}
+	if p.accept("map") {
+		if err := p.expect("<"); err != nil {
+			return nil, err
+		}
+		key, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(","); err != nil {
+			return nil, err
+		}
+		value, err := p.typeName()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(">"); err != nil {
+			return nil, err
+		}
+		f.key, f.value = key.value, value
+	} else {
+		typ, err := p.typeName()
+		if err != nil {
+			return nil, err
+		}
+		f.typ = typ
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	f.name = name.value
+	if err = p.expect("="); err != nil {
+		return nil, err
+	}
+	if t := p.next(); t.kind != tokenNumber {
+		return nil, p.unexpected(t, "field number")
+	}
+	if f.deprecated, err = p.fieldOptions(); err != nil {
+		return nil, err
+	}
+	return f, p.expect(";")
+}
+
+func (p *parser) enum() (*protoEnum, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	e := &protoEnum{name: name.value}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("}"):
+			return e, nil
+		case p.accept(";"):
+		case p.accept("option"):
+			if _, err = p.option(); err != nil {
+				return nil, err
+			}
+		case p.accept("reserved"):
+			if err = p.skipStatement(); err != nil {
+				return nil, err
+			}
+		default:
+			v, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			if err = p.expect("="); err != nil {
+				return nil, err
+			}
+			negative := p.accept("-")
+			t := p.next()
+			n, err := strconv.ParseInt(t.value, 0, 32)
+			if t.kind != tokenNumber || err != nil {
+				return nil, p.unexpected(t, "enum value")
+			}
+			if negative {
+				n = -n
+			}
+			e.values = append(e.values, protoEnumValue{name: v.value, number: int(n)})
+			if _, err = p.fieldOptions(); err != nil {
+				return nil, err
+			}
+			if err = p.expect(";"); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (p *parser) service(start token) (*protoService, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	s := &protoService{name: name.value, comments: start.comments}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case p.accept("}"):
+			return s, nil
+		case p.accept(";"):
+		case p.accept("option"):
+			deprecated, err := p.option()
+			if err != nil {
+				return nil, err
+			}
+			s.deprecated = s.deprecated || deprecated
+		case p.accept("rpc"):
+			m, err := p.method(t)
+			if err != nil {
+				return nil, err
+			}
+			s.methods = append(s.methods, m)
+		default:
+			return nil, p.unexpected(t, `"rpc"`)
+		}
+	}
+}
+
+func (p *parser) method(start token) (*protoMethod, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	m := &protoMethod{name: name.value, comments: start.comments}
+	if m.input, m.clientStreaming, err = p.methodType(); err != nil {
+		return nil, err
+	}
+	if err = p.expect("returns"); err != nil {
+		return nil, err
+	}
+	if m.output, m.serverStreaming, err = p.methodType(); err != nil {
+		return nil, err
+	}
+	if p.accept(";") {
+		return m, nil
+	}
+	if err = p.expect("{"); err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("}"):
+			return m, nil
+		case p.accept(";"):
+		case p.accept("option"):
+			deprecated, err := p.option()
+			if err != nil {
+				return nil, err
+			}
+			m.deprecated = m.deprecated || deprecated
+		default:
+			return nil, p.unexpected(p.peek(), `"option"`)
+		}
+	}
+}
+
+func (p *parser) methodType() (string, bool, error) {
+	if err := p.expect("("); err != nil {
+		return "", false, err
+	}
+	// stream is a valid type name, and only a keyword when followed by
+	// another type name.
+	streaming := false
+	if p.peek().kind == tokenIdent && p.peek().value == "stream" {
+		if next := p.tokens[p.pos+1]; next.kind == tokenIdent || next.value == "." {
+			p.next()
+			streaming = true
+		}
+	}
+	typ, err := p.typeName()
+	if err != nil {
+		return "", false, err
+	}
+	return typ, streaming, p.expect(")")
+}
@@ -0,0 +1,128 @@
+// Package protoimport converts protobuf definitions into YARP source files,
+// either from .proto sources or from an encoded FileDescriptorSet, such as
+// the ones produced by `protoc --descriptor_set_out`.
+//
+// Conversion follows these rules:
+//
+//   - Nested messages are declared at the top level, named after the
+//     concatenation of their enclosing message names (Outer.Inner becomes
+//     OuterInner).
+//   - Fields are renumbered sequentially from zero, in declaration order. A
+//     oneof takes the index preceding the ones of its cases.
+//   - Fields declared as optional, and singular message fields of proto3
+//     files, are annotated with @optional. Repeated fields are annotated
+//     with @repeated, and bytes are represented as array<uint8>.
+//   - YARP has no enumerations; enum fields are represented as int32, and
+//     list the enum values in their comments.
+//   - Well-known types are mapped to the YARP standard library when an
+//     equivalent exists. Wrapper types become optional primitives, and
+//     google.protobuf.Empty arguments and return values become void.
+//   - Leading comments and the deprecated option are retained.
+//
+// Constructs without an equivalent, such as client-streaming methods and
+// extensions, are dropped, and reported through Result.Warnings.
+package protoimport
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/libyarp/idl"
+)
+
+// ProtoExtension contains the extension used by protobuf source files.
+const ProtoExtension = ".proto"
+
+// Result holds a YARP source file converted from a protobuf file.
+type Result struct {
+	// Path contains the path of the original file, with its .proto
+	// extension replaced by idl.SourceExtension.
+	Path string
+
+	// Source contains the converted YARP source.
+	Source []byte
+
+	// File contains the parsed representation of Source.
+	File *idl.File
+
+	// Warnings describes constructs that could not be converted exactly.
+	Warnings []string
+}
+
+// Convert converts a single .proto source. Types referenced from imported
+// files are assumed to be declared by the package their qualified name
+// indicates; use ConvertFiles to convert files along with their imports.
+func Convert(path string, src []byte) (*Result, error) {
+	r, err := ConvertFiles(map[string][]byte{path: src})
+	if err != nil {
+		return nil, err
+	}
+	return r[0], nil
+}
+
+// ConvertFiles converts a set of .proto sources keyed by their import path,
+// resolving references across them. Results are sorted by path.
+func ConvertFiles(files map[string][]byte) ([]*Result, error) {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	var (
+		parsed   []*protoFile
+		warnings = map[string][]string{}
+	)
+	for _, p := range paths {
+		f, w, err := parseProto(p, files[p])
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, f)
+		for _, w := range w {
+			warnings[p] = append(warnings[p], p+": "+w)
+		}
+	}
+	return convert(parsed, warnings)
+}
+
+// ConvertDescriptorSet converts all files of an encoded
+// google.protobuf.FileDescriptorSet, in the order they appear in the set.
+// Comments are only retained when the set includes source code information.
+func ConvertDescriptorSet(data []byte) ([]*Result, error) {
+	files, warnings, err := decodeDescriptorSet(data)
+	if err != nil {
+		return nil, err
+	}
+	return convert(files, warnings)
+}
+
+func convert(files []*protoFile, warnings map[string][]string) ([]*Result, error) {
+	c := &converter{symbols: map[string]*symbol{}}
+	// Descriptor sets may include well-known types, which are mapped rather
+	// than converted.
+	converted := files[:0:0]
+	for _, f := range files {
+		if f.pkg != wellKnownPackage {
+			converted = append(converted, f)
+		}
+	}
+	files = converted
+	for _, f := range files {
+		if f.pkg == "" {
+			return nil, fmt.Errorf("%s: files without a package declaration cannot be converted", f.path)
+		}
+		if err := c.declare(f); err != nil {
+			return nil, err
+		}
+	}
+	results := make([]*Result, 0, len(files))
+	for _, f := range files {
+		r, err := c.file(f)
+		if err != nil {
+			return nil, err
+		}
+		r.Warnings = append(warnings[f.path], r.Warnings...)
+		results = append(results, r)
+	}
+	return results, nil
+}
@@ -0,0 +1,134 @@
+package protoimport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readFixtures(t *testing.T, paths ...string) map[string][]byte {
+	files := map[string][]byte{}
+	for _, p := range paths {
+		data, err := os.ReadFile(filepath.Join("../test/protoimport", p))
+		require.NoError(t, err)
+		files[p] = data
+	}
+	return files
+}
+
+func TestConvertFiles(t *testing.T) {
+	results, err := ConvertFiles(readFixtures(t, "contacts.proto", "common/money.proto"))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	dir := t.TempDir()
+	for _, r := range results {
+		expected, err := os.ReadFile(filepath.Join("../test/protoimport", r.Path))
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(r.Source), r.Path)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(r.Path)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, r.Path), r.Source, 0o644))
+	}
+	assert.Equal(t, []string{
+		"contacts.proto: enum Status is represented as int32",
+		"contacts.proto: client streaming of Contacts.Upload is not supported, and was converted into a single argument",
+	}, results[1].Warnings)
+	assert.Equal(t, []string{"ContactAddress", "ContactAddressLine"}, results[1].File.DeclaredMessages[1:3])
+
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load(filepath.Join(dir, "contacts.yarp")))
+	assert.Empty(t, fs.Validate())
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func varintField(num int, v uint64) []byte {
+	return appendVarint(appendVarint(nil, uint64(num)<<3|wireVarint), v)
+}
+
+func bytesField(num int, parts ...[]byte) []byte {
+	var data []byte
+	for _, p := range parts {
+		data = append(data, p...)
+	}
+	b := appendVarint(appendVarint(nil, uint64(num)<<3|wireBytes), uint64(len(data)))
+	return append(b, data...)
+}
+
+func stringField(num int, s string) []byte { return bytesField(num, []byte(s)) }
+
+func TestConvertDescriptorSet(t *testing.T) {
+	order := bytesField(4,
+		stringField(1, "Order"),
+		bytesField(2, stringField(1, "id"), varintField(3, 1), varintField(4, 1), varintField(5, 3)),
+		bytesField(2, stringField(1, "items"), varintField(3, 2), varintField(4, 3), varintField(5, 11), stringField(6, ".shop.Order.ItemsEntry")),
+		bytesField(2, stringField(1, "note"), varintField(3, 3), varintField(4, 1), varintField(5, 9), varintField(9, 0), varintField(17, 1)),
+		bytesField(3,
+			stringField(1, "ItemsEntry"),
+			bytesField(2, stringField(1, "key"), varintField(3, 1), varintField(4, 1), varintField(5, 9)),
+			bytesField(2, stringField(1, "value"), varintField(3, 2), varintField(4, 1), varintField(5, 5)),
+			bytesField(7, varintField(7, 1)),
+		),
+		bytesField(8, stringField(1, "_note")),
+	)
+	service := bytesField(6,
+		stringField(1, "Orders"),
+		bytesField(2, stringField(1, "Place"), stringField(2, ".shop.Order"), stringField(3, ".google.protobuf.Empty")),
+	)
+	info := bytesField(9, bytesField(1, bytesField(1, appendVarint(appendVarint(nil, 4), 0)), stringField(3, " Order is a purchase.\n")))
+	set := append(
+		bytesField(1, stringField(1, "google/protobuf/empty.proto"), stringField(2, "google.protobuf"), bytesField(4, stringField(1, "Empty"))),
+		bytesField(1, stringField(1, "shop/orders.proto"), stringField(2, "shop"), stringField(3, "google/protobuf/empty.proto"), order, service, info, stringField(12, "proto3"))...,
+	)
+
+	results, err := ConvertDescriptorSet(set)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "shop/orders.yarp", results[0].Path)
+	assert.Equal(t, `package shop;
+
+# Order is a purchase.
+message Order {
+    id int64 = 0;
+    items map<string, int32> = 1;
+    @optional note string = 2;
+}
+
+service Orders {
+    Place(Order);
+}
+`, string(results[0].Source))
+
+	_, err = ConvertDescriptorSet(set[:len(set)-3])
+	assert.ErrorContains(t, err, "invalid descriptor set")
+}
+
+func TestConvertErrors(t *testing.T) {
+	for name, src := range map[string]string{
+		"syntax":     "syntax = \"proto3\";\npackage a;\nmessage A {\n  int32 = 1;\n}\n",
+		"package":    "syntax = \"proto3\";\nmessage A {}\n",
+		"well-known": "syntax = \"proto3\";\npackage a;\nmessage A {\n  google.protobuf.Struct s = 1;\n}\n",
+		"collision":  "syntax = \"proto3\";\npackage a;\nmessage A {\n  message B {}\n}\nmessage AB {}\n",
+		"group":      "syntax = \"proto2\";\npackage a;\nmessage A {\n  optional group G = 1 {}\n}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Convert("a.proto", []byte(src))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := Convert("a.proto", []byte("package a;\nmessage A {\n  int32 = 1;\n}\n"))
+	var syntax idl.SyntaxError
+	require.ErrorAs(t, err, &syntax)
+	assert.Equal(t, 3, syntax.Line)
+}
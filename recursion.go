@@ -0,0 +1,289 @@
+package idl
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CodeRecursiveMessage identifies diagnostics emitted for messages referencing
+// themselves, either directly or through other messages.
+const CodeRecursiveMessage = "recursive-message"
+
+// RecursionPolicy determines how recursive messages are reported by the check
+// returned by RecursionCheck.
+type RecursionPolicy int
+
+const (
+	// RecursionAllow accepts recursive messages, as long as every cycle goes
+	// through an indirection: an optional or repeated field, an array, a
+	// map, or a oneof case. Cycles without indirection describe messages
+	// that cannot be constructed, and are reported as errors.
+	RecursionAllow RecursionPolicy = iota
+
+	// RecursionWarn reports every recursive message as a warning, in
+	// addition to the errors reported by RecursionAllow.
+	RecursionWarn
+
+	// RecursionError reports every recursive message as an error.
+	RecursionError
+)
+
+// RecursionCheck returns a Check reporting recursive messages according to the
+// provided policy. DefaultChecks includes RecursionCheck(RecursionAllow).
+func RecursionCheck(policy RecursionPolicy) Check {
+	return Check{
+		Code: CodeRecursiveMessage,
+		Run: func(f *FileSet) Diagnostics {
+			return checkRecursion(f, policy)
+		},
+	}
+}
+
+type messageEdge struct {
+	to       *Message
+	indirect bool
+}
+
+// messageEdges returns all messages directly referenced by fields of m,
+// indicating whether each reference goes through an indirection.
+func messageEdges(m *Message) []messageEdge {
+	var r []messageEdge
+	var visit func(items []FieldItem, inOneOf bool)
+	visit = func(items []FieldItem, inOneOf bool) {
+		for _, item := range items {
+			switch v := item.(type) {
+			case OneOfField:
+				visit(v.Items, true)
+			case Field:
+				_, optional := v.Annotations.FindByName(OptionalAnnotation)
+				_, repeated := v.Annotations.FindByName(RepeatedAnnotation)
+				WalkType(v.Type, func(t Type) bool {
+					switch tt := t.(type) {
+					case Array, Map:
+						// Everything nested in collections is indirect.
+						for _, n := range referencedMessages(tt) {
+							r = append(r, messageEdge{to: n, indirect: true})
+						}
+						return false
+					case Resolved:
+						r = append(r, messageEdge{to: tt.Message, indirect: inOneOf || optional || repeated})
+					}
+					return true
+				})
+			}
+		}
+	}
+	visit(m.Fields, false)
+	return r
+}
+
+// referencedMessages returns all resolved messages referenced by t.
+func referencedMessages(t Type) []*Message {
+	var r []*Message
+	WalkType(t, func(t Type) bool {
+		if v, ok := t.(Resolved); ok {
+			r = append(r, v.Message)
+		}
+		return true
+	})
+	return r
+}
+
+func checkRecursion(f *FileSet, policy RecursionPolicy) Diagnostics {
+	var diags Diagnostics
+	report := func(cycle []*Message, severity Severity, msg string) {
+		names := make([]string, len(cycle))
+		for i, m := range cycle {
+			names[i] = m.Name
+		}
+		var related []Location
+		for _, m := range cycle[1 : len(cycle)-1] {
+			related = append(related, locationOf(f.declaredIn[m], m.Offset))
+		}
+		diags = append(diags, Diagnostic{
+			Severity: severity,
+			Code:     CodeRecursiveMessage,
+			Message:  fmt.Sprintf("%s: %s", msg, strings.Join(names, " -> ")),
+			Location: locationOf(f.declaredIn[cycle[0]], cycle[0].Offset),
+			Related:  related,
+		})
+	}
+
+	direct := func(m *Message) []*Message {
+		var r []*Message
+		for _, e := range messageEdges(m) {
+			if !e.indirect {
+				r = append(r, e.to)
+			}
+		}
+		return r
+	}
+	all := func(m *Message) []*Message {
+		var r []*Message
+		for _, e := range messageEdges(m) {
+			r = append(r, e.to)
+		}
+		return r
+	}
+
+	// Cycles start from the member with the lowest FQN, so reports do not
+	// depend on declaration order.
+	fqn := func(m *Message) string {
+		return string(NewFQN(f.declaredIn[m].Package, m.Name))
+	}
+	nodes := slices.Clone(f.allMessages)
+	slices.SortStableFunc(nodes, func(a, b *Message) int {
+		return strings.Compare(fqn(a), fqn(b))
+	})
+
+	invalid := map[*Message]bool{}
+	for _, c := range findComponentCycles(nodes, direct) {
+		report(c.path, SeverityError, "message cannot be constructed, as it contains itself without indirection")
+		for _, m := range c.members {
+			invalid[m] = true
+		}
+	}
+	if policy == RecursionAllow {
+		return diags
+	}
+	severity := SeverityWarning
+	if policy == RecursionError {
+		severity = SeverityError
+	}
+	for _, c := range findComponentCycles(nodes, all) {
+		// Groups already reported as errors are not reported again.
+		if !slices.ContainsFunc(c.members, func(m *Message) bool { return invalid[m] }) {
+			report(c.path, severity, "recursive message")
+		}
+	}
+	return diags
+}
+
+// findCycles returns one cycle for each strongly connected component of the
+// graph described by nodes and edges containing a cycle. Each returned cycle
+// starts and ends with the same node, which is the first node of its
+// component in the order provided by nodes.
+func findCycles[T comparable](nodes []T, edges func(T) []T) [][]T {
+	var cycles [][]T
+	for _, c := range findComponentCycles(nodes, edges) {
+		cycles = append(cycles, c.path)
+	}
+	return cycles
+}
+
+// componentCycle holds a strongly connected component containing a cycle,
+// along with one of its cycles.
+type componentCycle[T comparable] struct {
+	members []T
+	path    []T
+}
+
+// findComponentCycles works like findCycles, also returning the members of
+// the component each cycle was found in.
+func findComponentCycles[T comparable](nodes []T, edges func(T) []T) []componentCycle[T] {
+	order := map[T]int{}
+	for i, n := range nodes {
+		order[n] = i
+	}
+	var cycles []componentCycle[T]
+	for _, c := range stronglyConnected(nodes, edges) {
+		members := map[T]bool{}
+		first := c[0]
+		for _, m := range c {
+			members[m] = true
+			if order[m] < order[first] {
+				first = m
+			}
+		}
+		if path := cycleFrom(first, members, edges); path != nil {
+			cycles = append(cycles, componentCycle[T]{members: c, path: path})
+		}
+	}
+	// Report cycles in the order provided by nodes.
+	for i := 1; i < len(cycles); i++ {
+		for j := i; j > 0 && order[cycles[j].path[0]] < order[cycles[j-1].path[0]]; j-- {
+			cycles[j], cycles[j-1] = cycles[j-1], cycles[j]
+		}
+	}
+	return cycles
+}
+
+// stronglyConnected returns the strongly connected components of the graph
+// described by nodes and edges, using Tarjan's algorithm. Components are
+// returned in reverse topological order: a component is only returned after
+// all components reachable from it.
+func stronglyConnected[T comparable](nodes []T, edges func(T) []T) [][]T {
+	index := map[T]int{}
+	low := map[T]int{}
+	onStack := map[T]bool{}
+	var stack []T
+	var components [][]T
+	next := 0
+
+	var connect func(n T)
+	connect = func(n T) {
+		index[n], low[n] = next, next
+		next++
+		stack = append(stack, n)
+		onStack[n] = true
+		for _, m := range edges(n) {
+			if _, ok := index[m]; !ok {
+				connect(m)
+				low[n] = min(low[n], low[m])
+			} else if onStack[m] {
+				low[n] = min(low[n], index[m])
+			}
+		}
+		if low[n] == index[n] {
+			var c []T
+			for {
+				m := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[m] = false
+				c = append(c, m)
+				if m == n {
+					break
+				}
+			}
+			components = append(components, c)
+		}
+	}
+	for _, n := range nodes {
+		if _, ok := index[n]; !ok {
+			connect(n)
+		}
+	}
+	return components
+}
+
+// cycleFrom returns a path starting and ending at start, visiting only
+// members, or nil in case no such path exists. When members holds other nodes
+// than start, the path goes through at least one of them, so that a node
+// referencing itself does not hide the larger cycle it takes part in.
+func cycleFrom[T comparable](start T, members map[T]bool, edges func(T) []T) []T {
+	visited := map[T]bool{start: true}
+	var path []T
+	var walk func(n T) bool
+	walk = func(n T) bool {
+		path = append(path, n)
+		for _, m := range edges(n) {
+			if m == start && (len(path) > 1 || len(members) == 1) {
+				path = append(path, m)
+				return true
+			}
+			if members[m] && !visited[m] {
+				visited[m] = true
+				if walk(m) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if walk(start) {
+		return path
+	}
+	return nil
+}
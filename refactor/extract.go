@@ -0,0 +1,180 @@
+package refactor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/libyarp/idl"
+)
+
+// ExtractMessage returns edits lifting fields of the message identified by
+// name, which may be an FQN, into a new message named newName, declared right
+// after it. Extracted fields retain their comments and annotations, and are
+// numbered from zero in declaration order. They are replaced by a single
+// field named fieldName referencing the new message, which takes the index
+// of the first extracted field; indexes of remaining extracted fields become
+// unused. Fields must be declared directly by the message, on lines of their
+// own. An error is returned in case the message or fields cannot be found,
+// names are invalid or already in use, or the message is frozen by
+// opts.Baseline.
+func ExtractMessage(fs *idl.FileSet, name string, fields []string, newName, fieldName string, opts Options) ([]Edit, error) {
+	sym, ok := fs.FindSymbol(name)
+	if !ok || sym.Kind != idl.SymbolMessage {
+		return nil, fmt.Errorf("unknown message %s", name)
+	}
+	if err := opts.frozen(sym.Name); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields to extract from %s", sym.Name)
+	}
+	for _, n := range []string{newName, fieldName} {
+		if !identifier.MatchString(n) {
+			return nil, fmt.Errorf("invalid name %q", n)
+		}
+	}
+	if _, ok := fs.FindSymbol(idl.NewFQN(sym.Name.Package(), newName).String()); ok {
+		return nil, fmt.Errorf("%s is already declared", idl.NewFQN(sym.Name.Package(), newName))
+	}
+
+	file, err := tokensOf(sym.File)
+	if err != nil {
+		return nil, err
+	}
+	msg, ok := file.MessageByName(sym.Message().Name)
+	if !ok {
+		return nil, fmt.Errorf("message %s is not declared by %s", sym.Name, sym.File.SourcePath)
+	}
+
+	wanted := map[string]bool{}
+	for _, f := range fields {
+		wanted[f] = true
+	}
+	var extracted []idl.Field
+	for _, item := range msg.Fields {
+		switch v := item.(type) {
+		case idl.Field:
+			if wanted[v.Name] {
+				extracted = append(extracted, v)
+				delete(wanted, v.Name)
+			} else if v.Name == fieldName {
+				return nil, fmt.Errorf("field %s is already declared by %s", fieldName, sym.Name)
+			}
+		case idl.OneOfField:
+			for _, f := range v.Items {
+				if c, ok := f.(idl.Field); ok && wanted[c.Name] {
+					return nil, fmt.Errorf("field %s of %s is part of a oneof", c.Name, sym.Name)
+				}
+			}
+		}
+	}
+	for _, f := range fields {
+		if wanted[f] {
+			return nil, fmt.Errorf("unknown field %s of %s", f, sym.Name)
+		}
+	}
+
+	src := file.Source
+	var edits []Edit
+	var body strings.Builder
+	for i, f := range extracted {
+		start, end, err := fieldLines(file, f)
+		if err != nil {
+			return nil, fmt.Errorf("field %s of %s: %w", f.Name, sym.Name, err)
+		}
+		tok, _ := indexToken(file, f)
+		body.Write(src[start:tok.Start])
+		body.WriteString(strconv.Itoa(i))
+		body.Write(src[tok.End:end])
+
+		text := ""
+		if i == 0 {
+			line := string(src[start:end])
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			text = fmt.Sprintf("%s%s %s = %d;\n", indent, fieldName, newName, f.Index)
+		}
+		edits = append(edits, Edit{Start: start, End: end, Text: text})
+	}
+	edits = append(edits, Edit{
+		Start: msg.Offset.End,
+		End:   msg.Offset.End,
+		Text:  fmt.Sprintf("\n\nmessage %s {\n%s}", newName, body.String()),
+	})
+	return sortEdits(edits), nil
+}
+
+// fieldLines returns the byte range of the lines declaring f, including the
+// comments and annotations preceding it, and a comment following it on its
+// last line. The range includes the line break terminating the last line. An
+// error is returned in case other declarations share these lines.
+func fieldLines(file *idl.File, f idl.Field) (int, int, error) {
+	r, ok := file.TokenRangeOf(f)
+	if !ok {
+		return 0, 0, fmt.Errorf("tokens could not be located")
+	}
+	tokens := file.Tokens
+
+	// Annotations written on the line of the field precede it.
+	first := r.Start
+	for first > 0 && tokens[first-1].Type != idl.LineBreak {
+		first--
+	}
+	if first != r.Start && tokens[first].Type != idl.Annotation {
+		return 0, 0, fmt.Errorf("field does not stand on its own line")
+	}
+
+	// Lines of comments and annotations directly preceding the field are
+	// attached to it.
+	for first > 1 && tokens[first-1].Type == idl.LineBreak {
+		ls := first - 1
+		for ls > 0 && tokens[ls-1].Type != idl.LineBreak {
+			ls--
+		}
+		if ls == first-1 || !isMetaLine(tokens[ls:first-1]) {
+			break
+		}
+		first = ls
+	}
+
+	last := r.End
+	if last < len(tokens) && (tokens[last].Type == idl.Comment || tokens[last].Type == idl.Pragma) {
+		last++
+	}
+	if last < len(tokens) && tokens[last].Type != idl.LineBreak && tokens[last].Type != idl.EOF {
+		return 0, 0, fmt.Errorf("field does not stand on its own line")
+	}
+
+	start := lineStart(file.Source, tokens[first].Start)
+	end := tokens[last-1].End
+	if last < len(tokens) && tokens[last].Type == idl.LineBreak {
+		end = tokens[last].End
+	}
+	return start, end, nil
+}
+
+// isMetaLine returns whether tokens, composing a line, only contain comments
+// or annotations.
+func isMetaLine(tokens []idl.Token) bool {
+	switch tokens[0].Type {
+	case idl.Comment, idl.Pragma, idl.Annotation:
+	default:
+		return false
+	}
+	for _, t := range tokens {
+		switch t.Type {
+		case idl.Semi, idl.OpenCurly, idl.CloseCurly:
+			return false
+		}
+	}
+	return true
+}
+
+// lineStart returns the offset of the beginning of the line containing the
+// byte at offset o of src.
+func lineStart(src []byte, o int) int {
+	for o > 0 && src[o-1] != '\n' {
+		o--
+	}
+	return o
+}
@@ -0,0 +1,70 @@
+package refactor
+
+import (
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractMessage(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/refactor/extract.yarp"))
+	file := fs.Files()[0]
+
+	edits, err := ExtractMessage(fs, "Customer", []string{"zip", "street", "city"}, "Address", "address", Options{})
+	require.NoError(t, err)
+	out := apply(file.Source, edits)
+	assert.Equal(t, `package org.example.refactor;
+
+# Customer of the shop.
+message Customer {
+    id int64 = 0;
+    address Address = 1;
+    name string = 2;
+    oneof {
+        email string = 6;
+    } = 5;
+}
+
+message Address {
+    # Street, including the number.
+    street string = 0;
+    @optional
+    city string = 1; # municipality
+    @optional zip string = 2;
+}
+`, out)
+
+	extracted := idl.NewFileSetFS(fstestFS("extract.yarp", out))
+	require.NoError(t, extracted.Load("extract.yarp"))
+	assert.Empty(t, extracted.Validate())
+}
+
+func TestExtractMessageErrors(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/refactor/extract.yarp"))
+
+	for _, tc := range []struct {
+		fields         []string
+		newName, field string
+		err            string
+	}{
+		{[]string{"street"}, "Customer", "address", "org.example.refactor.Customer is already declared"},
+		{[]string{"street"}, "Address", "name", "field name is already declared by org.example.refactor.Customer"},
+		{[]string{"street"}, "Address", "1st", `invalid name "1st"`},
+		{[]string{"email"}, "Address", "address", "field email of org.example.refactor.Customer is part of a oneof"},
+		{[]string{"country"}, "Address", "address", "unknown field country of org.example.refactor.Customer"},
+		{nil, "Address", "address", "no fields to extract from org.example.refactor.Customer"},
+	} {
+		_, err := ExtractMessage(fs, "Customer", tc.fields, tc.newName, tc.field, Options{})
+		assert.EqualError(t, err, tc.err)
+	}
+
+	src := "package foo;\n\nmessage Foo {\n    a int32 = 0; b int32 = 1;\n}\n"
+	fs = idl.NewFileSetFS(fstestFS("foo.yarp", src))
+	require.NoError(t, fs.Load("foo.yarp"))
+	_, err := ExtractMessage(fs, "Foo", []string{"a"}, "Bar", "bar", Options{})
+	assert.EqualError(t, err, "field a of foo.Foo: field does not stand on its own line")
+}
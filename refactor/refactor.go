@@ -0,0 +1,53 @@
+// Package refactor implements automated changes to schemas, such as
+// renumbering fields. Refactorings do not modify files themselves; instead,
+// they return textual Edits computed from the tokens of loaded sources, to be
+// applied by callers, leaving everything else in the files untouched.
+package refactor
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/edits"
+)
+
+// Edit represents the replacement of the bytes between Start (inclusive) and
+// End (exclusive) of a source file with Text. Edits may be applied, and
+// combined with the ones of other refactorings, through package edits.
+type Edit = edits.Edit
+
+// Options represents optional behaviour shared by refactorings.
+type Options struct {
+	// Baseline optionally contains a frozen FileSet, usually loaded through
+	// idl.LoadDescriptor from a published descriptor. Refactorings refuse to
+	// change the wire format of messages it declares.
+	Baseline *idl.FileSet
+}
+
+// frozen returns an error in case the message identified by fqn is declared
+// by the baseline of o.
+func (o Options) frozen(fqn idl.FQN) error {
+	if o.Baseline == nil {
+		return nil
+	}
+	if _, ok := o.Baseline.FindMessage(fqn.String()); ok {
+		return fmt.Errorf("message %s is frozen by the baseline", fqn)
+	}
+	return nil
+}
+
+// tokensOf reparses the source of file retaining its tokens, so edits can be
+// computed against them.
+func tokensOf(file *idl.File) (*idl.File, error) {
+	if file.Source == nil {
+		return nil, fmt.Errorf("source of %s is not available", file.SourcePath)
+	}
+	return idl.ParseSource(file.Source, idl.ParseOptions{RetainTokens: true})
+}
+
+// sortEdits sorts edits by their position in the source.
+func sortEdits(edits []Edit) []Edit {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Start < edits[j].Start })
+	return edits
+}
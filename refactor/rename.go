@@ -0,0 +1,222 @@
+package refactor
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/libyarp/idl"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Rename returns edits renaming the message or service identified by name,
+// which may be an FQN, to newName, keyed by the path of each file to be
+// changed. Besides the declaration itself, references from field types and
+// method signatures across all files of fs are updated, including the ones
+// written using former names declared through @renamed_from, retaining the
+// package qualifying them, if any. In case newName is listed as a former name
+// of the symbol, it is removed from its @renamed_from annotation. An error is
+// returned in case the symbol cannot be found, newName is invalid or already
+// declared, or the symbol is a message frozen by opts.Baseline.
+func Rename(fs *idl.FileSet, name, newName string, opts Options) (map[string][]Edit, error) {
+	sym, ok := fs.FindSymbol(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown symbol %s", name)
+	}
+	if !identifier.MatchString(newName) {
+		return nil, fmt.Errorf("invalid name %q", newName)
+	}
+	if _, ok := fs.FindSymbol(idl.NewFQN(sym.Name.Package(), newName).String()); ok {
+		return nil, fmt.Errorf("%s is already declared", idl.NewFQN(sym.Name.Package(), newName))
+	}
+	if sym.Kind == idl.SymbolMessage {
+		if err := opts.frozen(sym.Name); err != nil {
+			return nil, err
+		}
+	}
+	fs.Resolve()
+
+	r := &renamer{target: sym.Declaration, newName: newName, edits: map[string][]Edit{}}
+	for _, file := range fs.Files() {
+		parsed, err := tokensOf(file)
+		if err != nil {
+			return nil, err
+		}
+		r.file, r.parsed = file, parsed
+		for _, d := range file.Tree {
+			switch v := d.(type) {
+			case *idl.Message:
+				if d == sym.Declaration {
+					r.declaration(v.Offset, v.Annotations)
+				}
+				r.fields(v.Fields)
+			case *idl.Service:
+				if d == sym.Declaration {
+					r.declaration(v.Offset, v.Annotations)
+				}
+				for _, m := range v.Methods {
+					r.typeRef(m.Argument)
+					r.typeRef(m.Return)
+				}
+			}
+		}
+	}
+	for path, edits := range r.edits {
+		r.edits[path] = sortEdits(edits)
+	}
+	return r.edits, nil
+}
+
+// renamer holds the state of Rename while visiting files.
+type renamer struct {
+	target  idl.Declaration
+	newName string
+	edits   map[string][]Edit
+
+	// file contains the file being visited, and parsed the same file,
+	// reparsed retaining its tokens.
+	file, parsed *idl.File
+}
+
+func (r *renamer) add(e Edit) {
+	r.edits[r.file.SourcePath] = append(r.edits[r.file.SourcePath], e)
+}
+
+// tokensIn returns the tokens of the visited file within o.
+func (r *renamer) tokensIn(o idl.Offset) []idl.Token {
+	var tokens []idl.Token
+	for _, t := range r.parsed.Tokens {
+		if t.Start >= o.Start && t.End <= o.End && t.Type != idl.EOF {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// declaration renames the name token of the target, declared at o, and
+// removes the new name from its former names.
+func (r *renamer) declaration(o idl.Offset, annotations idl.AnnotationCollection) {
+	tokens := r.tokensIn(o)
+	if len(tokens) > 1 {
+		r.add(Edit{Start: tokens[1].Start, End: tokens[1].End, Text: r.newName})
+	}
+	for _, a := range annotations {
+		if a.Name != idl.RenamedFromAnnotation {
+			continue
+		}
+		for i, v := range a.Value {
+			if v != r.newName {
+				continue
+			}
+			if len(a.Value) == 1 {
+				r.add(removeLine(r.file.Source, a.Offset.Start, a.Offset.End))
+			} else {
+				r.add(removeValue(r.tokensIn(a.Offset), i))
+			}
+		}
+	}
+}
+
+// fields renames references to the target within the types of items.
+func (r *renamer) fields(items []idl.FieldItem) {
+	for _, item := range items {
+		switch f := item.(type) {
+		case idl.Field:
+			tokens, ok := r.parsed.TokensOf(f)
+			if !ok {
+				continue
+			}
+			names := typeNames(tokens[1:])
+			leaves := typeLeaves(f.Type)
+			if len(names) != len(leaves) {
+				continue
+			}
+			for i, leaf := range leaves {
+				if res, ok := leaf.(idl.Resolved); ok && idl.Declaration(res.Message) == r.target {
+					r.add(Edit{Start: names[i].Start, End: names[i].End, Text: r.newName})
+				}
+			}
+		case idl.OneOfField:
+			r.fields(f.Items)
+		}
+	}
+}
+
+// typeRef renames a method argument or return type referring to the target.
+func (r *renamer) typeRef(t idl.TypeRef) {
+	if t.IsVoid() || t.Target == nil || idl.Declaration(t.Target) != r.target {
+		return
+	}
+	if names := typeNames(r.tokensIn(t.Offset)); len(names) > 0 {
+		last := names[len(names)-1]
+		r.add(Edit{Start: last.Start, End: last.End, Text: r.newName})
+	}
+}
+
+// typeNames returns the last identifier of each (possibly qualified) type
+// name written in tokens, in order, stopping at '='. Names followed by '<',
+// such as array and map, are not included.
+func typeNames(tokens []idl.Token) []idl.Token {
+	var names []idl.Token
+	for i, t := range tokens {
+		if t.Type == idl.Equal {
+			break
+		}
+		if t.Type != idl.Identifier || t.Value == "stream" {
+			continue
+		}
+		if i+1 < len(tokens) && (tokens[i+1].Type == idl.Dot || tokens[i+1].Type == idl.OpenAngled) {
+			continue
+		}
+		names = append(names, t)
+	}
+	return names
+}
+
+// typeLeaves returns primitive and message types composing t, in the order
+// they are written.
+func typeLeaves(t idl.Type) []idl.Type {
+	switch v := t.(type) {
+	case idl.Array:
+		return typeLeaves(v.Of)
+	case idl.Map:
+		return append([]idl.Type{idl.Primitive{Kind: v.Key}}, typeLeaves(v.Value)...)
+	}
+	return []idl.Type{t}
+}
+
+// removeLine returns an edit removing the bytes between start and end of src,
+// along with the line containing them in case nothing else is written on it,
+// or otherwise with the spaces following them.
+func removeLine(src []byte, start, end int) Edit {
+	ls := start
+	for ls > 0 && (src[ls-1] == ' ' || src[ls-1] == '\t') {
+		ls--
+	}
+	le := end
+	for le < len(src) && (src[le] == ' ' || src[le] == '\t' || src[le] == '\r') {
+		le++
+	}
+	if (ls == 0 || src[ls-1] == '\n') && (le == len(src) || src[le] == '\n') {
+		if le < len(src) {
+			le++
+		}
+		return Edit{Start: ls, End: le}
+	}
+	return Edit{Start: start, End: le}
+}
+
+// removeValue returns an edit removing the i-th value of an annotation
+// composed by tokens, along with the comma separating it from its neighbour.
+func removeValue(tokens []idl.Token, i int) Edit {
+	var values []idl.Token
+	for _, t := range tokens {
+		if t.Type == idl.StringElement || t.Type == idl.Number || t.Type == idl.Identifier {
+			values = append(values, t)
+		}
+	}
+	if i == 0 {
+		return Edit{Start: values[0].Start, End: values[1].Start}
+	}
+	return Edit{Start: values[i-1].End, End: values[i].End}
+}
@@ -0,0 +1,86 @@
+package refactor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRename(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/refactor/rename/shop.yarp"))
+	common, ok := fs.FileByPath("../test/refactor/rename/common.yarp")
+	require.True(t, ok)
+	shop, ok := fs.FileByPath("../test/refactor/rename/shop.yarp")
+	require.True(t, ok)
+
+	edits, err := Rename(fs, "org.example.common.Customer", "Client", Options{})
+	require.NoError(t, err)
+	require.Len(t, edits, 2)
+	assert.Equal(t, `package org.example.common;
+
+@renamed_from("Buyer")
+message Client {
+    name string = 0;
+}
+`, apply(common.Source, edits[common.SourcePath]))
+	assert.Equal(t, `package org.example.shop;
+
+import "common.yarp";
+
+message Order {
+    buyer org.example.common.Client = 0;
+    previous array<org.example.common.Client> = 1;
+    oneof {
+        others map<string, org.example.common.Client> = 3;
+    } = 2;
+}
+
+service Customers {
+    get(org.example.common.Client) -> stream org.example.common.Client;
+    list() -> Order;
+}
+`, apply(shop.Source, edits[shop.SourcePath]))
+
+	edits, err = Rename(fs, "org.example.shop.Order", "Purchase", Options{})
+	require.NoError(t, err)
+	assert.Contains(t, apply(shop.Source, edits[shop.SourcePath]), "message Purchase {")
+	assert.Contains(t, apply(shop.Source, edits[shop.SourcePath]), "list() -> Purchase;")
+	assert.NotContains(t, edits, common.SourcePath)
+
+	edits, err = Rename(fs, "org.example.shop.Customers", "Clients", Options{})
+	require.NoError(t, err)
+	require.Len(t, edits[shop.SourcePath], 1)
+	assert.Contains(t, apply(shop.Source, edits[shop.SourcePath]), "service Clients {")
+}
+
+func TestRenameFormerName(t *testing.T) {
+	src := "package foo;\n\n# Buyer.\n@renamed_from(\"Client\")\nmessage Customer {\n    name string = 0;\n}\n"
+	fs := idl.NewFileSetFS(fstestFS("foo.yarp", src))
+	require.NoError(t, fs.Load("foo.yarp"))
+	edits, err := Rename(fs, "Customer", "Client", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "package foo;\n\n# Buyer.\nmessage Client {\n    name string = 0;\n}\n", apply([]byte(src), edits["foo.yarp"]))
+}
+
+func TestRenameErrors(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/refactor/rename/shop.yarp"))
+
+	_, err := Rename(fs, "Missing", "Other", Options{})
+	assert.EqualError(t, err, "unknown symbol Missing")
+
+	_, err = Rename(fs, "Order", "not-valid", Options{})
+	assert.EqualError(t, err, `invalid name "not-valid"`)
+
+	_, err = Rename(fs, "Order", "Customers", Options{})
+	assert.EqualError(t, err, "org.example.shop.Customers is already declared")
+
+	baseline := idl.NewFileSet()
+	require.NoError(t, baseline.Load(filepath.Join("..", "test", "refactor", "rename", "shop.yarp")))
+	_, err = Rename(fs, "Order", "Purchase", Options{Baseline: baseline})
+	assert.EqualError(t, err, "message org.example.shop.Order is frozen by the baseline")
+}
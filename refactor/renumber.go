@@ -0,0 +1,115 @@
+package refactor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/libyarp/idl"
+)
+
+// Strategy determines how RenumberFields assigns indexes.
+type Strategy int
+
+const (
+	// Compact closes gaps between indexes, retaining their relative order.
+	Compact Strategy = iota
+
+	// DeclarationOrder assigns ascending indexes following the order in
+	// which fields are declared. Oneofs are numbered before their cases.
+	DeclarationOrder
+)
+
+// numbered represents a field or oneof being renumbered.
+type numbered struct {
+	item  idl.FieldItem
+	index int
+}
+
+// RenumberFields returns edits rewriting the indexes of fields of the message
+// identified by name, which may be an FQN, according to s. Cases of oneofs
+// share the index space of their message, and indexes listed by @reserved
+// are skipped. An error is returned in case the message cannot be found, or
+// is frozen by opts.Baseline, as renumbering changes its wire format.
+func RenumberFields(fs *idl.FileSet, name string, s Strategy, opts Options) ([]Edit, error) {
+	sym, ok := fs.FindSymbol(name)
+	if !ok || sym.Kind != idl.SymbolMessage {
+		return nil, fmt.Errorf("unknown message %s", name)
+	}
+	if err := opts.frozen(sym.Name); err != nil {
+		return nil, err
+	}
+	file, err := tokensOf(sym.File)
+	if err != nil {
+		return nil, err
+	}
+	msg, ok := file.MessageByName(sym.Message().Name)
+	if !ok {
+		return nil, fmt.Errorf("message %s is not declared by %s", sym.Name, sym.File.SourcePath)
+	}
+
+	var items []numbered
+	var visit func(fields []idl.FieldItem)
+	visit = func(fields []idl.FieldItem) {
+		for _, item := range fields {
+			switch v := item.(type) {
+			case idl.Field:
+				items = append(items, numbered{item, v.Index})
+			case idl.OneOfField:
+				items = append(items, numbered{item, v.Index})
+				visit(v.Items)
+			}
+		}
+	}
+	visit(msg.Fields)
+
+	switch s {
+	case Compact:
+		sort.SliceStable(items, func(i, j int) bool { return items[i].index < items[j].index })
+	case DeclarationOrder:
+	default:
+		return nil, fmt.Errorf("invalid strategy %d", s)
+	}
+
+	reserved := reservedIndexes(msg)
+	var edits []Edit
+	next := 0
+	for _, n := range items {
+		for reserved[next] {
+			next++
+		}
+		if n.index != next {
+			tok, ok := indexToken(file, n.item)
+			if !ok {
+				return nil, fmt.Errorf("index of field of %s could not be located", sym.Name)
+			}
+			edits = append(edits, Edit{Start: tok.Start, End: tok.End, Text: strconv.Itoa(next)})
+		}
+		next++
+	}
+	return sortEdits(edits), nil
+}
+
+// indexToken returns the token containing the index of item, which is the
+// number preceding the semicolon terminating it.
+func indexToken(file *idl.File, item idl.FieldItem) (idl.Token, bool) {
+	tokens, ok := file.TokensOf(item)
+	if !ok || len(tokens) < 2 {
+		return idl.Token{}, false
+	}
+	tok := tokens[len(tokens)-2]
+	return tok, tok.Type == idl.Number
+}
+
+// reservedIndexes returns the indexes listed by the @reserved annotation of m.
+func reservedIndexes(m *idl.Message) map[int]bool {
+	r := map[int]bool{}
+	if a, ok := m.Annotations.FindByName(idl.ReservedAnnotation); ok {
+		for _, v := range a.Value {
+			if i, err := strconv.Atoi(v); err == nil {
+				r[i] = true
+			}
+		}
+	}
+	return r
+}
@@ -0,0 +1,78 @@
+package refactor
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/edits"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apply returns src with edits applied.
+func apply(src []byte, e []Edit) string {
+	r, err := edits.Apply(src, e)
+	if err != nil {
+		panic(err)
+	}
+	return string(r)
+}
+
+// fstestFS returns a filesystem containing a single file.
+func fstestFS(path, data string) fstest.MapFS {
+	return fstest.MapFS{path: {Data: []byte(data)}}
+}
+
+func TestRenumberFields(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/refactor/orders.yarp"))
+	file := fs.Files()[0]
+
+	edits, err := RenumberFields(fs, "Order", Compact, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, apply(file.Source, edits), `message Order {
+    id int64 = 0;
+    total float64 = 3;
+    oneof {
+        card string = 6;
+        invoice string = 5;
+    } = 4;
+    notes string = 2;
+}`)
+
+	edits, err = RenumberFields(fs, "org.example.refactor.Order", DeclarationOrder, Options{})
+	require.NoError(t, err)
+	assert.Contains(t, apply(file.Source, edits), `message Order {
+    id int64 = 0;
+    total float64 = 2;
+    oneof {
+        card string = 4;
+        invoice string = 5;
+    } = 3;
+    notes string = 6;
+}`)
+
+	edits, err = RenumberFields(fs, "Item", Compact, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, edits)
+
+	_, err = RenumberFields(fs, "Missing", Compact, Options{})
+	assert.EqualError(t, err, "unknown message Missing")
+}
+
+func TestRenumberFieldsBaseline(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/refactor/orders.yarp"))
+	fs.Resolve()
+	data, err := fs.MarshalDescriptor()
+	require.NoError(t, err)
+	baseline, err := idl.LoadDescriptor(data)
+	require.NoError(t, err)
+
+	_, err = RenumberFields(fs, "Order", Compact, Options{Baseline: baseline})
+	assert.EqualError(t, err, "message org.example.refactor.Order is frozen by the baseline")
+
+	_, err = RenumberFields(fs, "Order", Compact, Options{Baseline: idl.NewFileSet()})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,76 @@
+package idl
+
+// References returns the location of every name referencing the message
+// identified by fqn across files loaded into fs, in the order files were
+// registered (see Files), and then in the order names appear in each file.
+// References are found by resolving fs (see Resolve), and include names used
+// by field types, including elements of arrays and values of maps, and by
+// method arguments and return types, along with former names of the message
+// declared through @renamed_from. Offsets span names as written, including
+// their package, if any. The declaration itself is not included, and as
+// services cannot be referenced by schemas, nil is returned for them, as for
+// unknown names.
+func References(fs *FileSet, fqn FQN) []Location {
+	sym, ok := fs.FindSymbol(fqn.String())
+	if !ok || sym.Kind != SymbolMessage {
+		return nil
+	}
+	fs.Resolve()
+	target := sym.Message()
+	var refs []Location
+	for _, file := range fs.fileOrder {
+		for _, d := range file.Tree {
+			switch v := d.(type) {
+			case *Message:
+				refs = append(refs, fieldReferences(file, v.Fields, target)...)
+			case *Service:
+				for _, m := range v.Methods {
+					for _, ref := range []TypeRef{m.Argument, m.Return} {
+						if !ref.IsVoid() && ref.Target == target {
+							refs = append(refs, locationOf(file, ref.Offset))
+						}
+					}
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// fieldReferences returns the locations of names referencing target within
+// the types of items.
+func fieldReferences(file *File, items []FieldItem, target *Message) []Location {
+	var refs []Location
+	for _, item := range items {
+		switch v := item.(type) {
+		case Field:
+			types := messageTypes(v.Type)
+			names := typeNamesOf(file, v)
+			if len(types) != len(names) {
+				continue
+			}
+			for i, t := range types {
+				if r, ok := t.(Resolved); ok && r.Message == target {
+					refs = append(refs, locationOf(file, names[i].Offset))
+				}
+			}
+		case OneOfField:
+			refs = append(refs, fieldReferences(file, v.Items, target)...)
+		}
+	}
+	return refs
+}
+
+// messageTypes returns the Resolved and Unresolved types composing t, in the
+// order they are written.
+func messageTypes(t Type) []Type {
+	switch v := t.(type) {
+	case Array:
+		return messageTypes(v.Of)
+	case Map:
+		return messageTypes(v.Value)
+	case Resolved, Unresolved:
+		return []Type{t}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package idl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferences(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/definition/shop.yarp"))
+	shop, ok := fs.FileByPath("./test/definition/shop.yarp")
+	require.True(t, ok)
+	src := string(shop.Source)
+
+	spans := func(refs []Location) []string {
+		var r []string
+		for _, l := range refs {
+			assert.Equal(t, shop.SourcePath, l.File)
+			r = append(r, src[l.Offset.Start:l.Offset.End])
+		}
+		return r
+	}
+
+	money := References(fs, "org.example.common.Money")
+	assert.Equal(t, []string{
+		"org.example.common.Money",
+		"org.example.common.Cash",
+		"org.example.common.Money",
+	}, spans(money))
+	assert.Equal(t, Position{Line: 7, Column: 13}, money[0].Offset.StartsAt)
+	assert.Equal(t, strings.Index(src, "org.example.common.Money = 1"), money[0].Offset.Start)
+
+	order := References(fs, "Order")
+	assert.Equal(t, []string{"Order", "Order"}, spans(order))
+	assert.Equal(t, strings.Index(src, "Order = 1"), order[0].Offset.Start)
+	assert.Equal(t, strings.Index(src, "Order)"), order[1].Offset.Start)
+
+	item := References(fs, "org.example.shop.Item")
+	assert.Equal(t, []string{"Item", "Item"}, spans(item))
+	assert.Equal(t, strings.Index(src, "Item> = 0"), item[0].Offset.Start)
+	assert.Equal(t, strings.Index(src, "Item;"), item[1].Offset.Start)
+
+	// Definitions of references lead back to the referenced message.
+	sym, ok := fs.FindSymbol("org.example.common.Money")
+	require.True(t, ok)
+	for _, l := range money {
+		def, ok := Definition(fs, l.File, l.Offset.Start)
+		require.True(t, ok)
+		assert.Equal(t, sym.Offset, def.Offset)
+	}
+
+	assert.Nil(t, References(fs, "Orders"))
+	assert.Nil(t, References(fs, "Unknown"))
+}
@@ -0,0 +1,170 @@
+// Package registry implements a process-wide index of resolved YARP messages
+// and services, keyed by fully-qualified name. It serves as the lookup
+// backbone for dynamic codecs, gateways, and reflection services, which
+// receive type names at runtime and need the schema describing them.
+//
+// Schemas are registered either from a FileSet or from a compiled descriptor,
+// such as the ones embedded by the go-descriptor generator. A Registry is safe
+// for concurrent use.
+package registry
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/libyarp/idl"
+)
+
+// ConflictError indicates that a symbol being registered has already been
+// registered with a different definition.
+type ConflictError struct{ Name idl.FQN }
+
+func (c ConflictError) Error() string {
+	return fmt.Sprintf("%s is already registered with a different definition", c.Name)
+}
+
+type entry[T any] struct {
+	value       T
+	fingerprint [sha256.Size]byte
+}
+
+// Registry holds messages and services registered from one or more FileSets.
+// The zero value is an empty Registry ready for use.
+type Registry struct {
+	mu       sync.RWMutex
+	messages map[idl.FQN]entry[*idl.Message]
+	services map[idl.FQN]entry[*idl.Service]
+}
+
+// New returns an empty Registry.
+func New() *Registry { return &Registry{} }
+
+// Register resolves fs and adds all messages and services it holds, including
+// the ones declared by imported files. Registering a symbol already present
+// with an identical definition, as computed by idl.Fingerprint, is a no-op;
+// in case definitions differ, a ConflictError is returned. Registration is
+// atomic: in case an error is returned, the Registry is left unchanged.
+func (r *Registry) Register(fs *idl.FileSet) error {
+	if diags := fs.Resolve(); diags.HasErrors() {
+		return diags
+	}
+	messages := map[idl.FQN]entry[*idl.Message]{}
+	services := map[idl.FQN]entry[*idl.Service]{}
+	for _, sym := range fs.Symbols() {
+		switch sym.Kind {
+		case idl.SymbolMessage:
+			m := sym.Message()
+			messages[sym.Name] = entry[*idl.Message]{m, idl.Fingerprint(m)}
+		case idl.SymbolService:
+			s := sym.Service()
+			services[sym.Name] = entry[*idl.Service]{s, fs.ServiceFingerprint(s)}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, e := range messages {
+		if prev, ok := r.messages[name]; ok && prev.fingerprint != e.fingerprint {
+			return ConflictError{Name: name}
+		}
+	}
+	for name, e := range services {
+		if prev, ok := r.services[name]; ok && prev.fingerprint != e.fingerprint {
+			return ConflictError{Name: name}
+		}
+	}
+	if r.messages == nil {
+		r.messages = map[idl.FQN]entry[*idl.Message]{}
+		r.services = map[idl.FQN]entry[*idl.Service]{}
+	}
+	for name, e := range messages {
+		if _, ok := r.messages[name]; !ok {
+			r.messages[name] = e
+		}
+	}
+	for name, e := range services {
+		if _, ok := r.services[name]; !ok {
+			r.services[name] = e
+		}
+	}
+	return nil
+}
+
+// RegisterDescriptor loads a descriptor produced by
+// idl.FileSet.MarshalDescriptor and registers its contents, as Register does.
+func (r *Registry) RegisterDescriptor(data []byte) error {
+	fs, err := idl.LoadDescriptor(data)
+	if err != nil {
+		return err
+	}
+	return r.Register(fs)
+}
+
+// LookupMessage returns the message registered under the provided
+// fully-qualified name, along with a boolean indicating whether it was found.
+func (r *Registry) LookupMessage(name idl.FQN) (*idl.Message, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.messages[name]
+	return e.value, ok
+}
+
+// LookupService returns the service registered under the provided
+// fully-qualified name, along with a boolean indicating whether it was found.
+func (r *Registry) LookupService(name idl.FQN) (*idl.Service, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.services[name]
+	return e.value, ok
+}
+
+// Messages returns the names of all registered messages, sorted.
+func (r *Registry) Messages() []idl.FQN {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedKeys(r.messages)
+}
+
+// Services returns the names of all registered services, sorted.
+func (r *Registry) Services() []idl.FQN {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedKeys(r.services)
+}
+
+func sortedKeys[T any](m map[idl.FQN]T) []idl.FQN {
+	names := make([]idl.FQN, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// Default is the Registry used by package-level functions, and populated by
+// code emitted by the go-descriptor generator.
+var Default = New()
+
+// Register registers fs in Default. See Registry.Register.
+func Register(fs *idl.FileSet) error { return Default.Register(fs) }
+
+// RegisterDescriptor registers a descriptor in Default. See
+// Registry.RegisterDescriptor.
+func RegisterDescriptor(data []byte) error { return Default.RegisterDescriptor(data) }
+
+// MustRegisterDescriptor is like RegisterDescriptor, but panics in case the
+// descriptor cannot be registered. It is intended for use by generated init
+// functions.
+func MustRegisterDescriptor(data []byte) {
+	if err := RegisterDescriptor(data); err != nil {
+		panic("registry: " + err.Error())
+	}
+}
+
+// LookupMessage looks up a message in Default. See Registry.LookupMessage.
+func LookupMessage(name idl.FQN) (*idl.Message, bool) { return Default.LookupMessage(name) }
+
+// LookupService looks up a service in Default. See Registry.LookupService.
+func LookupService(name idl.FQN) (*idl.Service, bool) { return Default.LookupService(name) }
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadSource(t *testing.T, src string) *idl.FileSet {
+	fs := idl.NewFileSetFS(fstest.MapFS{"main.yarp": {Data: []byte(src)}})
+	require.NoError(t, fs.Load("main.yarp"))
+	return fs
+}
+
+const users = "package a;\nmessage User { id int64 = 0; }\nservice Users {\n    get(User) -> User;\n}\n"
+
+func TestRegister(t *testing.T) {
+	r := New()
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/typescript/main.yarp"))
+	require.NoError(t, r.Register(fs))
+
+	m, ok := r.LookupMessage("org.example.shop.Order")
+	require.True(t, ok)
+	assert.Equal(t, "Order", m.Name)
+	_, ok = r.LookupMessage("Order")
+	assert.False(t, ok)
+	assert.Contains(t, r.Messages(), idl.FQN("org.example.shop.Order"))
+	for _, name := range r.Services() {
+		s, ok := r.LookupService(name)
+		require.True(t, ok)
+		assert.Equal(t, name.Name(), s.Name)
+	}
+}
+
+func TestRegisterConflicts(t *testing.T) {
+	r := New()
+	require.NoError(t, r.Register(loadSource(t, users)))
+	original, _ := r.LookupMessage("a.User")
+
+	// Identical definitions are accepted and keep the original registration.
+	require.NoError(t, r.Register(loadSource(t, "package a;\n# Users.\nmessage User {\n    id int64 = 0;\n}\nservice Users { get(User) -> User; }\n")))
+	m, _ := r.LookupMessage("a.User")
+	assert.Same(t, original, m)
+
+	err := r.Register(loadSource(t, "package a;\nmessage Group { id int64 = 0; }\nmessage User { id int32 = 0; }\n"))
+	assert.Equal(t, ConflictError{Name: "a.User"}, err)
+	_, ok := r.LookupMessage("a.Group")
+	assert.False(t, ok, "failed registrations must not add symbols")
+
+	err = r.Register(loadSource(t, "package a;\nmessage User { id int64 = 0; }\nservice Users {\n    get(User) -> stream User;\n}\n"))
+	assert.Equal(t, ConflictError{Name: "a.Users"}, err)
+}
+
+func TestRegisterDescriptor(t *testing.T) {
+	data, err := loadSource(t, users).MarshalDescriptor()
+	require.NoError(t, err)
+
+	r := New()
+	require.NoError(t, r.RegisterDescriptor(data))
+	s, ok := r.LookupService("a.Users")
+	require.True(t, ok)
+	require.Len(t, s.Methods, 1)
+
+	assert.Error(t, r.RegisterDescriptor([]byte("garbage")))
+}
+
+func TestConcurrentUse(t *testing.T) {
+	var r Registry
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		fs := loadSource(t, users)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, r.Register(fs))
+		}()
+		go func() {
+			defer wg.Done()
+			if m, ok := r.LookupMessage("a.User"); ok {
+				assert.Equal(t, "User", m.Name)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []idl.FQN{"a.User"}, r.Messages())
+}
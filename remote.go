@@ -0,0 +1,192 @@
+package idl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Fetcher retrieves remote source files referenced by import directives such
+// as `import "https://schemas.example.com/common/types.yarp";`. checksum
+// contains the SHA-256 checksum pinned for url; FileSet verifies returned
+// contents against it regardless of the Fetcher implementation.
+type Fetcher interface {
+	Fetch(url string, checksum [32]byte) ([]byte, error)
+}
+
+// defaultClient performs requests when no client is provided. Unlike
+// http.DefaultClient, it gives up on unresponsive servers rather than
+// blocking loads indefinitely.
+var defaultClient = &http.Client{Timeout: 30 * time.Second}
+
+// maxResponseSize limits the size of bodies read from HTTP responses,
+// protecting against misbehaving servers.
+const maxResponseSize = 16 << 20
+
+// readBody reads the body of res, obtained from url, returning an error in
+// case it exceeds maxResponseSize.
+func readBody(url string, res *http.Response) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResponseSize {
+		return nil, fmt.Errorf("%s: response exceeds %d bytes", url, maxResponseSize)
+	}
+	return data, nil
+}
+
+// clientOrDefault returns c, or defaultClient, in case c is nil.
+func clientOrDefault(c *http.Client) *http.Client {
+	if c == nil {
+		return defaultClient
+	}
+	return c
+}
+
+// HTTPFetcher retrieves remote source files through HTTP requests.
+type HTTPFetcher struct {
+	// Client is used to perform requests. Defaults to a client giving up on
+	// requests after 30 seconds.
+	Client *http.Client
+}
+
+// Fetch implements Fetcher.
+func (h HTTPFetcher) Fetch(url string, _ [32]byte) ([]byte, error) {
+	res, err := clientOrDefault(h.Client).Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", url, res.Status)
+	}
+	return readBody(url, res)
+}
+
+// CachedFetcher wraps a Fetcher, storing fetched files in a local directory
+// keyed by their checksum. Files are only stored once their contents match
+// the checksum they were requested with.
+type CachedFetcher struct {
+	// Dir contains the path of the directory holding cached files.
+	Dir string
+
+	// Fetcher is used to retrieve files not present in Dir.
+	Fetcher Fetcher
+}
+
+// Fetch implements Fetcher.
+func (c CachedFetcher) Fetch(url string, checksum [32]byte) ([]byte, error) {
+	name := filepath.Join(c.Dir, hex.EncodeToString(checksum[:])+SourceExtension)
+	if data, err := os.ReadFile(name); err == nil && sha256.Sum256(data) == checksum {
+		return data, nil
+	}
+	data, err := c.Fetcher.Fetch(url, checksum)
+	if err != nil {
+		return nil, err
+	}
+	if sha256.Sum256(data) != checksum {
+		return data, nil
+	}
+	if err = os.MkdirAll(c.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(c.Dir, ".fetch-*")
+	if err != nil {
+		return nil, err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return nil, err
+	}
+	return data, nil
+}
+
+// SetFetcher defines the Fetcher used to retrieve remote imports. By default,
+// an HTTPFetcher using its default client is used.
+func (f *FileSet) SetFetcher(fetcher Fetcher) {
+	f.fetcher = fetcher
+}
+
+// Pin defines the hex-encoded SHA-256 checksum expected for the remote source
+// file under url. Remote imports are only loaded once pinned.
+func (f *FileSet) Pin(url, checksum string) error {
+	raw, err := hex.DecodeString(checksum)
+	if err != nil || len(raw) != sha256.Size {
+		return fmt.Errorf("%s: invalid SHA-256 checksum %q", url, checksum)
+	}
+	if f.pins == nil {
+		f.pins = map[string][32]byte{}
+	}
+	var sum [32]byte
+	copy(sum[:], raw)
+	f.pins[url] = sum
+	return nil
+}
+
+func isRemote(path string) bool {
+	return strings.HasPrefix(path, "https://")
+}
+
+// resolveRemoteImport returns the URL referenced by an import directive
+// present in the file under from, which is either a local path or a URL.
+// Imports made by remote files are resolved relative to their URL.
+func resolveRemoteImport(from, imp string) (string, error) {
+	if !isRemote(from) {
+		return imp, nil
+	}
+	base, err := url.Parse(from)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(imp)
+	if err != nil {
+		return "", err
+	}
+	u := base.ResolveReference(ref)
+	if path.Ext(u.Path) != SourceExtension {
+		u.Path += SourceExtension
+	}
+	return u.String(), nil
+}
+
+// fetchRemote retrieves the remote source file under url, ensuring its
+// contents match the pinned checksum. Files without a pinned checksum are only
+// accepted when trusted on first use (see UpdateLock).
+func (f *FileSet) fetchRemote(url string) ([]byte, error) {
+	sum, ok := f.pins[url]
+	if !ok && !f.unpinned {
+		return nil, RemoteImportNotPinnedError{URL: url}
+	}
+	fetcher := f.fetcher
+	if fetcher == nil {
+		fetcher = HTTPFetcher{}
+	}
+	data, err := fetcher.Fetch(url, sum)
+	if err != nil {
+		return nil, err
+	}
+	if actual := sha256.Sum256(data); ok && actual != sum {
+		return nil, ChecksumMismatchError{
+			URL:      url,
+			Expected: hex.EncodeToString(sum[:]),
+			Actual:   hex.EncodeToString(actual[:]),
+		}
+	}
+	return data, nil
+}
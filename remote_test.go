@@ -0,0 +1,122 @@
+package idl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	remoteTypes = "package org.example.remote;\n\nimport \"./money\";\n\nmessage Price {\n    value Money = 0;\n}\n"
+	remoteMoney = "package org.example.remote;\n\nmessage Money {\n    cents int64 = 0;\n}\n"
+)
+
+func checksumOf(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func remoteFixture(t *testing.T) (*httptest.Server, fstest.MapFS, *atomic.Int32) {
+	hits := new(atomic.Int32)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/schemas/types.yarp":
+			_, _ = w.Write([]byte(remoteTypes))
+		case "/schemas/money.yarp":
+			_, _ = w.Write([]byte(remoteMoney))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	fsys := fstest.MapFS{
+		"main.yarp": {Data: []byte("package org.example.main;\n\nimport \"" + srv.URL + "/schemas/types.yarp\";\n\nmessage Order {\n    price org.example.remote.Price = 0;\n}\n")},
+	}
+	return srv, fsys, hits
+}
+
+func TestFileSetRemoteImport(t *testing.T) {
+	srv, fsys, _ := remoteFixture(t)
+	typesURL := srv.URL + "/schemas/types.yarp"
+	moneyURL := srv.URL + "/schemas/money.yarp"
+
+	fs := NewFileSetFS(fsys)
+	fs.SetFetcher(HTTPFetcher{Client: srv.Client()})
+	err := fs.Load("main.yarp")
+	var notPinned RemoteImportNotPinnedError
+	require.ErrorAs(t, err, &notPinned)
+	assert.Equal(t, typesURL, notPinned.URL)
+
+	fs = NewFileSetFS(fsys)
+	fs.SetFetcher(HTTPFetcher{Client: srv.Client()})
+	require.NoError(t, fs.Pin(typesURL, checksumOf(remoteTypes)))
+	require.NoError(t, fs.Pin(moneyURL, checksumOf("tampered")))
+	var mismatch ChecksumMismatchError
+	require.ErrorAs(t, fs.Load("main.yarp"), &mismatch)
+	assert.Equal(t, moneyURL, mismatch.URL)
+	assert.Equal(t, checksumOf(remoteMoney), mismatch.Actual)
+
+	fs = NewFileSetFS(fsys)
+	fs.SetFetcher(HTTPFetcher{Client: srv.Client()})
+	require.NoError(t, fs.Pin(typesURL, checksumOf(remoteTypes)))
+	require.NoError(t, fs.Pin(moneyURL, checksumOf(remoteMoney)))
+	require.NoError(t, fs.Load("main.yarp"))
+	assert.Empty(t, fs.Validate())
+	o, ok := fs.Origin("org.example.remote.Money")
+	require.True(t, ok)
+	assert.Equal(t, moneyURL, o.Path())
+
+	assert.Error(t, fs.Pin(typesURL, "abc"))
+}
+
+func TestCachedFetcher(t *testing.T) {
+	srv, fsys, hits := remoteFixture(t)
+	fetcher := CachedFetcher{Dir: t.TempDir(), Fetcher: HTTPFetcher{Client: srv.Client()}}
+	load := func() *FileSet {
+		fs := NewFileSetFS(fsys)
+		fs.SetFetcher(fetcher)
+		require.NoError(t, fs.Pin(srv.URL+"/schemas/types.yarp", checksumOf(remoteTypes)))
+		require.NoError(t, fs.Pin(srv.URL+"/schemas/money.yarp", checksumOf(remoteMoney)))
+		require.NoError(t, fs.Load("main.yarp"))
+		return fs
+	}
+	load()
+	assert.Equal(t, int32(2), hits.Load())
+	fs := load()
+	assert.Equal(t, int32(2), hits.Load())
+	_, ok := fs.FindMessage("org.example.remote.Price")
+	assert.True(t, ok)
+}
+
+func TestClientOrDefault(t *testing.T) {
+	assert.NotZero(t, clientOrDefault(nil).Timeout)
+	assert.NotSame(t, http.DefaultClient, clientOrDefault(nil))
+	c := &http.Client{}
+	assert.Same(t, c, clientOrDefault(c))
+}
+
+func TestHTTPFetcherLimit(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		size := maxResponseSize
+		if r.URL.Path == "/large.yarp" {
+			size++
+		}
+		_, _ = w.Write(make([]byte, size))
+	}))
+	defer srv.Close()
+	f := HTTPFetcher{Client: srv.Client()}
+	data, err := f.Fetch(srv.URL+"/small.yarp", [32]byte{})
+	require.NoError(t, err)
+	assert.Len(t, data, maxResponseSize)
+	_, err = f.Fetch(srv.URL+"/large.yarp", [32]byte{})
+	assert.EqualError(t, err, fmt.Sprintf("%s/large.yarp: response exceeds %d bytes", srv.URL, maxResponseSize))
+}
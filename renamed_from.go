@@ -0,0 +1,160 @@
+package idl
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	// RenamedFromAnnotation contains the name of @renamed_from annotations,
+	// which list the former names of a message, field, or method, as in
+	// @renamed_from("Customer"). References to former names of messages are
+	// resolved to the renamed message, and generators may emit deprecated
+	// aliases under former names, so callers can migrate gradually.
+	RenamedFromAnnotation = "renamed_from"
+
+	// CodeInvalidRenamedFrom identifies diagnostics emitted for malformed
+	// @renamed_from annotations, and for former names colliding with other
+	// declarations.
+	CodeInvalidRenamedFrom = "invalid-renamed-from"
+
+	// CodeRenamedReference identifies diagnostics emitted for references
+	// using a former name of a message.
+	CodeRenamedReference = "renamed-reference"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RenamedFrom returns the former names declared through @renamed_from
+// annotations of the collection, in declaration order.
+func (a AnnotationCollection) RenamedFrom() []string {
+	var r []string
+	for _, v := range a {
+		if v.Name == RenamedFromAnnotation {
+			r = append(r, v.Value...)
+		}
+	}
+	return r
+}
+
+// lookupRenamed finds a message formerly named name, referenced from within
+// a given file.
+func (f *FileSet) lookupRenamed(from *File, name FQN) (*Message, bool) {
+	pkg := f.packageName
+	if from != nil {
+		pkg = from.Package
+	}
+	name = f.dealias(name.Qualify(pkg))
+	for _, m := range f.allMessages {
+		if f.packageOf(m) != name.Package() {
+			continue
+		}
+		for _, old := range m.Annotations.RenamedFrom() {
+			if old == name.Name() {
+				return m, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// renamedReference records a warning reporting a reference to name, a former
+// name of m. Since references are only resolved once, warnings are retained,
+// and reported by subsequent calls to Resolve.
+func (f *FileSet) renamedReference(file *File, o Offset, name string, m *Message) {
+	d := Diagnostic{
+		Severity: SeverityWarning,
+		Code:     CodeRenamedReference,
+		Message:  fmt.Sprintf("type %s was renamed to %s", name, NewFQN(f.packageOf(m), m.Name)),
+		Location: locationOf(file, o),
+		Related:  []Location{locationOf(f.declaredIn[m], m.Offset)},
+	}
+	for _, r := range f.renamedRefs {
+		if r.Location == d.Location && r.Message == d.Message {
+			return
+		}
+	}
+	f.renamedRefs = append(f.renamedRefs, d)
+}
+
+// renameScope tracks names declared within a message, service, or package,
+// reporting former names colliding with them.
+type renameScope struct {
+	file  *File
+	kind  string
+	names map[string]bool
+	diags Diagnostics
+}
+
+func (s *renameScope) check(name string, a AnnotationCollection) {
+	for _, v := range a {
+		if v.Name != RenamedFromAnnotation {
+			continue
+		}
+		fail := func(format string, args ...any) {
+			s.diags = append(s.diags, Diagnostic{
+				Severity: SeverityError,
+				Code:     CodeInvalidRenamedFrom,
+				Message:  fmt.Sprintf("%s %s: @%s: ", s.kind, name, v.Name) + fmt.Sprintf(format, args...),
+				Location: locationOf(s.file, v.Offset),
+			})
+		}
+		if len(v.Value) == 0 {
+			fail("expected at least one name")
+		}
+		for _, old := range v.Value {
+			switch {
+			case !identifier.MatchString(old):
+				fail("invalid name %q", old)
+			case old == name:
+				fail("former name %s is the current name", old)
+			case s.names[old]:
+				fail("former name %s is already in use", old)
+			default:
+				s.names[old] = true
+			}
+		}
+	}
+}
+
+func checkRenamedFrom(f *FileSet) Diagnostics {
+	var diags Diagnostics
+	packages := map[string]*renameScope{}
+	for fqn := range f.messages {
+		s, ok := packages[fqn.Package()]
+		if !ok {
+			s = &renameScope{kind: "message", names: map[string]bool{}}
+			packages[fqn.Package()] = s
+		}
+		s.names[fqn.Name()] = true
+	}
+	for _, m := range f.allMessages {
+		file := f.declaredIn[m]
+		s := packages[f.packageOf(m)]
+		s.file = file
+		s.check(m.Name, m.Annotations)
+
+		fields := allFields(m.Fields)
+		fs := &renameScope{file: file, kind: "field", names: map[string]bool{}}
+		for _, field := range fields {
+			fs.names[field.Name] = true
+		}
+		for _, field := range fields {
+			fs.check(field.Name, field.Annotations)
+		}
+		diags = append(diags, s.diags...)
+		diags = append(diags, fs.diags...)
+		s.diags = nil
+	}
+	for _, svc := range f.allServices {
+		ms := &renameScope{file: f.declaredIn[svc], kind: "method", names: map[string]bool{}}
+		for _, m := range svc.Methods {
+			ms.names[m.Name] = true
+		}
+		for _, m := range svc.Methods {
+			ms.check(m.Name, m.Annotations)
+		}
+		diags = append(diags, ms.diags...)
+	}
+	return diags
+}
@@ -0,0 +1,83 @@
+package idl
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenamedFrom(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/renamed/legacy.yarp"))
+	order, ok := fs.FindMessage("Order")
+	require.True(t, ok)
+	assert.Equal(t, []string{"Purchase"}, order.Annotations.RenamedFrom())
+	assert.Equal(t, []string{"qty", "count"}, allFields(order.Fields)[1].Annotations.RenamedFrom())
+
+	for i := 0; i < 2; i++ {
+		var messages []string
+		for _, d := range fs.Validate() {
+			assert.Equal(t, SeverityWarning, d.Severity)
+			assert.Equal(t, CodeRenamedReference, d.Code)
+			messages = append(messages, d.Message)
+		}
+		assert.Equal(t, []string{
+			"type Purchase was renamed to org.example.shop.Order",
+			"type Purchase was renamed to org.example.shop.Order",
+		}, messages)
+	}
+
+	receipt, ok := fs.FindMessage("Receipt")
+	require.True(t, ok)
+	assert.Equal(t, Resolved{Name: "org.example.shop.Order", Message: order}, allFields(receipt.Fields)[0].Type)
+	receipts, ok := fs.FindService("Receipts")
+	require.True(t, ok)
+	assert.Same(t, order, receipts.Methods[0].Argument.Target)
+
+	desc, err := fs.MarshalDescriptor()
+	require.NoError(t, err)
+	loaded, err := LoadDescriptor(desc)
+	require.NoError(t, err)
+	order, ok = loaded.FindMessage("Order")
+	require.True(t, ok)
+	assert.Equal(t, []string{"Purchase"}, order.Annotations.RenamedFrom())
+	receipt, ok = loaded.FindMessage("Receipt")
+	require.True(t, ok)
+	assert.Equal(t, Resolved{Name: "org.example.shop.Order", Message: order}, allFields(receipt.Fields)[0].Type)
+}
+
+func TestRenamedFromInvalid(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/renamed/invalid.yarp"))
+	var messages []string
+	for _, d := range fs.Validate() {
+		assert.Equal(t, CodeInvalidRenamedFrom, d.Code)
+		messages = append(messages, d.Message)
+	}
+	assert.Equal(t, []string{
+		"message Order: @renamed_from: former name Item is already in use",
+		"field id: @renamed_from: former name id is the current name",
+		"field amount: @renamed_from: former name total is already in use",
+		`field first: @renamed_from: invalid name "1st"`,
+		"message Item: @renamed_from: expected at least one name",
+		"method find: @renamed_from: former name fetch is already in use",
+	}, messages)
+}
+
+func TestCompareRenamedField(t *testing.T) {
+	load := func(src string) *FileSet {
+		fs := NewFileSetFS(fstest.MapFS{"a.yarp": {Data: []byte(src)}})
+		require.NoError(t, fs.Load("a.yarp"))
+		return fs
+	}
+	prev := load("package a;\nmessage M {\n    qty int32 = 0;\n    sku string = 1;\n}\n")
+	next := load("package a;\nmessage M {\n    @renamed_from(\"qty\") quantity int32 = 0;\n    code string = 1;\n}\n")
+	changes := CompareFileSets(prev, next)
+	require.Len(t, changes, 2)
+	assert.Equal(t, SeverityInfo, changes[0].Severity)
+	assert.Equal(t, "field a.M.qty was renamed to quantity, retaining its former name", changes[0].Message)
+	assert.Equal(t, SeverityWarning, changes[1].Severity)
+	assert.Equal(t, "field a.M.sku was renamed to code", changes[1].Message)
+}
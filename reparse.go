@@ -0,0 +1,297 @@
+package idl
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// TextChange represents the replacement of the bytes between Start
+// (inclusive) and End (exclusive) of a source file with Text, as reported by
+// editors as users type.
+type TextChange struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// Reparse applies change to the source of prev, and returns the File
+// resulting from parsing the updated source. Instead of scanning and parsing
+// the whole source again, only top-level messages and services touched by the
+// change are, while nodes and tokens following them are shifted to their new
+// positions, keeping latency low on large files edited by language servers.
+//
+// prev must retain its Source and Tokens (see ParseSource and
+// ParseOptions.RetainTokens), and is left untouched. The returned File
+// retains both as well, so it can be provided to subsequent calls. Changes
+// touching the package and import directives, or the first declaration of
+// the file, along with changes that cannot be confined to whole
+// declarations, cause the source to be parsed from scratch. Either way, the
+// result is identical to the one of ParseSource.
+func Reparse(prev *File, change TextChange) (*File, error) {
+	if change.Start < 0 || change.End < change.Start || change.End > len(prev.Source) {
+		return nil, fmt.Errorf("invalid change of bytes %d-%d", change.Start, change.End)
+	}
+	src := make([]byte, 0, len(prev.Source)-(change.End-change.Start)+len(change.Text))
+	src = append(src, prev.Source[:change.Start]...)
+	src = append(src, change.Text...)
+	src = append(src, prev.Source[change.End:]...)
+
+	if prev.Tokens != nil {
+		if f := reparseDeclarations(prev, change, src); f != nil {
+			return f, nil
+		}
+	}
+	f, err := ParseSource(src, ParseOptions{RetainTokens: true})
+	if err != nil {
+		return nil, err
+	}
+	f.SourcePath = prev.SourcePath
+	return f, nil
+}
+
+// reparseDeclarations implements Reparse, returning nil in case the change
+// cannot be handled incrementally.
+func reparseDeclarations(prev *File, change TextChange, src []byte) *File {
+	// The source is split into the header, containing the package and import
+	// directives and the first declaration, followed by chunks ending with
+	// the closing brace of each subsequent declaration. Chunks touched by the
+	// change are reparsed; from is the index of the first one within
+	// prev.Tree, and to is the index following the last one.
+	first := -1
+	for i, d := range prev.Tree {
+		switch d.(type) {
+		case *Message, *Service:
+			if first == -1 {
+				first = i
+			}
+		}
+	}
+	if first == -1 || change.Start <= prev.Tree[first].Span().End {
+		return nil
+	}
+	from := first + 1
+	for from < len(prev.Tree) && prev.Tree[from].Span().End < change.Start {
+		from++
+	}
+	// The reparsed region spans bytes start-end of the previous source, and
+	// extends to the end of the file when no declaration follows the change.
+	start, end, to := prev.Tree[from-1].Span().End, len(prev.Source), len(prev.Tree)
+	for i := from; i < len(prev.Tree); i++ {
+		if e := prev.Tree[i].Span().End; e > change.End {
+			end, to = e, i+1
+			break
+		}
+	}
+	delta := len(change.Text) - (change.End - change.Start)
+	newEnd := end + delta
+
+	tokens, err := scanRange(src, start, newEnd)
+	if err != nil {
+		return nil
+	}
+	// Tokens following a closing brace must be scanned alike regardless of
+	// the region being scanned on its own; otherwise, for instance, a
+	// comment may have extended past the region.
+	if end < len(prev.Source) && (len(tokens) < 2 || !tokens[len(tokens)-2].is(CloseCurly) || tokens[len(tokens)-2].End != newEnd) {
+		return nil
+	}
+
+	p := newParser(tokens)
+	p.retainTokens = true
+	p.file.declaredNames = map[string]any{}
+	for i, d := range prev.Tree {
+		if i < from || i >= to {
+			switch v := d.(type) {
+			case *Message:
+				p.file.declaredNames[v.Name] = v
+			case *Service:
+				p.file.declaredNames[v.Name] = v
+			}
+		}
+	}
+	p.flushMeta()
+	for !p.tokens.peek().is(EOF) {
+		if err := p.parseOne(p.messageOrService); err != nil {
+			return nil
+		}
+	}
+
+	tokenFrom := sort.Search(len(prev.Tokens), func(i int) bool { return prev.Tokens[i].Start >= start })
+	tokenTo := sort.Search(len(prev.Tokens), func(i int) bool { return prev.Tokens[i].Start >= end })
+	tokens = tokens[:len(tokens)-1] // EOF
+	oldEnd, newEndAt := positionOf(prev.Source, end), positionOf(src, newEnd)
+	s := shift{
+		line:    oldEnd.Line,
+		bytes:   delta,
+		lines:   newEndAt.Line - oldEnd.Line,
+		columns: newEndAt.Column - oldEnd.Column,
+		tokens:  len(tokens) - (tokenTo - tokenFrom),
+	}
+
+	f := &File{
+		Source:     src,
+		SourcePath: prev.SourcePath,
+		Tokens:     make([]Token, 0, len(prev.Tokens)+s.tokens),
+	}
+	f.Tokens = append(f.Tokens, prev.Tokens[:tokenFrom]...)
+	f.Tokens = append(f.Tokens, tokens...)
+	for _, t := range prev.Tokens[tokenTo:] {
+		f.Tokens = append(f.Tokens, s.token(t))
+	}
+
+	for _, d := range prev.Tree[:from] {
+		f.push(detach(cloneDeclaration(d)))
+	}
+	for _, d := range p.file.Tree {
+		f.push(d)
+	}
+	for _, d := range prev.Tree[to:] {
+		d = detach(cloneDeclaration(d))
+		s.declaration(d)
+		f.push(d)
+	}
+
+	f.tokenRanges = make(map[Offset]TokenRange, len(prev.tokenRanges)+len(p.file.tokenRanges))
+	for o, r := range prev.tokenRanges {
+		switch {
+		case o.End <= start:
+			f.tokenRanges[o] = r
+		case o.Start >= end:
+			f.tokenRanges[s.offset(o)] = TokenRange{Start: r.Start + s.tokens, End: r.End + s.tokens}
+		}
+	}
+	for o, r := range p.file.tokenRanges {
+		f.tokenRanges[o] = TokenRange{Start: r.Start + tokenFrom, End: r.End + tokenFrom}
+	}
+	return f
+}
+
+// detach clears fields set on d by a FileSet, so it matches a freshly parsed
+// declaration.
+func detach(d Declaration) Declaration {
+	switch v := d.(type) {
+	case *Message:
+		v.SourceFile = ""
+	case *Service:
+		v.SourceFile = ""
+	}
+	return d
+}
+
+// scanRange scans the bytes between start and end of src, which must not
+// split tokens, returning tokens positioned as if src had been scanned as a
+// whole, followed by EOF.
+func scanRange(src []byte, start, end int) ([]Token, error) {
+	tokens, err := Scan(bytes.NewReader(src[start:end]))
+	if err != nil {
+		return nil, err
+	}
+	base := positionOf(src, start)
+	for i := range tokens {
+		t := &tokens[i]
+		if t.Line == 1 {
+			t.Column += base.Column - 1
+		}
+		t.Line += base.Line - 1
+		t.Start += start
+		t.End += start
+	}
+	return tokens, nil
+}
+
+// positionOf returns the Position the scanner reports for the provided byte
+// offset of src.
+func positionOf(src []byte, offset int) Position {
+	p := Position{Line: 1, Column: 1}
+	for _, r := range string(src[:offset]) {
+		if r == '\n' {
+			p.Line++
+			p.Column = 1
+		}
+		p.Column++
+	}
+	return p
+}
+
+// shift moves nodes and tokens following a reparsed region to their
+// positions in the updated source.
+type shift struct {
+	// line contains the line the region ended at in the previous source.
+	// Columns are only shifted on it.
+	line    int
+	bytes   int
+	lines   int
+	columns int
+	tokens  int
+}
+
+func (s shift) position(p Position) Position {
+	if p.Line == s.line {
+		p.Column += s.columns
+	}
+	p.Line += s.lines
+	return p
+}
+
+func (s shift) offset(o Offset) Offset {
+	o.StartsAt = s.position(o.StartsAt)
+	o.EndsAt = s.position(o.EndsAt)
+	o.Start += s.bytes
+	o.End += s.bytes
+	return o
+}
+
+func (s shift) token(t Token) Token {
+	p := s.position(Position{Line: t.Line, Column: t.Column})
+	t.Line, t.Column = p.Line, p.Column
+	t.Start += s.bytes
+	t.End += s.bytes
+	return t
+}
+
+func (s shift) annotations(a AnnotationCollection) {
+	for i := range a {
+		a[i].Offset = s.offset(a[i].Offset)
+	}
+}
+
+func (s shift) fields(items []FieldItem) {
+	for i, item := range items {
+		switch v := item.(type) {
+		case Field:
+			v.Offset = s.offset(v.Offset)
+			s.annotations(v.Annotations)
+			items[i] = v
+		case OneOfField:
+			v.Offset = s.offset(v.Offset)
+			s.annotations(v.Annotations)
+			s.fields(v.Items)
+			items[i] = v
+		}
+	}
+}
+
+// declaration shifts d, which must not be shared with other Files.
+func (s shift) declaration(d Declaration) {
+	switch v := d.(type) {
+	case *Message:
+		v.Offset = s.offset(v.Offset)
+		s.annotations(v.Annotations)
+		s.fields(v.Fields)
+	case *Service:
+		v.Offset = s.offset(v.Offset)
+		s.annotations(v.Annotations)
+		for i := range v.Methods {
+			m := &v.Methods[i]
+			m.Offset = s.offset(m.Offset)
+			s.annotations(m.Annotations)
+			if !m.Argument.IsVoid() {
+				m.Argument.Offset = s.offset(m.Argument.Offset)
+			}
+			if !m.Return.IsVoid() {
+				m.Return.Offset = s.offset(m.Return.Offset)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package idl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertReparse asserts that reparsing prev with change produces the same
+// result of parsing the updated source from scratch.
+func assertReparse(t *testing.T, prev *File, change TextChange) *File {
+	t.Helper()
+	src := string(prev.Source[:change.Start]) + change.Text + string(prev.Source[change.End:])
+	expected, expectedErr := ParseSource([]byte(src), ParseOptions{RetainTokens: true})
+	f, err := Reparse(prev, change)
+	if expectedErr != nil {
+		// Some messages list alternatives in no particular order; errors
+		// are compared by their type and position.
+		require.Error(t, err, "change %+v", change)
+		assert.IsType(t, expectedErr, err, "change %+v", change)
+		if pe, ok := expectedErr.(ParseError); ok {
+			assert.Equal(t, pe.Token, err.(ParseError).Token, "change %+v", change)
+		} else {
+			assert.Equal(t, expectedErr, err, "change %+v", change)
+		}
+		return nil
+	}
+	require.NoError(t, err, "change %+v", change)
+	assert.Equal(t, expected, f, "change %+v", change)
+	return f
+}
+
+func TestReparse(t *testing.T) {
+	data, err := os.ReadFile("test/reparse/shop.yarp")
+	require.NoError(t, err)
+	prev, err := ParseSource(data, ParseOptions{RetainTokens: true})
+	require.NoError(t, err)
+	src := string(data)
+	at := func(s string) int { return strings.Index(src, s) }
+
+	incremental := []TextChange{
+		{Start: at("sku"), End: at("sku") + 3, Text: "code"},
+		{Start: at("sku"), End: at("sku"), Text: "\n\n    "},
+		{Start: at(" # Stock"), End: at("\n    price"), Text: ""},
+		{Start: at("@optional"), End: at("@optional"), Text: "@deprecated "},
+		{Start: at("voucher"), End: at("voucher"), Text: "gift_"},
+		{Start: at("} = 2"), End: at("} = 2") + 5, Text: "} = 4"},
+		{Start: at("place(Order)"), End: at("place(Order)"), Text: "cancel(Order);\n    "},
+		{Start: at("# Orders manages"), End: at("# Orders manages"), Text: "message Extra {\n    x int32 = 0;\n}\n\n"},
+		{Start: at("# Item represents"), End: at("# Orders manages"), Text: ""},
+		{Start: at("message Empty"), End: len(src), Text: ""},
+		{Start: len(src), End: len(src), Text: "message Tail {\n    ü string = 0;\n}\n"},
+		{Start: at("# Last"), End: at("# Last"), Text: "\n"},
+	}
+	for _, c := range incremental {
+		require.NotNil(t, reparseDeclarations(prev, c, []byte(src[:c.Start]+c.Text+src[c.End:])), "change %+v", c)
+		assertReparse(t, prev, c)
+	}
+
+	full := []TextChange{
+		{Start: at("org.example"), End: at("org.example") + 3, Text: "com"},
+		{Start: at("amount"), End: at("amount"), Text: "total "},
+		{Start: at("\n\n# Item represents"), End: at("\n\n# Item represents"), Text: " # Comment"},
+		{Start: at("sku"), End: at("sku"), Text: "} #"},
+		{Start: at("id string"), End: at("id string"), Text: "\"unterminated"},
+		{Start: at("message Item"), End: at("message Item") + 12, Text: "message Money"},
+	}
+	for _, c := range full {
+		assertReparse(t, prev, c)
+	}
+
+	// Every single-byte deletion and insertion must match a full parse.
+	for i := 0; i <= len(src); i++ {
+		if i < len(src) {
+			assertReparse(t, prev, TextChange{Start: i, End: i + 1})
+		}
+		for _, text := range []string{"\n", " ", "x", "}", "#"} {
+			assertReparse(t, prev, TextChange{Start: i, End: i, Text: text})
+		}
+	}
+
+	// Results can be reparsed in turn.
+	f := assertReparse(t, prev, TextChange{Start: at("list()"), End: at("list()"), Text: "get(Order) -> Order;\n    "})
+	f = assertReparse(t, f, TextChange{Start: at("Empty"), End: at("Empty") + 5, Text: "Nothing"})
+	require.NotNil(t, f)
+
+	_, err = Reparse(prev, TextChange{Start: 10, End: len(src) + 1})
+	assert.EqualError(t, err, fmt.Sprintf("invalid change of bytes 10-%d", len(src)+1))
+}
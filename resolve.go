@@ -0,0 +1,199 @@
+package idl
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// CodeUnknownType identifies diagnostics emitted when a referenced type
+	// cannot be found.
+	CodeUnknownType = "unknown-type"
+
+	// CodeIllegalMethodType identifies diagnostics emitted when a method
+	// signature references a type other than a message.
+	CodeIllegalMethodType = "illegal-method-type"
+
+	// CodeStreamingPrimitive identifies diagnostics emitted for methods
+	// streaming primitive values.
+	CodeStreamingPrimitive = "streaming-primitive"
+
+	// CodeAmbiguousType identifies diagnostics emitted when a short name
+	// cannot be found in the package of the referencing file, but is declared
+	// by more than one other package, and must therefore be qualified.
+	CodeAmbiguousType = "ambiguous-type"
+)
+
+// Resolve links every type referenced by messages and service methods known by
+// the FileSet to their definitions. Unresolved types referring to known
+// messages are replaced by Resolved values, and method TypeRefs have their
+// Target set. References to former names of messages, declared through
+// @renamed_from, are resolved to the renamed message, and reported as
+// warnings. References that cannot be resolved, and method signatures
+// referencing primitive types are reported through the returned Diagnostics.
+// Resolve may be called multiple times.
+func (f *FileSet) Resolve() Diagnostics {
+	var diags Diagnostics
+	for _, m := range f.allMessages {
+		file := f.declaredIn[m]
+		diags = append(diags, f.resolveFields(file, m.Fields)...)
+	}
+	for _, s := range f.allServices {
+		for i := range s.Methods {
+			diags = append(diags, f.resolveMethod(s, &s.Methods[i])...)
+		}
+	}
+	return append(diags, f.renamedRefs...)
+}
+
+// resolveMethod links the argument and return types of a method to their
+// definitions, reporting references to unknown types, and to primitive types,
+// which cannot be used in method signatures.
+func (f *FileSet) resolveMethod(s *Service, m *Method) Diagnostics {
+	var diags Diagnostics
+	file := f.declaredIn[s]
+	for _, ref := range []*TypeRef{&m.Argument, &m.Return} {
+		if ref.IsVoid() {
+			continue
+		}
+		role := "argument"
+		if ref == &m.Return {
+			role = "return type"
+		}
+		if _, ok := stringToPrimitive[ref.Name]; ok && ref.Package == "" {
+			code, msg := CodeIllegalMethodType, "uses primitive type %s as %s"
+			if ref.Streaming {
+				code, msg = CodeStreamingPrimitive, "streams primitive type %s as %s"
+			}
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Code:     code,
+				Message:  fmt.Sprintf("method %s of %s "+msg+"; only messages are allowed", m.Name, s.Name, ref.Name, role),
+				Location: locationOf(file, ref.Offset),
+				Related:  []Location{locationOf(file, m.Offset)},
+			})
+			continue
+		}
+		msg, ok := f.lookupMessage(file, ref.FQN())
+		if !ok {
+			if msg, ok = f.lookupRenamed(file, ref.FQN()); ok {
+				f.renamedReference(file, ref.Offset, ref.String(), msg)
+			}
+		}
+		if !ok {
+			d := f.unresolvedType(file, ref.Offset, ref.String())
+			d.Message += fmt.Sprintf(" used as %s of method %s of %s", role, m.Name, s.Name)
+			d.Related = append(d.Related, locationOf(file, m.Offset))
+			diags = append(diags, d)
+			continue
+		}
+		ref.Target = msg
+	}
+	return diags
+}
+
+func (f *FileSet) resolveFields(file *File, items []FieldItem) Diagnostics {
+	var diags Diagnostics
+	for i, item := range items {
+		switch v := item.(type) {
+		case Field:
+			t, d := f.resolveType(file, v.Offset, v.Type)
+			v.Type = t
+			items[i] = v
+			diags = append(diags, d...)
+		case OneOfField:
+			diags = append(diags, f.resolveFields(file, v.Items)...)
+		}
+	}
+	return diags
+}
+
+func (f *FileSet) resolveType(file *File, o Offset, t Type) (Type, Diagnostics) {
+	switch v := t.(type) {
+	case Unresolved:
+		msg, ok := f.lookupMessage(file, FQN(v.Name))
+		if !ok {
+			if msg, ok = f.lookupRenamed(file, FQN(v.Name)); !ok {
+				return t, Diagnostics{f.unresolvedType(file, o, v.Name)}
+			}
+			f.renamedReference(file, o, v.Name, msg)
+		}
+		return Resolved{Name: NewFQN(f.packageOf(msg), msg.Name), Message: msg}, nil
+	case Array:
+		of, d := f.resolveType(file, o, v.Of)
+		return Array{Of: of}, d
+	case Map:
+		val, d := f.resolveType(file, o, v.Value)
+		return Map{Key: v.Key, Value: val}, d
+	}
+	return t, nil
+}
+
+// lookupMessage finds a message referenced from within a given file. Short
+// names are looked up in the file's package, and package aliases defined
+// through AliasPackage are honored.
+func (f *FileSet) lookupMessage(from *File, name FQN) (*Message, bool) {
+	pkg := f.packageName
+	if from != nil {
+		pkg = from.Package
+	}
+	m, ok := f.messages[f.dealias(name.Qualify(pkg))]
+	return m, ok
+}
+
+func (f *FileSet) packageOf(d Declaration) string {
+	if file, ok := f.declaredIn[d]; ok {
+		return file.Package
+	}
+	return f.packageName
+}
+
+func locationOf(file *File, o Offset) Location {
+	l := Location{Offset: o}
+	if file != nil {
+		l.File = file.SourcePath
+	}
+	return l
+}
+
+// unresolvedType returns a Diagnostic reporting a name that could not be
+// resolved from within file. Short names are only looked up in the package of
+// the referencing file; in case other packages declare messages with the same
+// name, they are listed as candidates, and the diagnostic indicates the name
+// must be qualified.
+func (f *FileSet) unresolvedType(file *File, o Offset, name string) Diagnostic {
+	d := unknownType(file, o, name)
+	if FQN(name).IsQualified() {
+		return d
+	}
+	var candidates []*Message
+	for _, m := range f.allMessages {
+		if m.Name == name {
+			candidates = append(candidates, m)
+		}
+	}
+	if len(candidates) == 0 {
+		return d
+	}
+	names := make([]string, len(candidates))
+	for i, m := range candidates {
+		names[i] = NewFQN(f.packageOf(m), m.Name).String()
+		d.Related = append(d.Related, locationOf(f.declaredIn[m], m.Offset))
+	}
+	if len(candidates) == 1 {
+		d.Message = fmt.Sprintf("unknown type %s (did you mean %s?)", name, names[0])
+		return d
+	}
+	d.Code = CodeAmbiguousType
+	d.Message = fmt.Sprintf("ambiguous type %s must be qualified; candidates are %s", name, strings.Join(names, ", "))
+	return d
+}
+
+func unknownType(file *File, o Offset, name string) Diagnostic {
+	return Diagnostic{
+		Severity: SeverityError,
+		Code:     CodeUnknownType,
+		Message:  fmt.Sprintf("unknown type %s", name),
+		Location: locationOf(file, o),
+	}
+}
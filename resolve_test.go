@@ -0,0 +1,99 @@
+package idl
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetResolve(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/resolve/main.yarp"))
+	diags := fs.Resolve()
+
+	var unknown []string
+	for _, d := range diags {
+		assert.NotEmpty(t, d.Location.File)
+		unknown = append(unknown, d.Message)
+	}
+	assert.Equal(t, []string{CodeUnknownType, CodeUnknownType, CodeUnknownType, CodeIllegalMethodType}, diagnosticCodes(diags))
+	assert.Equal(t, []string{
+		"unknown type Unknown",
+		"unknown type Missing used as argument of method broken of Users",
+		"unknown type org.example.types.Gone used as return type of method broken of Users",
+		"method primitive of Users uses primitive type string as argument; only messages are allowed",
+	}, unknown)
+
+	user, ok := fs.FindMessage("User")
+	require.True(t, ok)
+	address, ok := fs.FindMessage("org.example.types.Address")
+	require.True(t, ok)
+
+	fields := user.Fields
+	assert.Equal(t, Resolved{Name: "org.example.types.Address", Message: address}, fields[1].(Field).Type)
+	require.IsType(t, Array{}, fields[2].(Field).Type)
+	assert.Equal(t, FQN("org.example.types.Tag"), fields[2].(Field).Type.(Array).Of.(Resolved).Name)
+	assert.Equal(t, user, fields[3].(Field).Type.(Map).Value.(Resolved).Message)
+	assert.Equal(t, Unresolved{Name: "Unknown"}, fields[4].(Field).Type)
+
+	lookup, ok := fs.FindMessage("org.example.types.Lookup")
+	require.True(t, ok)
+	assert.Equal(t, address, lookup.Fields[1].(Field).Type.(Resolved).Message)
+
+	method := slices.Collect(fs.AllServices())[0].Methods[0]
+	assert.Equal(t, lookup, method.Argument.Target)
+	assert.Equal(t, user, method.Return.Target)
+
+	assert.Len(t, fs.Resolve(), 4)
+}
+
+func TestResolveCrossPackageMethods(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/resolve/main.yarp"))
+	require.NoError(t, fs.Load("./test/resolve/services.yarp"))
+	diags := fs.Resolve()
+
+	lookups, ok := fs.FindService("org.example.types.Lookups")
+	require.True(t, ok)
+	address, ok := fs.FindMessage("org.example.types.Address")
+	require.True(t, ok)
+	assert.Equal(t, address, lookups.Methods[0].Return.Target)
+
+	users, ok := fs.FindService("Users")
+	require.True(t, ok)
+	broken := users.Methods[1]
+	assert.Nil(t, broken.Return.Target)
+	var gone *Diagnostic
+	for i, d := range diags {
+		if strings.Contains(d.Message, "org.example.types.Gone") {
+			gone = &diags[i]
+		}
+	}
+	require.NotNil(t, gone)
+	assert.Equal(t, broken.Return.Offset, gone.Location.Offset)
+	assert.Equal(t, 15, gone.Location.Offset.StartsAt.Line)
+
+	assert.True(t, fs.FromSamePackage("Users"))
+	assert.False(t, fs.FromSamePackage("org.example.types.Lookups"))
+	assert.False(t, fs.FromSamePackage("Lookups"))
+}
+
+func TestResolveAmbiguousNames(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/ambiguous/main.yarp"))
+	diags := fs.Resolve()
+	require.Len(t, diags, 2)
+
+	assert.Equal(t, CodeAmbiguousType, diags[0].Code)
+	assert.Equal(t, "ambiguous type Error must be qualified; candidates are org.example.billing.Error, org.example.auth.Error", diags[0].Message)
+	require.Len(t, diags[0].Related, 2)
+	assert.Equal(t, "billing.yarp", filepath.Base(diags[0].Related[0].File))
+	assert.Equal(t, "auth.yarp", filepath.Base(diags[0].Related[1].File))
+
+	assert.Equal(t, CodeUnknownType, diags[1].Code)
+	assert.Equal(t, "unknown type Status (did you mean org.example.auth.Status?)", diags[1].Message)
+}
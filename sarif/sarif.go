@@ -0,0 +1,245 @@
+// Package sarif renders idl.Diagnostics as SARIF 2.1.0 logs, allowing results
+// of validation and linting to be displayed by code scanning tools, such as
+// GitHub's, and other CI dashboards.
+package sarif
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/libyarp/idl"
+)
+
+// Version contains the version of SARIF logs produced by the package.
+const Version = "2.1.0"
+
+// Schema contains the URI of the JSON schema of SARIF logs.
+const Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// rootBaseID contains the name of the base URI paths under Options.Root are
+// relative to.
+const rootBaseID = "SRCROOT"
+
+// Options configures the produced log.
+type Options struct {
+	// ToolName contains the name of the tool reporting diagnostics, and
+	// defaults to "yarp".
+	ToolName string
+
+	// ToolVersion optionally contains the version of the tool.
+	ToolVersion string
+
+	// InformationURI optionally contains the URI of the tool's documentation.
+	InformationURI string
+
+	// Root optionally contains the directory containing sources, usually the
+	// root of their repository. Paths of files under Root are reported
+	// relative to it, as code scanning tools expect.
+	Root string
+
+	// Rules optionally contains descriptions of diagnostic codes, such as
+	// the Doc of lint rules, keyed by code.
+	Rules map[string]string
+}
+
+// Marshal returns diags encoded as a SARIF log containing a single run.
+func Marshal(diags idl.Diagnostics, opts Options) ([]byte, error) {
+	return json.MarshalIndent(convert(diags, opts), "", "  ")
+}
+
+// Write encodes diags as a SARIF log into w. See Marshal.
+func Write(w io.Writer, diags idl.Diagnostics, opts Options) error {
+	data, err := Marshal(diags, opts)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+type log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []run  `json:"runs"`
+}
+
+type run struct {
+	Tool               tool                   `json:"tool"`
+	OriginalURIBaseIDs map[string]artifactURI `json:"originalUriBaseIds,omitempty"`
+	Results            []result               `json:"results"`
+}
+
+type tool struct {
+	Driver driver `json:"driver"`
+}
+
+type driver struct {
+	Name           string           `json:"name"`
+	Version        string           `json:"version,omitempty"`
+	InformationURI string           `json:"informationUri,omitempty"`
+	Rules          []ruleDescriptor `json:"rules,omitempty"`
+}
+
+type ruleDescriptor struct {
+	ID               string   `json:"id"`
+	ShortDescription *message `json:"shortDescription,omitempty"`
+}
+
+type message struct {
+	Text string `json:"text"`
+}
+
+type result struct {
+	RuleID           string     `json:"ruleId,omitempty"`
+	RuleIndex        *int       `json:"ruleIndex,omitempty"`
+	Level            string     `json:"level"`
+	Message          message    `json:"message"`
+	Locations        []location `json:"locations,omitempty"`
+	RelatedLocations []location `json:"relatedLocations,omitempty"`
+}
+
+type location struct {
+	ID               *int             `json:"id,omitempty"`
+	PhysicalLocation physicalLocation `json:"physicalLocation"`
+}
+
+type physicalLocation struct {
+	ArtifactLocation artifactURI `json:"artifactLocation"`
+	Region           *region     `json:"region,omitempty"`
+}
+
+type artifactURI struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+func convert(diags idl.Diagnostics, opts Options) log {
+	name := opts.ToolName
+	if name == "" {
+		name = "yarp"
+	}
+	r := run{
+		Tool: tool{Driver: driver{
+			Name:           name,
+			Version:        opts.ToolVersion,
+			InformationURI: opts.InformationURI,
+		}},
+		Results: []result{},
+	}
+	if opts.Root != "" {
+		r.OriginalURIBaseIDs = map[string]artifactURI{rootBaseID: {URI: fileURI(opts.Root, true)}}
+	}
+
+	var codes []string
+	for _, d := range diags {
+		if d.Code != "" {
+			codes = append(codes, d.Code)
+		}
+	}
+	sort.Strings(codes)
+	indexes := map[string]int{}
+	for _, c := range codes {
+		if _, ok := indexes[c]; ok {
+			continue
+		}
+		indexes[c] = len(r.Tool.Driver.Rules)
+		rd := ruleDescriptor{ID: c}
+		if doc, ok := opts.Rules[c]; ok {
+			rd.ShortDescription = &message{Text: doc}
+		}
+		r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, rd)
+	}
+
+	for _, d := range diags {
+		res := result{
+			RuleID:  d.Code,
+			Level:   level(d.Severity),
+			Message: message{Text: d.Message},
+		}
+		if i, ok := indexes[d.Code]; ok {
+			res.RuleIndex = &i
+		}
+		if d.Location.File != "" {
+			res.Locations = []location{physical(d.Location, opts.Root)}
+		}
+		for i, rel := range d.Related {
+			if rel.File == "" {
+				continue
+			}
+			l := physical(rel, opts.Root)
+			id := i
+			l.ID = &id
+			res.RelatedLocations = append(res.RelatedLocations, l)
+		}
+		r.Results = append(r.Results, res)
+	}
+	return log{Version: Version, Schema: Schema, Runs: []run{r}}
+}
+
+// level returns the SARIF level corresponding to s.
+func level(s idl.Severity) string {
+	switch s {
+	case idl.SeverityError:
+		return "error"
+	case idl.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// physical returns the SARIF representation of l, whose path is made
+// relative to root, when possible.
+func physical(l idl.Location, root string) location {
+	a := artifactURI{URI: fileURI(l.File, false)}
+	if root != "" {
+		if abs, err := filepath.Abs(l.File); err == nil {
+			if rootAbs, err := filepath.Abs(root); err == nil {
+				if rel, err := filepath.Rel(rootAbs, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+					a = artifactURI{URI: (&url.URL{Path: filepath.ToSlash(rel)}).String(), URIBaseID: rootBaseID}
+				}
+			}
+		}
+	}
+	p := physicalLocation{ArtifactLocation: a}
+	if s := l.Offset.StartsAt; s.Line > 0 {
+		rg := &region{StartLine: s.Line, StartColumn: s.Column}
+		if e := l.Offset.EndsAt; e.Line >= s.Line {
+			rg.EndLine, rg.EndColumn = e.Line, e.Column
+		}
+		p.Region = rg
+	}
+	return location{PhysicalLocation: p}
+}
+
+// fileURI returns the URI of path. Absolute paths are represented as file
+// URIs, and directories, when dir is set, end with a slash.
+func fileURI(path string, dir bool) string {
+	if dir {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+	p := filepath.ToSlash(path)
+	if dir && !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	if filepath.IsAbs(path) {
+		if !strings.HasPrefix(p, "/") {
+			p = "/" + p
+		}
+		return (&url.URL{Scheme: "file", Path: p}).String()
+	}
+	return (&url.URL{Path: p}).String()
+}
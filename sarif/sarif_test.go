@@ -0,0 +1,94 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal(t *testing.T) {
+	root, err := filepath.Abs("../test")
+	require.NoError(t, err)
+	at := func(file string, line, col int) idl.Location {
+		return idl.Location{File: file, Offset: idl.Offset{
+			StartsAt: idl.Position{Line: line, Column: col},
+			EndsAt:   idl.Position{Line: line, Column: col + 4},
+		}}
+	}
+	diags := idl.Diagnostics{
+		{
+			Severity: idl.SeverityError,
+			Code:     idl.CodeDuplicateIndex,
+			Message:  "index 1 of field b is already used by field a in Foo",
+			Location: at(filepath.Join(root, "lint", "naming file.yarp"), 5, 7),
+			Related:  []idl.Location{at(filepath.Join(root, "lint", "naming file.yarp"), 4, 7)},
+		},
+		{Severity: idl.SeverityWarning, Code: "field-name", Message: "field unitPrice should be named unit_price", Location: at("/elsewhere/x.yarp", 2, 3)},
+		{Severity: idl.SeverityInfo, Message: "no location"},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, Write(&b, diags, Options{
+		ToolName:    "yarplint",
+		ToolVersion: "1.2.0",
+		Root:        root,
+		Rules:       map[string]string{"field-name": "Names of fields are written in snake_case."},
+	}))
+	assert.JSONEq(t, `{
+  "version": "2.1.0",
+  "$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+  "runs": [{
+    "tool": {"driver": {
+      "name": "yarplint",
+      "version": "1.2.0",
+      "rules": [
+        {"id": "duplicate-index"},
+        {"id": "field-name", "shortDescription": {"text": "Names of fields are written in snake_case."}}
+      ]
+    }},
+    "originalUriBaseIds": {"SRCROOT": {"uri": "file://`+filepath.ToSlash(root)+`/"}},
+    "results": [
+      {
+        "ruleId": "duplicate-index",
+        "ruleIndex": 0,
+        "level": "error",
+        "message": {"text": "index 1 of field b is already used by field a in Foo"},
+        "locations": [{"physicalLocation": {
+          "artifactLocation": {"uri": "lint/naming%20file.yarp", "uriBaseId": "SRCROOT"},
+          "region": {"startLine": 5, "startColumn": 7, "endLine": 5, "endColumn": 11}
+        }}],
+        "relatedLocations": [{"id": 0, "physicalLocation": {
+          "artifactLocation": {"uri": "lint/naming%20file.yarp", "uriBaseId": "SRCROOT"},
+          "region": {"startLine": 4, "startColumn": 7, "endLine": 4, "endColumn": 11}
+        }}]
+      },
+      {
+        "ruleId": "field-name",
+        "ruleIndex": 1,
+        "level": "warning",
+        "message": {"text": "field unitPrice should be named unit_price"},
+        "locations": [{"physicalLocation": {
+          "artifactLocation": {"uri": "file:///elsewhere/x.yarp"},
+          "region": {"startLine": 2, "startColumn": 3, "endLine": 2, "endColumn": 7}
+        }}]
+      },
+      {"level": "note", "message": {"text": "no location"}}
+    ]
+  }]
+}`, b.String())
+}
+
+func TestMarshalEmpty(t *testing.T) {
+	data, err := Marshal(nil, Options{})
+	require.NoError(t, err)
+	var v map[string]any
+	require.NoError(t, json.Unmarshal(data, &v))
+	run := v["runs"].([]any)[0].(map[string]any)
+	assert.Equal(t, map[string]any{"driver": map[string]any{"name": "yarp"}}, run["tool"])
+	assert.Equal(t, []any{}, run["results"])
+}
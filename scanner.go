@@ -5,6 +5,7 @@ import (
 	"io"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Scanner implements mechanisms responsible for reading an IDL file into a list
@@ -12,9 +13,15 @@ import (
 type Scanner struct {
 	tokens  []Token
 	data    []rune
+	offsets []int
 	dataLen int
 	start   int
 	current int
+
+	// interned, when set, maps token text to a previously allocated string
+	// with the same contents, and buf holds scratch space used to look it up.
+	interned map[string]string
+	buf      []byte
 }
 
 // Scan takes an io.Reader and returns a list of Token from it, or an error, in
@@ -37,15 +44,32 @@ func NewScanner(r io.Reader) (*Scanner, error) {
 	if err != nil {
 		return nil, err
 	}
+	data := []rune(string(buf))
 	return &Scanner{
 		tokens:  nil,
-		data:    []rune(string(buf)),
+		data:    data,
+		offsets: byteOffsets(data),
 		dataLen: len(buf),
 		start:   0,
 		current: 0,
 	}, nil
 }
 
+// byteOffsets returns the byte offset of each rune in data, followed by the
+// total length of data in bytes.
+func byteOffsets(data []rune) []int {
+	return appendByteOffsets(make([]int, 0, len(data)+1), data)
+}
+
+func appendByteOffsets(offsets []int, data []rune) []int {
+	n := 0
+	for _, r := range data {
+		offsets = append(offsets, n)
+		n += utf8.RuneLen(r)
+	}
+	return append(offsets, n)
+}
+
 // Run executes the scan process into the provided reader. Returns either a list
 // of Token, or an error.
 func (s *Scanner) Run() ([]Token, error) {
@@ -55,6 +79,7 @@ func (s *Scanner) Run() ([]Token, error) {
 			return nil, err
 		}
 	}
+	s.start = s.current
 	s.pushToken(EOF, "")
 	return s.tokens, nil
 }
@@ -66,9 +91,15 @@ func (s *Scanner) pushToken(k Element, v string) {
 		Value:  v,
 		Line:   l,
 		Column: c,
+		Start:  s.offsets[s.start],
+		End:    s.offsets[s.current],
 	})
 }
 
+// pragmaPrefix contains the prefix distinguishing pragmas from regular
+// comments.
+const pragmaPrefix = "yarp:"
+
 var simpleTokens = map[rune]Element{
 	'(':  OpenParen,
 	')':  CloseParen,
@@ -99,6 +130,7 @@ func (s *Scanner) scanToken() error {
 		// We advance later here so we can point the arrow to
 		// the beginning of it instead of the end.
 		s.advance()
+		s.tokens[len(s.tokens)-1].End = s.offsets[s.current]
 
 	case '\r', ' ', '\t':
 	// Just consume it. We don't care about spaces
@@ -108,7 +140,7 @@ func (s *Scanner) scanToken() error {
 		s.comment()
 	default:
 		if k, ok := simpleTokens[r]; ok {
-			s.pushToken(k, string(r))
+			s.pushToken(k, s.text(s.data[s.start:s.current]))
 		} else if unicode.IsDigit(r) {
 			s.number()
 		} else if unicode.IsGraphic(r) {
@@ -121,6 +153,30 @@ func (s *Scanner) scanToken() error {
 	return nil
 }
 
+// maxInterned bounds the amount of strings retained by a Scanner's intern
+// table.
+const maxInterned = 4096
+
+// text returns runes as a string. When the Scanner has an intern table, equal
+// values share a single allocation.
+func (s *Scanner) text(runes []rune) string {
+	if s.interned == nil {
+		return string(runes)
+	}
+	s.buf = s.buf[:0]
+	for _, r := range runes {
+		s.buf = utf8.AppendRune(s.buf, r)
+	}
+	if v, ok := s.interned[string(s.buf)]; ok {
+		return v
+	}
+	v := string(s.buf)
+	if len(s.interned) < maxInterned {
+		s.interned[v] = v
+	}
+	return v
+}
+
 func (s *Scanner) advance() rune {
 	r := s.data[s.current]
 	s.current++
@@ -174,9 +230,11 @@ func (s *Scanner) number() {
 	}
 	s.tokens = append(s.tokens, Token{
 		Type:   Number,
-		Value:  string(s.data[s.start:s.current]),
+		Value:  s.text(s.data[s.start:s.current]),
 		Line:   l,
 		Column: c,
+		Start:  s.offsets[s.start],
+		End:    s.offsets[s.current],
 	})
 }
 
@@ -199,28 +257,39 @@ func (s *Scanner) identifier() {
 
 	s.tokens = append(s.tokens, Token{
 		Type:   Identifier,
-		Value:  string(s.data[s.start:s.current]),
+		Value:  s.text(s.data[s.start:s.current]),
 		Line:   l,
 		Column: col,
+		Start:  s.offsets[s.start],
+		End:    s.offsets[s.current],
 	})
 }
 
+// comment consumes a comment until the end of the line. Comments starting
+// with "yarp:" are emitted as Pragma tokens, whose value contains the text
+// following the prefix.
 func (s *Scanner) comment() {
 	l, c := s.pos()
-	for s.peek() != '\n' {
+	for !s.isAtEnd() && s.peek() != '\n' {
 		s.advance()
 	}
+	kind, value := Comment, strings.TrimSpace(string(s.data[s.start+1:s.current]))
+	if v, ok := strings.CutPrefix(value, pragmaPrefix); ok {
+		kind, value = Pragma, strings.TrimSpace(v)
+	}
 	s.tokens = append(s.tokens, Token{
-		Type:   Comment,
-		Value:  strings.TrimSpace(string(s.data[s.start+1 : s.current])),
+		Type:   kind,
+		Value:  value,
 		Line:   l,
 		Column: c,
+		Start:  s.offsets[s.start],
+		End:    s.offsets[s.current],
 	})
 }
 
 func (s *Scanner) annotation() error {
 	l, c := s.pos()
-	for s.peek() != ' ' {
+	for isAnnotationRune(s.peek()) {
 		s.advance()
 	}
 	consumed := s.current - s.start
@@ -229,13 +298,26 @@ func (s *Scanner) annotation() error {
 	}
 	s.tokens = append(s.tokens, Token{
 		Type:   Annotation,
-		Value:  string(s.data[s.start+1 : s.current]),
+		Value:  s.text(s.data[s.start+1 : s.current]),
 		Line:   l,
 		Column: c,
+		Start:  s.offsets[s.start],
+		End:    s.offsets[s.current],
 	})
 	return nil
 }
 
+// isAnnotationRune returns whether r may compose an annotation name. Names end
+// at the first other character, so values may immediately follow them, as in
+// @reserved(5, "legacy"). Dots are accepted to allow namespaced annotations,
+// such as @go.package.
+func isAnnotationRune(r rune) bool {
+	return (r >= 'a' && r <= 'z') ||
+		(r >= 'A' && r <= 'Z') ||
+		(r >= '0' && r <= '9') ||
+		r == '_' || r == '.'
+}
+
 func (s *Scanner) string() error {
 	l, c := s.pos()
 	s.advance() // consume "
@@ -266,6 +348,8 @@ loop:
 		Value:  strings.ReplaceAll(string(s.data[s.start+1:s.current-1]), "\\\"", `"`),
 		Line:   l,
 		Column: c,
+		Start:  s.offsets[s.start],
+		End:    s.offsets[s.current],
 	})
 
 	return nil
@@ -0,0 +1,41 @@
+package idl
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SearchPathEnv contains the name of the environment variable listing
+// directories searched for imports, separated by os.PathListSeparator, in
+// case FileSetOptions.SearchPath is nil.
+const SearchPathEnv = "YARPPATH"
+
+// searchPath returns the canonical form of directories searched for imports
+// after include paths. Directories that do not exist are ignored.
+func (f *FileSet) searchPath() []string {
+	if f.searchRoots != nil {
+		return f.searchRoots
+	}
+	dirs := f.options.SearchPath
+	if _, ok := f.sourceFS().(osSource); ok && dirs == nil {
+		dirs = filepath.SplitList(os.Getenv(SearchPathEnv))
+	}
+	src := f.sourceFS()
+	f.searchRoots = []string{}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		abs, err := src.abs(dir)
+		if err != nil {
+			continue
+		}
+		if stat, err := src.stat(abs); err != nil || !stat.IsDir() {
+			continue
+		}
+		if abs, _, err = src.canonical(abs); err == nil {
+			f.searchRoots = append(f.searchRoots, abs)
+		}
+	}
+	return f.searchRoots
+}
@@ -0,0 +1,30 @@
+package idl
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetSearchPath(t *testing.T) {
+	t.Setenv(SearchPathEnv, strings.Join([]string{"./test/include/missing", "./test/include/shared"}, string(os.PathListSeparator)))
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/include/app/order.yarp"))
+	assert.Empty(t, fs.Validate())
+	_, ok := fs.FindMessage("org.example.common.Money")
+	assert.True(t, ok)
+
+	fs = NewFileSet()
+	fs.SetOptions(FileSetOptions{SearchPath: []string{}})
+	var notFound ImportFileNotFoundError
+	require.ErrorAs(t, fs.Load("./test/include/app/order.yarp"), &notFound)
+
+	t.Setenv(SearchPathEnv, "")
+	fs = NewFileSetFS(os.DirFS("test/include"))
+	fs.SetOptions(FileSetOptions{SearchPath: []string{"shared"}})
+	require.NoError(t, fs.Load("app/order.yarp"))
+	assert.Empty(t, fs.Validate())
+}
@@ -0,0 +1,231 @@
+package idl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version represents a semantic version, such as v1.2.3 or v2.0.0-beta.1.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string
+}
+
+// ParseVersion parses a semantic version, optionally prefixed by v. Minor and
+// patch components may be omitted, and default to zero.
+func ParseVersion(s string) (Version, error) {
+	v, _, err := parseVersionParts(s)
+	return v, err
+}
+
+// parseVersionParts parses a version, also returning how many numeric
+// components were provided.
+func parseVersionParts(s string) (Version, int, error) {
+	raw := strings.TrimPrefix(s, "v")
+	var v Version
+	if i := strings.IndexByte(raw, '-'); i >= 0 {
+		raw, v.Pre = raw[:i], raw[i+1:]
+		if v.Pre == "" {
+			return Version{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+	}
+	components := strings.Split(raw, ".")
+	if len(components) > 3 {
+		return Version{}, 0, fmt.Errorf("invalid version %q", s)
+	}
+	dst := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, c := range components {
+		n, err := strconv.Atoi(c)
+		if err != nil || n < 0 {
+			return Version{}, 0, fmt.Errorf("invalid version %q", s)
+		}
+		*dst[i] = n
+	}
+	return v, len(components), nil
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare returns -1, 0, or 1 depending on whether v precedes, equals, or
+// follows o. Pre-release versions precede their release, and are ordered as
+// described by SemVer: dot-separated identifiers are compared in turn,
+// numerically when both are numeric, with numeric identifiers preceding
+// alphanumeric ones, and a prefix of identifiers preceding longer lists.
+func (v Version) Compare(o Version) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	a, b := strings.Split(v.Pre, "."), strings.Split(o.Pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := comparePreIdentifiers(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// comparePreIdentifiers compares identifiers of pre-release versions.
+func comparePreIdentifiers(a, b string) int {
+	an, bn := isNumeric(a), isNumeric(b)
+	switch {
+	case an && bn:
+		// Numbers are compared by length first, so they may exceed the range
+		// of integers.
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// isNumeric returns whether s only holds decimal digits.
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Constraint represents a set of acceptable versions, such as ^1.2, ~1.2.3,
+// >=1.0.0 <2.0.0, or a single exact version. Space-separated comparisons must
+// all hold, and alternatives may be separated by ||.
+type Constraint struct {
+	raw  string
+	sets [][]comparison
+}
+
+type comparison struct {
+	op string
+	v  Version
+}
+
+// ParseConstraint parses a version constraint.
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: s}
+	for _, alt := range strings.Split(s, "||") {
+		var set []comparison
+		for _, term := range strings.Fields(alt) {
+			cmp, err := parseComparison(term)
+			if err != nil {
+				return Constraint{}, fmt.Errorf("invalid constraint %q: %w", s, err)
+			}
+			set = append(set, cmp...)
+		}
+		if len(set) == 0 {
+			return Constraint{}, fmt.Errorf("invalid constraint %q", s)
+		}
+		c.sets = append(c.sets, set)
+	}
+	return c, nil
+}
+
+func parseComparison(term string) ([]comparison, error) {
+	for _, op := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		rest, ok := strings.CutPrefix(term, op)
+		if !ok {
+			continue
+		}
+		v, parts, err := parseVersionParts(rest)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "^":
+			upper := Version{Major: v.Major + 1}
+			switch {
+			case v.Major == 0 && v.Minor == 0 && parts > 2:
+				upper = Version{Patch: v.Patch + 1}
+			case v.Major == 0 && parts > 1:
+				upper = Version{Minor: v.Minor + 1}
+			}
+			return []comparison{{">=", v}, {"<", upper}}, nil
+		case "~":
+			upper := Version{Major: v.Major, Minor: v.Minor + 1}
+			if parts == 1 {
+				upper = Version{Major: v.Major + 1}
+			}
+			return []comparison{{">=", v}, {"<", upper}}, nil
+		}
+		return []comparison{{op, v}}, nil
+	}
+	v, err := ParseVersion(term)
+	if err != nil {
+		return nil, err
+	}
+	return []comparison{{"=", v}}, nil
+}
+
+// Check returns whether v satisfies the constraint.
+func (c Constraint) Check(v Version) bool {
+outer:
+	for _, set := range c.sets {
+		for _, cmp := range set {
+			r := v.Compare(cmp.v)
+			ok := false
+			switch cmp.op {
+			case "=":
+				ok = r == 0
+			case ">":
+				ok = r > 0
+			case ">=":
+				ok = r >= 0
+			case "<":
+				ok = r < 0
+			case "<=":
+				ok = r <= 0
+			}
+			if !ok {
+				continue outer
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Exact returns the version accepted by the constraint, in case it only
+// accepts a single version.
+func (c Constraint) Exact() (Version, bool) {
+	if len(c.sets) == 1 && len(c.sets[0]) == 1 && c.sets[0][0].op == "=" {
+		return c.sets[0][0].v, true
+	}
+	return Version{}, false
+}
+
+func (c Constraint) String() string { return c.raw }
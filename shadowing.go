@@ -0,0 +1,71 @@
+package idl
+
+import "fmt"
+
+// CodeShadowedName identifies diagnostics emitted by the check returned by
+// ShadowingCheck.
+const CodeShadowedName = "shadowed-name"
+
+// ShadowingPolicy determines the severity of diagnostics emitted by the check
+// returned by ShadowingCheck.
+type ShadowingPolicy int
+
+const (
+	// ShadowingWarn reports shadowing messages as warnings.
+	ShadowingWarn ShadowingPolicy = iota
+
+	// ShadowingError reports shadowing messages as errors.
+	ShadowingError
+)
+
+// ShadowingCheck returns a Check reporting messages sharing their short name
+// with a message of another package declared by a file imported into their
+// package. Short names always refer to the local message, which may surprise
+// readers expecting the imported one. Diagnostics are emitted at the local
+// declaration, and relate to the imported one. The check is not part of
+// DefaultChecks.
+func ShadowingCheck(policy ShadowingPolicy) Check {
+	return Check{
+		Code: CodeShadowedName,
+		Run: func(f *FileSet) Diagnostics {
+			return checkShadowing(f, policy)
+		},
+	}
+}
+
+func checkShadowing(f *FileSet, policy ShadowingPolicy) Diagnostics {
+	severity := SeverityWarning
+	if policy == ShadowingError {
+		severity = SeverityError
+	}
+	var diags Diagnostics
+	reported := map[[2]*Message]bool{}
+	for _, file := range f.fileOrder {
+		for _, path := range f.imports[file] {
+			imported, ok := f.files[path]
+			if !ok || imported.Package == file.Package {
+				continue
+			}
+			for _, name := range imported.DeclaredMessages {
+				local, ok := f.messages[NewFQN(file.Package, name)]
+				if !ok {
+					continue
+				}
+				shadowed := f.messages[NewFQN(imported.Package, name)]
+				if reported[[2]*Message{local, shadowed}] {
+					continue
+				}
+				reported[[2]*Message{local, shadowed}] = true
+				diags = append(diags, Diagnostic{
+					Severity: severity,
+					Code:     CodeShadowedName,
+					Message: fmt.Sprintf("message %s shadows %s imported by %s",
+						NewFQN(file.Package, name), NewFQN(imported.Package, name), file.SourcePath),
+					Location: locationOf(f.declaredIn[local], local.Offset),
+					Related:  []Location{locationOf(imported, shadowed.Offset)},
+				})
+			}
+		}
+	}
+	return diags
+}
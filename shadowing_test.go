@@ -0,0 +1,33 @@
+package idl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowingCheck(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.LoadDir("./test/shadowing"))
+	assert.Empty(t, fs.Validate())
+
+	diags := fs.Validate(ShadowingCheck(ShadowingWarn))
+	require.Len(t, diags, 2)
+	assert.Equal(t, []string{CodeShadowedName, CodeShadowedName}, diagnosticCodes(diags))
+	assert.False(t, diags.HasErrors())
+
+	assert.Contains(t, diags[0].Message, "message org.example.main.Error shadows org.example.billing.Error imported by ")
+	assert.Equal(t, "main.yarp", filepath.Base(diags[0].Location.File))
+	assert.Equal(t, 5, diags[0].Location.Offset.StartsAt.Line)
+	require.Len(t, diags[0].Related, 1)
+	assert.Equal(t, "billing.yarp", filepath.Base(diags[0].Related[0].File))
+	assert.Equal(t, 3, diags[0].Related[0].Offset.StartsAt.Line)
+
+	assert.Contains(t, diags[1].Message, "message org.example.main.Invoice shadows org.example.billing.Invoice")
+	assert.Equal(t, "invoice.yarp", filepath.Base(diags[1].Location.File))
+
+	diags = fs.Validate(ShadowingCheck(ShadowingError))
+	assert.True(t, diags.HasErrors())
+}
@@ -0,0 +1,168 @@
+package idl
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// sourceFS abstracts the filesystem from which a FileSet reads source files.
+type sourceFS interface {
+	// abs returns the canonical representation of p, used to identify files.
+	abs(p string) (string, error)
+	join(elem ...string) string
+	dir(p string) string
+	stat(p string) (fs.FileInfo, error)
+	readFile(p string) ([]byte, error)
+	walkDir(root string, fn fs.WalkDirFunc) error
+	glob(pattern string) ([]string, error)
+	// rel returns target relative to base, and whether target lies within
+	// base.
+	rel(base, target string) (string, bool)
+	// canonical resolves symbolic links in p, an existing path returned by
+	// abs, and replaces each of its components by the spelling used on disk.
+	// The returned boolean indicates whether the case of any component was
+	// changed.
+	canonical(p string) (string, bool, error)
+}
+
+// osSource reads files from the local filesystem.
+type osSource struct{}
+
+func (osSource) abs(p string) (string, error)       { return filepath.Abs(p) }
+func (osSource) join(elem ...string) string         { return filepath.Join(elem...) }
+func (osSource) dir(p string) string                { return filepath.Dir(p) }
+func (osSource) stat(p string) (fs.FileInfo, error) { return os.Stat(p) }
+func (osSource) readFile(p string) ([]byte, error)  { return os.ReadFile(p) }
+func (osSource) walkDir(root string, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, fn)
+}
+func (osSource) glob(pattern string) ([]string, error) { return filepath.Glob(pattern) }
+func (osSource) rel(base, target string) (string, bool) {
+	r, err := filepath.Rel(base, target)
+	if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return r, true
+}
+
+func (osSource) canonical(p string) (string, bool, error) {
+	p, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", false, err
+	}
+	vol := filepath.VolumeName(p)
+	root := vol + string(filepath.Separator)
+	parts := strings.Split(strings.TrimPrefix(p[len(vol):], string(filepath.Separator)), string(filepath.Separator))
+	c, changed := matchCase(root, parts, filepath.Join, os.Stat, os.ReadDir)
+	return c, changed, nil
+}
+
+// fsSource reads files from a fs.FS. Paths are slash-separated, and relative
+// to the root of the filesystem.
+type fsSource struct{ fsys fs.FS }
+
+func (s fsSource) abs(p string) (string, error) {
+	p = path.Clean(strings.TrimPrefix(filepath.ToSlash(p), "/"))
+	if !fs.ValidPath(p) {
+		return "", fmt.Errorf("%s: path escapes filesystem root", p)
+	}
+	return p, nil
+}
+
+func (fsSource) join(elem ...string) string { return path.Join(elem...) }
+func (fsSource) dir(p string) string        { return path.Dir(p) }
+
+func (s fsSource) stat(p string) (fs.FileInfo, error) { return fs.Stat(s.fsys, p) }
+
+func (s fsSource) readFile(p string) ([]byte, error) { return fs.ReadFile(s.fsys, p) }
+
+func (s fsSource) readDir(p string) ([]fs.DirEntry, error) { return fs.ReadDir(s.fsys, p) }
+
+func (s fsSource) walkDir(root string, fn fs.WalkDirFunc) error {
+	return fs.WalkDir(s.fsys, root, fn)
+}
+
+func (s fsSource) glob(pattern string) ([]string, error) {
+	return fs.Glob(s.fsys, strings.TrimPrefix(pattern, "/"))
+}
+
+func (fsSource) rel(base, target string) (string, bool) {
+	if base == "." {
+		return target, true
+	}
+	if !strings.HasPrefix(target, base+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(target, base+"/"), true
+}
+
+// canonical only adjusts the case of p, as fs.FS does not expose symbolic
+// links.
+func (s fsSource) canonical(p string) (string, bool, error) {
+	if p == "." {
+		return p, false, nil
+	}
+	c, changed := matchCase(".", strings.Split(p, "/"), path.Join, s.stat, s.readDir)
+	return c, changed, nil
+}
+
+// matchCase appends each of parts to root, replacing components absent from
+// their parent directory by an entry differing only in case, when one exists.
+// Listing a directory is only required when a differently-cased spelling of
+// a component can be opened, which is the case on case-insensitive
+// filesystems.
+func matchCase(root string, parts []string, join func(...string) string, stat func(string) (fs.FileInfo, error), readDir func(string) ([]fs.DirEntry, error)) (string, bool) {
+	cur, changed := root, false
+	for i, part := range parts {
+		swapped := swapCase(part)
+		if swapped == part {
+			cur = join(cur, part)
+			continue
+		}
+		if _, err := stat(join(cur, swapped)); err != nil {
+			cur = join(cur, part)
+			continue
+		}
+		entries, err := readDir(cur)
+		if err != nil {
+			return join(append([]string{cur}, parts[i:]...)...), changed
+		}
+		match := part
+		for _, e := range entries {
+			if e.Name() == part {
+				match = part
+				break
+			}
+			if match == part && strings.EqualFold(e.Name(), part) {
+				match = e.Name()
+			}
+		}
+		changed = changed || match != part
+		cur = join(cur, match)
+	}
+	return cur, changed
+}
+
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if u := unicode.ToUpper(r); u != r {
+			return u
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// NewFileSetFS creates a new FileSet reading source files from the provided
+// fs.FS instead of the local filesystem, allowing schemas embedded through
+// go:embed to be loaded. Paths provided to Load and AddIncludePath, along with
+// paths of loaded files, are relative to the root of fsys.
+func NewFileSetFS(fsys fs.FS) *FileSet {
+	f := NewFileSet()
+	f.source = fsSource{fsys: fsys}
+	return f
+}
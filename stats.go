@@ -0,0 +1,81 @@
+package idl
+
+import "fmt"
+
+// Stats summarizes the contents of a FileSet.
+type Stats struct {
+	Files    int
+	Packages int
+	Messages int
+	Services int
+	Methods  int
+
+	// Fields contains the amount of fields declared by all messages,
+	// including cases of oneof fields.
+	Fields int
+
+	// MaxNesting contains the deepest nesting level of a field, in which
+	// oneof cases, arrays, and maps each add one level. A message containing
+	// only fields of plain types has a nesting level of zero.
+	MaxNesting int
+
+	// Bytes contains the total size of loaded source files.
+	Bytes int
+}
+
+// String returns a one-line summary of s, suitable for compilation reports.
+func (s Stats) String() string {
+	return fmt.Sprintf("%d files, %d packages, %d messages, %d fields, %d services, %d methods, %d bytes",
+		s.Files, s.Packages, s.Messages, s.Fields, s.Services, s.Methods, s.Bytes)
+}
+
+// Stats returns a summary of all files, packages, and declarations loaded
+// into the FileSet.
+func (f *FileSet) Stats() Stats {
+	s := Stats{
+		Files:    len(f.fileOrder),
+		Packages: len(f.packageOrder),
+		Messages: len(f.allMessages),
+		Services: len(f.allServices),
+	}
+	for _, file := range f.fileOrder {
+		s.Bytes += len(file.Source)
+	}
+	for _, svc := range f.allServices {
+		s.Methods += len(svc.Methods)
+	}
+	for _, m := range f.allMessages {
+		s.Fields += len(allFields(m.Fields))
+		if n := itemsNesting(m.Fields); n > s.MaxNesting {
+			s.MaxNesting = n
+		}
+	}
+	return s
+}
+
+func itemsNesting(items []FieldItem) int {
+	max := 0
+	for _, item := range items {
+		n := 0
+		switch v := item.(type) {
+		case Field:
+			n = typeNesting(v.Type)
+		case OneOfField:
+			n = 1 + itemsNesting(v.Items)
+		}
+		if n > max {
+			max = n
+		}
+	}
+	return max
+}
+
+func typeNesting(t Type) int {
+	switch v := t.(type) {
+	case Array:
+		return 1 + typeNesting(v.Of)
+	case Map:
+		return 1 + typeNesting(v.Value)
+	}
+	return 0
+}
@@ -0,0 +1,38 @@
+package idl
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetStats(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/resolve/main.yarp"))
+	require.NoError(t, fs.Load("./test/resolve/services.yarp"))
+
+	bytes := 0
+	for _, name := range []string{"main", "types", "services"} {
+		src, err := os.ReadFile("./test/resolve/" + name + ".yarp")
+		require.NoError(t, err)
+		bytes += len(src)
+	}
+	assert.Equal(t, Stats{
+		Files:      3,
+		Packages:   2,
+		Messages:   4,
+		Services:   2,
+		Methods:    4,
+		Fields:     9,
+		MaxNesting: 1,
+		Bytes:      bytes,
+	}, fs.Stats())
+	assert.Contains(t, fs.Stats().String(), "3 files, 2 packages, 4 messages, 9 fields, 2 services, 4 methods, ")
+
+	fs = NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/recursive.yarp"))
+	assert.Equal(t, 1, fs.Stats().MaxNesting)
+	assert.Equal(t, Stats{}, NewFileSet().Stats())
+}
@@ -0,0 +1,50 @@
+package idl
+
+import (
+	"embed"
+	"io/fs"
+	"strings"
+)
+
+// StdPrefix contains the prefix of imports referring to the standard library
+// of well-known types shipped with this package, such as
+// `import "yarp/std/time";`. Types provided by the standard library are
+// declared by the yarp.std package:
+//
+//   - yarp/std/time: Timestamp and Duration
+//   - yarp/std/empty: Empty
+//   - yarp/std/any: Any
+//   - yarp/std/uuid: UUID
+//   - yarp/std/money: Money
+//
+// Standard library files are always resolved from the copy embedded into this
+// package, and take precedence over any other file under the same path.
+const StdPrefix = "yarp/std/"
+
+//go:embed std/*.yarp
+var stdFiles embed.FS
+
+func isStd(path string) bool {
+	return strings.HasPrefix(path, StdPrefix)
+}
+
+// resolveStd returns the path of the standard library file referenced by an
+// import.
+func resolveStd(path string) (string, fs.FileInfo, error) {
+	if !strings.HasSuffix(path, SourceExtension) {
+		path += SourceExtension
+	}
+	stat, err := fs.Stat(stdFiles, stdName(path))
+	if err != nil {
+		return "", nil, SourceFileNotFoundError{Path: path}
+	}
+	return path, stat, nil
+}
+
+func readStd(path string) ([]byte, error) {
+	return fs.ReadFile(stdFiles, stdName(path))
+}
+
+func stdName(path string) string {
+	return "std/" + strings.TrimPrefix(path, StdPrefix)
+}
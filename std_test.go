@@ -0,0 +1,50 @@
+package idl
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdImports(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.yarp": {Data: []byte(`package org.example.main;
+
+import "yarp/std/time";
+import "yarp/std/empty";
+import "yarp/std/any";
+import "yarp/std/uuid";
+import "yarp/std/money";
+
+message Event {
+    id yarp.std.UUID = 0;
+    at yarp.std.Timestamp = 1;
+    timeout yarp.std.Duration = 2;
+    payload yarp.std.Any = 3;
+    price yarp.std.Money = 4;
+}
+
+service Events {
+    publish(Event) -> yarp.std.Empty;
+}
+`)},
+	}
+	fs := NewFileSetFS(fsys)
+	require.NoError(t, fs.Load("main.yarp"))
+	assert.Empty(t, fs.Validate())
+
+	std, ok := fs.PackageByName("yarp.std")
+	require.True(t, ok)
+	assert.Len(t, std.Messages(), 6)
+	o, ok := fs.Origin("yarp.std.Timestamp")
+	require.True(t, ok)
+	assert.Equal(t, "yarp/std/time.yarp", o.Path())
+
+	fs = NewFileSetFS(fstest.MapFS{
+		"main.yarp": {Data: []byte("package a;\n\nimport \"yarp/std/missing\";\n")},
+	})
+	var notFound SourceFileNotFoundError
+	assert.ErrorAs(t, fs.Load("main.yarp"), &notFound)
+}
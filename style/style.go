@@ -0,0 +1,273 @@
+// Package style loads project-level style configuration, shared by editors
+// and CI so they format and lint schemas alike. Configuration is written in
+// TOML, in a file named .yarpstyle or yarp.toml, discovered upward from the
+// directory of the sources being processed:
+//
+//	[format]
+//	align_indexes = true
+//	keep_groups = true
+//
+//	[naming]
+//	field = "camel"
+//
+//	[lint.rules.require-doc]
+//	enabled = true
+//	severity = "error"
+//
+//	[lint.rules.require-doc.options]
+//	kinds = "message,service"
+//
+//	[lint]
+//	baseline = "lint-baseline.json"
+//
+// The naming table sets the style option of the naming rule of messages,
+// services, fields, and methods (see package lint), and takes precedence
+// over options of the same rules set through the lint table. The baseline
+// of the lint table is resolved against the directory of the style file.
+package style
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/lint"
+)
+
+// FileNames contains the names of style files, in order of precedence within
+// a directory.
+var FileNames = []string{".yarpstyle", "yarp.toml"}
+
+// Config represents a style configuration.
+type Config struct {
+	// Path contains the path of the file the configuration was loaded from.
+	// It is empty for the default configuration.
+	Path string
+
+	// Format contains options for idl.FormatWithOptions.
+	Format idl.FormatOptions
+
+	// Lint contains the configuration of linters.
+	Lint lint.Config
+}
+
+// Default returns the configuration used in absence of style files, which
+// formats sources as idl.Format does, and runs default lint rules.
+func Default() Config {
+	return Config{Format: idl.FormatOptions{KeepGroups: true}}
+}
+
+// Parse parses the contents of a style file. Unknown keys are rejected.
+func Parse(data []byte) (Config, error) {
+	tree, err := parseTOML(data)
+	if err != nil {
+		return Config{}, err
+	}
+	c := Default()
+	d := decoder{}
+	d.table(tree, "", map[string]func(v any, key string){
+		"format": func(v any, key string) {
+			d.table(v, key, map[string]func(v any, key string){
+				"align_indexes": func(v any, key string) { d.bool(v, key, &c.Format.AlignIndexes) },
+				"keep_groups":   func(v any, key string) { d.bool(v, key, &c.Format.KeepGroups) },
+			})
+		},
+		"lint": func(v any, key string) {
+			d.table(v, key, map[string]func(v any, key string){
+				"rules":    func(v any, key string) { c.Lint.Rules = d.rules(v, key) },
+				"baseline": func(v any, key string) { c.Lint.Baseline = d.string(v, key) },
+			})
+		},
+		"naming": func(v any, key string) {
+			d.table(v, key, map[string]func(v any, key string){
+				"message": func(v any, key string) { d.naming(v, key, &c.Lint, "message-name") },
+				"service": func(v any, key string) { d.naming(v, key, &c.Lint, "service-name") },
+				"field":   func(v any, key string) { d.naming(v, key, &c.Lint, "field-name") },
+				"method":  func(v any, key string) { d.naming(v, key, &c.Lint, "method-name") },
+			})
+		},
+	})
+	if d.err != nil {
+		return Config{}, d.err
+	}
+	// Baselines are only loaded by linters, as their paths are resolved by
+	// Load.
+	lc := c.Lint
+	lc.Baseline = ""
+	if _, err := lint.New(lc); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// Load reads and parses the style file under the provided path.
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	c.Path = path
+	if c.Lint.Baseline != "" && !filepath.IsAbs(c.Lint.Baseline) {
+		c.Lint.Baseline = filepath.Join(filepath.Dir(path), c.Lint.Baseline)
+	}
+	return c, nil
+}
+
+// Find returns the path of the style file closest to dir, looking into dir
+// and each of its parents, or an empty string in case none exists.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			stat, err := os.Stat(path)
+			if err == nil && !stat.IsDir() {
+				return path, nil
+			}
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return "", err
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Discover loads the style file closest to dir, as located by Find, or
+// returns Default in case none exists.
+func Discover(dir string) (Config, error) {
+	path, err := Find(dir)
+	if err != nil || path == "" {
+		return Default(), err
+	}
+	return Load(path)
+}
+
+// decoder converts parsed TOML tables into a Config, retaining the first
+// error found.
+type decoder struct {
+	err error
+}
+
+func (d *decoder) fail(format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, args...)
+	}
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// table decodes entries of v, a table, through fields, keyed by name.
+func (d *decoder) table(v any, key string, fields map[string]func(v any, key string)) {
+	t, ok := v.(map[string]any)
+	if !ok {
+		d.fail("%s must be a table", key)
+		return
+	}
+	for _, k := range sortedKeys(t) {
+		f, ok := fields[k]
+		if !ok {
+			d.fail("unknown key %s", join(key, k))
+			continue
+		}
+		f(t[k], join(key, k))
+	}
+}
+
+func (d *decoder) bool(v any, key string, dst *bool) {
+	b, ok := v.(bool)
+	if !ok {
+		d.fail("%s must be a boolean", key)
+	}
+	*dst = b
+}
+
+func (d *decoder) string(v any, key string) string {
+	s, ok := v.(string)
+	if !ok {
+		d.fail("%s must be a string", key)
+	}
+	return s
+}
+
+// rules decodes the configuration of lint rules.
+func (d *decoder) rules(v any, key string) map[string]lint.RuleConfig {
+	t, ok := v.(map[string]any)
+	if !ok {
+		d.fail("%s must be a table", key)
+		return nil
+	}
+	rules := map[string]lint.RuleConfig{}
+	for _, id := range sortedKeys(t) {
+		var rc lint.RuleConfig
+		d.table(t[id], join(key, id), map[string]func(v any, key string){
+			"enabled": func(v any, key string) {
+				rc.Enabled = new(bool)
+				d.bool(v, key, rc.Enabled)
+			},
+			"severity": func(v any, key string) { rc.Severity = d.string(v, key) },
+			"options": func(v any, key string) {
+				o, ok := v.(map[string]any)
+				if !ok {
+					d.fail("%s must be a table", key)
+					return
+				}
+				rc.Options = map[string]string{}
+				for name, value := range o {
+					switch value.(type) {
+					case string, bool, int64:
+						rc.Options[name] = fmt.Sprint(value)
+					default:
+						d.fail("%s must be a string, boolean, or integer", join(key, name))
+					}
+				}
+			},
+		})
+		rules[id] = rc
+	}
+	return rules
+}
+
+// naming sets the style option of the rule identified by id.
+func (d *decoder) naming(v any, key string, c *lint.Config, id string) {
+	s := d.string(v, key)
+	if c.Rules == nil {
+		c.Rules = map[string]lint.RuleConfig{}
+	}
+	rc := c.Rules[id]
+	options := map[string]string{}
+	for k, v := range rc.Options {
+		options[k] = v
+	}
+	options["style"] = s
+	rc.Options = options
+	c.Rules[id] = rc
+}
+
+func sortedKeys(t map[string]any) []string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,93 @@
+package style
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/lint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscover(t *testing.T) {
+	c, err := Discover("../test/style/other")
+	require.NoError(t, err)
+	abs, err := filepath.Abs("../test/style/yarp.toml")
+	require.NoError(t, err)
+	assert.Equal(t, abs, c.Path)
+	assert.Equal(t, idl.FormatOptions{AlignIndexes: true, KeepGroups: true}, c.Format)
+	enabled := true
+	assert.Equal(t, lint.Config{Rules: map[string]lint.RuleConfig{
+		"field-name":  {Options: map[string]string{"style": "camel"}},
+		"require-doc": {Enabled: &enabled, Severity: "error", Options: map[string]string{"kinds": "message,service"}},
+	}}, c.Lint)
+
+	c, err = Discover("../test/style/nested/deeper")
+	require.NoError(t, err)
+	assert.Equal(t, ".yarpstyle", filepath.Base(c.Path))
+	assert.Equal(t, idl.FormatOptions{}, c.Format)
+
+	c, err = Discover(t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, Default(), c)
+}
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(`
+[lint]
+rules."method-name" = { }
+`))
+	assert.Zero(t, c)
+	assert.EqualError(t, err, `line 3: invalid value "{"`)
+
+	for src, msg := range map[string]string{
+		"[format]\nindent = 2":                              "unknown key format.indent",
+		"[lint]\nbaseline = 1":                              "lint.baseline must be a string",
+		"[format]\nalign_indexes = \"yes\"":                 "format.align_indexes must be a boolean",
+		"naming = 1":                                        "naming must be a table",
+		"[lint.rules.missing]\nenabled = true":              "unknown rule missing",
+		"[lint.rules.field-name]\nseverity = \"fatal\"":     `rule field-name: invalid severity "fatal"`,
+		"[format]\n[format]":                                "line 2: table format is defined twice",
+		"[format]\nkeep_groups = true\nkeep_groups = false": "line 3: key keep_groups is defined twice",
+		"[format\n":             "line 1: expected ']'",
+		"name = \"unterminated": "line 1: unterminated string",
+	} {
+		_, err := Parse([]byte(src))
+		assert.EqualError(t, err, msg, src)
+	}
+}
+
+func TestLoadBaseline(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "yarp.toml")
+	require.NoError(t, os.WriteFile(path, []byte("[lint]\nbaseline = \"lint/baseline.json\"\n"), 0o644))
+	c, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "lint", "baseline.json"), c.Lint.Baseline)
+
+	// Baselines are only loaded once linters are created.
+	_, err = lint.New(c.Lint)
+	assert.ErrorContains(t, err, "baseline: open ")
+}
+
+func TestParseTOML(t *testing.T) {
+	tree, err := parseTOML([]byte(`
+title = "a \"quoted\" \u00e9 value" # comment
+literal = 'C:\path'
+list = ["a", 'b', 1_000, true,]
+a.b."c.d" = -3
+
+[x."y z"]
+empty = []
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"title":   "a \"quoted\" é value",
+		"literal": `C:\path`,
+		"list":    []any{"a", "b", int64(1000), true},
+		"a":       map[string]any{"b": map[string]any{"c.d": int64(-3)}},
+		"x":       map[string]any{"y z": map[string]any{"empty": []any(nil)}},
+	}, tree)
+}
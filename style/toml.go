@@ -0,0 +1,251 @@
+package style
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML parses the subset of TOML used by style files into nested maps:
+// tables, dotted keys, comments, and values being strings, booleans,
+// integers, or single-line arrays of those.
+func parseTOML(data []byte) (map[string]any, error) {
+	root := map[string]any{}
+	current := root
+	defined := map[string]bool{}
+	for i, line := range strings.Split(string(data), "\n") {
+		p := &tomlLine{text: line}
+		if err := p.parse(root, &current, defined); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+	return root, nil
+}
+
+// tomlLine holds the state of parseTOML while parsing a single line.
+type tomlLine struct {
+	text string
+	pos  int
+}
+
+func (p *tomlLine) parse(root map[string]any, current *map[string]any, defined map[string]bool) error {
+	p.space()
+	if p.done() {
+		return nil
+	}
+	if p.text[p.pos] == '[' {
+		p.pos++
+		path, err := p.key()
+		if err != nil {
+			return err
+		}
+		if !p.consume(']') {
+			return fmt.Errorf("expected ']'")
+		}
+		if !p.done() {
+			return fmt.Errorf("unexpected %q after table header", p.text[p.pos:])
+		}
+		name := strings.Join(path, ".")
+		if defined[name] {
+			return fmt.Errorf("table %s is defined twice", name)
+		}
+		defined[name] = true
+		t, err := table(root, path)
+		if err != nil {
+			return err
+		}
+		*current = t
+		return nil
+	}
+
+	path, err := p.key()
+	if err != nil {
+		return err
+	}
+	if !p.consume('=') {
+		return fmt.Errorf("expected '=' after key %s", strings.Join(path, "."))
+	}
+	v, err := p.value()
+	if err != nil {
+		return err
+	}
+	if !p.done() {
+		return fmt.Errorf("unexpected %q after value", p.text[p.pos:])
+	}
+	t, err := table(*current, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	name := path[len(path)-1]
+	if _, ok := t[name]; ok {
+		return fmt.Errorf("key %s is defined twice", strings.Join(path, "."))
+	}
+	t[name] = v
+	return nil
+}
+
+// table returns the table under path within t, creating it if needed.
+func table(t map[string]any, path []string) (map[string]any, error) {
+	for i, k := range path {
+		switch v := t[k].(type) {
+		case nil:
+			n := map[string]any{}
+			t[k] = n
+			t = n
+		case map[string]any:
+			t = v
+		default:
+			return nil, fmt.Errorf("key %s is not a table", strings.Join(path[:i+1], "."))
+		}
+	}
+	return t, nil
+}
+
+// space skips whitespace and comments.
+func (p *tomlLine) space() {
+	for p.pos < len(p.text) {
+		switch p.text[p.pos] {
+		case ' ', '\t', '\r':
+			p.pos++
+		case '#':
+			p.pos = len(p.text)
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlLine) done() bool {
+	p.space()
+	return p.pos >= len(p.text)
+}
+
+// consume skips whitespace and c, returning whether c was present.
+func (p *tomlLine) consume(c byte) bool {
+	p.space()
+	if p.pos < len(p.text) && p.text[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// key parses a dotted key composed of bare or quoted keys.
+func (p *tomlLine) key() ([]string, error) {
+	var path []string
+	for {
+		p.space()
+		if p.pos < len(p.text) && (p.text[p.pos] == '"' || p.text[p.pos] == '\'') {
+			s, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, s)
+		} else {
+			start := p.pos
+			for p.pos < len(p.text) && isBareKey(p.text[p.pos]) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, fmt.Errorf("expected key")
+			}
+			path = append(path, p.text[start:p.pos])
+		}
+		if !p.consume('.') {
+			return path, nil
+		}
+	}
+}
+
+func isBareKey(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// value parses a string, boolean, integer, or array.
+func (p *tomlLine) value() (any, error) {
+	p.space()
+	if p.pos >= len(p.text) {
+		return nil, fmt.Errorf("expected value")
+	}
+	switch c := p.text[p.pos]; {
+	case c == '"' || c == '\'':
+		return p.string()
+	case c == '[':
+		p.pos++
+		var values []any
+		for !p.consume(']') {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			if !p.consume(',') {
+				if !p.consume(']') {
+					return nil, fmt.Errorf("expected ',' or ']'")
+				}
+				break
+			}
+		}
+		return values, nil
+	}
+	start := p.pos
+	for p.pos < len(p.text) && p.text[p.pos] != ',' && p.text[p.pos] != ']' && p.text[p.pos] != '#' && p.text[p.pos] != ' ' && p.text[p.pos] != '\t' {
+		p.pos++
+	}
+	switch word := p.text[start:p.pos]; word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	default:
+		n, err := strconv.ParseInt(strings.ReplaceAll(word, "_", ""), 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", word)
+		}
+		return n, nil
+	}
+}
+
+// string parses a basic string, supporting common escapes, or a literal one.
+func (p *tomlLine) string() (string, error) {
+	quote := p.text[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.text) {
+		c := p.text[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && quote == '"':
+			if p.pos >= len(p.text) {
+				return "", fmt.Errorf("unterminated string")
+			}
+			e := p.text[p.pos]
+			p.pos++
+			switch e {
+			case '"', '\\':
+				b.WriteByte(e)
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if p.pos+4 > len(p.text) {
+					return "", fmt.Errorf("invalid escape sequence")
+				}
+				r, err := strconv.ParseUint(p.text[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					return "", fmt.Errorf("invalid escape sequence")
+				}
+				b.WriteRune(rune(r))
+				p.pos += 4
+			default:
+				return "", fmt.Errorf("invalid escape sequence \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
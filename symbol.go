@@ -0,0 +1,107 @@
+package idl
+
+import "fmt"
+
+// SymbolKind indicates which kind of declaration a Symbol represents.
+type SymbolKind int
+
+const (
+	// SymbolMessage indicates a Symbol represents a Message.
+	SymbolMessage SymbolKind = iota + 1
+
+	// SymbolService indicates a Symbol represents a Service.
+	SymbolService
+)
+
+func (k SymbolKind) String() string {
+	switch k {
+	case SymbolMessage:
+		return "message"
+	case SymbolService:
+		return "service"
+	default:
+		return fmt.Sprintf("SymbolKind(%d)", int(k))
+	}
+}
+
+// Symbol represents a named declaration registered by a FileSet.
+type Symbol struct {
+	Kind        SymbolKind
+	Name        FQN
+	Declaration Declaration
+
+	// File represents the source file declaring the symbol.
+	File *File
+
+	// Offset represents the position of the declaration within File.
+	Offset Offset
+}
+
+// Message returns the Message represented by the Symbol, or nil, in case it
+// does not represent a message.
+func (s Symbol) Message() *Message {
+	m, _ := s.Declaration.(*Message)
+	return m
+}
+
+// Service returns the Service represented by the Symbol, or nil, in case it
+// does not represent a service.
+func (s Symbol) Service() *Service {
+	svc, _ := s.Declaration.(*Service)
+	return svc
+}
+
+// FindService takes a service name (e.g. SomethingService) or FQN (e.g.
+// package.SomethingService) and returns a Service along with a boolean
+// indicating whether the provided name could be resolved to a service.
+func (f *FileSet) FindService(name string) (*Service, bool) {
+	// Short names should be present in the package we're processing.
+	s, ok := f.services[f.dealias(FQN(name).Qualify(f.packageName))]
+	return s, ok
+}
+
+// FindSymbol takes a name or FQN and returns the Symbol it refers to, along
+// with a boolean indicating whether the provided name could be resolved.
+// Short names are resolved within the primary package.
+func (f *FileSet) FindSymbol(name string) (Symbol, bool) {
+	fqn := f.dealias(FQN(name).Qualify(f.packageName))
+	if m, ok := f.messages[fqn]; ok {
+		return f.symbolOf(m), true
+	}
+	if s, ok := f.services[fqn]; ok {
+		return f.symbolOf(s), true
+	}
+	return Symbol{}, false
+}
+
+// Symbols returns all symbols declared by files loaded into the FileSet, in
+// the order files were registered (see Files), and then in the order they
+// appear in each file.
+func (f *FileSet) Symbols() []Symbol {
+	var symbols []Symbol
+	for _, file := range f.fileOrder {
+		for _, d := range file.Tree {
+			switch d.(type) {
+			case *Message, *Service:
+				symbols = append(symbols, f.symbolOf(d))
+			}
+		}
+	}
+	return symbols
+}
+
+func (f *FileSet) symbolOf(d Declaration) Symbol {
+	file := f.declaredIn[d]
+	sym := Symbol{Declaration: d, File: file}
+	switch v := d.(type) {
+	case *Message:
+		sym.Kind = SymbolMessage
+		sym.Name = NewFQN(file.Package, v.Name)
+		sym.Offset = v.Offset
+	case *Service:
+		sym.Kind = SymbolService
+		sym.Name = NewFQN(file.Package, v.Name)
+		sym.Offset = v.Offset
+	}
+	return sym
+}
@@ -0,0 +1,65 @@
+package idl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetFindSymbol(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/resolve/main.yarp"))
+	require.NoError(t, fs.Load("./test/resolve/services.yarp"))
+
+	svc, ok := fs.FindService("Users")
+	require.True(t, ok)
+	assert.Equal(t, "Users", svc.Name)
+	_, ok = fs.FindService("Lookups")
+	assert.False(t, ok)
+	_, ok = fs.FindService("org.example.types.Lookups")
+	assert.True(t, ok)
+
+	sym, ok := fs.FindSymbol("User")
+	require.True(t, ok)
+	assert.Equal(t, SymbolMessage, sym.Kind)
+	assert.Equal(t, FQN("org.example.main.User"), sym.Name)
+	assert.Equal(t, "User", sym.Message().Name)
+	assert.Nil(t, sym.Service())
+
+	sym, ok = fs.FindSymbol("org.example.types.Lookups")
+	require.True(t, ok)
+	assert.Equal(t, SymbolService, sym.Kind)
+	assert.Equal(t, "service", sym.Kind.String())
+	assert.Equal(t, "Lookups", sym.Service().Name)
+
+	_, ok = fs.FindSymbol("Unknown")
+	assert.False(t, ok)
+}
+
+func TestFileSetSymbols(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/resolve/main.yarp"))
+	require.NoError(t, fs.Load("./test/resolve/services.yarp"))
+
+	var names []string
+	for _, s := range fs.Symbols() {
+		names = append(names, s.Kind.String()+" "+s.Name.String())
+		require.NotNil(t, s.File)
+		assert.Equal(t, s.Offset, s.Declaration.Span())
+	}
+	assert.Equal(t, []string{
+		"message org.example.types.Address",
+		"message org.example.types.Tag",
+		"message org.example.types.Lookup",
+		"message org.example.main.User",
+		"service org.example.main.Users",
+		"service org.example.types.Lookups",
+	}, names)
+
+	sym, ok := fs.FindSymbol("Users")
+	require.True(t, ok)
+	assert.Equal(t, "main.yarp", filepath.Base(sym.File.SourcePath))
+	assert.Equal(t, 13, sym.Offset.StartsAt.Line)
+}
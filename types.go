@@ -48,14 +48,19 @@ const (
 	Annotation             // Anything from @ until next space
 	StringElement          // Anything between "
 	EOF
+	Pragma // Anything from # yarp: onwards
 )
 
-// Token represents a single token present in a source file
+// Token represents a single token present in a source file. Start and End
+// contain the byte offsets delimiting the token in the source; End is
+// exclusive.
 type Token struct {
 	Type   Element
 	Value  string
 	Line   int
 	Column int
+	Start  int
+	End    int
 }
 
 func (t Token) is(o Element) bool { return t.Type == o }
@@ -72,6 +77,7 @@ const (
 	TypeArray
 	TypeMap
 	TypeUnresolved
+	TypeResolved
 )
 
 type Type interface {
@@ -102,3 +108,44 @@ type Unresolved struct {
 }
 
 func (Unresolved) Type() TypeType { return TypeUnresolved }
+
+// Resolved represents a reference to a Message, linked to its definition by
+// FileSet.Resolve.
+type Resolved struct {
+	Name    FQN
+	Message *Message
+}
+
+func (Resolved) Type() TypeType { return TypeResolved }
+
+// WalkType traverses a Type tree in depth-first order, invoking fn for t and
+// every type nested within it (such as array items and map values). In case fn
+// returns false, types nested in the provided value are not visited.
+func WalkType(t Type, fn func(Type) bool) {
+	if t == nil || !fn(t) {
+		return
+	}
+	switch v := t.(type) {
+	case Array:
+		WalkType(v.Of, fn)
+	case Map:
+		WalkType(Primitive{Kind: v.Key}, fn)
+		WalkType(v.Value, fn)
+	}
+}
+
+// ReferencedNames returns the names of all messages referenced by t, resolved
+// or not, in the order they appear.
+func ReferencedNames(t Type) []string {
+	var r []string
+	WalkType(t, func(t Type) bool {
+		switch v := t.(type) {
+		case Unresolved:
+			r = append(r, v.Name)
+		case Resolved:
+			r = append(r, v.Name.String())
+		}
+		return true
+	})
+	return r
+}
@@ -58,11 +58,12 @@ func _() {
 	_ = x[Annotation-16]
 	_ = x[StringElement-17]
 	_ = x[EOF-18]
+	_ = x[Pragma-19]
 }
 
-const _Element_name = "InvalidElementIdentifierOpenCurlyCloseCurlyOpenParenCloseParenOpenAngledCloseAngledCommaDotLineBreakEqualNumberArrowSemiCommentAnnotationStringElementEOF"
+const _Element_name = "InvalidElementIdentifierOpenCurlyCloseCurlyOpenParenCloseParenOpenAngledCloseAngledCommaDotLineBreakEqualNumberArrowSemiCommentAnnotationStringElementEOFPragma"
 
-var _Element_index = [...]uint8{0, 14, 24, 33, 43, 52, 62, 72, 83, 88, 91, 100, 105, 111, 116, 120, 127, 137, 150, 153}
+var _Element_index = [...]uint8{0, 14, 24, 33, 43, 52, 62, 72, 83, 88, 91, 100, 105, 111, 116, 120, 127, 137, 150, 153, 159}
 
 func (i Element) String() string {
 	if i < 0 || i >= Element(len(_Element_index)-1) {
@@ -0,0 +1,91 @@
+package idl
+
+import "fmt"
+
+// CodeUnreferenced identifies diagnostics emitted by the check returned by
+// UnreferencedCheck.
+const CodeUnreferenced = "unreferenced"
+
+// UnreferencedOptions determines which declarations are considered used by
+// Unreferenced.
+type UnreferencedOptions struct {
+	// EntryPoints lists names or FQNs of messages and services considered
+	// used regardless of references to them. Short names are resolved within
+	// the primary package. In case EntryPoints is empty, all services are
+	// used as entry points.
+	EntryPoints []string
+}
+
+// Unreferenced returns all messages and services that cannot be reached from
+// the configured entry points, in the order returned by Symbols. A message is
+// reached when it is referenced by a method of a reached service, or by a
+// field of a reached message. Types are resolved before the analysis runs.
+func (f *FileSet) Unreferenced(opts UnreferencedOptions) []Symbol {
+	f.Resolve()
+	reached := map[Declaration]bool{}
+	var queue []*Message
+	reachMessage := func(m *Message) {
+		if m != nil && !reached[m] {
+			reached[m] = true
+			queue = append(queue, m)
+		}
+	}
+	reachService := func(s *Service) {
+		reached[s] = true
+		for _, m := range s.Methods {
+			reachMessage(m.Argument.Target)
+			reachMessage(m.Return.Target)
+		}
+	}
+
+	if len(opts.EntryPoints) == 0 {
+		for _, s := range f.allServices {
+			reachService(s)
+		}
+	}
+	for _, name := range opts.EntryPoints {
+		sym, ok := f.FindSymbol(name)
+		if !ok {
+			continue
+		}
+		switch sym.Kind {
+		case SymbolMessage:
+			reachMessage(sym.Message())
+		case SymbolService:
+			reachService(sym.Service())
+		}
+	}
+	for i := 0; i < len(queue); i++ {
+		for _, e := range messageEdges(queue[i]) {
+			reachMessage(e.to)
+		}
+	}
+
+	var r []Symbol
+	for _, s := range f.Symbols() {
+		if !reached[s.Declaration] {
+			r = append(r, s)
+		}
+	}
+	return r
+}
+
+// UnreferencedCheck returns a Check reporting declarations returned by
+// Unreferenced as warnings.
+func UnreferencedCheck(opts UnreferencedOptions) Check {
+	return Check{
+		Code: CodeUnreferenced,
+		Run: func(f *FileSet) Diagnostics {
+			var diags Diagnostics
+			for _, s := range f.Unreferenced(opts) {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Code:     CodeUnreferenced,
+					Message:  fmt.Sprintf("%s %s is never used", s.Kind, s.Name),
+					Location: locationOf(s.File, s.Offset),
+				})
+			}
+			return diags
+		},
+	}
+}
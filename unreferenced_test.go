@@ -0,0 +1,40 @@
+package idl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func symbolNames(symbols []Symbol) []string {
+	var r []string
+	for _, s := range symbols {
+		r = append(r, s.Name.String())
+	}
+	return r
+}
+
+func TestUnreferenced(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/unreferenced/main.yarp"))
+
+	assert.Equal(t, []string{
+		"org.example.unreferenced.Legacy",
+		"org.example.unreferenced.LegacyDetails",
+	}, symbolNames(fs.Unreferenced(UnreferencedOptions{})))
+
+	assert.Equal(t, []string{
+		"org.example.unreferenced.Request",
+		"org.example.unreferenced.Items",
+	}, symbolNames(fs.Unreferenced(UnreferencedOptions{
+		EntryPoints: []string{"Admin", "org.example.unreferenced.Legacy"},
+	})))
+
+	diags := fs.Validate(UnreferencedCheck(UnreferencedOptions{}))
+	require.Len(t, diags, 2)
+	assert.Equal(t, SeverityWarning, diags[0].Severity)
+	assert.Equal(t, CodeUnreferenced, diags[0].Code)
+	assert.Equal(t, "message org.example.unreferenced.Legacy is never used", diags[0].Message)
+	assert.Equal(t, 15, diags[0].Location.Offset.StartsAt.Line)
+}
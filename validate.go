@@ -0,0 +1,403 @@
+package idl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// CodeDuplicateIndex identifies diagnostics emitted when two fields of a
+	// message share the same index.
+	CodeDuplicateIndex = "duplicate-index"
+
+	// CodeDuplicateName identifies diagnostics emitted when two fields of a
+	// message, or two methods of a service share the same name.
+	CodeDuplicateName = "duplicate-name"
+
+	// CodeEmptyService identifies diagnostics emitted for services declaring
+	// no methods.
+	CodeEmptyService = "empty-service"
+
+	// CodeInvalidMapKey identifies diagnostics emitted for maps using keys of
+	// types that cannot be used as map keys.
+	CodeInvalidMapKey = "invalid-map-key"
+
+	// CodeIndexContinuity identifies diagnostics emitted by the check
+	// returned by IndexContinuityCheck.
+	CodeIndexContinuity = "index-continuity"
+
+	// CodeReservedViolation identifies diagnostics emitted for fields using
+	// indexes or names reserved by their message through @reserved.
+	CodeReservedViolation = "reserved-violation"
+)
+
+// ReservedAnnotation contains a constant representing the name of @reserved
+// annotations, which list indexes and names that cannot be used by fields of
+// a message. For instance, @reserved(3, 4, "old_name").
+const ReservedAnnotation = "reserved"
+
+// Check represents a single semantic check run by FileSet.Validate.
+type Check struct {
+	// Code identifies the check, and is used as the Code of diagnostics it
+	// emits.
+	Code string
+
+	// Run executes the check against a resolved FileSet.
+	Run func(f *FileSet) Diagnostics
+}
+
+// DefaultChecks contains the list of checks executed by Validate when no
+// checks are explicitly provided.
+var DefaultChecks = []Check{
+	{Code: CodeDuplicateIndex, Run: checkDuplicateIndexes},
+	{Code: CodeDuplicateName, Run: checkDuplicateNames},
+	{Code: CodeEmptyService, Run: checkEmptyServices},
+	{Code: CodeInvalidMapKey, Run: checkMapKeys},
+	{Code: CodeReservedViolation, Run: checkReserved},
+	{Code: CodeInvalidConstraint, Run: checkConstraints},
+	{Code: CodeInvalidMethodOption, Run: checkMethodOptions},
+	{Code: CodeInvalidRenamedFrom, Run: checkRenamedFrom},
+	RecursionCheck(RecursionAllow),
+}
+
+// Validate resolves all references in the FileSet and runs the provided checks
+// against it, followed by checks added through RegisterCheck, returning all
+// problems found. In case no checks are provided, DefaultChecks is used.
+// Diagnostics emitted by Resolve, such as unknown types and illegal method
+// signatures, are always reported.
+func (f *FileSet) Validate(checks ...Check) Diagnostics {
+	if len(checks) == 0 {
+		checks = DefaultChecks
+	}
+	diags := f.Resolve()
+	for _, c := range append(append([]Check{}, checks...), f.checks...) {
+		diags = append(diags, c.Run(f)...)
+	}
+	return diags
+}
+
+// RegisterCheck adds a check executed by every subsequent call to Validate,
+// allowing custom rules, such as naming conventions or required annotations,
+// to be enforced alongside built-in checks. Diagnostics emitted by c are
+// expected to use its Code.
+func (f *FileSet) RegisterCheck(c Check) {
+	f.checks = append(f.checks, c)
+}
+
+// WithoutChecks returns a copy of checks, excluding the ones identified by the
+// provided codes.
+func WithoutChecks(checks []Check, codes ...string) []Check {
+	r := make([]Check, 0, len(checks))
+outer:
+	for _, c := range checks {
+		for _, code := range codes {
+			if c.Code == code {
+				continue outer
+			}
+		}
+		r = append(r, c)
+	}
+	return r
+}
+
+// checkDuplicateIndexes reports fields sharing an index within a message.
+// Cases of a oneof share the index space of their message, since they are
+// encoded alongside sibling fields.
+func checkDuplicateIndexes(f *FileSet) Diagnostics {
+	var diags Diagnostics
+	for _, m := range f.allMessages {
+		file := f.declaredIn[m]
+		seen := map[int]FieldItem{}
+		var visit func(items []FieldItem)
+		visit = func(items []FieldItem) {
+			for _, item := range items {
+				idx := fieldIndex(item)
+				if prev, ok := seen[idx]; ok {
+					diags = append(diags, Diagnostic{
+						Severity: SeverityError,
+						Code:     CodeDuplicateIndex,
+						Message: fmt.Sprintf("index %d of %s is already used by %s in %s",
+							idx, describeFieldItem(item), describeFieldItem(prev), m.Name),
+						Location: locationOf(file, item.Span()),
+						Related:  []Location{locationOf(file, prev.Span())},
+					})
+				} else {
+					seen[idx] = item
+				}
+				if o, ok := item.(OneOfField); ok {
+					visit(o.Items)
+				}
+			}
+		}
+		visit(m.Fields)
+	}
+	return diags
+}
+
+func fieldIndex(item FieldItem) int {
+	switch v := item.(type) {
+	case Field:
+		return v.Index
+	case OneOfField:
+		return v.Index
+	}
+	return -1
+}
+
+func describeFieldItem(item FieldItem) string {
+	if f, ok := item.(Field); ok {
+		return "field " + f.Name
+	}
+	return "oneof"
+}
+
+// checkDuplicateNames reports fields sharing a name within a message, including
+// cases of oneof fields, and methods sharing a name within a service.
+func checkDuplicateNames(f *FileSet) Diagnostics {
+	var diags Diagnostics
+	duplicate := func(file *File, what, name, owner string, o, prev Offset) {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityError,
+			Code:     CodeDuplicateName,
+			Message:  fmt.Sprintf("%s %s is declared multiple times in %s", what, name, owner),
+			Location: locationOf(file, o),
+			Related:  []Location{locationOf(file, prev)},
+		})
+	}
+	for _, m := range f.allMessages {
+		file := f.declaredIn[m]
+		seen := map[string]Offset{}
+		for _, field := range allFields(m.Fields) {
+			if prev, ok := seen[field.Name]; ok {
+				duplicate(file, "field", field.Name, m.Name, field.Offset, prev)
+				continue
+			}
+			seen[field.Name] = field.Offset
+		}
+	}
+	for _, s := range f.allServices {
+		file := f.declaredIn[s]
+		seen := map[string]Offset{}
+		for _, m := range s.Methods {
+			if prev, ok := seen[m.Name]; ok {
+				duplicate(file, "method", m.Name, s.Name, m.Offset, prev)
+				continue
+			}
+			seen[m.Name] = m.Offset
+		}
+	}
+	return diags
+}
+
+func checkEmptyServices(f *FileSet) Diagnostics {
+	var diags Diagnostics
+	for _, s := range f.allServices {
+		if len(s.Methods) == 0 {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     CodeEmptyService,
+				Message:  fmt.Sprintf("service %s declares no methods", s.Name),
+				Location: locationOf(f.declaredIn[s], s.Offset),
+			})
+		}
+	}
+	return diags
+}
+
+func checkMapKeys(f *FileSet) Diagnostics {
+	var diags Diagnostics
+	for _, m := range f.allMessages {
+		file := f.declaredIn[m]
+		for _, field := range allFields(m.Fields) {
+			WalkType(field.Type, func(t Type) bool {
+				mp, ok := t.(Map)
+				if !ok {
+					return true
+				}
+				if _, ok = mp.Key.Info(); !ok || mp.Key == Bool {
+					diags = append(diags, Diagnostic{
+						Severity: SeverityError,
+						Code:     CodeInvalidMapKey,
+						Message:  fmt.Sprintf("field %s of %s uses %s as map key", field.Name, m.Name, mp.Key),
+						Location: locationOf(file, field.Offset),
+					})
+				}
+				return true
+			})
+		}
+	}
+	return diags
+}
+
+func checkReserved(f *FileSet) Diagnostics {
+	var diags Diagnostics
+	for _, m := range f.allMessages {
+		a, ok := m.Annotations.FindByName(ReservedAnnotation)
+		if !ok {
+			continue
+		}
+		file := f.declaredIn[m]
+		indexes, names := reservedOf(m)
+		report := func(o Offset, what string) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Code:     CodeReservedViolation,
+				Message:  fmt.Sprintf("%s is reserved by %s", what, m.Name),
+				Location: locationOf(file, o),
+				Related:  []Location{locationOf(file, a.Offset)},
+			})
+		}
+		for _, item := range m.Fields {
+			if o, ok := item.(OneOfField); ok && indexes[o.Index] {
+				report(o.Offset, fmt.Sprintf("index %d", o.Index))
+			}
+		}
+		for _, field := range allFields(m.Fields) {
+			if indexes[field.Index] {
+				report(field.Offset, fmt.Sprintf("index %d", field.Index))
+			}
+			if names[field.Name] {
+				report(field.Offset, fmt.Sprintf("name %s", field.Name))
+			}
+		}
+	}
+	return diags
+}
+
+// reservedOf returns indexes and names listed by the @reserved annotation of m.
+func reservedOf(m *Message) (map[int]bool, map[string]bool) {
+	indexes := map[int]bool{}
+	names := map[string]bool{}
+	if a, ok := m.Annotations.FindByName(ReservedAnnotation); ok {
+		for _, v := range a.Value {
+			if i, err := strconv.Atoi(v); err == nil {
+				indexes[i] = true
+			} else {
+				names[v] = true
+			}
+		}
+	}
+	return indexes, names
+}
+
+// allFields returns all plain fields present in items, including the ones
+// declared within OneOfField values.
+func allFields(items []FieldItem) []Field {
+	var r []Field
+	for _, item := range items {
+		switch v := item.(type) {
+		case Field:
+			r = append(r, v)
+		case OneOfField:
+			r = append(r, allFields(v.Items)...)
+		}
+	}
+	return r
+}
+
+// IndexContinuityOptions configures the check returned by
+// IndexContinuityCheck.
+type IndexContinuityOptions struct {
+	// RequireDense reports messages whose indexes do not form a contiguous
+	// range starting at zero. Indexes listed by @reserved are considered used.
+	RequireDense bool
+
+	// RequireAscending reports fields declared with an index lower than the
+	// one of the field preceding it.
+	RequireAscending bool
+}
+
+// IndexContinuityCheck returns a Check reporting gaps and out-of-order
+// declarations of field indexes, which usually indicate copy-paste mistakes.
+// The check is not part of DefaultChecks, and emits warnings.
+func IndexContinuityCheck(opts IndexContinuityOptions) Check {
+	return Check{
+		Code: CodeIndexContinuity,
+		Run: func(f *FileSet) Diagnostics {
+			var diags Diagnostics
+			for _, m := range f.allMessages {
+				diags = append(diags, checkIndexContinuity(f.declaredIn[m], m, opts)...)
+			}
+			return diags
+		},
+	}
+}
+
+func checkIndexContinuity(file *File, m *Message, opts IndexContinuityOptions) Diagnostics {
+	var diags Diagnostics
+	var items []FieldItem
+	var flatten func([]FieldItem)
+	flatten = func(fields []FieldItem) {
+		for _, item := range fields {
+			items = append(items, item)
+			if o, ok := item.(OneOfField); ok {
+				flatten(o.Items)
+			}
+		}
+	}
+	flatten(m.Fields)
+
+	if opts.RequireAscending {
+		for i := 1; i < len(items); i++ {
+			prev, cur := fieldIndex(items[i-1]), fieldIndex(items[i])
+			if _, isOneOf := items[i-1].(OneOfField); isOneOf {
+				// Cases of a oneof are declared before its own index.
+				continue
+			}
+			if cur < prev {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Code:     CodeIndexContinuity,
+					Message: fmt.Sprintf("index %d of %s in %s is declared after index %d",
+						cur, describeFieldItem(items[i]), m.Name, prev),
+					Location: locationOf(file, items[i].Span()),
+					Related:  []Location{locationOf(file, items[i-1].Span())},
+				})
+			}
+		}
+	}
+
+	if opts.RequireDense && len(items) > 0 {
+		used := map[int]bool{}
+		for _, item := range items {
+			used[fieldIndex(item)] = true
+		}
+		if a, ok := m.Annotations.FindByName(ReservedAnnotation); ok {
+			for _, v := range a.Value {
+				if i, err := strconv.Atoi(v); err == nil {
+					used[i] = true
+				}
+			}
+		}
+		max := 0
+		for i := range used {
+			if i > max {
+				max = i
+			}
+		}
+		var missing []int
+		for i := 0; i < max; i++ {
+			if !used[i] {
+				missing = append(missing, i)
+			}
+		}
+		if len(missing) > 0 {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Code:     CodeIndexContinuity,
+				Message:  fmt.Sprintf("indexes of %s are not contiguous; missing %s", m.Name, joinInts(missing)),
+				Location: locationOf(file, m.Offset),
+			})
+		}
+	}
+	return diags
+}
+
+func joinInts(v []int) string {
+	s := make([]string, len(v))
+	for i, n := range v {
+		s[i] = strconv.Itoa(n)
+	}
+	return strings.Join(s, ", ")
+}
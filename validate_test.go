@@ -0,0 +1,171 @@
+package idl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diagnosticCodes(d Diagnostics) []string {
+	var r []string
+	for _, v := range d {
+		r = append(r, v.Code)
+	}
+	return r
+}
+
+func TestFileSetValidate(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/invalid.yarp"))
+	broken, ok := fs.FindMessage("Broken")
+	require.True(t, ok)
+	scores := broken.Fields[4].(Field)
+	scores.Type = Map{Key: Bool, Value: scores.Type.(Map).Value}
+	broken.Fields[4] = scores
+
+	diags := fs.Validate()
+	assert.Equal(t, []string{
+		CodeStreamingPrimitive,
+		CodeDuplicateIndex,
+		CodeDuplicateIndex,
+		CodeDuplicateIndex,
+		CodeDuplicateIndex,
+		CodeEmptyService,
+		CodeInvalidMapKey,
+		CodeReservedViolation,
+		CodeReservedViolation,
+	}, diagnosticCodes(diags))
+	assert.True(t, diags.HasErrors())
+	assert.Len(t, diags[1].Related, 1)
+
+	diags = fs.Validate(WithoutChecks(DefaultChecks, CodeDuplicateIndex, CodeReservedViolation)...)
+	assert.Equal(t, []string{
+		CodeStreamingPrimitive,
+		CodeEmptyService,
+		CodeInvalidMapKey,
+	}, diagnosticCodes(diags))
+}
+
+func TestFileSetValidateFixtures(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/resolve/types.yarp"))
+	assert.Empty(t, fs.Validate())
+}
+
+func TestFileSetRegisterCheck(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/resolve/services.yarp"))
+	fs.RegisterCheck(Check{
+		Code: "request-suffix",
+		Run: func(f *FileSet) Diagnostics {
+			var diags Diagnostics
+			for s := range f.AllServices() {
+				for _, m := range s.Methods {
+					if !strings.HasSuffix(m.Argument.Name, "Request") {
+						diags = append(diags, Diagnostic{
+							Severity: SeverityWarning,
+							Code:     "request-suffix",
+							Message:  m.Argument.Name + " does not end in Request",
+						})
+					}
+				}
+			}
+			return diags
+		},
+	})
+
+	diags := fs.Validate()
+	require.Equal(t, []string{"request-suffix"}, diagnosticCodes(diags))
+	assert.Equal(t, "Lookup does not end in Request", diags[0].Message)
+	assert.Len(t, fs.Validate(WithoutChecks(DefaultChecks, CodeDuplicateIndex)...), 1)
+}
+
+func TestCheckDuplicateIndexes(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/invalid.yarp"))
+	fs.Resolve()
+	var messages []string
+	for _, d := range checkDuplicateIndexes(fs) {
+		require.Len(t, d.Related, 1)
+		assert.NotEqual(t, d.Location, d.Related[0])
+		messages = append(messages, d.Message)
+	}
+	assert.Equal(t, []string{
+		"index 0 of field name is already used by field id in Broken",
+		"index 0 of field number is already used by field id in Choices",
+		"index 1 of field other is already used by field text in Choices",
+		"index 2 of field trailing is already used by oneof in Choices",
+	}, messages)
+}
+
+func TestIndexContinuityCheck(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/gaps.yarp"))
+	assert.Empty(t, fs.Validate())
+
+	check := IndexContinuityCheck(IndexContinuityOptions{RequireDense: true, RequireAscending: true})
+	diags := fs.Validate(check)
+	require.Len(t, diags, 2)
+	assert.Equal(t, "index 1 of field b in Gaps is declared after index 3", diags[0].Message)
+	assert.Equal(t, "indexes of Gaps are not contiguous; missing 2", diags[1].Message)
+	assert.Equal(t, SeverityWarning, diags[0].Severity)
+}
+
+func TestCheckDuplicateNames(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/names.yarp"))
+	var messages []string
+	for _, d := range fs.Validate() {
+		require.Equal(t, CodeDuplicateName, d.Code)
+		require.Len(t, d.Related, 1)
+		messages = append(messages, d.Message)
+	}
+	assert.Equal(t, []string{
+		"field id is declared multiple times in Person",
+		"field email is declared multiple times in Person",
+		"method get_person is declared multiple times in People",
+	}, messages)
+}
+
+func TestRecursionCheck(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/recursive.yarp"))
+
+	messages := func(d Diagnostics) []string {
+		var r []string
+		for _, v := range d {
+			r = append(r, v.Severity.String()+": "+v.Message)
+		}
+		return r
+	}
+
+	assert.Equal(t, []string{
+		"error: message cannot be constructed, as it contains itself without indirection: Impossible -> Impossible",
+	}, messages(fs.Validate()))
+
+	assert.Equal(t, []string{
+		"error: message cannot be constructed, as it contains itself without indirection: Impossible -> Impossible",
+		"warning: recursive message: Node -> Node",
+		"warning: recursive message: Ping -> Pong -> Ping",
+	}, messages(fs.Validate(RecursionCheck(RecursionWarn))))
+
+	diags := fs.Validate(RecursionCheck(RecursionError))
+	assert.Len(t, diags.Errors(), 3)
+	assert.Len(t, diags[2].Related, 1)
+}
+
+func TestRecursionCheckGroups(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/validate/recursive_groups.yarp"))
+
+	var messages []string
+	for _, d := range fs.Validate(RecursionCheck(RecursionWarn)) {
+		messages = append(messages, d.Severity.String()+": "+d.Message)
+	}
+	assert.Equal(t, []string{
+		"error: message cannot be constructed, as it contains itself without indirection: Loop -> Loop",
+		"warning: recursive message: Branch -> Leaf -> Branch",
+	}, messages)
+}
@@ -0,0 +1,377 @@
+// Package validator checks values against the constraints declared by
+// message fields through @min, @max, @len, @pattern, and @required. See
+// idl.FieldConstraints for the semantics of each annotation.
+//
+// Values may be provided as maps in the representation described by package
+// yarptext, as produced by yarptext.Unmarshal, or as Go structs. Absent
+// entries of maps represent unset optional fields and oneof cases, while
+// other fields hold their zero value when absent. Fields of structs are
+// matched to message fields through a `yarp:"name"` tag, or by their name as
+// produced by names.Go. Within structs, nil pointers, slices, and maps
+// represent unset optional fields and oneof cases.
+//
+// Generated code may perform the same checks without loading schemas: the
+// typescript-validators generator, implemented by package gen/tsvalidate,
+// emits them as TypeScript functions.
+package validator
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/libyarp/idl"
+	"github.com/libyarp/idl/names"
+)
+
+// Violation describes a value breaking a constraint.
+type Violation struct {
+	// Path identifies the offending value, such as items[2].sku.
+	Path string
+
+	// Rule contains the name of the annotation declaring the constraint.
+	Rule string
+
+	// Message contains a human-readable description of the problem.
+	Message string
+}
+
+func (v Violation) String() string { return v.Path + ": " + v.Message }
+
+// ValidationError holds all violations found by Validator.Validate.
+type ValidationError struct{ Violations []Violation }
+
+func (e *ValidationError) Error() string {
+	s := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		s[i] = v.String()
+	}
+	return strings.Join(s, "; ")
+}
+
+type rule struct {
+	field       idl.Field
+	typ         idl.Type
+	optional    bool
+	constraints idl.Constraints
+}
+
+// Validator checks values of a message, and of all messages it references.
+// A Validator is safe for concurrent use.
+type Validator struct {
+	root  *idl.Message
+	rules map[*idl.Message][]rule
+}
+
+// New returns a Validator for values of m, which must have been resolved by
+// a FileSet. An error is returned in case constraints of m or of messages it
+// references are invalid.
+func New(m *idl.Message) (*Validator, error) {
+	v := &Validator{root: m, rules: map[*idl.Message][]rule{}}
+	if err := v.compile(m); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (v *Validator) compile(m *idl.Message) error {
+	if _, ok := v.rules[m]; ok {
+		return nil
+	}
+	v.rules[m] = nil
+	var rules []rule
+	var visit func(items []idl.FieldItem, oneof bool) error
+	visit = func(items []idl.FieldItem, oneof bool) error {
+		for _, item := range items {
+			switch f := item.(type) {
+			case idl.Field:
+				c, err := idl.FieldConstraints(f)
+				if err != nil {
+					return fmt.Errorf("%s: %w", m.Name, err)
+				}
+				t := f.Type
+				if _, ok := f.Annotations.FindByName(idl.RepeatedAnnotation); ok {
+					if _, isArray := t.(idl.Array); !isArray {
+						t = idl.Array{Of: t}
+					}
+				}
+				_, optional := f.Annotations.FindByName(idl.OptionalAnnotation)
+				rules = append(rules, rule{field: f, typ: t, optional: optional || oneof, constraints: c})
+				var nested []*idl.Message
+				idl.WalkType(t, func(t idl.Type) bool {
+					if r, ok := t.(idl.Resolved); ok {
+						nested = append(nested, r.Message)
+					}
+					return true
+				})
+				for _, n := range nested {
+					if err := v.compile(n); err != nil {
+						return err
+					}
+				}
+			case idl.OneOfField:
+				if err := visit(f.Items, true); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := visit(m.Fields, false); err != nil {
+		return err
+	}
+	v.rules[m] = rules
+	return nil
+}
+
+// Validate checks x, which must represent a value of the message provided to
+// New, returning a *ValidationError listing all violations found. Other
+// errors indicate x does not match the shape of the message.
+func (v *Validator) Validate(x any) error {
+	var violations []Violation
+	if err := v.message(v.root, x, "", &violations); err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func (v *Validator) message(m *idl.Message, x any, path string, out *[]Violation) error {
+	for _, r := range v.rules[m] {
+		p := r.field.Name
+		if path != "" {
+			p = path + "." + p
+		}
+		val, set, err := fieldValue(x, r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		if !set {
+			if r.constraints.Required {
+				*out = append(*out, Violation{Path: p, Rule: idl.RequiredAnnotation, Message: "is required"})
+			}
+			continue
+		}
+		if err := v.value(r, r.typ, val, p, true, out); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// value checks val, a value of type t. top indicates whether val is the value
+// of the field itself, rather than one of its elements.
+func (v *Validator) value(r rule, t idl.Type, val reflect.Value, path string, top bool, out *[]Violation) error {
+	c := r.constraints
+	report := func(rule, format string, args ...any) {
+		*out = append(*out, Violation{Path: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+	for val.Kind() == reflect.Pointer || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return fmt.Errorf("unexpected nil value")
+		}
+		val = val.Elem()
+	}
+
+	if top {
+		if c.Required && !r.optional && isZero(t, val) {
+			report(idl.RequiredAnnotation, "is required")
+		}
+		if c.Len != nil {
+			n := val.Len()
+			if val.Kind() == reflect.String {
+				n = utf8.RuneCountInString(val.String())
+			}
+			if n < c.Len.Min || n > c.Len.Max {
+				if c.Len.Min == c.Len.Max {
+					report(idl.LenAnnotation, "length %d differs from %d", n, c.Len.Min)
+				} else {
+					report(idl.LenAnnotation, "length %d is outside range [%d, %d]", n, c.Len.Min, c.Len.Max)
+				}
+			}
+		}
+	}
+
+	switch t := t.(type) {
+	case idl.Primitive:
+		if c.Min != nil || c.Max != nil {
+			n, err := number(val)
+			if err != nil {
+				return err
+			}
+			switch {
+			case n == nil && c.Min != nil:
+				report(idl.MinAnnotation, "value NaN cannot be compared to %s", c.Min.RatString())
+			case n == nil:
+				report(idl.MaxAnnotation, "value NaN cannot be compared to %s", c.Max.RatString())
+			default:
+				if c.Min != nil && n.Cmp(c.Min) < 0 {
+					report(idl.MinAnnotation, "value %v is less than %s", val, c.Min.RatString())
+				}
+				if c.Max != nil && n.Cmp(c.Max) > 0 {
+					report(idl.MaxAnnotation, "value %v is greater than %s", val, c.Max.RatString())
+				}
+			}
+		}
+		if c.Pattern != nil {
+			if val.Kind() != reflect.String {
+				return fmt.Errorf("cannot use %s as string", val.Type())
+			}
+			if !c.Pattern.MatchString(val.String()) {
+				report(idl.PatternAnnotation, "value %q does not match %s", val.String(), c.Pattern)
+			}
+		}
+	case idl.Array:
+		if p, ok := t.Of.(idl.Primitive); ok && p.Kind == idl.Uint8 {
+			return nil
+		}
+		if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+			return fmt.Errorf("cannot use %s as array", val.Type())
+		}
+		for i := 0; i < val.Len(); i++ {
+			if err := v.value(r, t.Of, val.Index(i), fmt.Sprintf("%s[%d]", path, i), false, out); err != nil {
+				return err
+			}
+		}
+	case idl.Map:
+		if val.Kind() != reflect.Map {
+			return fmt.Errorf("cannot use %s as map", val.Type())
+		}
+		if _, ok := t.Value.(idl.Resolved); !ok {
+			return nil
+		}
+		// Constraints apply to map values only through their own messages.
+		keys := val.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			p := fmt.Sprintf("%s[%v]", path, k)
+			if err := v.message(t.Value.(idl.Resolved).Message, val.MapIndex(k).Interface(), p, out); err != nil {
+				return err
+			}
+		}
+	case idl.Resolved:
+		return v.message(t.Message, val.Interface(), path, out)
+	}
+	return nil
+}
+
+// fieldValue returns the value held by x for the field described by r, along
+// with whether it is set.
+func fieldValue(x any, r rule) (reflect.Value, bool, error) {
+	if m, ok := x.(map[string]any); ok {
+		val, ok := m[r.field.Name]
+		if !ok || val == nil {
+			if r.optional {
+				return reflect.Value{}, false, nil
+			}
+			return reflect.ValueOf(zero(r.typ)), true, nil
+		}
+		return reflect.ValueOf(val), true, nil
+	}
+
+	rv := reflect.ValueOf(x)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return reflect.Value{}, false, fmt.Errorf("unexpected nil value")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, false, fmt.Errorf("cannot use %T as message", x)
+	}
+	f, ok := structField(rv, r.field)
+	if !ok {
+		return reflect.Value{}, false, fmt.Errorf("%s has no field for %s", rv.Type(), r.field.Name)
+	}
+	switch f.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		if f.IsNil() {
+			if r.optional {
+				return reflect.Value{}, false, nil
+			}
+			if f.Kind() == reflect.Pointer || f.Kind() == reflect.Interface {
+				return reflect.Value{}, false, fmt.Errorf("unexpected nil value")
+			}
+		}
+	}
+	return f, true, nil
+}
+
+// zero returns a value of type t representing the value of fields absent from
+// maps.
+func zero(t idl.Type) any {
+	switch t := t.(type) {
+	case idl.Primitive:
+		switch {
+		case t.Kind == idl.String:
+			return ""
+		case t.Kind == idl.Bool:
+			return false
+		case t.Kind.IsFloat():
+			return float64(0)
+		case t.Kind.IsSigned():
+			return int64(0)
+		}
+		return uint64(0)
+	case idl.Array:
+		return []any{}
+	case idl.Map:
+		return map[any]any{}
+	}
+	return map[string]any{}
+}
+
+func structField(rv reflect.Value, f idl.Field) (reflect.Value, bool) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if tag, ok := t.Field(i).Tag.Lookup("yarp"); ok && strings.Split(tag, ",")[0] == f.Name {
+			return rv.Field(i), true
+		}
+	}
+	if sf, ok := t.FieldByName(names.Go.Field(f)); ok && sf.IsExported() {
+		return rv.FieldByIndex(sf.Index), true
+	}
+	return reflect.Value{}, false
+}
+
+// number returns val as a rational number, or nil, in case val is NaN.
+// Infinities are represented by values beyond the range of any bound.
+func number(val reflect.Value) (*big.Rat, error) {
+	switch {
+	case val.CanInt():
+		return new(big.Rat).SetInt64(val.Int()), nil
+	case val.CanUint():
+		return new(big.Rat).SetFrac(new(big.Int).SetUint64(val.Uint()), big.NewInt(1)), nil
+	case val.CanFloat():
+		f := val.Float()
+		switch {
+		case math.IsNaN(f):
+			return nil, nil
+		case math.IsInf(f, 0):
+			// Larger than any float64, and hence than any bound.
+			huge := new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), 1100))
+			if f < 0 {
+				huge.Neg(huge)
+			}
+			return huge, nil
+		}
+		return new(big.Rat).SetFloat64(f), nil
+	}
+	return nil, fmt.Errorf("cannot use %s as number", val.Type())
+}
+
+func isZero(t idl.Type, val reflect.Value) bool {
+	switch t.(type) {
+	case idl.Array, idl.Map:
+		return val.Len() == 0
+	case idl.Resolved:
+		return false
+	}
+	return val.IsZero()
+}
@@ -0,0 +1,110 @@
+package validator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func userValidator(t *testing.T) *Validator {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/constraints/user.yarp"))
+	require.Empty(t, fs.Validate())
+	m, ok := fs.FindMessage("User")
+	require.True(t, ok)
+	v, err := New(m)
+	require.NoError(t, err)
+	return v
+}
+
+func violations(t *testing.T, err error) []string {
+	if err == nil {
+		return nil
+	}
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	var r []string
+	for _, v := range verr.Violations {
+		r = append(r, v.Rule+" "+v.String())
+	}
+	return r
+}
+
+type Profile struct {
+	Bio string
+}
+
+type User struct {
+	ID          int64
+	Handle      string
+	Age         uint8
+	Email       *string
+	Tags        []string
+	Coordinates []float64
+	Profile     *Profile
+	Labels      map[string]string `yarp:"labels"`
+}
+
+func TestValidateStruct(t *testing.T) {
+	v := userValidator(t)
+	email := "jane@example.org"
+	valid := User{ID: 1, Handle: "jane", Email: &email, Tags: []string{"a"}, Coordinates: []float64{-12.5, 90.5}, Profile: &Profile{Bio: "Hi"}}
+	assert.NoError(t, v.Validate(valid))
+	assert.NoError(t, v.Validate(&valid))
+
+	invalid := User{
+		Handle:      "Jane",
+		Age:         151,
+		Tags:        []string{"ok", "Nope"},
+		Coordinates: []float64{math.Inf(-1), math.NaN()},
+		Profile:     &Profile{},
+		Labels:      map[string]string{"a": "", "b": "", "c": ""},
+	}
+	assert.Equal(t, []string{
+		"min id: value 0 is less than 1",
+		`pattern handle: value "Jane" does not match ^(?:[a-z][a-z0-9_]*)$`,
+		"max age: value 151 is greater than 150",
+		"required email: is required",
+		`pattern tags[1]: value "Nope" does not match ^(?:[a-z]+)$`,
+		"min coordinates[0]: value -Inf is less than -90",
+		"min coordinates[1]: value NaN cannot be compared to -90",
+		"required profile.bio: is required",
+		"len labels: length 3 is outside range [0, 2]",
+	}, violations(t, v.Validate(invalid)))
+
+	type Partial struct{ ID int64 }
+	assert.ErrorContains(t, v.Validate(Partial{ID: 1}), "has no field for handle")
+}
+
+func TestValidateMap(t *testing.T) {
+	v := userValidator(t)
+	m := map[string]any{
+		"handle":  "jo",
+		"tags":    []any{},
+		"profile": map[string]any{"bio": "x"},
+	}
+	assert.Equal(t, []string{
+		"min id: value 0 is less than 1",
+		"len handle: length 2 is outside range [3, 32]",
+		"required email: is required",
+		"len tags: length 0 is outside range [1, 4]",
+	}, violations(t, v.Validate(m)))
+
+	m["id"] = int64(3)
+	m["handle"] = "joe"
+	m["email"] = "joe@example.org"
+	m["tags"] = []any{"x"}
+	assert.NoError(t, v.Validate(m))
+}
+
+func TestNewInvalidConstraints(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/constraints/invalid.yarp"))
+	m, ok := fs.FindMessage("Invalid")
+	require.True(t, ok)
+	_, err := New(m)
+	assert.EqualError(t, err, "Invalid: field name: @min: cannot be applied to string")
+}
@@ -0,0 +1,131 @@
+package idl
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VendorDir contains the name of directories holding vendored dependencies.
+// Imports that are not relative to the importing file (that is, not starting
+// with ./ or ../) are first looked up in the closest VendorDir found in the
+// directory of the importing file or any of its parents. This includes
+// imports of URLs, which are looked up under the layout used by
+// FileSet.Vendor.
+const VendorDir = "yarp_vendor"
+
+func isRelativeImport(path string) bool {
+	return strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../")
+}
+
+// vendorRoot returns the closest VendorDir containing the file under the
+// provided path.
+func (f *FileSet) vendorRoot(from string) (string, bool) {
+	src := f.sourceFS()
+	dir := src.dir(from)
+	for {
+		candidate := src.join(dir, VendorDir)
+		if st, err := src.stat(candidate); err == nil && st.IsDir() {
+			if f.vendorRoots == nil {
+				f.vendorRoots = map[string]bool{}
+			}
+			f.vendorRoots[candidate] = true
+			return candidate, true
+		}
+		parent := src.dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Vendor copies every loaded dependency into the directory dst. Passing a
+// VendorDir placed alongside the schemas of a project as dst allows it to be
+// loaded without include paths, search paths, or network access.
+//
+// Files provided by an include path, a directory of the search path, or a
+// vendor directory preserve their path relative to the root they were found
+// in. Remote files provided by requirements of the module in use are stored
+// under the path they are imported through, and other remote files under
+// their host and path, such as example.org/schemas/money.yarp. Files imported
+// by dependencies are dependencies themselves, while other local files, such
+// as the ones passed to Load, are left out, as are standard library files.
+// An error is returned in case a dependency cannot be placed into dst.
+func (f *FileSet) Vendor(dst string) error {
+	roots := append(append([]string{}, f.includePaths...), f.searchRoots...)
+	var vendored []string
+	for r := range f.vendorRoots {
+		vendored = append(vendored, r)
+	}
+	sort.Strings(vendored)
+	roots = append(roots, vendored...)
+
+	// Files are ordered after the ones they import, so importers are visited
+	// first when walking backwards.
+	dependency := map[string]bool{}
+	for i := len(f.fileOrder) - 1; i >= 0; i-- {
+		file := f.fileOrder[i]
+		if isStd(file.SourcePath) {
+			continue
+		}
+		p, ok := f.vendorPath(roots, file.SourcePath)
+		if !ok && !dependency[file.SourcePath] {
+			continue
+		}
+		if !ok {
+			return fmt.Errorf("%s: cannot be vendored, as it lies outside of include paths, search paths, and vendor directories", file.SourcePath)
+		}
+		for _, imported := range f.imports[file] {
+			dependency[imported] = true
+		}
+		if err := writeVendored(filepath.Join(dst, filepath.FromSlash(p)), file.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// vendorPath returns the slash-separated path, relative to a vendor
+// directory, in which the file under the provided path is stored once
+// vendored, in case it is provided by one of the provided roots, or is
+// remote.
+func (f *FileSet) vendorPath(roots []string, file string) (string, bool) {
+	if isRemote(file) {
+		return f.remoteVendorPath(file)
+	}
+	for _, root := range roots {
+		if rel, ok := f.sourceFS().rel(root, file); ok {
+			return filepath.ToSlash(rel), true
+		}
+	}
+	return "", false
+}
+
+// remoteVendorPath returns the path, relative to a vendor directory, in which
+// the remote file under the provided URL is stored once vendored.
+func (f *FileSet) remoteVendorPath(rawURL string) (string, bool) {
+	if p, ok := f.module.vendorPath(rawURL); ok {
+		return p, true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", false
+	}
+	p := path.Clean(u.Host + "/" + u.Path)
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", false
+	}
+	return p, true
+}
+
+func writeVendored(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
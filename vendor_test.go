@@ -0,0 +1,99 @@
+package idl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetVendor(t *testing.T) {
+	fs := NewFileSet()
+	require.NoError(t, fs.AddIncludePath("./test/include/shared"))
+	require.NoError(t, fs.Load("./test/include/app/order.yarp"))
+
+	root := t.TempDir()
+	require.NoError(t, fs.Vendor(filepath.Join(root, VendorDir)))
+	vendored := filepath.Join(root, VendorDir, "common", "types.yarp")
+	assert.FileExists(t, vendored)
+	assert.NoFileExists(t, filepath.Join(root, VendorDir, "app", "order.yarp"))
+
+	src, err := os.ReadFile("./test/include/app/order.yarp")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "app", "common"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "app", "order.yarp"), src, 0o644))
+	// Vendored files take precedence over files next to the importer.
+	require.NoError(t, os.WriteFile(filepath.Join(root, "app", "common", "types.yarp"), []byte("package org.example.shadowed;\n"), 0o644))
+
+	fs = NewFileSet()
+	require.NoError(t, fs.Load(filepath.Join(root, "app", "order.yarp")))
+	assert.Empty(t, fs.Validate())
+	o, ok := fs.Origin("org.example.common.Money")
+	require.True(t, ok)
+	assert.Equal(t, vendored, o.Path())
+
+	// Vendoring again from a vendored FileSet reproduces the same tree.
+	again := t.TempDir()
+	require.NoError(t, fs.Vendor(again))
+	assert.FileExists(t, filepath.Join(again, "common", "types.yarp"))
+}
+
+func TestFileSetVendorSearchPath(t *testing.T) {
+	t.Setenv(SearchPathEnv, "./test/include/shared")
+	fs := NewFileSet()
+	require.NoError(t, fs.Load("./test/include/app/order.yarp"))
+
+	root := t.TempDir()
+	require.NoError(t, fs.Vendor(filepath.Join(root, VendorDir)))
+	assert.FileExists(t, filepath.Join(root, VendorDir, "common", "types.yarp"))
+	assert.NoFileExists(t, filepath.Join(root, VendorDir, "app", "order.yarp"))
+}
+
+func TestFileSetVendorRemote(t *testing.T) {
+	srv, fsys, hits := remoteFixture(t)
+	host := strings.TrimPrefix(srv.URL, "https://")
+	root := t.TempDir()
+	require.NoError(t, os.CopyFS(root, fsys))
+
+	fs := NewFileSet()
+	fs.SetFetcher(HTTPFetcher{Client: srv.Client()})
+	fs.UpdateLock()
+	require.NoError(t, fs.Load(filepath.Join(root, "main.yarp")))
+	require.NoError(t, fs.Vendor(filepath.Join(root, VendorDir)))
+	money := filepath.Join(root, VendorDir, host, "schemas", "money.yarp")
+	assert.FileExists(t, filepath.Join(root, VendorDir, host, "schemas", "types.yarp"))
+	assert.FileExists(t, money)
+
+	// Vendored URL imports are loaded without reaching the network.
+	hits.Store(0)
+	fs = NewFileSet()
+	fs.SetFetcher(HTTPFetcher{Client: srv.Client()})
+	require.NoError(t, fs.Load(filepath.Join(root, "main.yarp")))
+	assert.Empty(t, fs.Validate())
+	assert.Zero(t, hits.Load())
+	o, ok := fs.Origin("org.example.remote.Money")
+	require.True(t, ok)
+	assert.Equal(t, money, o.Path())
+}
+
+func TestFileSetVendorOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"app/order.yarp":           "package org.example.app;\n\nimport \"common/types\";\n",
+		"shared/common/types.yarp": "package org.example.common;\n\nimport \"../../outside\";\n",
+		"outside.yarp":             "package org.example.outside;\n",
+	}
+	for name, src := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(src), 0o644))
+	}
+	fs := NewFileSet()
+	require.NoError(t, fs.AddIncludePath(filepath.Join(root, "shared")))
+	require.NoError(t, fs.Load(filepath.Join(root, "app", "order.yarp")))
+	err := fs.Vendor(t.TempDir())
+	assert.ErrorContains(t, err, "outside.yarp: cannot be vendored")
+}
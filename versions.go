@@ -0,0 +1,227 @@
+package idl
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// VersionSource provides information about published versions of external
+// schema dependencies.
+type VersionSource interface {
+	// Versions returns all published versions of the dependency under path.
+	Versions(path string) ([]Version, error)
+
+	// Manifest returns the module manifest of a given version of the
+	// dependency under path, or nil, in case it has none.
+	Manifest(path string, v Version) (*Module, error)
+}
+
+// HTTPVersionSource provides versions of dependencies published over HTTPS.
+// Versions of a dependency are listed, one per line, under
+// https://<path>/versions, and manifests are read from
+// https://<path>/<version>/yarp.mod.
+type HTTPVersionSource struct {
+	// Client is used to perform requests. Defaults to a client giving up on
+	// requests after 30 seconds, as for HTTPFetcher.
+	Client *http.Client
+}
+
+// Versions implements VersionSource.
+func (h HTTPVersionSource) Versions(path string) ([]Version, error) {
+	data, err := HTTPFetcher{Client: h.Client}.Fetch("https://"+path+"/versions", [32]byte{})
+	if err != nil {
+		return nil, err
+	}
+	var versions []Version
+	for _, line := range strings.Fields(string(data)) {
+		v, err := ParseVersion(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
+
+// Manifest implements VersionSource.
+func (h HTTPVersionSource) Manifest(path string, v Version) (*Module, error) {
+	url := "https://" + path + "/" + v.String() + "/" + ModuleFile
+	res, err := clientOrDefault(h.Client).Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%s: unexpected status %s", url, res.Status)
+	}
+	data, err := readBody(url, res)
+	if err != nil {
+		return nil, err
+	}
+	return ParseModule(data)
+}
+
+// RequiredBy describes a constraint imposed on a dependency, and who imposed
+// it.
+type RequiredBy struct {
+	// By contains the path and version of the dependency imposing the
+	// constraint, or "main module".
+	By         string
+	Constraint string
+}
+
+// VersionConflictError indicates that no published version of a dependency
+// satisfies all constraints imposed on it.
+type VersionConflictError struct {
+	Path        string
+	Constraints []RequiredBy
+}
+
+func (v VersionConflictError) Error() string {
+	parts := make([]string, len(v.Constraints))
+	for i, c := range v.Constraints {
+		parts[i] = fmt.Sprintf("%s (required by %s)", c.Constraint, c.By)
+	}
+	return fmt.Sprintf("no version of %s satisfies all constraints: %s", v.Path, strings.Join(parts, ", "))
+}
+
+// ResolveVersions selects a version for every dependency required by root,
+// directly or through manifests of other dependencies. For each dependency,
+// the highest version satisfying all constraints imposed on it is selected.
+// Pre-release versions are only selected when explicitly required.
+func ResolveVersions(root *Module, src VersionSource) (map[string]Version, error) {
+	available := map[string][]Version{}
+	manifests := map[string]*Module{}
+	selected := map[string]Version{}
+
+	// Changing the version selected for a dependency may change constraints
+	// imposed by it, so selection is repeated until it settles.
+	for round := 0; round < 100; round++ {
+		constraints := map[string][]RequiredBy{}
+		var order []string
+		add := func(by string, reqs []Requirement) {
+			for _, r := range reqs {
+				if _, ok := constraints[r.Path]; !ok {
+					order = append(order, r.Path)
+				}
+				constraints[r.Path] = append(constraints[r.Path], RequiredBy{By: by, Constraint: r.Version})
+			}
+		}
+		add("main module", root.Requires)
+		for i := 0; i < len(order); i++ {
+			path := order[i]
+			v, ok := selected[path]
+			if !ok {
+				continue
+			}
+			key := path + "@" + v.String()
+			m, ok := manifests[key]
+			if !ok {
+				var err error
+				if m, err = src.Manifest(path, v); err != nil {
+					return nil, err
+				}
+				manifests[key] = m
+			}
+			if m != nil {
+				add(path+" "+v.String(), m.Requires)
+			}
+		}
+
+		next := map[string]Version{}
+		for _, path := range order {
+			versions, ok := available[path]
+			if !ok {
+				var err error
+				if versions, err = src.Versions(path); err != nil {
+					return nil, err
+				}
+				sort.Slice(versions, func(i, j int) bool { return versions[i].Compare(versions[j]) > 0 })
+				available[path] = versions
+			}
+			v, err := selectVersion(path, versions, constraints[path])
+			if err != nil {
+				return nil, err
+			}
+			next[path] = v
+		}
+		if sameVersions(selected, next) {
+			return next, nil
+		}
+		selected = next
+	}
+	return nil, fmt.Errorf("version selection did not settle")
+}
+
+// selectVersion returns the highest version, from versions sorted in
+// descending order, satisfying all provided constraints.
+func selectVersion(path string, versions []Version, reqs []RequiredBy) (Version, error) {
+	constraints := make([]Constraint, len(reqs))
+	explicit := map[Version]bool{}
+	for i, r := range reqs {
+		c, err := ParseConstraint(r.Constraint)
+		if err != nil {
+			return Version{}, fmt.Errorf("%s (required by %s): %w", path, r.By, err)
+		}
+		constraints[i] = c
+		if v, ok := c.Exact(); ok {
+			explicit[v] = true
+		}
+	}
+outer:
+	for _, v := range versions {
+		if v.Pre != "" && !explicit[v] {
+			continue
+		}
+		for _, c := range constraints {
+			if !c.Check(v) {
+				continue outer
+			}
+		}
+		return v, nil
+	}
+	return Version{}, VersionConflictError{Path: path, Constraints: reqs}
+}
+
+func sameVersions(a, b map[string]Version) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveRequirements selects versions for all dependencies of the module
+// configured through UseModule, including dependencies required by other
+// dependencies, using ResolveVersions. Requirements of the module are replaced
+// by the selected versions.
+func (f *FileSet) ResolveRequirements(src VersionSource) error {
+	if f.module == nil {
+		return fmt.Errorf("no module in use")
+	}
+	selected, err := ResolveVersions(f.module, src)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(selected))
+	for p := range selected {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	reqs := make([]Requirement, len(paths))
+	for i, p := range paths {
+		reqs[i] = Requirement{Path: p, Version: selected[p].String()}
+	}
+	f.module.Requires = reqs
+	return nil
+}
@@ -0,0 +1,168 @@
+package idl
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstraints(t *testing.T) {
+	cases := []struct {
+		constraint string
+		accepts    []string
+		rejects    []string
+	}{
+		{"v1.2.3", []string{"1.2.3"}, []string{"1.2.4", "1.2.3-rc.1"}},
+		{"^1.2", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0"}},
+		{"^0.2.1", []string{"0.2.1", "0.2.9"}, []string{"0.3.0", "0.2.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4", "0.1.0", "0.0.2"}},
+		{"^0.0", []string{"0.0.0", "0.0.9"}, []string{"0.1.0"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+		{">=1.0.0 <2.0.0", []string{"1.0.0", "1.5.0"}, []string{"0.9.0", "2.0.0"}},
+		{"^1.0 || ^3.0", []string{"1.4.0", "3.1.0"}, []string{"2.0.0"}},
+	}
+	for _, c := range cases {
+		constraint, err := ParseConstraint(c.constraint)
+		require.NoError(t, err, c.constraint)
+		for _, s := range c.accepts {
+			v, err := ParseVersion(s)
+			require.NoError(t, err)
+			assert.True(t, constraint.Check(v), "%s should accept %s", c.constraint, s)
+		}
+		for _, s := range c.rejects {
+			v, err := ParseVersion(s)
+			require.NoError(t, err)
+			assert.False(t, constraint.Check(v), "%s should reject %s", c.constraint, s)
+		}
+	}
+
+	_, err := ParseConstraint(">=x")
+	assert.Error(t, err)
+	v, _ := ParseVersion("2.0.0-beta.1")
+	assert.Equal(t, "v2.0.0-beta.1", v.String())
+	assert.Equal(t, -1, v.Compare(Version{Major: 2}))
+}
+
+func TestVersionCompare(t *testing.T) {
+	// Precedence example of the SemVer specification.
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0",
+	}
+	for i, a := range ordered {
+		va, err := ParseVersion(a)
+		require.NoError(t, err)
+		for j, b := range ordered {
+			vb, err := ParseVersion(b)
+			require.NoError(t, err)
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+			assert.Equal(t, expected, va.Compare(vb), "%s <=> %s", a, b)
+		}
+	}
+
+	v, _ := ParseVersion("1.0.0-rc.99999999999999999999")
+	w, _ := ParseVersion("1.0.0-rc.100000000000000000000")
+	assert.Equal(t, -1, v.Compare(w))
+	assert.Equal(t, 1, w.Compare(v))
+}
+
+type fakeVersions struct {
+	versions  map[string][]string
+	manifests map[string]string
+}
+
+func (f fakeVersions) Versions(path string) ([]Version, error) {
+	var r []Version
+	for _, s := range f.versions[path] {
+		v, err := ParseVersion(s)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, v)
+	}
+	return r, nil
+}
+
+func (f fakeVersions) Manifest(path string, v Version) (*Module, error) {
+	src, ok := f.manifests[path+"@"+v.String()]
+	if !ok {
+		return nil, nil
+	}
+	return ParseModule([]byte(src))
+}
+
+func TestResolveVersions(t *testing.T) {
+	src := fakeVersions{
+		versions: map[string][]string{
+			"example.com/left":  {"v1.0.0", "v1.1.0"},
+			"example.com/right": {"v1.0.0"},
+			"example.com/base":  {"v1.0.0", "v1.2.0", "v1.5.0", "v2.0.0", "v2.1.0-rc.1"},
+		},
+		manifests: map[string]string{
+			"example.com/left@v1.1.0":  "package left\nrequire example.com/base ^1.2\n",
+			"example.com/left@v1.0.0":  "package left\nrequire example.com/base ^1.0\n",
+			"example.com/right@v1.0.0": "package right\nrequire example.com/base <1.5.0\n",
+		},
+	}
+	root := &Module{Package: "app", Requires: []Requirement{
+		{Path: "example.com/left", Version: "^1.0"},
+		{Path: "example.com/right", Version: "^1.0"},
+	}}
+	selected, err := ResolveVersions(root, src)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Version{
+		"example.com/left":  {Major: 1, Minor: 1},
+		"example.com/right": {Major: 1},
+		"example.com/base":  {Major: 1, Minor: 2},
+	}, selected)
+
+	root.Requires = append(root.Requires, Requirement{Path: "example.com/base", Version: "^2.0"})
+	_, err = ResolveVersions(root, src)
+	var conflict VersionConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "example.com/base", conflict.Path)
+	assert.Equal(t, "no version of example.com/base satisfies all constraints: "+
+		"^2.0 (required by main module), ^1.2 (required by example.com/left v1.1.0), "+
+		"<1.5.0 (required by example.com/right v1.0.0)", err.Error())
+}
+
+func TestHTTPVersionSource(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/schemas/versions":
+			fmt.Fprintln(w, "v1.0.0\nv1.1.0")
+		case "/schemas/v1.1.0/types.yarp":
+			_, _ = w.Write([]byte(remoteTypes))
+		case "/schemas/v1.1.0/money.yarp":
+			_, _ = w.Write([]byte(remoteMoney))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	fs := NewFileSet()
+	fs.SetFetcher(HTTPFetcher{Client: srv.Client()})
+	fs.module = &Module{Package: "app", Requires: []Requirement{{Path: host + "/schemas", Version: "^1.0"}}}
+	fs.unpinned = true
+	_, _, err := fs.resolveImport("main.yarp", host+"/schemas/types")
+	assert.Error(t, err)
+
+	require.NoError(t, fs.ResolveRequirements(HTTPVersionSource{Client: srv.Client()}))
+	assert.Equal(t, []Requirement{{Path: host + "/schemas", Version: "v1.1.0"}}, fs.Module().Requires)
+	u, _, err := fs.resolveImport("main.yarp", host+"/schemas/types")
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL+"/schemas/v1.1.0/types.yarp", u)
+}
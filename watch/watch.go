@@ -0,0 +1,242 @@
+// Package watch implements a Watcher that keeps a FileSet up to date as YARP
+// source files change on disk.
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/libyarp/idl"
+)
+
+// DefaultDebounce contains the default amount of time the Watcher waits for
+// further changes before rebuilding a FileSet.
+const DefaultDebounce = 100 * time.Millisecond
+
+// Event represents the result of building a FileSet, either when the Watcher
+// starts, or after source files change.
+type Event struct {
+	// FileSet contains the newly built FileSet, or nil, in case it could not
+	// be loaded.
+	FileSet *idl.FileSet
+
+	// Changed contains the paths of files whose changes triggered the build.
+	// It is empty for the initial build.
+	Changed []string
+
+	// Diagnostics contains problems found by validating FileSet.
+	Diagnostics idl.Diagnostics
+
+	// Err contains the error returned while loading the FileSet, if any.
+	Err error
+}
+
+// BuildFunc builds a new FileSet from source files. It is invoked by the
+// Watcher once it starts, and every time a source file changes.
+type BuildFunc func() (*idl.FileSet, error)
+
+// Options represents optional configuration for a Watcher.
+type Options struct {
+	// Debounce determines how long the Watcher waits for further changes
+	// before rebuilding. Defaults to DefaultDebounce.
+	Debounce time.Duration
+
+	// Checks contains the checks used to validate built FileSets. In case it
+	// is empty, idl.DefaultChecks is used.
+	Checks []idl.Check
+}
+
+// Watcher observes directories containing source files, and delivers an Event
+// on its Events channel every time a FileSet is rebuilt.
+type Watcher struct {
+	// Events delivers the result of every build. It is closed once the
+	// Watcher is closed.
+	Events <-chan Event
+
+	events  chan Event
+	build   BuildFunc
+	opts    Options
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+	once    sync.Once
+
+	// dirs holds directories observed as requested by New, while imported
+	// holds the ones containing files of the last FileSet built.
+	dirs     map[string]bool
+	imported map[string]bool
+}
+
+// New creates a Watcher observing the provided directories (and their
+// subdirectories), invoking build once immediately, and again every time a
+// source file within them changes. After each successful build, directories
+// containing files of the FileSet are observed as well, so changes to
+// imported files trigger builds wherever they are located.
+func New(dirs []string, build BuildFunc, opts Options) (*Watcher, error) {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan Event, 1)
+	w := &Watcher{
+		Events:   events,
+		events:   events,
+		build:    build,
+		opts:     opts,
+		watcher:  fw,
+		done:     make(chan struct{}),
+		dirs:     map[string]bool{},
+		imported: map[string]bool{},
+	}
+	for _, d := range dirs {
+		if err = w.addRecursive(d); err != nil {
+			_ = fw.Close()
+			return nil, err
+		}
+	}
+	go w.run()
+	return w, nil
+}
+
+// NewForFiles creates a Watcher observing the directories containing the
+// provided entry files, along with the ones containing files they import,
+// rebuilding a FileSet loading all of them.
+func NewForFiles(paths []string, opts Options) (*Watcher, error) {
+	cache := idl.NewCache()
+	var dirs []string
+	for _, p := range paths {
+		dirs = append(dirs, filepath.Dir(p))
+	}
+	return New(dirs, func() (*idl.FileSet, error) {
+		fs := idl.NewFileSet()
+		fs.SetCache(cache)
+		for _, p := range paths {
+			if err := fs.Load(p); err != nil {
+				return nil, err
+			}
+		}
+		return fs, nil
+	}, opts)
+}
+
+// Close stops the Watcher, and closes its Events channel.
+func (w *Watcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+	})
+	return err
+}
+
+func (w *Watcher) run() {
+	defer close(w.events)
+	if !w.emit(nil) {
+		return
+	}
+	var timer <-chan time.Time
+	changed := map[string]bool{}
+	for {
+		select {
+		case <-w.done:
+			return
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Has(fsnotify.Create) {
+				if st, err := os.Stat(ev.Name); err == nil && st.IsDir() {
+					_ = w.addRecursive(ev.Name)
+					continue
+				}
+			}
+			if !strings.HasSuffix(ev.Name, idl.SourceExtension) || ev.Op == fsnotify.Chmod {
+				continue
+			}
+			changed[ev.Name] = true
+			timer = time.After(w.opts.Debounce)
+		case <-w.watcher.Errors:
+			// Errors reported by the underlying watcher are transient, and
+			// will be followed by further events.
+		case <-timer:
+			timer = nil
+			paths := make([]string, 0, len(changed))
+			for p := range changed {
+				paths = append(paths, p)
+			}
+			changed = map[string]bool{}
+			if !w.emit(paths) {
+				return
+			}
+		}
+	}
+}
+
+func (w *Watcher) emit(changed []string) bool {
+	sort.Strings(changed)
+	ev := Event{Changed: changed}
+	ev.FileSet, ev.Err = w.build()
+	if ev.Err == nil && ev.FileSet != nil {
+		ev.Diagnostics = ev.FileSet.Validate(w.opts.Checks...)
+		w.watchFiles(ev.FileSet)
+	}
+	select {
+	case w.events <- ev:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *Watcher) addRecursive(dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			w.dirs[abs] = true
+			delete(w.imported, abs)
+		}
+		return w.watcher.Add(p)
+	})
+}
+
+// watchFiles observes directories containing local files of fs which are not
+// observed yet, and stops observing the ones holding no file of fs anymore.
+func (w *Watcher) watchFiles(fs *idl.FileSet) {
+	wanted := map[string]bool{}
+	for _, f := range fs.Files() {
+		p := f.SourcePath
+		if strings.HasPrefix(p, idl.StdPrefix) || strings.HasPrefix(p, "https://") {
+			continue
+		}
+		if abs, err := filepath.Abs(p); err == nil && !w.dirs[filepath.Dir(abs)] {
+			wanted[filepath.Dir(abs)] = true
+		}
+	}
+	for dir := range w.imported {
+		if !wanted[dir] {
+			_ = w.watcher.Remove(dir)
+			delete(w.imported, dir)
+		}
+	}
+	for dir := range wanted {
+		if !w.imported[dir] && w.watcher.Add(dir) == nil {
+			w.imported[dir] = true
+		}
+	}
+}
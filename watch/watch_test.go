@@ -0,0 +1,93 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, w *Watcher) Event {
+	select {
+	case ev := <-w.Events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return Event{}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.yarp")
+	require.NoError(t, os.WriteFile(path, []byte("package a;\n\nmessage A {\n    id int64 = 0;\n}\n"), 0o644))
+
+	w, err := NewForFiles([]string{path}, Options{Debounce: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer func() { _ = w.Close() }()
+
+	ev := receive(t, w)
+	require.NoError(t, ev.Err)
+	assert.Empty(t, ev.Changed)
+	_, ok := ev.FileSet.FindMessage("A")
+	assert.True(t, ok)
+
+	require.NoError(t, os.WriteFile(path, []byte("package a;\n\nmessage B {\n    id int64 = 0;\n    other int64 = 0;\n}\n"), 0o644))
+	ev = receive(t, w)
+	require.NoError(t, ev.Err)
+	assert.Equal(t, []string{path}, ev.Changed)
+	_, ok = ev.FileSet.FindMessage("B")
+	assert.True(t, ok)
+	assert.Len(t, ev.Diagnostics, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte("package a;\n\nmessage {"), 0o644))
+	ev = receive(t, w)
+	assert.Error(t, ev.Err)
+	assert.Nil(t, ev.FileSet)
+
+	require.NoError(t, w.Close())
+	for range w.Events {
+	}
+}
+
+func TestWatcherImports(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, src string) string {
+		p := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(src), 0o644))
+		return p
+	}
+	path := write("app/main.yarp", "package a;\n\nimport \"../shared/types\";\n")
+	shared := write("shared/types.yarp", "package a;\n\nmessage A {\n    id int64 = 0;\n}\n")
+	other := write("other/types.yarp", "package a;\n\nmessage B {\n    id int64 = 0;\n}\n")
+
+	w, err := NewForFiles([]string{path}, Options{Debounce: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer func() { _ = w.Close() }()
+	ev := receive(t, w)
+	require.NoError(t, ev.Err)
+
+	// Changes to imported files outside of the entry directory trigger builds.
+	write("shared/types.yarp", "package a;\n\nmessage C {\n    id int64 = 0;\n}\n")
+	ev = receive(t, w)
+	require.NoError(t, ev.Err)
+	assert.Equal(t, []string{shared}, ev.Changed)
+	_, ok := ev.FileSet.FindMessage("C")
+	assert.True(t, ok)
+
+	// Directories of newly imported files are observed after a build.
+	write("app/main.yarp", "package a;\n\nimport \"../other/types\";\n")
+	ev = receive(t, w)
+	require.NoError(t, ev.Err)
+	write("other/types.yarp", "package a;\n\nmessage D {\n    id int64 = 0;\n}\n")
+	ev = receive(t, w)
+	require.NoError(t, ev.Err)
+	assert.Equal(t, []string{other}, ev.Changed)
+	_, ok = ev.FileSet.FindMessage("D")
+	assert.True(t, ok)
+	assert.NotContains(t, w.imported, filepath.Dir(shared))
+}
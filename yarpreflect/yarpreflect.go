@@ -0,0 +1,619 @@
+// Package yarpreflect exposes a read-only view over validated YARP schemas.
+// Descriptors are decoupled from the syntax tree produced by the parser: they
+// carry resolved types and fully-qualified names, but no source positions or
+// comments, making them suitable for runtime consumers such as codecs,
+// gateways, and reflection services.
+//
+// Descriptors are created through Build, and must not be modified. Slices
+// returned by accessors are copies, and can be freely altered by callers.
+package yarpreflect
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/libyarp/idl"
+)
+
+// Annotation represents an annotation applied to a declaration.
+type Annotation struct {
+	Name   string
+	Values []string
+}
+
+type annotations []Annotation
+
+func annotationsOf(a idl.AnnotationCollection) annotations {
+	r := make(annotations, len(a))
+	for i, v := range a {
+		r[i] = Annotation{Name: v.Name, Values: append([]string{}, v.Value...)}
+	}
+	return r
+}
+
+func (a annotations) all() []Annotation {
+	r := make([]Annotation, len(a))
+	for i, v := range a {
+		r[i] = Annotation{Name: v.Name, Values: append([]string{}, v.Values...)}
+	}
+	return r
+}
+
+func (a annotations) find(name string) (Annotation, bool) {
+	for _, v := range a {
+		if v.Name == name {
+			return Annotation{Name: v.Name, Values: append([]string{}, v.Values...)}, true
+		}
+	}
+	return Annotation{}, false
+}
+
+// renamedFrom returns the former names declared through @renamed_from.
+func (a annotations) renamedFrom() []string {
+	var r []string
+	for _, v := range a {
+		if v.Name == idl.RenamedFromAnnotation {
+			r = append(r, v.Values...)
+		}
+	}
+	return r
+}
+
+func (a annotations) formerly(name string) bool {
+	for _, n := range a.renamedFrom() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Schema holds descriptors of all messages and services of a FileSet.
+type Schema struct {
+	messages map[idl.FQN]*MessageDescriptor
+	services map[idl.FQN]*ServiceDescriptor
+}
+
+// Build validates fs with the default checks and returns descriptors of all
+// messages and services it holds, including the ones declared by imported
+// files. In case validation reports errors, they are returned as
+// idl.Diagnostics.
+func Build(fs *idl.FileSet) (*Schema, error) {
+	if diags := fs.Validate(); diags.HasErrors() {
+		return nil, diags
+	}
+	s := &Schema{messages: map[idl.FQN]*MessageDescriptor{}, services: map[idl.FQN]*ServiceDescriptor{}}
+	byDecl := map[*idl.Message]*MessageDescriptor{}
+	symbols := fs.Symbols()
+	for _, sym := range symbols {
+		if m := sym.Message(); m != nil {
+			d := &MessageDescriptor{name: sym.Name, annotations: annotationsOf(m.Annotations)}
+			s.messages[sym.Name] = d
+			byDecl[m] = d
+		}
+	}
+	for m, d := range byDecl {
+		if err := d.build(m, byDecl); err != nil {
+			return nil, err
+		}
+	}
+	for _, sym := range symbols {
+		svc := sym.Service()
+		if svc == nil {
+			continue
+		}
+		d := &ServiceDescriptor{name: sym.Name, annotations: annotationsOf(svc.Annotations)}
+		for _, m := range svc.Methods {
+			opts, err := m.Options()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", sym.Name, err)
+			}
+			md := &MethodDescriptor{
+				name:        m.Name,
+				service:     d,
+				streaming:   m.Return.Streaming,
+				options:     opts,
+				annotations: annotationsOf(m.Annotations),
+			}
+			if md.input, err = messageOf(m.Argument, byDecl); err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", sym.Name, m.Name, err)
+			}
+			if md.output, err = messageOf(m.Return, byDecl); err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", sym.Name, m.Name, err)
+			}
+			d.methods = append(d.methods, md)
+		}
+		s.services[sym.Name] = d
+	}
+	return s, nil
+}
+
+func messageOf(t idl.TypeRef, byDecl map[*idl.Message]*MessageDescriptor) (*MessageDescriptor, error) {
+	if t.IsVoid() {
+		return nil, nil
+	}
+	if d, ok := byDecl[t.Target]; ok {
+		return d, nil
+	}
+	return nil, fmt.Errorf("unresolved type %s", t.FQN())
+}
+
+// Message returns the descriptor of the message identified by name, or by a
+// former name declared through @renamed_from, along with a boolean indicating
+// whether it exists.
+func (s *Schema) Message(name idl.FQN) (*MessageDescriptor, bool) {
+	if d, ok := s.messages[name]; ok {
+		return d, true
+	}
+	for _, d := range s.messages {
+		if d.Package() == name.Package() && d.annotations.formerly(name.Name()) {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// Service returns the descriptor of the service identified by name, along
+// with a boolean indicating whether it exists.
+func (s *Schema) Service(name idl.FQN) (*ServiceDescriptor, bool) {
+	d, ok := s.services[name]
+	return d, ok
+}
+
+// Method returns the descriptor of the method identified by name, composed of
+// the fully-qualified name of its service followed by the method name, such
+// as org.example.Orders.place.
+func (s *Schema) Method(name idl.FQN) (*MethodDescriptor, bool) {
+	svc, ok := s.services[idl.FQN(name.Package())]
+	if !ok {
+		return nil, false
+	}
+	return svc.MethodByName(name.Name())
+}
+
+// Methods returns descriptors of the methods of all services, sorted by
+// full name, forming a table through which middlewares can look up methods
+// being called.
+func (s *Schema) Methods() []*MethodDescriptor {
+	var r []*MethodDescriptor
+	for _, svc := range s.Services() {
+		r = append(r, svc.methods...)
+	}
+	sort.SliceStable(r, func(i, j int) bool { return r[i].FullName() < r[j].FullName() })
+	return r
+}
+
+// Messages returns descriptors of all messages, sorted by name.
+func (s *Schema) Messages() []*MessageDescriptor {
+	r := make([]*MessageDescriptor, 0, len(s.messages))
+	for _, d := range s.messages {
+		r = append(r, d)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].name < r[j].name })
+	return r
+}
+
+// Services returns descriptors of all services, sorted by name.
+func (s *Schema) Services() []*ServiceDescriptor {
+	r := make([]*ServiceDescriptor, 0, len(s.services))
+	for _, d := range s.services {
+		r = append(r, d)
+	}
+	sort.Slice(r, func(i, j int) bool { return r[i].name < r[j].name })
+	return r
+}
+
+// MessageDescriptor describes a message.
+type MessageDescriptor struct {
+	name        idl.FQN
+	fields      []*FieldDescriptor
+	oneofs      []*OneofDescriptor
+	annotations annotations
+}
+
+func (d *MessageDescriptor) build(m *idl.Message, byDecl map[*idl.Message]*MessageDescriptor) error {
+	var visit func(items []idl.FieldItem, oneof *OneofDescriptor) error
+	visit = func(items []idl.FieldItem, oneof *OneofDescriptor) error {
+		for _, item := range items {
+			switch v := item.(type) {
+			case idl.Field:
+				typ := v.Type
+				if _, ok := v.Annotations.FindByName(idl.RepeatedAnnotation); ok {
+					if _, isArray := typ.(idl.Array); !isArray {
+						typ = idl.Array{Of: typ}
+					}
+				}
+				t, err := typeOf(typ, byDecl)
+				if err != nil {
+					return fmt.Errorf("%s.%s: %w", d.name, v.Name, err)
+				}
+				_, optional := v.Annotations.FindByName(idl.OptionalAnnotation)
+				f := &FieldDescriptor{
+					name:        v.Name,
+					index:       v.Index,
+					typ:         t,
+					optional:    optional || oneof != nil,
+					message:     d,
+					oneof:       oneof,
+					annotations: annotationsOf(v.Annotations),
+				}
+				d.fields = append(d.fields, f)
+				if oneof != nil {
+					oneof.fields = append(oneof.fields, f)
+				}
+			case idl.OneOfField:
+				o := &OneofDescriptor{index: v.Index, message: d, annotations: annotationsOf(v.Annotations)}
+				d.oneofs = append(d.oneofs, o)
+				if err := visit(v.Items, o); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return visit(m.Fields, nil)
+}
+
+// FullName returns the fully-qualified name of the message.
+func (d *MessageDescriptor) FullName() idl.FQN { return d.name }
+
+// Name returns the name of the message, without its package.
+func (d *MessageDescriptor) Name() string { return d.name.Name() }
+
+// Package returns the package declaring the message.
+func (d *MessageDescriptor) Package() string { return d.name.Package() }
+
+// Fields returns all fields of the message in declaration order, including
+// oneof cases.
+func (d *MessageDescriptor) Fields() []*FieldDescriptor {
+	return append([]*FieldDescriptor{}, d.fields...)
+}
+
+// FieldByName returns the field identified by name, or by a former name
+// declared through @renamed_from, along with a boolean indicating whether it
+// exists.
+func (d *MessageDescriptor) FieldByName(name string) (*FieldDescriptor, bool) {
+	for _, f := range d.fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	for _, f := range d.fields {
+		if f.annotations.formerly(name) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// FieldByIndex returns the field identified by index, along with a boolean
+// indicating whether it exists.
+func (d *MessageDescriptor) FieldByIndex(index int) (*FieldDescriptor, bool) {
+	for _, f := range d.fields {
+		if f.index == index {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// Oneofs returns all oneofs of the message in declaration order.
+func (d *MessageDescriptor) Oneofs() []*OneofDescriptor {
+	return append([]*OneofDescriptor{}, d.oneofs...)
+}
+
+// RenamedFrom returns the former names of the message, declared through
+// @renamed_from.
+func (d *MessageDescriptor) RenamedFrom() []string { return d.annotations.renamedFrom() }
+
+// Annotations returns all annotations applied to the message.
+func (d *MessageDescriptor) Annotations() []Annotation { return d.annotations.all() }
+
+// Annotation returns the annotation identified by name, along with a boolean
+// indicating whether it is present.
+func (d *MessageDescriptor) Annotation(name string) (Annotation, bool) {
+	return d.annotations.find(name)
+}
+
+// OneofDescriptor describes a oneof, of which at most one field may be set.
+type OneofDescriptor struct {
+	index       int
+	message     *MessageDescriptor
+	fields      []*FieldDescriptor
+	annotations annotations
+}
+
+// Index returns the index declared by the oneof.
+func (o *OneofDescriptor) Index() int { return o.index }
+
+// Message returns the message containing the oneof.
+func (o *OneofDescriptor) Message() *MessageDescriptor { return o.message }
+
+// Fields returns the cases of the oneof in declaration order.
+func (o *OneofDescriptor) Fields() []*FieldDescriptor {
+	return append([]*FieldDescriptor{}, o.fields...)
+}
+
+// Annotations returns all annotations applied to the oneof.
+func (o *OneofDescriptor) Annotations() []Annotation { return o.annotations.all() }
+
+// FieldDescriptor describes a field of a message.
+type FieldDescriptor struct {
+	name        string
+	index       int
+	typ         *TypeDescriptor
+	optional    bool
+	message     *MessageDescriptor
+	oneof       *OneofDescriptor
+	annotations annotations
+}
+
+// Name returns the name of the field.
+func (f *FieldDescriptor) Name() string { return f.name }
+
+// Index returns the index of the field.
+func (f *FieldDescriptor) Index() int { return f.index }
+
+// Type returns the type of the field.
+func (f *FieldDescriptor) Type() *TypeDescriptor { return f.typ }
+
+// Optional returns whether the field may be absent, which is the case of
+// fields annotated with @optional and of oneof cases.
+func (f *FieldDescriptor) Optional() bool { return f.optional }
+
+// Message returns the message containing the field.
+func (f *FieldDescriptor) Message() *MessageDescriptor { return f.message }
+
+// Oneof returns the oneof the field is a case of, or nil.
+func (f *FieldDescriptor) Oneof() *OneofDescriptor { return f.oneof }
+
+// RenamedFrom returns the former names of the field, declared through
+// @renamed_from.
+func (f *FieldDescriptor) RenamedFrom() []string { return f.annotations.renamedFrom() }
+
+// Annotations returns all annotations applied to the field.
+func (f *FieldDescriptor) Annotations() []Annotation { return f.annotations.all() }
+
+// Annotation returns the annotation identified by name, along with a boolean
+// indicating whether it is present.
+func (f *FieldDescriptor) Annotation(name string) (Annotation, bool) {
+	return f.annotations.find(name)
+}
+
+// Kind indicates the kind of a TypeDescriptor.
+type Kind int
+
+const (
+	// KindPrimitive indicates a primitive type.
+	KindPrimitive Kind = iota + 1
+
+	// KindArray indicates an array type.
+	KindArray
+
+	// KindMap indicates a map type.
+	KindMap
+
+	// KindMessage indicates a message type.
+	KindMessage
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindPrimitive:
+		return "primitive"
+	case KindArray:
+		return "array"
+	case KindMap:
+		return "map"
+	case KindMessage:
+		return "message"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// TypeDescriptor describes the type of a field. Fields annotated with
+// @repeated are described as arrays.
+type TypeDescriptor struct {
+	kind      Kind
+	primitive idl.PrimitiveType
+	elem      *TypeDescriptor
+	message   *MessageDescriptor
+}
+
+func typeOf(t idl.Type, byDecl map[*idl.Message]*MessageDescriptor) (*TypeDescriptor, error) {
+	switch v := t.(type) {
+	case idl.Primitive:
+		return &TypeDescriptor{kind: KindPrimitive, primitive: v.Kind}, nil
+	case idl.Array:
+		elem, err := typeOf(v.Of, byDecl)
+		if err != nil {
+			return nil, err
+		}
+		return &TypeDescriptor{kind: KindArray, elem: elem}, nil
+	case idl.Map:
+		elem, err := typeOf(v.Value, byDecl)
+		if err != nil {
+			return nil, err
+		}
+		return &TypeDescriptor{kind: KindMap, primitive: v.Key, elem: elem}, nil
+	case idl.Resolved:
+		if d, ok := byDecl[v.Message]; ok {
+			return &TypeDescriptor{kind: KindMessage, message: d}, nil
+		}
+		return nil, fmt.Errorf("unresolved type %s", v.Name)
+	case idl.Unresolved:
+		return nil, fmt.Errorf("unresolved type %s", v.Name)
+	}
+	return nil, fmt.Errorf("unsupported type %T", t)
+}
+
+// Kind returns the kind of the type.
+func (t *TypeDescriptor) Kind() Kind { return t.kind }
+
+// Primitive returns the primitive type represented by t, for KindPrimitive,
+// or the type of map keys, for KindMap. Other kinds return idl.Invalid.
+func (t *TypeDescriptor) Primitive() idl.PrimitiveType { return t.primitive }
+
+// Elem returns the type of array elements, for KindArray, or of map values,
+// for KindMap. Other kinds return nil.
+func (t *TypeDescriptor) Elem() *TypeDescriptor { return t.elem }
+
+// Message returns the message represented by t, for KindMessage. Other kinds
+// return nil.
+func (t *TypeDescriptor) Message() *MessageDescriptor { return t.message }
+
+// String returns the representation of t in the YARP syntax, using
+// fully-qualified message names.
+func (t *TypeDescriptor) String() string {
+	switch t.kind {
+	case KindPrimitive:
+		return t.primitive.Keyword()
+	case KindArray:
+		return "array<" + t.elem.String() + ">"
+	case KindMap:
+		return "map<" + t.primitive.Keyword() + ", " + t.elem.String() + ">"
+	case KindMessage:
+		return t.message.name.String()
+	}
+	return t.kind.String()
+}
+
+// ServiceDescriptor describes a service.
+type ServiceDescriptor struct {
+	name        idl.FQN
+	methods     []*MethodDescriptor
+	annotations annotations
+}
+
+// FullName returns the fully-qualified name of the service.
+func (s *ServiceDescriptor) FullName() idl.FQN { return s.name }
+
+// Name returns the name of the service, without its package.
+func (s *ServiceDescriptor) Name() string { return s.name.Name() }
+
+// Package returns the package declaring the service.
+func (s *ServiceDescriptor) Package() string { return s.name.Package() }
+
+// Methods returns all methods of the service in declaration order.
+func (s *ServiceDescriptor) Methods() []*MethodDescriptor {
+	return append([]*MethodDescriptor{}, s.methods...)
+}
+
+// MethodByName returns the method identified by name, or by a former name
+// declared through @renamed_from, along with a boolean indicating whether it
+// exists.
+func (s *ServiceDescriptor) MethodByName(name string) (*MethodDescriptor, bool) {
+	for _, m := range s.methods {
+		if m.name == name {
+			return m, true
+		}
+	}
+	for _, m := range s.methods {
+		if m.annotations.formerly(name) {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// Annotations returns all annotations applied to the service.
+func (s *ServiceDescriptor) Annotations() []Annotation { return s.annotations.all() }
+
+// Annotation returns the annotation identified by name, along with a boolean
+// indicating whether it is present.
+func (s *ServiceDescriptor) Annotation(name string) (Annotation, bool) {
+	return s.annotations.find(name)
+}
+
+// MethodDescriptor describes a method of a service.
+type MethodDescriptor struct {
+	name        string
+	service     *ServiceDescriptor
+	input       *MessageDescriptor
+	output      *MessageDescriptor
+	streaming   bool
+	options     idl.MethodOptions
+	annotations annotations
+}
+
+// Name returns the name of the method.
+func (m *MethodDescriptor) Name() string { return m.name }
+
+// FullName returns the fully-qualified name of the service declaring the
+// method, followed by the method name.
+func (m *MethodDescriptor) FullName() idl.FQN {
+	return idl.NewFQN(m.service.name.String(), m.name)
+}
+
+// Service returns the service declaring the method.
+func (m *MethodDescriptor) Service() *ServiceDescriptor { return m.service }
+
+// Input returns the message taken by the method, or nil, in case it takes no
+// arguments.
+func (m *MethodDescriptor) Input() *MessageDescriptor { return m.input }
+
+// Output returns the message returned by the method, or nil, in case it
+// returns no value.
+func (m *MethodDescriptor) Output() *MessageDescriptor { return m.output }
+
+// Streaming returns whether the method streams its output.
+func (m *MethodDescriptor) Streaming() bool { return m.streaming }
+
+// Options returns the options declared by the method through @timeout and
+// @idempotent.
+func (m *MethodDescriptor) Options() idl.MethodOptions { return m.options }
+
+// Info returns a summary of the method, as emitted in method tables by the
+// go-descriptor generator.
+func (m *MethodDescriptor) Info() MethodInfo {
+	info := MethodInfo{
+		FullName:   m.FullName(),
+		Streaming:  m.streaming,
+		Timeout:    m.options.Timeout,
+		Idempotent: m.options.Idempotent,
+	}
+	if m.input != nil {
+		info.Argument = m.input.name
+	}
+	if m.output != nil {
+		info.Return = m.output.name
+	}
+	return info
+}
+
+// RenamedFrom returns the former names of the method, declared through
+// @renamed_from.
+func (m *MethodDescriptor) RenamedFrom() []string { return m.annotations.renamedFrom() }
+
+// Annotations returns all annotations applied to the method.
+func (m *MethodDescriptor) Annotations() []Annotation { return m.annotations.all() }
+
+// Annotation returns the annotation identified by name, along with a boolean
+// indicating whether it is present.
+func (m *MethodDescriptor) Annotation(name string) (Annotation, bool) {
+	return m.annotations.find(name)
+}
+
+// MethodInfo summarizes a method as plain data, allowing method tables to be
+// declared by generated code, and consulted by transport middlewares to make
+// routing and retry decisions without loading schemas.
+type MethodInfo struct {
+	// FullName contains the fully-qualified name of the service declaring
+	// the method, followed by the method name.
+	FullName idl.FQN
+
+	// Argument and Return contain the fully-qualified names of the messages
+	// taken and returned by the method, or empty strings for void.
+	Argument, Return idl.FQN
+
+	// Streaming indicates whether the method streams its output.
+	Streaming bool
+
+	// Timeout contains the duration declared through @timeout, or zero.
+	Timeout time.Duration
+
+	// Idempotent indicates whether the method is annotated with @idempotent.
+	Idempotent bool
+}
@@ -0,0 +1,153 @@
+package yarpreflect
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/dynamic/order.yarp"))
+	s, err := Build(fs)
+	require.NoError(t, err)
+
+	order, ok := s.Message("org.example.shop.Order")
+	require.True(t, ok)
+	assert.Equal(t, "Order", order.Name())
+	assert.Equal(t, "org.example.shop", order.Package())
+	assert.Len(t, s.Messages(), 4)
+
+	var types []string
+	for _, f := range order.Fields() {
+		types = append(types, f.Name()+" "+f.Type().String())
+	}
+	assert.Equal(t, []string{
+		"id int64",
+		"items array<org.example.shop.Item>",
+		"notes string",
+		"quantities map<string, int32>",
+		"card string",
+		"voucher org.example.shop.Voucher",
+		"digest array<uint8>",
+		"total float64",
+		"rate float32",
+		"paid bool",
+	}, types)
+
+	notes, ok := order.FieldByName("notes")
+	require.True(t, ok)
+	assert.True(t, notes.Optional())
+	_, ok = notes.Annotation(idl.OptionalAnnotation)
+	assert.True(t, ok)
+	assert.Same(t, order, notes.Message())
+
+	voucher, ok := order.FieldByIndex(6)
+	require.True(t, ok)
+	assert.True(t, voucher.Optional())
+	require.NotNil(t, voucher.Oneof())
+	assert.Equal(t, 4, voucher.Oneof().Index())
+	assert.Len(t, voucher.Oneof().Fields(), 2)
+	assert.Equal(t, KindMessage, voucher.Type().Kind())
+	target, _ := s.Message("org.example.shop.Voucher")
+	assert.Same(t, target, voucher.Type().Message())
+
+	quantities, _ := order.FieldByName("quantities")
+	assert.Equal(t, KindMap, quantities.Type().Kind())
+	assert.Equal(t, idl.String, quantities.Type().Primitive())
+	assert.Equal(t, idl.Int32, quantities.Type().Elem().Primitive())
+
+	// Accessors return copies.
+	order.Fields()[0] = nil
+	assert.NotNil(t, order.Fields()[0])
+}
+
+func TestBuildServices(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/typescript/main.yarp"))
+	s, err := Build(fs)
+	require.NoError(t, err)
+	require.NotEmpty(t, s.Services())
+
+	for _, svc := range s.Services() {
+		found, ok := s.Service(svc.FullName())
+		require.True(t, ok)
+		assert.Same(t, svc, found)
+		for _, m := range svc.Methods() {
+			assert.Same(t, svc, m.Service())
+			byName, ok := svc.MethodByName(m.Name())
+			assert.True(t, ok)
+			assert.Same(t, m, byName)
+			if m.Input() != nil {
+				in, ok := s.Message(m.Input().FullName())
+				assert.True(t, ok)
+				assert.Same(t, in, m.Input())
+			}
+		}
+	}
+}
+
+func TestBuildInvalid(t *testing.T) {
+	fs := idl.NewFileSetFS(fstest.MapFS{"main.yarp": {Data: []byte("package a;\nmessage A { b Missing = 0; }\n")}})
+	require.NoError(t, fs.Load("main.yarp"))
+	_, err := Build(fs)
+	var diags idl.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	assert.True(t, diags.HasErrors())
+}
+
+func TestMethods(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/methods/orders.yarp"))
+	s, err := Build(fs)
+	require.NoError(t, err)
+
+	var fqns []idl.FQN
+	for _, m := range s.Methods() {
+		fqns = append(fqns, m.FullName())
+	}
+	assert.Equal(t, []idl.FQN{"org.example.orders.Orders.get", "org.example.orders.Orders.place", "org.example.orders.Orders.watch"}, fqns)
+
+	watch, ok := s.Method("org.example.orders.Orders.watch")
+	require.True(t, ok)
+	assert.Equal(t, MethodInfo{
+		FullName:  "org.example.orders.Orders.watch",
+		Argument:  "org.example.orders.Order",
+		Return:    "org.example.orders.Order",
+		Streaming: true,
+		Timeout:   30 * time.Second,
+	}, watch.Info())
+	_, ok = s.Method("org.example.orders.Orders.missing")
+	assert.False(t, ok)
+}
+
+func TestRenamedFrom(t *testing.T) {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/renamed/shop.yarp"))
+	s, err := Build(fs)
+	require.NoError(t, err)
+
+	order, ok := s.Message("org.example.shop.Purchase")
+	require.True(t, ok)
+	assert.Equal(t, idl.FQN("org.example.shop.Order"), order.FullName())
+	assert.Equal(t, []string{"Purchase"}, order.RenamedFrom())
+
+	f, ok := order.FieldByName("count")
+	require.True(t, ok)
+	assert.Equal(t, "quantity", f.Name())
+	assert.Equal(t, []string{"qty", "count"}, f.RenamedFrom())
+
+	svc, ok := s.Service("org.example.shop.Orders")
+	require.True(t, ok)
+	m, ok := svc.MethodByName("fetch")
+	require.True(t, ok)
+	assert.Equal(t, "get", m.Name())
+	assert.Equal(t, []string{"fetch"}, m.RenamedFrom())
+
+	_, ok = s.Message("org.example.Purchase")
+	assert.False(t, ok)
+}
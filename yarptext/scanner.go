@@ -0,0 +1,136 @@
+package yarptext
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/libyarp/idl"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenColon
+	tokenComma
+	tokenSemicolon
+	tokenOpenCurly
+	tokenCloseCurly
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+var tokenNames = map[tokenKind]string{
+	tokenEOF:          "end of input",
+	tokenIdent:        "identifier",
+	tokenNumber:       "number",
+	tokenString:       "string",
+	tokenColon:        "':'",
+	tokenComma:        "','",
+	tokenSemicolon:    "';'",
+	tokenOpenCurly:    "'{'",
+	tokenCloseCurly:   "'}'",
+	tokenOpenBracket:  "'['",
+	tokenCloseBracket: "']'",
+}
+
+func (k tokenKind) String() string { return tokenNames[k] }
+
+type token struct {
+	kind         tokenKind
+	text         string
+	line, column int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenIdent, tokenNumber:
+		return t.kind.String() + " " + t.text
+	case tokenString:
+		return "string " + strconv.Quote(t.text)
+	}
+	return t.kind.String()
+}
+
+var punctuation = map[byte]tokenKind{
+	':': tokenColon, ',': tokenComma, ';': tokenSemicolon,
+	'{': tokenOpenCurly, '}': tokenCloseCurly,
+	'[': tokenOpenBracket, ']': tokenCloseBracket,
+}
+
+func isIdentByte(c byte, first bool) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || !first && c >= '0' && c <= '9'
+}
+
+func isNumberByte(c, prev byte) bool {
+	return c >= '0' && c <= '9' || c == '.' || c == 'e' || c == 'E' ||
+		(c == '+' || c == '-') && (prev == 'e' || prev == 'E')
+}
+
+// scan splits data into tokens, terminated by a tokenEOF.
+func scan(data []byte) ([]token, error) {
+	var tokens []token
+	line, lineStart := 1, 0
+	for i := 0; i < len(data); {
+		c := data[i]
+		col := utf8.RuneCount(data[lineStart:i]) + 1
+		switch {
+		case c == '\n':
+			i++
+			line, lineStart = line+1, i
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case punctuation[c] != tokenEOF:
+			tokens = append(tokens, token{kind: punctuation[c], text: string(c), line: line, column: col})
+			i++
+		case isIdentByte(c, true):
+			start := i
+			for i < len(data) && isIdentByte(data[i], false) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(data[start:i]), line: line, column: col})
+		case c == '-' || c >= '0' && c <= '9' || c == '.':
+			start := i
+			i++
+			if c == '-' && i+3 <= len(data) && string(data[i:i+3]) == "inf" {
+				i += 3
+			} else {
+				for i < len(data) && isNumberByte(data[i], data[i-1]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(data[start:i]), line: line, column: col})
+		case c == '"':
+			start := i
+			i++
+			for i < len(data) && data[i] != '"' && data[i] != '\n' {
+				if data[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(data) || data[i] != '"' {
+				return nil, idl.SyntaxError{Message: "unterminated string", Line: line, Column: col}
+			}
+			i++
+			text, err := strconv.Unquote(string(data[start:i]))
+			if err != nil {
+				return nil, idl.SyntaxError{Message: fmt.Sprintf("invalid string %s", data[start:i]), Line: line, Column: col}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, line: line, column: col})
+		default:
+			r, _ := utf8.DecodeRune(data[i:])
+			return nil, idl.SyntaxError{Message: fmt.Sprintf("unexpected character %q", r), Line: line, Column: col}
+		}
+	}
+	col := utf8.RuneCount(data[lineStart:]) + 1
+	return append(tokens, token{kind: tokenEOF, line: line, column: col}), nil
+}
@@ -0,0 +1,589 @@
+// Package yarptext implements a human-readable text format for messages,
+// driven by their schema, suited for test fixtures and configuration files.
+// A document holds the fields of a message:
+//
+//	# Comments extend to the end of the line.
+//	id: 42
+//	notes: "leave at door"
+//	tags: ["urgent", "gift"]
+//	items {
+//	    sku: "a1"
+//	    count: 2
+//	}
+//	items {
+//	    sku: "b2"
+//	}
+//	quantities {
+//	    "a1": 2
+//	    "b2": 1
+//	}
+//
+// Fields holding primitives and lists are followed by a colon, which is
+// optional before messages and maps. Fields and list elements may be
+// separated by commas or semicolons. Repeating a field holding an array or
+// repeated field appends to it, which is how Marshal writes arrays of
+// messages; other arrays are written as lists. Maps hold keys followed by a
+// colon and their value.
+//
+// Strings and arrays of uint8 are written as double-quoted strings, using the
+// escape sequences of Go string literals. Integers are written in decimal;
+// floats may additionally be written as inf, -inf, or nan. Booleans are
+// written as true or false.
+//
+// Values are represented in Go as follows:
+//
+//   - primitives: int8, int16, int32, int64, uint8, uint16, uint32, uint64,
+//     float32, float64, bool, and string, matching their YARP types;
+//   - arrays of uint8: []byte;
+//   - other arrays, and repeated fields: []any;
+//   - maps: map[any]any, keyed by values of the key's type;
+//   - messages: map[string]any, keyed by field name. Unset fields are
+//     absent, or nil.
+package yarptext
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/libyarp/idl"
+)
+
+// Indent contains the string used to indent nested blocks by Marshal.
+const Indent = "    "
+
+// Marshal returns the text representation of v, holding values of the fields
+// of m keyed by their name. m must have been resolved by a FileSet. Fields
+// are written in declaration order, and unset fields, empty arrays, and
+// empty maps are omitted.
+func Marshal(m *idl.Message, v map[string]any) ([]byte, error) {
+	p := &printer{}
+	if err := p.message(m, v, ""); err != nil {
+		return nil, err
+	}
+	return p.buf.Bytes(), nil
+}
+
+type printer struct {
+	buf bytes.Buffer
+}
+
+func (p *printer) message(m *idl.Message, values map[string]any, indent string) error {
+	fields := fieldsOf(m)
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.Name] = true
+	}
+	for name := range values {
+		if !known[name] {
+			return fmt.Errorf("%s: unknown field %s", m.Name, name)
+		}
+	}
+	for _, f := range fields {
+		v, ok := values[f.Name]
+		if !ok || v == nil {
+			continue
+		}
+		t := f.typ()
+		switch t := t.(type) {
+		case idl.Array:
+			items, isList := v.([]any)
+			if isList && len(items) == 0 {
+				continue
+			}
+			if _, nested := t.Of.(idl.Resolved); nested && isList {
+				for _, item := range items {
+					if err := p.block(indent, f.Name, t.Of, item); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+		case idl.Map:
+			if entries, ok := v.(map[any]any); ok && len(entries) == 0 {
+				continue
+			}
+		}
+		if err := p.block(indent, f.Name, t, v); err != nil {
+			return fmt.Errorf("%s.%s: %w", m.Name, f.Name, err)
+		}
+	}
+	return nil
+}
+
+// block writes a field named name holding v.
+func (p *printer) block(indent, name string, t idl.Type, v any) error {
+	p.buf.WriteString(indent + name)
+	switch t.(type) {
+	case idl.Resolved, idl.Map:
+		p.buf.WriteString(" ")
+	default:
+		p.buf.WriteString(": ")
+	}
+	if err := p.value(t, v, indent); err != nil {
+		return err
+	}
+	p.buf.WriteString("\n")
+	return nil
+}
+
+func (p *printer) value(t idl.Type, v any, indent string) error {
+	switch t := t.(type) {
+	case idl.Primitive:
+		s, err := formatPrimitive(t.Kind, v)
+		if err != nil {
+			return err
+		}
+		p.buf.WriteString(s)
+	case idl.Array:
+		if raw, ok := v.([]byte); ok {
+			p.buf.WriteString(strconv.Quote(string(raw)))
+			return nil
+		}
+		items, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("cannot write %T as an array", v)
+		}
+		p.buf.WriteString("[")
+		for i, item := range items {
+			if i > 0 {
+				p.buf.WriteString(", ")
+			}
+			if err := p.value(t.Of, item, indent); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		p.buf.WriteString("]")
+	case idl.Map:
+		entries, ok := v.(map[any]any)
+		if !ok {
+			return fmt.Errorf("cannot write %T as a map", v)
+		}
+		keys := make([]any, 0, len(entries))
+		for k := range entries {
+			keys = append(keys, k)
+		}
+		sortKeys(keys)
+		p.buf.WriteString("{\n")
+		for _, k := range keys {
+			key, err := formatPrimitive(t.Key, k)
+			if err != nil {
+				return err
+			}
+			p.buf.WriteString(indent + Indent + key + ": ")
+			if err = p.value(t.Value, entries[k], indent+Indent); err != nil {
+				return fmt.Errorf("value of %s: %w", key, err)
+			}
+			p.buf.WriteString("\n")
+		}
+		p.buf.WriteString(indent + "}")
+	case idl.Resolved:
+		values, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("cannot write %T as %s", v, t.Name)
+		}
+		p.buf.WriteString("{\n")
+		if err := p.message(t.Message, values, indent+Indent); err != nil {
+			return err
+		}
+		p.buf.WriteString(indent + "}")
+	default:
+		return fmt.Errorf("unsupported type %T", t)
+	}
+	return nil
+}
+
+// formatPrimitive writes v, which must be represented by the Go type matching
+// p.
+func formatPrimitive(p idl.PrimitiveType, v any) (string, error) {
+	if kind, ok := primitiveKind(v); !ok || kind != p {
+		return "", fmt.Errorf("cannot write %T as %s", v, p.Keyword())
+	}
+	switch x := v.(type) {
+	case string:
+		return strconv.Quote(x), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case float32:
+		return formatFloat(float64(x), 32), nil
+	case float64:
+		return formatFloat(x, 64), nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// primitiveKind returns the primitive type represented by the Go type of v.
+func primitiveKind(v any) (idl.PrimitiveType, bool) {
+	switch v.(type) {
+	case int8:
+		return idl.Int8, true
+	case int16:
+		return idl.Int16, true
+	case int32:
+		return idl.Int32, true
+	case int64:
+		return idl.Int64, true
+	case uint8:
+		return idl.Uint8, true
+	case uint16:
+		return idl.Uint16, true
+	case uint32:
+		return idl.Uint32, true
+	case uint64:
+		return idl.Uint64, true
+	case float32:
+		return idl.Float32, true
+	case float64:
+		return idl.Float64, true
+	case bool:
+		return idl.Bool, true
+	case string:
+		return idl.String, true
+	}
+	return 0, false
+}
+
+func formatFloat(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "nan"
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+// sortKeys sorts map keys, which share a single primitive type.
+func sortKeys(keys []any) {
+	sort.Slice(keys, func(i, j int) bool {
+		switch a := keys[i].(type) {
+		case string:
+			return a < keys[j].(string)
+		case bool:
+			return !a && keys[j].(bool)
+		case uint8, uint16, uint32, uint64:
+			return toUint(a) < toUint(keys[j])
+		}
+		return toInt(keys[i]) < toInt(keys[j])
+	})
+}
+
+func toUint(v any) uint64 {
+	switch x := v.(type) {
+	case uint8:
+		return uint64(x)
+	case uint16:
+		return uint64(x)
+	case uint32:
+		return uint64(x)
+	case uint64:
+		return x
+	}
+	return 0
+}
+
+func toInt(v any) int64 {
+	switch x := v.(type) {
+	case int8:
+		return int64(x)
+	case int16:
+		return int64(x)
+	case int32:
+		return int64(x)
+	case int64:
+		return x
+	}
+	return 0
+}
+
+// Unmarshal parses data, returning the values it holds for fields of m,
+// which must have been resolved by a FileSet. Fields absent from data are
+// absent from the returned map. Syntax errors, along with values not matching
+// the schema, are reported as idl.SyntaxError values.
+func Unmarshal(data []byte, m *idl.Message) (map[string]any, error) {
+	tokens, err := scan(data)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	return p.message(m, tokenEOF)
+}
+
+// field represents a field of a message, or a case of one of its oneofs.
+type field struct {
+	idl.Field
+	// oneof contains the index of the oneof containing the field, or -1.
+	oneof int
+}
+
+// typ returns the type of values held by f, represented as an Array for
+// repeated fields.
+func (f field) typ() idl.Type {
+	if _, ok := f.Annotations.FindByName(idl.RepeatedAnnotation); ok {
+		if _, isArray := f.Type.(idl.Array); !isArray {
+			return idl.Array{Of: f.Type}
+		}
+	}
+	return f.Type
+}
+
+func fieldsOf(m *idl.Message) []field {
+	var r []field
+	var visit func(items []idl.FieldItem, oneof int)
+	visit = func(items []idl.FieldItem, oneof int) {
+		for _, item := range items {
+			switch v := item.(type) {
+			case idl.Field:
+				r = append(r, field{v, oneof})
+			case idl.OneOfField:
+				visit(v.Items, v.Index)
+			}
+		}
+	}
+	visit(m.Fields, -1)
+	return r
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return idl.SyntaxError{Message: fmt.Sprintf(format, args...), Line: t.line, Column: t.column}
+}
+
+func (p *parser) expect(kind tokenKind) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return t, p.errorf(t, "expected %s, found %s", kind, t)
+	}
+	return t, nil
+}
+
+// skipSeparator consumes an optional comma or semicolon.
+func (p *parser) skipSeparator() {
+	if k := p.peek().kind; k == tokenComma || k == tokenSemicolon {
+		p.next()
+	}
+}
+
+// message parses fields of m until the provided closing token, which is
+// consumed.
+func (p *parser) message(m *idl.Message, closing tokenKind) (map[string]any, error) {
+	fields := map[string]field{}
+	for _, f := range fieldsOf(m) {
+		fields[f.Name] = f
+	}
+	values := map[string]any{}
+	seen := map[string]bool{}
+	oneofs := map[int]string{}
+	for {
+		t := p.next()
+		if t.kind == closing {
+			return values, nil
+		}
+		if t.kind != tokenIdent {
+			return nil, p.errorf(t, "expected field name, found %s", t)
+		}
+		f, ok := fields[t.text]
+		if !ok {
+			return nil, p.errorf(t, "%s has no field %s", m.Name, t.text)
+		}
+		typ := f.typ()
+		_, isArray := typ.(idl.Array)
+		if seen[f.Name] && !isArray {
+			return nil, p.errorf(t, "field %s is set more than once", f.Name)
+		}
+		seen[f.Name] = true
+		if f.oneof >= 0 {
+			if other, ok := oneofs[f.oneof]; ok && other != f.Name {
+				return nil, p.errorf(t, "fields %s and %s of the same oneof are both set", other, f.Name)
+			}
+			oneofs[f.oneof] = f.Name
+		}
+
+		colon := p.peek().kind == tokenColon
+		if colon {
+			p.next()
+		}
+		start := p.peek()
+		var v any
+		var err error
+		if a, ok := typ.(idl.Array); ok && start.kind == tokenOpenCurly {
+			// Repeated block of an array of messages.
+			if v, err = p.value(a.Of); err != nil {
+				return nil, err
+			}
+			prev, _ := values[f.Name].([]any)
+			values[f.Name] = append(prev, v)
+		} else {
+			if !colon && start.kind != tokenOpenCurly {
+				return nil, p.errorf(start, "expected ':' after %s, found %s", f.Name, start)
+			}
+			if v, err = p.value(typ); err != nil {
+				return nil, err
+			}
+			if prev, ok := values[f.Name].([]any); ok {
+				if items, ok := v.([]any); ok {
+					v = append(prev, items...)
+				}
+			}
+			values[f.Name] = v
+		}
+		p.skipSeparator()
+	}
+}
+
+// value parses a value of type t.
+func (p *parser) value(t idl.Type) (any, error) {
+	switch t := t.(type) {
+	case idl.Primitive:
+		return p.primitive(t.Kind)
+	case idl.Array:
+		pr, ok := t.Of.(idl.Primitive)
+		isBytes := ok && pr.Kind == idl.Uint8
+		if isBytes && p.peek().kind == tokenString {
+			tok := p.next()
+			return []byte(tok.text), nil
+		}
+		if _, err := p.expect(tokenOpenBracket); err != nil {
+			return nil, err
+		}
+		items := []any{}
+		for p.peek().kind != tokenCloseBracket {
+			item, err := p.value(t.Of)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+		if _, err := p.expect(tokenCloseBracket); err != nil {
+			return nil, err
+		}
+		if isBytes {
+			raw := make([]byte, len(items))
+			for i, item := range items {
+				raw[i] = item.(uint8)
+			}
+			return raw, nil
+		}
+		return items, nil
+	case idl.Map:
+		if _, err := p.expect(tokenOpenCurly); err != nil {
+			return nil, err
+		}
+		entries := map[any]any{}
+		for p.peek().kind != tokenCloseCurly {
+			start := p.peek()
+			key, err := p.primitive(t.Key)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := entries[key]; ok {
+				return nil, p.errorf(start, "duplicated key %s", start.text)
+			}
+			if _, err = p.expect(tokenColon); err != nil {
+				return nil, err
+			}
+			if entries[key], err = p.value(t.Value); err != nil {
+				return nil, err
+			}
+			p.skipSeparator()
+		}
+		p.next()
+		return entries, nil
+	case idl.Resolved:
+		if _, err := p.expect(tokenOpenCurly); err != nil {
+			return nil, err
+		}
+		return p.message(t.Message, tokenCloseCurly)
+	case idl.Unresolved:
+		return nil, p.errorf(p.peek(), "unresolved type %s", t.Name)
+	}
+	return nil, p.errorf(p.peek(), "unsupported type %T", t)
+}
+
+// primitive parses a value of the provided kind, represented by its Go type.
+func (p *parser) primitive(kind idl.PrimitiveType) (any, error) {
+	t := p.next()
+	switch {
+	case kind == idl.String:
+		if t.kind == tokenString {
+			return t.text, nil
+		}
+	case kind == idl.Bool:
+		if t.kind == tokenIdent && (t.text == "true" || t.text == "false") {
+			return t.text == "true", nil
+		}
+	case kind.IsFloat():
+		var f float64
+		switch {
+		case t.kind == tokenIdent && t.text == "inf":
+			f = math.Inf(1)
+		case t.kind == tokenNumber && t.text == "-inf":
+			f = math.Inf(-1)
+		case t.kind == tokenIdent && t.text == "nan":
+			f = math.NaN()
+		case t.kind == tokenNumber:
+			var err error
+			if f, err = strconv.ParseFloat(t.text, kind.BitWidth()); err != nil {
+				return nil, p.errorf(t, "invalid %s value %s", kind.Keyword(), t.text)
+			}
+		default:
+			return nil, p.errorf(t, "expected %s value, found %s", kind.Keyword(), t)
+		}
+		if kind == idl.Float32 {
+			return float32(f), nil
+		}
+		return f, nil
+	case t.kind == tokenNumber:
+		if kind.IsSigned() {
+			i, err := strconv.ParseInt(t.text, 10, kind.BitWidth())
+			if err != nil {
+				return nil, p.errorf(t, "invalid %s value %s", kind.Keyword(), t.text)
+			}
+			switch kind {
+			case idl.Int8:
+				return int8(i), nil
+			case idl.Int16:
+				return int16(i), nil
+			case idl.Int32:
+				return int32(i), nil
+			}
+			return i, nil
+		}
+		u, err := strconv.ParseUint(t.text, 10, kind.BitWidth())
+		if err != nil {
+			return nil, p.errorf(t, "invalid %s value %s", kind.Keyword(), t.text)
+		}
+		switch kind {
+		case idl.Uint8:
+			return uint8(u), nil
+		case idl.Uint16:
+			return uint16(u), nil
+		case idl.Uint32:
+			return uint32(u), nil
+		}
+		return u, nil
+	}
+	return nil, p.errorf(t, "expected %s value, found %s", kind.Keyword(), t)
+}
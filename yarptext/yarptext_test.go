@@ -0,0 +1,115 @@
+package yarptext
+
+import (
+	"errors"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/libyarp/idl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func order(t *testing.T) *idl.Message {
+	fs := idl.NewFileSet()
+	require.NoError(t, fs.Load("../test/yarptext/order.yarp"))
+	require.Empty(t, fs.Validate())
+	m, ok := fs.FindMessage("org.example.shop.Order")
+	require.True(t, ok)
+	return m
+}
+
+func TestRoundTrip(t *testing.T) {
+	src, err := os.ReadFile("../test/yarptext/order.txt")
+	require.NoError(t, err)
+
+	m := order(t)
+	v, err := Unmarshal(src, m)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"id":    int64(-42),
+		"notes": "leave at door\n",
+		"tags":  []any{"urgent", "gift"},
+		"items": []any{
+			map[string]any{"sku": "a1", "weights": []any{float32(1.5), float32(math.Inf(1))}},
+			map[string]any{"sku": "b2"},
+		},
+		"quantities": map[any]any{uint16(2): int32(1), uint16(10): int32(-3)},
+		"voucher":    map[string]any{"code": "FREE"},
+		"digest":     []byte{0, 1, 'a', 'b'},
+		"total":      1e21,
+		"paid":       true,
+	}, v)
+
+	out, err := Marshal(m, v)
+	require.NoError(t, err)
+	assert.Equal(t, string(src), string(out))
+}
+
+func TestUnmarshalSyntax(t *testing.T) {
+	m := order(t)
+	v, err := Unmarshal([]byte(`# Inline form.
+id: 7, tags: ["a"]; items { sku: "x", weights: [] } items: { sku: "y" }
+quantities: { 1: 2, 3: 4 }
+card: "4242"
+digest: [0, 255]
+`), m)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"id":         int64(7),
+		"tags":       []any{"a"},
+		"items":      []any{map[string]any{"sku": "x", "weights": []any{}}, map[string]any{"sku": "y"}},
+		"quantities": map[any]any{uint16(1): int32(2), uint16(3): int32(4)},
+		"card":       "4242",
+		"digest":     []byte{0, 255},
+	}, v)
+
+	out, err := Marshal(m, v)
+	require.NoError(t, err)
+	assert.Equal(t, `id: 7
+tags: ["a"]
+items {
+    sku: "x"
+}
+items {
+    sku: "y"
+}
+quantities {
+    1: 2
+    3: 4
+}
+card: "4242"
+digest: "\x00\xff"
+`, string(out))
+}
+
+func TestMarshalErrors(t *testing.T) {
+	m := order(t)
+	_, err := Marshal(m, map[string]any{"missing": 1})
+	assert.EqualError(t, err, "Order: unknown field missing")
+	_, err = Marshal(m, map[string]any{"id": "1"})
+	assert.EqualError(t, err, "Order.id: cannot write string as int64")
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for src, msg := range map[string]string{
+		"id: 1\nid: 2":                        "field id is set more than once at line 2, column 1",
+		"missing: 1":                          "Order has no field missing at line 1, column 1",
+		"id 1":                                "expected ':' after id, found number 1 at line 1, column 4",
+		"id: \"1\"":                           `expected int64 value, found string "1" at line 1, column 5`,
+		"quantities { 70000: 1 }":             "invalid uint16 value 70000 at line 1, column 14",
+		"card: \"x\"\nvoucher { code: \"\" }": "fields card and voucher of the same oneof are both set at line 2, column 1",
+		"items { sku: 1 }":                    "expected string value, found number 1 at line 1, column 14",
+		"notes: \"open":                       "unterminated string at line 1, column 8",
+		"id: 1 }":                             "expected field name, found '}' at line 1, column 7",
+		"tags: [\"a\" \"b\"]":                 `expected ']', found string "b" at line 1, column 12`,
+		"id: @":                               "unexpected character '@' at line 1, column 5",
+	} {
+		_, err := Unmarshal([]byte(src), order(t))
+		var syntax idl.SyntaxError
+		if assert.True(t, errors.As(err, &syntax), src) {
+			assert.EqualError(t, err, msg, src)
+		}
+	}
+}